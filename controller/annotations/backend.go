@@ -7,29 +7,81 @@ import (
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
 )
 
-func HandleBackendAnnotations(backend *models.Backend, k8sStore store.K8s, client api.HAProxyClient, annotations ...map[string]string) {
-	for _, a := range GetBackendAnnotations(client, backend) {
-		annValue := k8sStore.GetValueFromAnnotations(a.GetName(), annotations...)
-		if annValue == "" {
-			continue
-		}
-		HandleAnnotation(a, annValue)
+func init() {
+	Register("backend-config-snippet", SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+		return NewBackendCfgSnippet(n, ctx.Store, ctx.Client, ctx.Backend)
+	})
+	Register("abortonclose", SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+		return NewBackendAbortOnClose(n, ctx.Backend)
+	})
+	Register("timeout-check", SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+		return NewBackendTimeoutCheck(n, ctx.Backend)
+	})
+	// Timeouts of the defaults section which can be overridden by backend
+	for _, name := range []string{"timeout-http-keep-alive", "timeout-queue", "timeout-server", "timeout-tunnel"} {
+		Register(name, SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+			var configmap map[string]string
+			if ctx.Store.ConfigMaps.Main != nil {
+				configmap = ctx.Store.ConfigMaps.Main.Annotations
+			}
+			return NewBackendTimeout(n, ctx.Store.GetValueFromAnnotations(n, configmap), ctx.Backend)
+		})
 	}
+	Register("load-balance", SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+		return NewBackendLoadBalance(n, ctx.Backend)
+	})
+	Register("cookie-persistence", SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+		return NewBackendCookie(n, ctx.Backend)
+	})
+	Register("retries", SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+		return NewBackendRetries(n, ctx.Backend)
+	})
+	Register("redispatch", SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+		return NewBackendRedispatch(n, ctx.Backend)
+	})
+	// HTTP mode only
+	Register("check-http", SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+		if ctx.Backend.Mode != "http" {
+			return nil
+		}
+		return NewBackendCheckHTTP(n, ctx.Backend)
+	})
+	// Any mode, registered after check-http to take precedence over it
+	Register("check-type", SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+		return NewBackendCheckType(n, ctx.Backend)
+	})
+	Register("forwarded-for", SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+		if ctx.Backend.Mode != "http" {
+			return nil
+		}
+		return NewBackendForwardedFor(n, ctx.Backend)
+	})
+	Register("backend-protocol", SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+		if ctx.Backend.Mode != "http" {
+			return nil
+		}
+		return NewBackendProtocol(n, ctx.Backend)
+	})
+	Register("http-reuse", SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+		if ctx.Backend.Mode != "http" {
+			return nil
+		}
+		return NewBackendHTTPReuse(n, ctx.Backend)
+	})
+	// Registered after load-balance to take precedence over it
+	Register("persistence-url-param", SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+		if ctx.Backend.Mode != "http" {
+			return nil
+		}
+		return NewBackendPersistenceURLParam(n, ctx.Backend)
+	})
+}
+
+// HandleBackendAnnotations handles backend annotations, owners naming the resources of the annotations maps
+func HandleBackendAnnotations(backend *models.Backend, k8sStore store.K8s, client api.HAProxyClient, owners []string, annotations ...map[string]string) {
+	Handle(SCOPE_BACKEND, Context{Client: client, Store: k8sStore, Backend: backend, Owners: owners}, annotations...)
 }
 
 func GetBackendAnnotations(client api.HAProxyClient, b *models.Backend) []Annotation {
-	annotations := []Annotation{
-		NewBackendCfgSnippet("backend-config-snippet", client, b),
-		NewBackendAbortOnClose("abortonclose", b),
-		NewBackendTimeoutCheck("timeout-check", b),
-		NewBackendLoadBalance("load-balance", b),
-		NewBackendCookie("cookie-persistence", b),
-	}
-	if b.Mode == "http" {
-		annotations = append(annotations,
-			NewBackendCheckHTTP("check-http", b),
-			NewBackendForwardedFor("forwarded-for", b),
-		)
-	}
-	return annotations
+	return Get(SCOPE_BACKEND, Context{Client: client, Backend: b})
 }
@@ -7,24 +7,40 @@ import (
 	"github.com/haproxytech/client-native/v2/models"
 
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
 )
 
 type BackendCfgSnippet struct {
 	name    string
+	owner   string
 	data    []string
+	store   store.K8s
 	client  api.HAProxyClient
 	backend *models.Backend
 }
 
-func NewBackendCfgSnippet(n string, c api.HAProxyClient, b *models.Backend) *BackendCfgSnippet {
-	return &BackendCfgSnippet{name: n, client: c, backend: b}
+func NewBackendCfgSnippet(n string, k store.K8s, c api.HAProxyClient, b *models.Backend) *BackendCfgSnippet {
+	return &BackendCfgSnippet{name: n, store: k, client: c, backend: b}
 }
 
 func (a *BackendCfgSnippet) GetName() string {
 	return a.name
 }
 
+func (a *BackendCfgSnippet) setOwner(owner string) {
+	a.owner = owner
+}
+
+// Parse accepts the snippet or a ConfigMap or Secret key reference holding it,
+// the namespace of the reference defaults to the one of the annotation owner.
 func (a *BackendCfgSnippet) Parse(input string) error {
+	if store.IsValueRef(input) {
+		value, err := a.store.FetchValueRef(input, ownerNamespace(a.owner))
+		if err != nil {
+			return err
+		}
+		input = value
+	}
 	for _, line := range strings.Split(strings.Trim(input, "\n"), "\n") {
 		if line = strings.TrimSpace(line); line != "" {
 			a.data = append(a.data, line)
@@ -37,8 +53,5 @@ func (a *BackendCfgSnippet) Parse(input string) error {
 }
 
 func (a *BackendCfgSnippet) Update() error {
-	if len(a.data) == 0 {
-		return a.client.BackendCfgSnippetSet(a.backend.Name, nil)
-	}
-	return a.client.BackendCfgSnippetSet(a.backend.Name, &a.data)
+	return setSnippet(a.client, SNIPPET_BACKEND, a.backend.Name, a.owner, a.data)
 }
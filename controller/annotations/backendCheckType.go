@@ -0,0 +1,95 @@
+package annotations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/haproxytech/client-native/v2/models"
+)
+
+// BackendCheckType configures protocol specific health checks, mainly for
+// TCP services. Rules of tcp-check are set from check-tcp-script annotation.
+type BackendCheckType struct {
+	name    string
+	backend *models.Backend
+	check   string
+	mysql   *models.MysqlCheckParams
+	pgsql   *models.PgsqlCheckParams
+	smtp    *models.SmtpchkParams
+}
+
+func NewBackendCheckType(n string, b *models.Backend) *BackendCheckType {
+	return &BackendCheckType{name: n, backend: b}
+}
+
+func (a *BackendCheckType) GetName() string {
+	return a.name
+}
+
+func (a *BackendCheckType) Parse(input string) error {
+	params := strings.Fields(input)
+	if len(params) == 0 {
+		return fmt.Errorf("check-type: missing check type")
+	}
+	a.check, a.mysql, a.pgsql, a.smtp = params[0], nil, nil, nil
+	args := params[1:]
+	switch a.check {
+	case "ssl-hello-chk", "ldap-check", "redis-check", "tcp-check":
+		if len(args) != 0 {
+			return fmt.Errorf("check-type: %s takes no parameter", a.check)
+		}
+	case "mysql-check":
+		// mysql-check [user <username> [post-41|pre-41]]
+		a.mysql = &models.MysqlCheckParams{}
+		if len(args) == 0 {
+			break
+		}
+		if args[0] != "user" || len(args) < 2 || len(args) > 3 {
+			return fmt.Errorf("check-type: mysql-check expects 'user <username> [post-41|pre-41]'")
+		}
+		a.mysql.Username = args[1]
+		if len(args) == 3 {
+			a.mysql.ClientVersion = args[2]
+		}
+	case "pgsql-check":
+		// pgsql-check user <username>
+		if len(args) != 2 || args[0] != "user" {
+			return fmt.Errorf("check-type: pgsql-check expects 'user <username>'")
+		}
+		a.pgsql = &models.PgsqlCheckParams{Username: args[1]}
+	case "smtpchk":
+		// smtpchk [HELO|EHLO <domain>]
+		a.smtp = &models.SmtpchkParams{}
+		if len(args) == 0 {
+			break
+		}
+		if len(args) != 2 {
+			return fmt.Errorf("check-type: smtpchk expects '[HELO|EHLO <domain>]'")
+		}
+		a.smtp.Hello, a.smtp.Domain = args[0], args[1]
+	case "httpchk":
+		return fmt.Errorf("check-type: use check-http annotation for HTTP checks")
+	default:
+		return fmt.Errorf("check-type: unknown check type '%s'", a.check)
+	}
+	if a.mysql != nil {
+		if err := a.mysql.Validate(nil); err != nil {
+			return fmt.Errorf("check-type: %w", err)
+		}
+	}
+	if a.smtp != nil {
+		if err := a.smtp.Validate(nil); err != nil {
+			return fmt.Errorf("check-type: %w", err)
+		}
+	}
+	return nil
+}
+
+func (a *BackendCheckType) Update() error {
+	a.backend.AdvCheck = a.check
+	a.backend.HttpchkParams = nil
+	a.backend.MysqlCheckParams = a.mysql
+	a.backend.PgsqlCheckParams = a.pgsql
+	a.backend.SmtpchkParams = a.smtp
+	return nil
+}
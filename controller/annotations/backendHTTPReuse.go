@@ -0,0 +1,36 @@
+package annotations
+
+import (
+	"fmt"
+
+	"github.com/haproxytech/client-native/v2/models"
+)
+
+type BackendHTTPReuse struct {
+	name    string
+	reuse   string
+	backend *models.Backend
+}
+
+func NewBackendHTTPReuse(n string, b *models.Backend) *BackendHTTPReuse {
+	return &BackendHTTPReuse{name: n, backend: b}
+}
+
+func (a *BackendHTTPReuse) GetName() string {
+	return a.name
+}
+
+func (a *BackendHTTPReuse) Parse(input string) error {
+	switch input {
+	case "never", "safe", "aggressive", "always":
+		a.reuse = input
+	default:
+		return fmt.Errorf("http-reuse: incorrect value '%s'", input)
+	}
+	return nil
+}
+
+func (a *BackendHTTPReuse) Update() error {
+	a.backend.HTTPReuse = a.reuse
+	return nil
+}
@@ -3,7 +3,6 @@ package annotations
 import (
 	"errors"
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -11,9 +10,10 @@ import (
 )
 
 type BackendLoadBalance struct {
-	name    string
-	params  *models.Balance
-	backend *models.Backend
+	name     string
+	params   *models.Balance
+	hashType *models.BackendHashType
+	backend  *models.Backend
 }
 
 func NewBackendLoadBalance(n string, b *models.Backend) *BackendLoadBalance {
@@ -25,7 +25,7 @@ func (a *BackendLoadBalance) GetName() string {
 }
 
 func (a *BackendLoadBalance) Parse(input string) error {
-	params, err := getParamsFromInput(input)
+	params, hashType, err := getParamsFromInput(input)
 	if err != nil {
 		return fmt.Errorf("load-balance: %w", err)
 	}
@@ -33,96 +33,126 @@ func (a *BackendLoadBalance) Parse(input string) error {
 	if err := params.Validate(nil); err != nil {
 		return fmt.Errorf("load-balance: %w", err)
 	}
+	if hashType != nil {
+		if err := hashType.Validate(nil); err != nil {
+			return fmt.Errorf("load-balance: %w", err)
+		}
+	}
 	a.params = params
+	a.hashType = hashType
 	return nil
 }
 
 func (a *BackendLoadBalance) Update() error {
 	a.backend.Balance = a.params
+	a.backend.HashType = a.hashType
 	return nil
 }
 
-func getParamsFromInput(value string) (*models.Balance, error) {
+// getParamsFromInput parses the "<algorithm>[(<arg>)] [<options>] [hash-type <method> [<function>] [<modifier>]]"
+// load-balance value into balance and hash-type parameters.
+func getParamsFromInput(value string) (*models.Balance, *models.BackendHashType, error) {
 	balance := &models.Balance{}
-	tokens := strings.Split(value, " ")
+	tokens := strings.Fields(value)
 	if len(tokens) == 0 {
-		return nil, errors.New("missing algorithm name")
+		return nil, nil, errors.New("missing algorithm name")
 	}
 
-	reg := regexp.MustCompile(`(\\(|\\))"`)
-	algorithmTokens := reg.Split(tokens[0], -1)
-	algorithm := algorithmTokens[0]
+	algorithm, arg := tokens[0], ""
+	if i := strings.IndexByte(algorithm, '('); i != -1 {
+		if !strings.HasSuffix(algorithm, ")") {
+			return nil, nil, fmt.Errorf("invalid algorithm '%s'", algorithm)
+		}
+		algorithm, arg = algorithm[:i], algorithm[i+1:len(algorithm)-1]
+	}
 	balance.Algorithm = &algorithm
-	if len(algorithmTokens) == 3 {
-		switch algorithm {
-		case "hdr":
-			balance.HdrName = algorithmTokens[1]
-		case "random":
-			if randomDraws, err := strconv.Atoi(algorithmTokens[1]); err == nil {
-				balance.RandomDraws = int64(randomDraws)
+	switch algorithm {
+	case "hdr":
+		if arg == "" {
+			return nil, nil, errors.New("missing header name in hdr(<name>)")
+		}
+		balance.HdrName = arg
+	case "random":
+		if arg != "" {
+			randomDraws, err := strconv.Atoi(arg)
+			if err != nil || randomDraws < 1 {
+				return nil, nil, fmt.Errorf("invalid random draws '%s'", arg)
 			}
-		case "rdp-cookie":
-			balance.RdpCookieName = algorithmTokens[1]
+			balance.RandomDraws = int64(randomDraws)
+		}
+	case "rdp-cookie":
+		balance.RdpCookieName = arg
+	default:
+		if arg != "" {
+			return nil, nil, fmt.Errorf("algorithm '%s' takes no argument", algorithm)
 		}
 	}
 	i := 1
 	if algorithm == "url_param" {
+		if len(tokens) < 2 {
+			return nil, nil, errors.New("missing url_param parameter name")
+		}
 		balance.URLParam = tokens[i]
 		i++
 	}
 
+	var hashType *models.BackendHashType
+	intParam := func(option string) (int64, error) {
+		if i+1 >= len(tokens) {
+			return 0, fmt.Errorf("missing parameter for option '%s'", option)
+		}
+		i++
+		v, err := strconv.ParseInt(tokens[i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid parameter '%s' for option '%s'", tokens[i], option)
+		}
+		return v, nil
+	}
+	var err error
 	for ; i < len(tokens); i++ {
 		token := tokens[i]
 		switch token {
 		case "len":
-			if i+1 >= len(tokens) {
-				logger.Errorf("Missing parameter for option '%s' in balance configuration", token)
-				continue
-			}
-			if length, err := strconv.Atoi(tokens[i+1]); err == nil {
-				balance.URILen = int64(length)
-			}
-			// We already got the next token
-			i++
+			balance.URILen, err = intParam(token)
 		case "depth":
-			if i+1 >= len(tokens) {
-				logger.Errorf("Missing parameter for option '%s' in balance configuration", token)
-				continue
-			}
-			if depth, err := strconv.Atoi(tokens[i+1]); err == nil {
-				balance.URIDepth = int64(depth)
-			}
-			// We already got the next token
-			i++
+			balance.URIDepth, err = intParam(token)
 		case "whole":
 			balance.URIWhole = true
 		case "max_wait":
-			if i+1 >= len(tokens) {
-				logger.Errorf("Missing parameter for option '%s' in balance configuration", token)
-				continue
-			}
-			if maxWait, err := strconv.Atoi(tokens[i+1]); err == nil {
-				balance.URLParamMaxWait = int64(maxWait)
-			}
-			// We already got the next token
-			i++
+			balance.URLParamMaxWait, err = intParam(token)
 		case "path-only":
 			balance.URIPathOnly = true
 		case "check_post":
-			if i+1 >= len(tokens) {
-				logger.Errorf("Missing parameter for option '%s' in balance configuration", token)
-				continue
-			}
-			if checkPost, err := strconv.Atoi(tokens[i+1]); err == nil {
-				balance.URLParamCheckPost = int64(checkPost)
-			}
-			// We already got the next token
-			i++
+			balance.URLParamCheckPost, err = intParam(token)
 		case "use_domain_only":
 			balance.HdrUseDomainOnly = true
+		case "hash-type":
+			hashType, err = getHashType(tokens[i+1:])
+			i = len(tokens)
 		default:
-			logger.Warningf("balance configuration '%s' is ignored", token)
+			err = fmt.Errorf("unknown option '%s'", token)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return balance, hashType, nil
+}
+
+func getHashType(tokens []string) (*models.BackendHashType, error) {
+	if len(tokens) == 0 || len(tokens) > 3 {
+		return nil, errors.New("hash-type expects '<method> [<function>] [<modifier>]'")
+	}
+	hashType := &models.BackendHashType{Method: tokens[0]}
+	for _, token := range tokens[1:] {
+		if token == "avalanche" {
+			hashType.Modifier = token
+			continue
+		}
+		if hashType.Function != "" {
+			return nil, fmt.Errorf("unexpected hash-type parameter '%s'", token)
 		}
+		hashType.Function = token
 	}
-	return balance, nil
+	return hashType, nil
 }
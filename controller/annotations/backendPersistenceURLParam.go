@@ -0,0 +1,44 @@
+package annotations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/haproxytech/client-native/v2/models"
+)
+
+// BackendPersistenceURLParam balances requests on the hash of a query parameter,
+// so clients sending the same value reach the same server without cookies.
+// It takes precedence over load-balance annotation.
+type BackendPersistenceURLParam struct {
+	name    string
+	param   string
+	backend *models.Backend
+}
+
+func NewBackendPersistenceURLParam(n string, b *models.Backend) *BackendPersistenceURLParam {
+	return &BackendPersistenceURLParam{name: n, backend: b}
+}
+
+func (a *BackendPersistenceURLParam) GetName() string {
+	return a.name
+}
+
+func (a *BackendPersistenceURLParam) Parse(input string) error {
+	if len(strings.Fields(input)) != 1 {
+		return fmt.Errorf("persistence-url-param: Incorrect input %s", input)
+	}
+	a.param = input
+	return nil
+}
+
+func (a *BackendPersistenceURLParam) Update() error {
+	algorithm := "url_param"
+	a.backend.Balance = &models.Balance{
+		Algorithm: &algorithm,
+		URLParam:  a.param,
+	}
+	// Keep the hash stable when servers are added or removed
+	a.backend.HashType = &models.BackendHashType{Method: "consistent"}
+	return nil
+}
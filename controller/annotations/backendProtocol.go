@@ -0,0 +1,45 @@
+package annotations
+
+import (
+	"fmt"
+
+	"github.com/haproxytech/client-native/v2/models"
+)
+
+// grpcServerTimeout is the backend server timeout of gRPC services,
+// long enough for gRPC streams which may stay idle between messages.
+const grpcServerTimeout = 3600000
+
+// BackendProtocol adjusts backend timeouts to the protocol of backend-protocol
+// annotation, servers being configured by ServerBackendProtocol.
+type BackendProtocol struct {
+	name    string
+	proto   string
+	backend *models.Backend
+}
+
+func NewBackendProtocol(n string, b *models.Backend) *BackendProtocol {
+	return &BackendProtocol{name: n, backend: b}
+}
+
+func (a *BackendProtocol) GetName() string {
+	return a.name
+}
+
+func (a *BackendProtocol) Parse(input string) error {
+	switch input {
+	case "h1", "h2", "grpc":
+		a.proto = input
+	default:
+		return fmt.Errorf("unknown protocol '%s'", input)
+	}
+	return nil
+}
+
+func (a *BackendProtocol) Update() error {
+	if a.proto == "grpc" && a.backend.ServerTimeout == nil {
+		timeout := int64(grpcServerTimeout)
+		a.backend.ServerTimeout = &timeout
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package annotations
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// BackendRedispatch toggles "option redispatch", overriding the defaults section one,
+// so requests are sent to another server when their persistent server fails.
+type BackendRedispatch struct {
+	name       string
+	redispatch *models.Redispatch
+	backend    *models.Backend
+}
+
+func NewBackendRedispatch(n string, b *models.Backend) *BackendRedispatch {
+	return &BackendRedispatch{name: n, backend: b}
+}
+
+func (a *BackendRedispatch) GetName() string {
+	return a.name
+}
+
+// Parse accepts "<bool> [<interval>]", interval being the retries
+// on which requests are redispatched, negative values counting from the last one.
+func (a *BackendRedispatch) Parse(input string) error {
+	params := strings.Fields(input)
+	if len(params) == 0 || len(params) > 2 {
+		return fmt.Errorf("redispatch: incorrect value '%s'", input)
+	}
+	enabled, err := utils.GetBoolValue(params[0], "redispatch")
+	if err != nil {
+		return err
+	}
+	a.redispatch = &models.Redispatch{Enabled: utils.PtrString("disabled")}
+	if !enabled {
+		if len(params) == 2 {
+			return fmt.Errorf("redispatch: interval requires redispatch to be enabled")
+		}
+		return nil
+	}
+	a.redispatch.Enabled = utils.PtrString("enabled")
+	if len(params) == 2 {
+		interval, errInterval := strconv.ParseInt(params[1], 10, 64)
+		if errInterval != nil || interval == 0 {
+			return fmt.Errorf("redispatch: incorrect interval '%s'", params[1])
+		}
+		a.redispatch.Interval = interval
+	}
+	return nil
+}
+
+func (a *BackendRedispatch) Update() error {
+	a.backend.Redispatch = a.redispatch
+	return nil
+}
@@ -0,0 +1,36 @@
+package annotations
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/haproxytech/client-native/v2/models"
+)
+
+type BackendRetries struct {
+	name    string
+	retries *int64
+	backend *models.Backend
+}
+
+func NewBackendRetries(n string, b *models.Backend) *BackendRetries {
+	return &BackendRetries{name: n, backend: b}
+}
+
+func (a *BackendRetries) GetName() string {
+	return a.name
+}
+
+func (a *BackendRetries) Parse(input string) error {
+	retries, err := strconv.ParseInt(input, 10, 64)
+	if err != nil || retries < 0 {
+		return fmt.Errorf("retries: incorrect value '%s'", input)
+	}
+	a.retries = &retries
+	return nil
+}
+
+func (a *BackendRetries) Update() error {
+	a.backend.Retries = a.retries
+	return nil
+}
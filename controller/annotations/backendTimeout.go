@@ -0,0 +1,54 @@
+package annotations
+
+import (
+	"errors"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// BackendTimeout sets a backend timeout when it differs from the
+// defaults section one, set from the ConfigMap by DefaultTimeout.
+type BackendTimeout struct {
+	name         string
+	defaultValue string
+	timeout      *int64
+	backend      *models.Backend
+}
+
+func NewBackendTimeout(n string, defaultValue string, b *models.Backend) *BackendTimeout {
+	return &BackendTimeout{name: n, defaultValue: defaultValue, backend: b}
+}
+
+func (a *BackendTimeout) GetName() string {
+	return a.name
+}
+
+func (a *BackendTimeout) Parse(input string) error {
+	var err error
+	a.timeout, err = utils.ParseTime(input)
+	if err != nil {
+		return err
+	}
+	if defaultTimeout, errDefault := utils.ParseTime(a.defaultValue); errDefault == nil && *defaultTimeout == *a.timeout {
+		a.timeout = nil
+	}
+	return nil
+}
+
+func (a *BackendTimeout) Update() error {
+	switch a.name {
+	case "timeout-http-keep-alive":
+		a.backend.HTTPKeepAliveTimeout = a.timeout
+	case "timeout-queue":
+		a.backend.QueueTimeout = a.timeout
+	case "timeout-server":
+		a.backend.ServerTimeout = a.timeout
+	case "timeout-tunnel":
+		a.backend.TunnelTimeout = a.timeout
+	default:
+		return errors.New("unknown param")
+	}
+	return nil
+}
@@ -21,9 +21,20 @@ func (a *DefaultLogFormat) GetName() string {
 	return a.name
 }
 
+// JSONLogFormat is the structured log-format of "json" log-format value,
+// string fields being escaped to keep each log line a valid JSON object.
+const JSONLogFormat = `{"time":"%trg","client_ip":"%ci","client_port":%cp,"frontend":"%f","backend":"%b","server":"%s",` +
+	`"method":"%HM","uri":"%{+E}HU","version":"%HV","status":%ST,"bytes_read":%B,` +
+	`"request_time":%TR,"queue_time":%Tw,"connect_time":%Tc,"response_time":%Tr,"total_time":%Ta,` +
+	`"termination_state":"%tsc","actconn":%ac,"feconn":%fc,"beconn":%bc,"srv_conn":%sc,"retries":%rc,` +
+	`"srv_queue":%sq,"backend_queue":%bq}`
+
 func (a *DefaultLogFormat) Parse(input string) error {
-	a.data = strings.TrimSpace(input)
-	a.data = "'" + strings.TrimSpace(input) + "'"
+	input = strings.TrimSpace(input)
+	if input == "json" {
+		input = JSONLogFormat
+	}
+	a.data = "'" + input + "'"
 	if a.data == "" {
 		return errors.New("unable to parse log-format: empty input")
 	}
@@ -0,0 +1,15 @@
+package annotations
+
+import (
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+// HandleFrontendAnnotations handles the frontend annotations of an ingress.
+// Built-in frontend annotations are handled by the controller, this runs
+// annotations registered for SCOPE_FRONTEND which configure HAProxy rules
+// via cfg.HAProxyRules.
+func HandleFrontendAnnotations(ingress *store.Ingress, k8sStore store.K8s, client api.HAProxyClient, cfg *config.ControllerCfg, annotations ...map[string]string) {
+	Handle(SCOPE_FRONTEND, Context{Client: client, Store: k8sStore, Ingress: ingress, Cfg: cfg}, annotations...)
+}
@@ -9,6 +9,7 @@ import (
 
 type FrontendCfgSnippet struct {
 	name      string
+	owner     string
 	data      []string
 	frontends []string
 	client    api.HAProxyClient
@@ -22,6 +23,10 @@ func (a *FrontendCfgSnippet) GetName() string {
 	return a.name
 }
 
+func (a *FrontendCfgSnippet) setOwner(owner string) {
+	a.owner = owner
+}
+
 func (a *FrontendCfgSnippet) Parse(input string) error {
 	for _, line := range strings.Split(strings.Trim(input, "\n"), "\n") {
 		if line = strings.TrimSpace(line); line != "" {
@@ -35,20 +40,9 @@ func (a *FrontendCfgSnippet) Parse(input string) error {
 }
 
 func (a *FrontendCfgSnippet) Update() error {
-	switch len(a.data) {
-	case 0:
-		logger.Infof("Removing config-snippet in %s frontends", strings.Join(a.frontends, ","))
-		for _, ft := range a.frontends {
-			if err := a.client.FrontendCfgSnippetSet(ft, nil); err != nil {
-				return err
-			}
-		}
-	default:
-		logger.Infof("Updating config-snippet in %s frontends", strings.Join(a.frontends, ","))
-		for _, ft := range a.frontends {
-			if err := a.client.FrontendCfgSnippetSet(ft, &a.data); err != nil {
-				return err
-			}
+	for _, ft := range a.frontends {
+		if err := setSnippet(a.client, SNIPPET_FRONTEND, ft, a.owner, a.data); err != nil {
+			return err
 		}
 	}
 	return nil
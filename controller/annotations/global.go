@@ -1,45 +1,108 @@
 package annotations
 
 import (
+	"fmt"
+
 	"github.com/haproxytech/client-native/v2/models"
 
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
 )
 
-func HandleGlobalAnnotations(global *models.Global, defaults *models.Defaults, k8sStore store.K8s, client api.HAProxyClient, annotations map[string]string) {
-	annList := GetGlobalAnnotations(client, global, defaults)
-	for _, a := range annList {
-		annValue := k8sStore.GetValueFromAnnotations(a.GetName(), annotations)
-		if annValue == "" {
+func init() {
+	Register("frontend-config-snippet", SCOPE_GLOBAL, func(n string, ctx Context) Annotation {
+		return NewFrontendCfgSnippet(n, ctx.Client, []string{"http", "https"})
+	})
+	Register("stats-config-snippet", SCOPE_GLOBAL, func(n string, ctx Context) Annotation {
+		return NewFrontendCfgSnippet(n, ctx.Client, []string{"stats"})
+	})
+	Register("global-config-snippet", SCOPE_GLOBAL, func(n string, ctx Context) Annotation {
+		return NewGlobalCfgSnippet(n, ctx.Client)
+	})
+	Register("syslog-server", SCOPE_GLOBAL, func(n string, ctx Context) Annotation {
+		return NewGlobalSyslogServers(n, ctx.Client, ctx.Global)
+	})
+	Register("nbthread", SCOPE_GLOBAL, func(n string, ctx Context) Annotation {
+		return NewGlobalNbthread(n, ctx.Global)
+	})
+	Register("cpu-map", SCOPE_GLOBAL, func(n string, ctx Context) Annotation {
+		return NewGlobalCPUMap(n, ctx.Global)
+	})
+	Register("maxconn", SCOPE_GLOBAL, func(n string, ctx Context) Annotation {
+		return NewGlobalMaxconn(n, ctx.Global)
+	})
+	for _, name := range TuneParams {
+		Register(name, SCOPE_GLOBAL, func(n string, ctx Context) Annotation {
+			return NewGlobalTune(n, ctx.Client)
+		})
+	}
+	for _, name := range []string{
+		"ssl-default-bind-ciphers",
+		"ssl-default-bind-ciphersuites",
+		"ssl-default-bind-options",
+		"ssl-default-server-ciphers",
+		"ssl-default-server-ciphersuites",
+		"ssl-default-server-options",
+	} {
+		Register(name, SCOPE_GLOBAL, func(n string, ctx Context) Annotation {
+			return NewGlobalSSLDefault(n, ctx.Global)
+		})
+	}
+	// Applied on top of ssl-default-bind-options
+	for _, name := range []string{"ssl-min-ver", "ssl-max-ver"} {
+		Register(name, SCOPE_GLOBAL, func(n string, ctx Context) Annotation {
+			return NewGlobalSSLVersion(n, ctx.Global)
+		})
+	}
+	Register("hard-stop-after", SCOPE_GLOBAL, func(n string, ctx Context) Annotation {
+		return NewGlobalHardStopAfter(n, ctx.Global)
+	})
+	for _, name := range []string{"http-server-close", "http-keep-alive", "dontlognull", "logasap"} {
+		Register(name, SCOPE_GLOBAL, func(n string, ctx Context) Annotation {
+			return NewDefaultOption(n, ctx.Defaults)
+		})
+	}
+	for _, name := range []string{
+		"timeout-http-request",
+		"timeout-connect",
+		"timeout-client",
+		"timeout-client-fin",
+		"timeout-queue",
+		"timeout-server",
+		"timeout-server-fin",
+		"timeout-tunnel",
+		"timeout-http-keep-alive",
+	} {
+		Register(name, SCOPE_GLOBAL, func(n string, ctx Context) Annotation {
+			return NewDefaultTimeout(n, ctx.Defaults)
+		})
+	}
+	Register("log-format", SCOPE_GLOBAL, func(n string, ctx Context) Annotation {
+		return NewDefaultLogFormat(n, ctx.Defaults)
+	})
+}
+
+// HandleGlobalAnnotations handles global annotations, the values of the Global
+// custom resource taking precedence over the main ConfigMap ones.
+func HandleGlobalAnnotations(global *models.Global, defaults *models.Defaults, k8sStore store.K8s, client api.HAProxyClient) {
+	owners := []string{
+		fmt.Sprintf("global %s/%s", k8sStore.Global.Namespace, k8sStore.Global.Name),
+		fmt.Sprintf("configmap %s/%s", k8sStore.ConfigMaps.Main.Namespace, k8sStore.ConfigMaps.Main.Name),
+	}
+	Handle(SCOPE_GLOBAL, Context{Client: client, Store: k8sStore, Global: global, Defaults: defaults, Owners: owners}, k8sStore.Global.Annotations, k8sStore.ConfigMaps.Main.Annotations)
+	// Tune parameters are not part of the global model, so they are removed when unset
+	for _, name := range TuneParams {
+		if k8sStore.GetValueFromAnnotations(name, k8sStore.Global.Annotations, k8sStore.ConfigMaps.Main.Annotations) != "" {
 			continue
 		}
-		HandleAnnotation(a, annValue)
+		if value, err := client.GlobalTuneGet(name); err != nil || value == "" {
+			continue
+		}
+		logger.Infof("Removing %s", name)
+		logger.Error(client.GlobalTuneSet(name, ""))
 	}
 }
 
 func GetGlobalAnnotations(client api.HAProxyClient, global *models.Global, defaults *models.Defaults) []Annotation {
-	return []Annotation{
-		NewFrontendCfgSnippet("frontend-config-snippet", client, []string{"http", "https"}),
-		NewFrontendCfgSnippet("stats-config-snippet", client, []string{"stats"}),
-		NewGlobalCfgSnippet("global-config-snippet", client),
-		NewGlobalSyslogServers("syslog-server", client, global),
-		NewGlobalNbthread("nbthread", global),
-		NewGlobalMaxconn("maxconn", global),
-		NewGlobalHardStopAfter("hard-stop-after", global),
-		NewDefaultOption("http-server-close", defaults),
-		NewDefaultOption("http-keep-alive", defaults),
-		NewDefaultOption("dontlognull", defaults),
-		NewDefaultOption("logasap", defaults),
-		NewDefaultTimeout("timeout-http-request", defaults),
-		NewDefaultTimeout("timeout-connect", defaults),
-		NewDefaultTimeout("timeout-client", defaults),
-		NewDefaultTimeout("timeout-client-fin", defaults),
-		NewDefaultTimeout("timeout-queue", defaults),
-		NewDefaultTimeout("timeout-server", defaults),
-		NewDefaultTimeout("timeout-server-fin", defaults),
-		NewDefaultTimeout("timeout-tunnel", defaults),
-		NewDefaultTimeout("timeout-http-keep-alive", defaults),
-		NewDefaultLogFormat("log-format", defaults),
-	}
+	return Get(SCOPE_GLOBAL, Context{Client: client, Global: global, Defaults: defaults})
 }
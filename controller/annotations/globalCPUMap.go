@@ -0,0 +1,64 @@
+package annotations
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/haproxytech/client-native/v2/models"
+)
+
+type GlobalCPUMap struct {
+	name    string
+	cpuMaps []*models.CPUMap
+	global  *models.Global
+}
+
+var (
+	cpuMapProcessRegex = regexp.MustCompile(`^(auto:)?(all|odd|even|[0-9]+(-[0-9]+)?)(/(all|odd|even|[0-9]+(-[0-9]+)?))?$`)
+	cpuMapCPUSetRegex  = regexp.MustCompile(`^[0-9]+(-[0-9]+)?$`)
+)
+
+func NewGlobalCPUMap(n string, g *models.Global) *GlobalCPUMap {
+	return &GlobalCPUMap{name: n, global: g}
+}
+
+func (a *GlobalCPUMap) GetName() string {
+	return a.name
+}
+
+// Parse expects one "<process-set>[/<thread-set>] <cpu-set>..." mapping per line,
+// e.g. "1/1-4 0-3" binding the four threads of the process to the first four CPUs.
+func (a *GlobalCPUMap) Parse(input string) error {
+	a.cpuMaps = nil
+	for _, line := range strings.Split(input, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return fmt.Errorf("missing cpu set in '%s'", strings.TrimSpace(line))
+		}
+		if !cpuMapProcessRegex.MatchString(fields[0]) {
+			return fmt.Errorf("incorrect process set '%s'", fields[0])
+		}
+		for _, cpus := range fields[1:] {
+			if !cpuMapCPUSetRegex.MatchString(cpus) {
+				return fmt.Errorf("incorrect cpu set '%s'", cpus)
+			}
+		}
+		process, cpuSet := fields[0], strings.Join(fields[1:], " ")
+		a.cpuMaps = append(a.cpuMaps, &models.CPUMap{Process: &process, CPUSet: &cpuSet})
+	}
+	return nil
+}
+
+func (a *GlobalCPUMap) Update() error {
+	if len(a.cpuMaps) == 0 {
+		logger.Infof("Removing cpu-map")
+	} else {
+		logger.Infof("Setting cpu-map with %d mappings", len(a.cpuMaps))
+	}
+	a.global.CPUMaps = a.cpuMaps
+	return nil
+}
@@ -4,13 +4,12 @@ import (
 	"errors"
 	"strings"
 
-	"github.com/haproxytech/config-parser/v4/types"
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
 )
 
 type GlobalCfgSnippet struct {
-	name string
-	// data   *types.StringSliceC
+	name   string
+	owner  string
 	data   []string
 	client api.HAProxyClient
 }
@@ -23,6 +22,10 @@ func (a *GlobalCfgSnippet) GetName() string {
 	return a.name
 }
 
+func (a *GlobalCfgSnippet) setOwner(owner string) {
+	a.owner = owner
+}
+
 func (a *GlobalCfgSnippet) Parse(input string) error {
 	for _, line := range strings.Split(strings.Trim(input, "\n"), "\n") {
 		if line = strings.TrimSpace(line); line != "" {
@@ -36,10 +39,5 @@ func (a *GlobalCfgSnippet) Parse(input string) error {
 }
 
 func (a *GlobalCfgSnippet) Update() error {
-	if len(a.data) == 0 {
-		logger.Infof("Removing global config-snippet")
-		return a.client.GlobalCfgSnippet(nil)
-	}
-	logger.Infof("Updating global config-snippet")
-	return a.client.GlobalCfgSnippet(&types.StringSliceC{Value: a.data})
+	return setSnippet(a.client, SNIPPET_GLOBAL, "", a.owner, a.data)
 }
@@ -0,0 +1,99 @@
+package annotations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/haproxytech/client-native/v2/models"
+)
+
+// GlobalSSLDefault sets the default SSL settings of HAProxy binds
+type GlobalSSLDefault struct {
+	name   string
+	value  string
+	global *models.Global
+}
+
+func NewGlobalSSLDefault(n string, g *models.Global) *GlobalSSLDefault {
+	return &GlobalSSLDefault{name: n, global: g}
+}
+
+func (a *GlobalSSLDefault) GetName() string {
+	return a.name
+}
+
+func (a *GlobalSSLDefault) Parse(input string) error {
+	a.value = strings.Join(strings.Fields(input), " ")
+	if strings.HasSuffix(a.name, "-ciphers") || strings.HasSuffix(a.name, "-ciphersuites") {
+		if strings.Contains(a.value, " ") {
+			return errors.New("ciphers must be a colon separated list")
+		}
+	}
+	return nil
+}
+
+func (a *GlobalSSLDefault) Update() error {
+	switch a.name {
+	case "ssl-default-bind-ciphers":
+		a.global.SslDefaultBindCiphers = a.value
+	case "ssl-default-bind-ciphersuites":
+		a.global.SslDefaultBindCiphersuites = a.value
+	case "ssl-default-bind-options":
+		a.global.SslDefaultBindOptions = a.value
+	case "ssl-default-server-ciphers":
+		a.global.SslDefaultServerCiphers = a.value
+	case "ssl-default-server-ciphersuites":
+		a.global.SslDefaultServerCiphersuites = a.value
+	case "ssl-default-server-options":
+		a.global.SslDefaultServerOptions = a.value
+	default:
+		return errors.New("unknown param")
+	}
+	return nil
+}
+
+// GlobalSSLVersion sets the minimum or maximum TLS version of HAProxy binds,
+// as ssl-min-ver and ssl-max-ver default bind options.
+type GlobalSSLVersion struct {
+	name    string
+	version string
+	global  *models.Global
+}
+
+var sslVersions = map[string]struct{}{"SSLv3": {}, "TLSv1.0": {}, "TLSv1.1": {}, "TLSv1.2": {}, "TLSv1.3": {}}
+
+func NewGlobalSSLVersion(n string, g *models.Global) *GlobalSSLVersion {
+	return &GlobalSSLVersion{name: n, global: g}
+}
+
+func (a *GlobalSSLVersion) GetName() string {
+	return a.name
+}
+
+func (a *GlobalSSLVersion) Parse(input string) error {
+	if _, ok := sslVersions[input]; !ok {
+		return fmt.Errorf("incorrect version '%s'", input)
+	}
+	a.version = input
+	return nil
+}
+
+// Update replaces the version option of ssl-default-bind-options,
+// removing the deprecated no-* and force-* version options it supersedes.
+func (a *GlobalSSLVersion) Update() error {
+	option := a.name
+	fields := strings.Fields(a.global.SslDefaultBindOptions)
+	options := make([]string, 0, len(fields)+2)
+	for i := 0; i < len(fields); i++ {
+		switch {
+		case fields[i] == option:
+			i++
+		case fields[i] == "no-sslv3", strings.HasPrefix(fields[i], "no-tlsv"), strings.HasPrefix(fields[i], "force-"):
+		default:
+			options = append(options, fields[i])
+		}
+	}
+	a.global.SslDefaultBindOptions = strings.Join(append(options, option, a.version), " ")
+	return nil
+}
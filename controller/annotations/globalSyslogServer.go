@@ -17,9 +17,24 @@ type GlobalSyslogServers struct {
 	logTargets models.LogTargets
 	client     api.HAProxyClient
 	stdout     bool
+	ring       []string
 	restart    bool
 }
 
+// LogRingName is the ring buffer section of "ring" syslog-server address,
+// its events can be read with "show events" runtime command.
+const LogRingName = "logs"
+
+var logLevels = map[string]struct{}{
+	"emerg": {}, "alert": {}, "crit": {}, "err": {}, "warning": {}, "notice": {}, "info": {}, "debug": {},
+}
+
+var logFacilities = map[string]struct{}{
+	"kern": {}, "user": {}, "mail": {}, "daemon": {}, "auth": {}, "syslog": {}, "lpr": {}, "news": {},
+	"uucp": {}, "cron": {}, "auth2": {}, "ftp": {}, "ntp": {}, "audit": {}, "alert": {}, "cron2": {},
+	"local0": {}, "local1": {}, "local2": {}, "local3": {}, "local4": {}, "local5": {}, "local6": {}, "local7": {},
+}
+
 func NewGlobalSyslogServers(n string, c api.HAProxyClient, g *models.Global) *GlobalSyslogServers {
 	return &GlobalSyslogServers{name: n, client: c, global: g}
 }
@@ -34,8 +49,11 @@ func (a *GlobalSyslogServers) GetName() string {
 //  syslog-server: |
 //    address:127.0.0.1, port:514, facility:local0
 //    address:192.168.1.1, port:514, facility:local1
+// The "ring" address logs to a ring buffer, whose size in bytes is set with
+// "size" param and whose events are forwarded over TCP to the "server" param address.
 func (a *GlobalSyslogServers) Parse(input string) error {
 	a.stdout = false
+	a.ring = nil
 	for _, syslogLine := range strings.Split(input, "\n") {
 		if syslogLine == "" {
 			continue
@@ -48,7 +66,7 @@ func (a *GlobalSyslogServers) Parse(input string) error {
 			if param == "" {
 				continue
 			}
-			parts := strings.Split(param, ":")
+			parts := strings.SplitN(param, ":", 2)
 			// param should be key: value
 			if len(parts) == 2 {
 				logParams[parts[0]] = parts[1]
@@ -65,6 +83,15 @@ func (a *GlobalSyslogServers) Parse(input string) error {
 		} else {
 			logTarget.Address = address
 		}
+		ring := logParams["address"] == "ring"
+		if ring {
+			if a.ring != nil {
+				logger.Errorf("incorrect syslog Line: only one ring address allowed in '%s'", syslogLine)
+				continue
+			}
+			logTarget.Address = "ring@" + LogRingName
+			a.ring = ringDirectives(logParams)
+		}
 		for k, v := range logParams {
 			switch strings.ToLower(k) {
 			case "address":
@@ -72,9 +99,13 @@ func (a *GlobalSyslogServers) Parse(input string) error {
 					a.stdout = true
 				}
 			case "port":
-				if logParams["address"] != "stdout" {
+				if logParams["address"] != "stdout" && !ring {
 					logTarget.Address += ":" + v
 				}
+			case "size", "server":
+				if !ring {
+					logger.Errorf("syslog param '%s' requires ring address in '%s'", k, syslogLine)
+				}
 			case "length":
 				if length, errConv := strconv.Atoi(v); errConv == nil {
 					logTarget.Length = int64(length)
@@ -82,10 +113,22 @@ func (a *GlobalSyslogServers) Parse(input string) error {
 			case "format":
 				logTarget.Format = v
 			case "facility":
+				if _, ok := logFacilities[v]; !ok {
+					logger.Errorf("unknown syslog facility: '%s' in '%s'", v, syslogLine)
+					continue
+				}
 				logTarget.Facility = v
 			case "level":
+				if _, ok := logLevels[v]; !ok {
+					logger.Errorf("unknown syslog level: '%s' in '%s'", v, syslogLine)
+					continue
+				}
 				logTarget.Level = v
 			case "minlevel":
+				if _, ok := logLevels[v]; !ok {
+					logger.Errorf("unknown syslog level: '%s' in '%s'", v, syslogLine)
+					continue
+				}
 				logTarget.Minlevel = v
 			default:
 				logger.Errorf("unknown syslog param: '%s' in '%s' ", k, syslogLine)
@@ -101,6 +144,9 @@ func (a *GlobalSyslogServers) Parse(input string) error {
 }
 
 func (a *GlobalSyslogServers) Update() error {
+	if err := a.updateRing(); err != nil {
+		return err
+	}
 	a.client.GlobalDeleteLogTargets()
 	if len(a.logTargets) == 0 {
 		logger.Infof("log targets removed")
@@ -139,3 +185,49 @@ func (a *GlobalSyslogServers) Update() error {
 func (a *GlobalSyslogServers) Restart() bool {
 	return a.restart
 }
+
+// updateRing creates, updates or removes the ring buffer section used by log targets
+func (a *GlobalSyslogServers) updateRing() error {
+	current, err := a.client.RingGet(LogRingName)
+	if err != nil {
+		return err
+	}
+	if a.ring == nil {
+		if current == nil {
+			return nil
+		}
+		logger.Infof("removing log ring buffer '%s'", LogRingName)
+		return a.client.RingDelete(LogRingName)
+	}
+	if strings.Join(current, "\n") == strings.Join(a.ring, "\n") {
+		return nil
+	}
+	logger.Infof("setting log ring buffer '%s'", LogRingName)
+	return a.client.RingSet(LogRingName, a.ring)
+}
+
+// ringDirectives returns the ring section directives of the ring syslog line params
+func ringDirectives(logParams map[string]string) []string {
+	format := "rfc3164"
+	if f, ok := logParams["format"]; ok {
+		format = f
+	}
+	ring := []string{
+		`description "HAProxy logs"`,
+		"format " + format,
+	}
+	if length, ok := logParams["length"]; ok {
+		ring = append(ring, "maxlen "+length)
+	}
+	if size, ok := logParams["size"]; ok {
+		ring = append(ring, "size "+size)
+	}
+	if server, ok := logParams["server"]; ok {
+		ring = append(ring,
+			"timeout connect 5s",
+			"timeout server 10s",
+			"server syslog "+server,
+		)
+	}
+	return ring
+}
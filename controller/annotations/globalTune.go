@@ -0,0 +1,60 @@
+package annotations
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+)
+
+// TuneParams are the tune.* global parameters set by annotations of the same name
+var TuneParams = []string{
+	"tune.bufsize",
+	"tune.maxrewrite",
+	"tune.ssl.cachesize",
+	"tune.h2.max-concurrent-streams",
+}
+
+var tuneMin = map[string]int64{
+	"tune.bufsize":                   1024,
+	"tune.maxrewrite":                0,
+	"tune.ssl.cachesize":             0,
+	"tune.h2.max-concurrent-streams": 1,
+}
+
+// GlobalTune sets a tune.* global parameter, these parameters
+// are not part of the global model thus set with the client.
+type GlobalTune struct {
+	name   string
+	data   string
+	client api.HAProxyClient
+}
+
+func NewGlobalTune(n string, c api.HAProxyClient) *GlobalTune {
+	return &GlobalTune{name: n, client: c}
+}
+
+func (a *GlobalTune) GetName() string {
+	return a.name
+}
+
+func (a *GlobalTune) Parse(input string) error {
+	v, err := strconv.ParseInt(input, 10, 64)
+	if err != nil {
+		return err
+	}
+	if v < tuneMin[a.name] {
+		return fmt.Errorf("value %d lower than %d", v, tuneMin[a.name])
+	}
+	a.data = strconv.FormatInt(v, 10)
+	return nil
+}
+
+func (a *GlobalTune) Update() error {
+	current, err := a.client.GlobalTuneGet(a.name)
+	if err != nil || current == a.data {
+		return err
+	}
+	logger.Infof("Setting %s to %s", a.name, a.data)
+	return a.client.GlobalTuneSet(a.name, a.data)
+}
@@ -1,6 +1,7 @@
 package annotations
 
 import (
+	"github.com/haproxytech/kubernetes-ingress/controller/metrics"
 	"github.com/haproxytech/kubernetes-ingress/controller/utils"
 )
 
@@ -15,6 +16,7 @@ var logger = utils.GetLogger()
 func HandleAnnotation(a Annotation, value string) {
 	err := a.Parse(value)
 	if err != nil {
+		metrics.AnnotationParseError(a.GetName())
 		logger.Errorf("%s: %s", a.GetName(), err)
 		return
 	}
@@ -0,0 +1,150 @@
+package annotations
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+// Scope is the configuration level an annotation applies to
+type Scope int
+
+//nolint: golint,stylecheck
+const (
+	SCOPE_GLOBAL Scope = iota
+	SCOPE_FRONTEND
+	SCOPE_BACKEND
+	SCOPE_SERVER
+)
+
+func (s Scope) String() string {
+	switch s {
+	case SCOPE_GLOBAL:
+		return "global"
+	case SCOPE_FRONTEND:
+		return "frontend"
+	case SCOPE_BACKEND:
+		return "backend"
+	case SCOPE_SERVER:
+		return "server"
+	}
+	return fmt.Sprintf("Scope(%d)", int(s))
+}
+
+// Context holds the objects an annotation can configure.
+// Only the fields relevant to the annotation scope are set:
+//   - SCOPE_GLOBAL: Global and Defaults
+//   - SCOPE_FRONTEND: Ingress and Cfg (to add HAProxy rules via Cfg.HAProxyRules)
+//   - SCOPE_BACKEND: Backend
+//   - SCOPE_SERVER: Server and Certs
+//
+// Owners names the resources of the annotations maps given to Handle, in the
+// same order, so configuration set by an annotation can be tracked to its resource.
+type Context struct {
+	Client   api.HAProxyClient
+	Store    store.K8s
+	Global   *models.Global
+	Defaults *models.Defaults
+	Ingress  *store.Ingress
+	Cfg      *config.ControllerCfg
+	Backend  *models.Backend
+	Server   *models.Server
+	Certs    *haproxy.Certificates
+	Owners   []string
+}
+
+// Factory returns the Annotation parsing and applying the annotation "name"
+// in the given context, or nil when the annotation does not apply to it.
+type Factory func(name string, ctx Context) Annotation
+
+type registration struct {
+	name    string
+	factory Factory
+}
+
+var (
+	registry   = make(map[Scope][]registration)
+	registryMu sync.RWMutex
+)
+
+// Register adds an annotation to the registry, annotations of a given scope
+// are handled in their registration order.
+// This is the extension point for custom annotations: a downstream build can
+// call Register from the init function of a package imported by its main package
+// instead of patching the controller. Each scope is dispatched by its Handle* function:
+//   - SCOPE_GLOBAL: HandleGlobalAnnotations, for the Global resource and main ConfigMap
+//   - SCOPE_FRONTEND: HandleFrontendAnnotations, for each ingress
+//   - SCOPE_BACKEND: HandleBackendAnnotations, for the backend of each service
+//   - SCOPE_SERVER: HandleServerAnnotations, for the servers of each service
+//
+// Register panics if the scope is not one of them or if the annotation is already
+// registered for the scope.
+func Register(name string, scope Scope, factory Factory) {
+	if scope < SCOPE_GLOBAL || scope > SCOPE_SERVER {
+		panic(fmt.Sprintf("annotation '%s' registered for unsupported %s scope", name, scope))
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, r := range registry[scope] {
+		if r.name == name {
+			panic(fmt.Sprintf("annotation '%s' already registered for %s scope", name, scope))
+		}
+	}
+	registry[scope] = append(registry[scope], registration{name: name, factory: factory})
+}
+
+// Registered returns the names of the annotations registered for the scope
+func Registered(scope Scope) []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry[scope]))
+	for _, r := range registry[scope] {
+		names = append(names, r.name)
+	}
+	return names
+}
+
+// Get returns the annotations registered for the scope which apply to the given context
+func Get(scope Scope, ctx Context) []Annotation {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	annotations := make([]Annotation, 0, len(registry[scope]))
+	for _, r := range registry[scope] {
+		if a := r.factory(r.name, ctx); a != nil {
+			annotations = append(annotations, a)
+		}
+	}
+	return annotations
+}
+
+// Handle parses and applies the annotations registered for the scope,
+// values are looked up in the given annotations maps by order of precedence.
+func Handle(scope Scope, ctx Context, annotations ...map[string]string) {
+	for _, a := range Get(scope, ctx) {
+		annValue := ctx.Store.GetValueFromAnnotations(a.GetName(), annotations...)
+		if annValue == "" {
+			continue
+		}
+		if owned, ok := a.(ownedAnnotation); ok {
+			owned.setOwner(owner(a.GetName(), ctx.Owners, annotations))
+		}
+		HandleAnnotation(a, annValue)
+	}
+}
+
+// owner returns the resource the annotation value comes from,
+// empty when the value is a default one.
+func owner(name string, owners []string, annotations []map[string]string) string {
+	for i, a := range annotations {
+		if _, ok := a[name]; ok && i < len(owners) {
+			return owners[i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package annotations
+
+import (
+	"testing"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+// customAnnotation records the value it is updated with in the context of its scope
+type customAnnotation struct {
+	name   string
+	value  string
+	update func(value string)
+}
+
+func (a *customAnnotation) GetName() string { return a.name }
+
+func (a *customAnnotation) Parse(value string) error {
+	a.value = value
+	return nil
+}
+
+func (a *customAnnotation) Update() error {
+	a.update(a.value)
+	return nil
+}
+
+func TestCustomBackendAndServerAnnotations(t *testing.T) {
+	Register("test-custom-backend", SCOPE_BACKEND, func(n string, ctx Context) Annotation {
+		return &customAnnotation{name: n, update: func(value string) { ctx.Backend.ExternalCheckCommand = value }}
+	})
+	Register("test-custom-server", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return &customAnnotation{name: n, update: func(value string) { ctx.Server.Cookie = value }}
+	})
+	values := map[string]string{
+		"test-custom-backend": "/bin/check",
+		"test-custom-server":  "custom-server",
+	}
+
+	backend := &models.Backend{Name: "test", Mode: "http"}
+	HandleBackendAnnotations(backend, store.K8s{}, nil, nil, values)
+	if backend.ExternalCheckCommand != "/bin/check" {
+		t.Errorf("custom backend annotation not applied, external-check command = '%s'", backend.ExternalCheckCommand)
+	}
+	server := &models.Server{}
+	HandleServerAnnotations(server, store.K8s{}, nil, nil, values)
+	if server.Cookie != "custom-server" {
+		t.Errorf("custom server annotation not applied, cookie = '%s'", server.Cookie)
+	}
+}
+
+func TestRegisterUnsupportedScope(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() of an unsupported scope did not panic")
+		}
+	}()
+	Register("test-custom-unsupported", SCOPE_SERVER+1, func(n string, ctx Context) Annotation { return nil })
+}
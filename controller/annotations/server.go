@@ -8,28 +8,65 @@ import (
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
 )
 
+func init() {
+	Register("check", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerCheck(n, ctx.Server)
+	})
+	Register("check-interval", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerCheckInter(n, ctx.Server)
+	})
+	Register("cookie-persistence", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerCookie(n, ctx.Server)
+	})
+	Register("observe-layer7", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerObserve(n, ctx.Server)
+	})
+	Register("error-limit", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerErrorLimit(n, ctx.Server)
+	})
+	Register("on-error", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerOnError(n, ctx.Server)
+	})
+	Register("pod-maxconn", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerMaxconn(n, ctx.Server)
+	})
+	Register("send-proxy-protocol", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerSendProxy(n, ctx.Server)
+	})
+	// server-maxconn is registered after pod-maxconn so it takes precedence
+	Register("server-maxconn", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerMaxconn(n, ctx.Server)
+	})
+	Register("server-maxqueue", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerMaxqueue(n, ctx.Server)
+	})
+	// Order is important for ssl annotations so they don't conflict
+	Register("server-ssl", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerSSL(n, ctx.Server)
+	})
+	Register("server-crt", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerCrt(n, ctx.Store, ctx.Certs, ctx.Server)
+	})
+	Register("server-ca", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerCA(n, ctx.Store, ctx.Certs, ctx.Server)
+	})
+	Register("server-sni", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerSNI(n, ctx.Server)
+	})
+	Register("server-proto", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerProto(n, ctx.Server)
+	})
+	// Registered after ssl and proto annotations to take precedence over them
+	Register("backend-protocol", SCOPE_SERVER, func(n string, ctx Context) Annotation {
+		return NewServerBackendProtocol(n, ctx.Server)
+	})
+}
+
 // HandleServerAnnotations returns a pointer to a server model holding server configuration from annotations
 func HandleServerAnnotations(server *models.Server, k8sStore store.K8s, client api.HAProxyClient, haproxyCerts *haproxy.Certificates, annotations ...map[string]string) {
-	for _, a := range GetServerAnnotations(server, k8sStore, haproxyCerts) {
-		annValue := k8sStore.GetValueFromAnnotations(a.GetName(), annotations...)
-		if annValue == "" {
-			continue
-		}
-		HandleAnnotation(a, annValue)
-	}
+	Handle(SCOPE_SERVER, Context{Client: client, Store: k8sStore, Server: server, Certs: haproxyCerts}, annotations...)
 }
 
 func GetServerAnnotations(s *models.Server, k8sStore store.K8s, certs *haproxy.Certificates) []Annotation {
-	return []Annotation{
-		NewServerCheck("check", s),
-		NewServerCheckInter("check-interval", s),
-		NewServerCookie("cookie-persistence", s),
-		NewServerMaxconn("pod-maxconn", s),
-		NewServerSendProxy("send-proxy-protocol", s),
-		// Order is important for ssl annotations so they don't conflict
-		NewServerSSL("server-ssl", s),
-		NewServerCrt("server-crt", k8sStore, certs, s),
-		NewServerCA("server-ca", k8sStore, certs, s),
-		NewServerProto("server-proto", s),
-	}
+	return Get(SCOPE_SERVER, Context{Store: k8sStore, Server: s, Certs: certs})
 }
@@ -0,0 +1,53 @@
+package annotations
+
+import (
+	"fmt"
+
+	"github.com/haproxytech/client-native/v2/models"
+)
+
+// ServerBackendProtocol sets the protocol used to connect to backend servers:
+// negotiated via ALPN with SSL servers, set with "proto" otherwise.
+type ServerBackendProtocol struct {
+	name   string
+	proto  string
+	server *models.Server
+}
+
+func NewServerBackendProtocol(n string, s *models.Server) *ServerBackendProtocol {
+	return &ServerBackendProtocol{name: n, server: s}
+}
+
+func (a *ServerBackendProtocol) GetName() string {
+	return a.name
+}
+
+func (a *ServerBackendProtocol) Parse(input string) error {
+	switch input {
+	case "h1", "h2", "grpc":
+		a.proto = input
+	default:
+		return fmt.Errorf("unknown protocol '%s'", input)
+	}
+	return nil
+}
+
+func (a *ServerBackendProtocol) Update() error {
+	ssl := a.server.Ssl == "enabled"
+	switch {
+	case a.proto == "h1" && ssl:
+		a.server.Alpn = "http/1.1"
+		a.server.Proto = ""
+	case a.proto == "h1":
+		a.server.Alpn = ""
+		a.server.Proto = ""
+	case ssl:
+		// h2 and grpc
+		a.server.Alpn = "h2"
+		a.server.Proto = ""
+	default:
+		a.server.Alpn = ""
+		a.server.Proto = "h2"
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package annotations
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/haproxytech/client-native/v2/models"
+)
+
+type ServerErrorLimit struct {
+	name   string
+	limit  int64
+	server *models.Server
+}
+
+func NewServerErrorLimit(n string, s *models.Server) *ServerErrorLimit {
+	return &ServerErrorLimit{name: n, server: s}
+}
+
+func (a *ServerErrorLimit) GetName() string {
+	return a.name
+}
+
+func (a *ServerErrorLimit) Parse(input string) error {
+	v, err := strconv.ParseInt(input, 10, 64)
+	if err != nil || v < 1 {
+		return fmt.Errorf("incorrect value '%s'", input)
+	}
+	a.limit = v
+	return nil
+}
+
+func (a *ServerErrorLimit) Update() error {
+	a.server.ErrorLimit = a.limit
+	return nil
+}
@@ -0,0 +1,36 @@
+package annotations
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/haproxytech/client-native/v2/models"
+)
+
+type ServerMaxqueue struct {
+	name     string
+	maxqueue *int64
+	server   *models.Server
+}
+
+func NewServerMaxqueue(n string, s *models.Server) *ServerMaxqueue {
+	return &ServerMaxqueue{name: n, server: s}
+}
+
+func (a *ServerMaxqueue) GetName() string {
+	return a.name
+}
+
+func (a *ServerMaxqueue) Parse(input string) error {
+	v, err := strconv.ParseInt(input, 10, 64)
+	if err != nil || v < 0 {
+		return fmt.Errorf("incorrect value '%s'", input)
+	}
+	a.maxqueue = &v
+	return nil
+}
+
+func (a *ServerMaxqueue) Update() error {
+	a.server.Maxqueue = a.maxqueue
+	return nil
+}
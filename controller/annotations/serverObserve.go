@@ -0,0 +1,38 @@
+package annotations
+
+import (
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// ServerObserve makes HAProxy observe the HTTP responses of servers in live
+// traffic, errors then trigger the on-error action after error-limit ones.
+type ServerObserve struct {
+	name    string
+	enabled bool
+	server  *models.Server
+}
+
+func NewServerObserve(n string, s *models.Server) *ServerObserve {
+	return &ServerObserve{name: n, server: s}
+}
+
+func (a *ServerObserve) GetName() string {
+	return a.name
+}
+
+func (a *ServerObserve) Parse(input string) error {
+	var err error
+	a.enabled, err = utils.GetBoolValue(input, "observe-layer7")
+	return err
+}
+
+func (a *ServerObserve) Update() error {
+	if a.enabled {
+		a.server.Observe = "layer7"
+	} else {
+		a.server.Observe = ""
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package annotations
+
+import (
+	"fmt"
+
+	"github.com/haproxytech/client-native/v2/models"
+)
+
+type ServerOnError struct {
+	name   string
+	action string
+	server *models.Server
+}
+
+func NewServerOnError(n string, s *models.Server) *ServerOnError {
+	return &ServerOnError{name: n, server: s}
+}
+
+func (a *ServerOnError) GetName() string {
+	return a.name
+}
+
+func (a *ServerOnError) Parse(input string) error {
+	switch input {
+	case "fastinter", "fail-check", "sudden-death", "mark-down":
+		a.action = input
+	default:
+		return fmt.Errorf("incorrect value '%s'", input)
+	}
+	return nil
+}
+
+func (a *ServerOnError) Update() error {
+	a.server.OnError = a.action
+	return nil
+}
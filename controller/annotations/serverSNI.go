@@ -0,0 +1,51 @@
+package annotations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/haproxytech/client-native/v2/models"
+)
+
+type ServerSNI struct {
+	name   string
+	sni    string
+	host   string
+	server *models.Server
+}
+
+func NewServerSNI(n string, s *models.Server) *ServerSNI {
+	return &ServerSNI{name: n, server: s}
+}
+
+func (a *ServerSNI) GetName() string {
+	return a.name
+}
+
+// Parse accepts either a hostname or a sample expression
+// such as "req.hdr(host)" evaluated for each connection.
+func (a *ServerSNI) Parse(input string) error {
+	if strings.ContainsAny(input, " \t") {
+		return fmt.Errorf("incorrect value '%s'", input)
+	}
+	a.sni, a.host = input, ""
+	if !strings.Contains(input, "(") {
+		a.sni = "str(" + input + ")"
+		a.host = input
+	}
+	return nil
+}
+
+func (a *ServerSNI) Update() error {
+	if a.server.Ssl != "enabled" {
+		return errors.New("requires server-ssl, server-crt or server-ca")
+	}
+	a.server.Sni = a.sni
+	// Health checks and server certificate verification use the same hostname
+	a.server.CheckSni = a.host
+	if a.server.Verify == "required" {
+		a.server.Verifyhost = a.host
+	}
+	return nil
+}
@@ -26,6 +26,9 @@ func (a *ServerSendProxy) Parse(input string) error {
 	switch v {
 	case "proxy", "proxy-v1", "proxy-v2", "proxy-v2-ssl", "proxy-v2-ssl-cn":
 		a.proxyPorto = v
+	case "v1", "v2", "v2-ssl", "v2-ssl-cn":
+		// Short form of protocol versions
+		a.proxyPorto = "proxy-" + v
 	default:
 		return fmt.Errorf("%s is an unknown enum", input)
 	}
@@ -0,0 +1,257 @@
+package annotations
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/haproxytech/config-parser/v4/types"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+)
+
+// Config snippets set by annotations are tracked by HAProxy section, with the
+// resource their annotation comes from. Snippets of sections which were not set
+// during a sync, because the annotation was removed or the resource deleted,
+// are then removed by PurgeSnippets. Snippets updated during a sync are checked
+// by ValidateSnippets, the invalid ones being reverted to their previous value.
+
+//nolint: golint,stylecheck
+const (
+	SNIPPET_GLOBAL   = "global"
+	SNIPPET_FRONTEND = "frontend"
+	SNIPPET_BACKEND  = "backend"
+)
+
+// ownedAnnotation is implemented by annotations tracking the resource they come from
+type ownedAnnotation interface {
+	setOwner(owner string)
+}
+
+// ownerNamespace returns the namespace of the "<kind> <namespace>/<name>" owner
+func ownerNamespace(owner string) string {
+	i := strings.IndexByte(owner, ' ')
+	j := strings.IndexByte(owner, '/')
+	if i == -1 || j < i {
+		return ""
+	}
+	return owner[i+1 : j]
+}
+
+type snippetSection struct {
+	kind string
+	name string
+}
+
+func (s snippetSection) String() string {
+	if s.name == "" {
+		return s.kind
+	}
+	return fmt.Sprintf("%s '%s'", s.kind, s.name)
+}
+
+// snippetChange is a config snippet updated during current sync
+type snippetChange struct {
+	owner    string
+	previous []string
+	data     []string
+}
+
+// RejectedSnippet is a config snippet which made HAProxy configuration invalid,
+// the previous snippet of the section being kept.
+type RejectedSnippet struct {
+	// Owner is the "<kind> <namespace>/<name>" resource of the annotation
+	Owner   string
+	Section string
+	Err     error
+}
+
+var snippets = struct {
+	sync.Mutex
+	// owners of the snippets set during current sync
+	owners map[snippetSection]string
+	// changed is true when a snippet changed during current sync
+	changed bool
+	// changes holds the snippets updated during current sync
+	changes map[snippetSection]snippetChange
+	// rejected holds the invalid snippets, which are not applied again until they change
+	rejected map[snippetSection][]string
+}{
+	owners:   make(map[snippetSection]string),
+	changes:  make(map[snippetSection]snippetChange),
+	rejected: make(map[snippetSection][]string),
+}
+
+// setSnippet sets the config snippet of the section when it differs from the
+// current one, and records the section as owned by owner for current sync.
+func setSnippet(client api.HAProxyClient, kind, name, owner string, data []string) error {
+	section := snippetSection{kind: kind, name: name}
+	snippets.Lock()
+	if previous, ok := snippets.owners[section]; ok && previous != owner {
+		logger.Warningf("%s '%s' config-snippet of %s overridden by %s", kind, name, previous, owner)
+	}
+	snippets.owners[section] = owner
+	rejected, isRejected := snippets.rejected[section]
+	snippets.Unlock()
+	if isRejected && equalSnippets(rejected, data) {
+		return fmt.Errorf("%s config-snippet of %s rejected by a previous configuration check", section, owner)
+	}
+	current, err := getSnippet(client, section)
+	if err != nil {
+		return err
+	}
+	if equalSnippets(current, data) {
+		return nil
+	}
+	if err = writeSnippet(client, section, data); err != nil {
+		return err
+	}
+	logger.Debugf("%s '%s' config-snippet of %s updated, reload required", kind, name, owner)
+	snippets.Lock()
+	snippets.changed = true
+	if _, ok := snippets.changes[section]; !ok {
+		snippets.changes[section] = snippetChange{owner: owner, previous: current, data: data}
+	} else {
+		change := snippets.changes[section]
+		change.owner, change.data = owner, data
+		snippets.changes[section] = change
+	}
+	delete(snippets.rejected, section)
+	snippets.Unlock()
+	return nil
+}
+
+// PurgeSnippets removes the config snippets of global, frontends and backends
+// sections which were not set during current sync, then starts tracking the
+// next sync. It reports whether snippets changed, requiring a reload.
+func PurgeSnippets(client api.HAProxyClient) (reload bool) {
+	snippets.Lock()
+	owners := snippets.owners
+	reload = snippets.changed
+	snippets.owners = make(map[snippetSection]string)
+	snippets.changed = false
+	snippets.Unlock()
+
+	sections := []snippetSection{{kind: SNIPPET_GLOBAL}}
+	if frontends, err := client.FrontendsGet(); err == nil {
+		for _, frontend := range frontends {
+			sections = append(sections, snippetSection{kind: SNIPPET_FRONTEND, name: frontend.Name})
+		}
+	}
+	if backends, err := client.BackendsGet(); err == nil {
+		for _, backend := range backends {
+			sections = append(sections, snippetSection{kind: SNIPPET_BACKEND, name: backend.Name})
+		}
+	}
+	snippets.Lock()
+	for section := range snippets.rejected {
+		if _, ok := owners[section]; !ok {
+			delete(snippets.rejected, section)
+		}
+	}
+	snippets.Unlock()
+	for _, section := range sections {
+		if _, ok := owners[section]; ok {
+			continue
+		}
+		current, err := getSnippet(client, section)
+		if err != nil || len(current) == 0 {
+			continue
+		}
+		if err = writeSnippet(client, section, nil); err != nil {
+			logger.Error(err)
+			continue
+		}
+		logger.Debugf("orphan %s '%s' config-snippet removed, reload required", section.kind, section.name)
+		reload = true
+	}
+	return reload
+}
+
+// ValidateSnippets checks HAProxy configuration when config snippets were updated
+// during current sync. When it is invalid because of some of them, they are reverted
+// to their previous value and returned, so a single invalid snippet does not prevent
+// the whole configuration from being applied.
+// It is called after PurgeSnippets, once all snippets are set.
+func ValidateSnippets(client api.HAProxyClient) (rejected []RejectedSnippet) {
+	snippets.Lock()
+	changes := snippets.changes
+	snippets.changes = make(map[snippetSection]snippetChange)
+	snippets.Unlock()
+	if len(changes) == 0 || client.APICheckTransaction() == nil {
+		return nil
+	}
+	sections := make([]snippetSection, 0, len(changes))
+	for section := range changes {
+		sections = append(sections, section)
+	}
+	sort.Slice(sections, func(i, j int) bool {
+		return sections[i].String() < sections[j].String()
+	})
+	for _, section := range sections {
+		logger.Error(writeSnippet(client, section, changes[section].previous))
+	}
+	if err := client.APICheckTransaction(); err != nil {
+		// Not caused by snippets, the error is reported when committing the configuration
+		for _, section := range sections {
+			logger.Error(writeSnippet(client, section, changes[section].data))
+		}
+		return nil
+	}
+	// Snippets are applied back one by one, keeping the valid ones
+	for _, section := range sections {
+		change := changes[section]
+		logger.Error(writeSnippet(client, section, change.data))
+		err := client.APICheckTransaction()
+		if err == nil {
+			continue
+		}
+		logger.Error(writeSnippet(client, section, change.previous))
+		snippets.Lock()
+		snippets.rejected[section] = change.data
+		snippets.Unlock()
+		rejected = append(rejected, RejectedSnippet{
+			Owner:   change.owner,
+			Section: section.String(),
+			Err:     err,
+		})
+	}
+	return rejected
+}
+
+func getSnippet(client api.HAProxyClient, section snippetSection) ([]string, error) {
+	switch section.kind {
+	case SNIPPET_GLOBAL:
+		return client.GlobalCfgSnippetGet()
+	case SNIPPET_FRONTEND:
+		return client.FrontendCfgSnippetGet(section.name)
+	default:
+		return client.BackendCfgSnippetGet(section.name)
+	}
+}
+
+// writeSnippet replaces the config snippet of the section, removing it when data is empty
+func writeSnippet(client api.HAProxyClient, section snippetSection, data []string) error {
+	switch section.kind {
+	case SNIPPET_GLOBAL:
+		if len(data) == 0 {
+			return client.GlobalCfgSnippet(nil)
+		}
+		return client.GlobalCfgSnippet(&types.StringSliceC{Value: data})
+	case SNIPPET_FRONTEND:
+		if len(data) == 0 {
+			return client.FrontendCfgSnippetSet(section.name, nil)
+		}
+		return client.FrontendCfgSnippetSet(section.name, &data)
+	default:
+		if len(data) == 0 {
+			return client.BackendCfgSnippetSet(section.name, nil)
+		}
+		return client.BackendCfgSnippetSet(section.name, &data)
+	}
+}
+
+func equalSnippets(a, b []string) bool {
+	return strings.Join(a, "\n") == strings.Join(b, "\n")
+}
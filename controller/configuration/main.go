@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/rules"
@@ -29,14 +31,59 @@ type ControllerCfg struct {
 	HAProxyRules    *haproxy.Rules
 	Certificates    *haproxy.Certificates
 	ActiveBackends  map[string]struct{}
-	RateLimitTables []string
+	RateLimitTables map[string]RateLimitTable
 	FrontHTTP       string
 	FrontHTTPS      string
 	FrontSSL        string
+	FrontStats      string
+	ExtraFrontends  map[string]ExtraFrontend
 	BackSSL         string
 	Env             Env
 	HTTPS           bool
 	SSLPassthrough  bool
+	SPOE            bool // set when ingress annotations require the controller SPOE agent
+	FaultDelay      bool // set when ingress annotations require the fault injection Lua script
+
+	// Unused backends kept in drain until backend-removal-grace-period expires
+	DrainingBackends map[string]*DrainingBackend
+
+	// Request headers forwarded to auth-request-service, sent by the SPOE auth-request message
+	AuthRequestHeaders map[string]struct{}
+}
+
+// HTTPFrontends returns the main HTTP and HTTPS frontends followed by
+// the extra frontends of the "frontends" annotation.
+func (c *ControllerCfg) HTTPFrontends() []string {
+	frontends := []string{c.FrontHTTP, c.FrontHTTPS}
+	extra := make([]string, 0, len(c.ExtraFrontends))
+	for name := range c.ExtraFrontends {
+		extra = append(extra, name)
+	}
+	sort.Strings(extra)
+	return append(frontends, extra...)
+}
+
+// ExtraFrontend is an HTTP frontend declared with the "frontends" annotation,
+// exposing the routes of the ingresses selecting it with the "frontend" annotation.
+type ExtraFrontend struct {
+	Port int64
+	SSL  bool
+}
+
+// RateLimitTable is the definition of a rate limiting stick-table,
+// Ingress is the first ingress which defined it.
+type RateLimitTable struct {
+	Period  int64
+	Size    *int64
+	Key     string
+	Ingress string
+}
+
+// DrainingBackend is an unused backend whose servers are in drain
+// since Since, Servers being the ones set in drain by the controller.
+type DrainingBackend struct {
+	Since   time.Time
+	Servers []string
 }
 
 // Directories and files required by haproxy and controller
@@ -64,7 +111,9 @@ func (c *ControllerCfg) Init() (err error) {
 	c.FrontHTTP = "http"
 	c.FrontHTTPS = "https"
 	c.FrontSSL = "ssl"
+	c.FrontStats = "stats"
 	c.BackSSL = "ssl"
+	c.ExtraFrontends = make(map[string]ExtraFrontend)
 	if err = c.envInit(); err != nil {
 		return err
 	}
@@ -74,6 +123,9 @@ func (c *ControllerCfg) Init() (err error) {
 	}
 	c.Certificates = haproxy.NewCertificates(c.Env.CaCertDir, c.Env.FrontendCertDir, c.Env.BackendCertDir)
 	c.ActiveBackends = make(map[string]struct{})
+	c.RateLimitTables = make(map[string]RateLimitTable)
+	c.AuthRequestHeaders = make(map[string]struct{})
+	c.DrainingBackends = make(map[string]*DrainingBackend)
 	return nil
 }
 
@@ -81,7 +133,11 @@ func (c *ControllerCfg) haproxyRulesInit() error {
 	if c.HAProxyRules == nil {
 		c.HAProxyRules = haproxy.NewRules()
 	} else {
-		c.HAProxyRules.Clean(c.FrontHTTP, c.FrontHTTPS, c.FrontSSL)
+		frontends := []string{c.FrontHTTP, c.FrontHTTPS, c.FrontSSL}
+		for name := range c.ExtraFrontends {
+			frontends = append(frontends, name)
+		}
+		c.HAProxyRules.Clean(frontends...)
 	}
 	var errors utils.Errors
 	errors.Add(
@@ -89,47 +145,68 @@ func (c *ControllerCfg) haproxyRulesInit() error {
 		c.HAProxyRules.AddRule(rules.SetHdr{
 			ForwardedProto: true,
 		}, "", c.FrontHTTPS),
+		c.AddRoutingRules("", c.FrontHTTP, c.FrontHTTPS),
+	)
+
+	return errors.Result()
+}
+
+// AddRoutingRules adds the rules setting the variables used for backend switching
+// to the frontends, with the host and path maps of the given extra frontend,
+// the maps of the main frontends being used when extraFrontend is empty.
+func (c *ControllerCfg) AddRoutingRules(extraFrontend string, frontends ...string) error {
+	hostMap := haproxy.GetMapPath(haproxy.FrontendMap(haproxy.MAP_HOST, extraFrontend))
+	pathExactMap := haproxy.GetMapPath(haproxy.FrontendMap(haproxy.MAP_PATH_EXACT, extraFrontend))
+	pathPrefixExactMap := haproxy.GetMapPath(haproxy.FrontendMap(haproxy.MAP_PATH_PREFIX_EXACT, extraFrontend))
+	pathPrefixMap := haproxy.GetMapPath(haproxy.FrontendMap(haproxy.MAP_PATH_PREFIX, extraFrontend))
+	var errors utils.Errors
+	errors.Add(
 		// txn.base var used for logging
 		c.HAProxyRules.AddRule(rules.ReqSetVar{
 			Name:       "base",
 			Scope:      "txn",
 			Expression: "base",
-		}, "", c.FrontHTTP, c.FrontHTTPS),
+		}, "", frontends...),
 		// Backend switching rules.
 		c.HAProxyRules.AddRule(rules.ReqSetVar{
 			Name:       "path",
 			Scope:      "txn",
 			Expression: "path",
-		}, "", c.FrontHTTP, c.FrontHTTPS),
+		}, "", frontends...),
 		c.HAProxyRules.AddRule(rules.ReqSetVar{
 			Name:       "host",
 			Scope:      "txn",
 			Expression: "req.hdr(Host),field(1,:),lower",
-		}, "", c.FrontHTTP, c.FrontHTTPS),
+		}, "", frontends...),
 		c.HAProxyRules.AddRule(rules.ReqSetVar{
 			Name:       "host_match",
 			Scope:      "txn",
-			Expression: fmt.Sprintf("var(txn.host),map(%s)", haproxy.GetMapPath(haproxy.MAP_HOST)),
-		}, "", c.FrontHTTP, c.FrontHTTPS),
+			Expression: fmt.Sprintf("var(txn.host),map(%s)", hostMap),
+		}, "", frontends...),
 		c.HAProxyRules.AddRule(rules.ReqSetVar{
 			Name:       "host_match",
 			Scope:      "txn",
-			Expression: fmt.Sprintf("var(txn.host),regsub(^[^.]*,,),map(%s,'')", haproxy.GetMapPath(haproxy.MAP_HOST)),
+			Expression: fmt.Sprintf("var(txn.host),regsub(^[^.]*,,),map(%s,'')", hostMap),
 			CondTest:   "!{ var(txn.host_match) -m found }",
-		}, "", c.FrontHTTP, c.FrontHTTPS),
+		}, "", frontends...),
+		c.HAProxyRules.AddRule(rules.ReqSetVar{
+			Name:       "path_match",
+			Scope:      "txn",
+			Expression: fmt.Sprintf("var(txn.host_match),concat(,txn.path,),map(%s)", pathExactMap),
+		}, "", frontends...),
 		c.HAProxyRules.AddRule(rules.ReqSetVar{
 			Name:       "path_match",
 			Scope:      "txn",
-			Expression: fmt.Sprintf("var(txn.host_match),concat(,txn.path,),map(%s)", haproxy.GetMapPath(haproxy.MAP_PATH_EXACT)),
-		}, "", c.FrontHTTP, c.FrontHTTPS),
+			Expression: fmt.Sprintf("var(txn.host_match),concat(,txn.path,),map(%s)", pathPrefixExactMap),
+			CondTest:   "!{ var(txn.path_match) -m found }",
+		}, "", frontends...),
 		c.HAProxyRules.AddRule(rules.ReqSetVar{
 			Name:       "path_match",
 			Scope:      "txn",
-			Expression: fmt.Sprintf("var(txn.host_match),concat(,txn.path,),map_beg(%s)", haproxy.GetMapPath(haproxy.MAP_PATH_PREFIX)),
+			Expression: fmt.Sprintf("var(txn.host_match),concat(,txn.path,),map_beg(%s)", pathPrefixMap),
 			CondTest:   "!{ var(txn.path_match) -m found }",
-		}, "", c.FrontHTTP, c.FrontHTTPS),
+		}, "", frontends...),
 	)
-
 	return errors.Result()
 }
 
@@ -198,7 +275,10 @@ func (c *ControllerCfg) envInit() (err error) {
 // Clean cleans all the statuses of various data that was changed
 // deletes them completely or just resets them if needed
 func (c *ControllerCfg) Clean() error {
-	c.RateLimitTables = []string{}
+	c.RateLimitTables = make(map[string]RateLimitTable)
+	c.AuthRequestHeaders = make(map[string]struct{})
+	c.SPOE = false
+	c.FaultDelay = false
 	c.ActiveBackends = make(map[string]struct{})
 	c.MapFiles.Clean()
 	c.Certificates.Clean()
@@ -15,23 +15,37 @@
 package controller
 
 import (
+	"fmt"
+	"net"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/haproxytech/client-native/v2/models"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/record"
 
+	"github.com/haproxytech/kubernetes-ingress/controller/annotations"
 	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/process"
 	"github.com/haproxytech/kubernetes-ingress/controller/route"
+	"github.com/haproxytech/kubernetes-ingress/controller/service"
+	"github.com/haproxytech/kubernetes-ingress/controller/spoe"
 	"github.com/haproxytech/kubernetes-ingress/controller/status"
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/udp"
 	"github.com/haproxytech/kubernetes-ingress/controller/utils"
 )
 
-// HAProxyController is ingress controller
+// HAProxyController is ingress controller.
+// It is created with New and run with Start until Stop is called,
+// so it can be embedded in other programs.
 type HAProxyController struct {
 	Cfg            config.ControllerCfg
 	Client         api.HAProxyClient
@@ -42,11 +56,68 @@ type HAProxyController struct {
 	eventChan      chan SyncDataEvent
 	statusChan     chan status.SyncIngress
 	k8s            *K8s
+	recorder       record.EventRecorder
 	ready          bool
 	reload         bool
 	restart        bool
 	updateHandlers []UpdateHandler
 	haproxyProcess process.Process
+	spoeAgentOnce  sync.Once
+	spoeAgent      *spoe.Agent
+	udpProxy       *udp.Proxy
+	svcSettings    service.Settings
+	customRoutes   *route.CustomRoutes
+	lastFullSync   time.Time
+	stop           chan struct{}
+	stopOnce       sync.Once
+	// routines are the goroutines watching resources and syncing HAProxy, Stop waits for them
+	routines sync.WaitGroup
+	// gracePeriod of grace-period annotation in milliseconds, accessed atomically
+	gracePeriod int64
+}
+
+// Options configures the HAProxyController returned by New.
+// Cfg and OSArgs are required, dependencies left unset are created by Start,
+// setting them allows to embed the controller or to run it with fakes in tests.
+type Options struct {
+	Cfg    config.ControllerCfg
+	OSArgs utils.OSArgs
+	// Store holds Kubernetes resources, created from OSArgs when nil
+	Store *store.K8s
+	// Client configures HAProxy, initialized with Cfg.Env files when nil
+	Client api.HAProxyClient
+	// K8s is the Kubernetes API client, in cluster one or the one of
+	// OSArgs.KubeConfig in external mode when nil
+	K8s *K8s
+	// Process controls HAProxy process, started by the controller when nil
+	Process process.Process
+}
+
+// New returns a HAProxyController configured with opts, controllers
+// share no state so that several of them can run in the same program.
+func New(opts Options) *HAProxyController {
+	c := &HAProxyController{
+		Cfg:            opts.Cfg,
+		OSArgs:         opts.OSArgs,
+		Client:         opts.Client,
+		k8s:            opts.K8s,
+		haproxyProcess: opts.Process,
+		udpProxy:       udp.NewProxy(),
+		svcSettings:    service.Settings{ClusterDomain: "cluster.local"},
+		customRoutes:   route.NewCustomRoutes(),
+	}
+	if c.OSArgs.SPOEAgentAddr == "" {
+		c.OSArgs.SPOEAgentAddr = spoe.DefaultAgentAddr
+	}
+	if c.OSArgs.SPOEAgentAdvertiseAddr == "" {
+		c.OSArgs.SPOEAgentAdvertiseAddr = c.OSArgs.SPOEAgentAddr
+	}
+	if opts.Store != nil {
+		c.Store = *opts.Store
+	} else {
+		c.Store = store.NewK8sStore(opts.OSArgs)
+	}
+	return c
 }
 
 // Wrapping a Native-Client transaction and commit it.
@@ -67,22 +138,50 @@ func (c *HAProxyController) clientAPIClosure(fn func() error) (err error) {
 	return nil
 }
 
-// Start initializes and runs HAProxyController
-func (c *HAProxyController) Start() {
+// Start initializes and runs HAProxyController, Kubernetes resources
+// are then watched and HAProxy configured until Stop is called.
+// Stop must also be called when Start fails, to stop HAProxy if it was started.
+func (c *HAProxyController) Start() error {
 	var err error
 	logger.SetLevel(c.OSArgs.LogLevel.LogLevel)
+	c.stop = make(chan struct{})
 
 	// Initialize controller
 	err = c.Cfg.Init()
 	if err != nil {
-		logger.Panic(err)
+		return err
 	}
-	c.Client, err = api.Init(c.Cfg.Env.TransactionDir, c.Cfg.Env.MainCFGFile, c.Cfg.Env.HAProxyBinary, c.Cfg.Env.RuntimeSocket)
-	if err != nil {
-		logger.Panic(err)
+	c.svcSettings.ErrFileDir = c.Cfg.Env.ErrFileDir
+	if c.OSArgs.ClusterDomain != "" {
+		c.svcSettings.ClusterDomain = c.OSArgs.ClusterDomain
+	}
+	if c.OSArgs.TransparentProxy {
+		var netAdmin bool
+		if netAdmin, err = utils.HasCapability(utils.CAP_NET_ADMIN); err != nil {
+			return fmt.Errorf("transparent-proxy: unable to check capabilities: %w", err)
+		} else if !netAdmin {
+			return fmt.Errorf("transparent-proxy: NET_ADMIN capability is required")
+		}
+		c.svcSettings.TransparentProxy = true
+	}
+	if c.OSArgs.SPOEAgent && c.spoeAgentUnreachable() {
+		return fmt.Errorf("spoe-agent: Dataplane API instances cannot reach the SPOE agent on loopback address '%s', set --spoe-agent-advertise-address", c.OSArgs.SPOEAgentAdvertiseAddr)
+	}
+	if c.Client == nil {
+		c.Client, err = api.Init(c.Cfg.Env.TransactionDir, c.Cfg.Env.MainCFGFile, c.Cfg.Env.HAProxyBinary, c.Cfg.Env.RuntimeSocket)
+		if err != nil {
+			return err
+		}
+	}
+	if err = c.initHandlers(); err != nil {
+		return err
+	}
+	if err = c.haproxyStartup(); err != nil {
+		return err
+	}
+	if c.OSArgs.MetricsBindAddr != "" {
+		go c.serveMetrics()
 	}
-	c.initHandlers()
-	c.haproxyStartup()
 
 	// Controller PublishService
 	parts := strings.Split(c.OSArgs.PublishService, "/")
@@ -94,38 +193,146 @@ func (c *HAProxyController) Start() {
 	}
 
 	// Get K8s client
-	c.k8s, err = GetKubernetesClient(c.OSArgs.DisableServiceExternalName)
-	if c.OSArgs.External {
-		kubeconfig := filepath.Join(utils.HomeDir(), ".kube", "config")
-		if c.OSArgs.KubeConfig != "" {
-			kubeconfig = c.OSArgs.KubeConfig
+	if c.k8s == nil {
+		c.k8s, err = GetKubernetesClient(c.OSArgs.DisableServiceExternalName)
+		if c.OSArgs.External {
+			kubeconfig := filepath.Join(utils.HomeDir(), ".kube", "config")
+			if c.OSArgs.KubeConfig != "" {
+				kubeconfig = c.OSArgs.KubeConfig
+			}
+			c.k8s, err = GetRemoteKubernetesClient(kubeconfig, c.OSArgs.DisableServiceExternalName)
+		}
+		if err != nil {
+			return err
 		}
-		c.k8s, err = GetRemoteKubernetesClient(kubeconfig, c.OSArgs.DisableServiceExternalName)
-	}
-	if err != nil {
-		logger.Panic(err)
 	}
+	c.recorder = c.k8s.EventRecorder()
 	x := c.k8s.API.Discovery()
-	if k8sVersion, err := x.ServerVersion(); err != nil {
-		logger.Panicf("Unable to get Kubernetes version: %v\n", err)
-	} else {
-		logger.Printf("Running on Kubernetes version: %s %s", k8sVersion.String(), k8sVersion.Platform)
+	k8sVersion, err := x.ServerVersion()
+	if err != nil {
+		return fmt.Errorf("unable to get Kubernetes version: %w", err)
 	}
+	logger.Printf("Running on Kubernetes version: %s %s", k8sVersion.String(), k8sVersion.Platform)
+	c.setZone()
 
 	// Monitor k8s events
 	c.eventChan = make(chan SyncDataEvent, watch.DefaultChanSize*6)
-	go c.monitorChanges()
+	c.run(c.monitorChanges)
 	if c.PublishService != nil {
 		// Update Ingress status
 		c.statusChan = make(chan status.SyncIngress, watch.DefaultChanSize*6)
-		go status.UpdateIngress(c.k8s.API, c.Store, c.statusChan)
+		c.run(func() {
+			status.UpdateIngress(c.k8s.API, c.Store, c.statusChan, c.stop)
+		})
 	}
+	return nil
 }
 
-// Stop handles shutting down HAProxyController
+// run runs fn in a goroutine which Stop waits for, fn must return once the controller is stopped.
+func (c *HAProxyController) run(fn func()) {
+	c.routines.Add(1)
+	go func() {
+		defer c.routines.Done()
+		fn()
+	}()
+}
+
+// Stop handles shutting down HAProxyController: Kubernetes
+// resources are no more watched and HAProxy is soft-stopped,
+// after the grace period during which it is reported as not ready.
+// It returns once the controller goroutines are done.
 func (c *HAProxyController) Stop() {
 	logger.Infof("Stopping Ingress Controller")
-	logger.Error(c.haproxyService("stop"))
+	c.stopOnce.Do(func() {
+		if c.stop != nil {
+			close(c.stop)
+		}
+	})
+	c.routines.Wait()
+	c.drain()
+	c.udpProxy.Close()
+	if c.haproxyProcess != nil {
+		logger.Error(c.haproxyService("stop"))
+	}
+	if c.spoeAgent != nil {
+		logger.Error(c.spoeAgent.Close())
+	}
+}
+
+// drain fails HAProxy health checks and keeps serving requests for the grace period,
+// so that load balancers and endpoints stop sending new connections before soft-stop.
+// HAProxy instances configured with --dataplane-url are not stopped with the controller.
+func (c *HAProxyController) drain() {
+	grace := time.Duration(atomic.LoadInt64(&c.gracePeriod)) * time.Millisecond
+	if grace == 0 || len(c.OSArgs.DataplaneURLs) != 0 {
+		return
+	}
+	if _, err := c.Client.ExecuteRaw("disable frontend healthz"); err != nil {
+		logger.Errorf("unable to disable healthz frontend: %s", err)
+	}
+	logger.Infof("Waiting %s grace period before HAProxy soft-stop", grace)
+	time.Sleep(grace)
+}
+
+// setZone sets the zone of the controller used by topology-aware-routing annotation, when
+// --zone is not set it is the one of the node of the controller pod given by POD_NAMESPACE
+// and POD_NAME environment variables, out of cluster controllers having no zone.
+func (c *HAProxyController) setZone() {
+	c.svcSettings.Zone = c.OSArgs.Zone
+	podNamespace, podName := os.Getenv("POD_NAMESPACE"), os.Getenv("POD_NAME")
+	if c.svcSettings.Zone == "" && !c.OSArgs.External && podNamespace != "" && podName != "" {
+		zone, err := c.k8s.GetPodZone(podNamespace, podName)
+		if err != nil {
+			logger.Warningf("unable to get controller zone: %s", err)
+		}
+		c.svcSettings.Zone = zone
+	}
+	if c.svcSettings.Zone != "" {
+		logger.Printf("Controller zone: %s", c.svcSettings.Zone)
+	}
+}
+
+// spoeAgentUnreachable returns true when HAProxy instances configured with --dataplane-url,
+// running in other pods, are given the loopback address of the SPOE agent to connect to.
+func (c *HAProxyController) spoeAgentUnreachable() bool {
+	if len(c.OSArgs.DataplaneURLs) == 0 {
+		return false
+	}
+	host, _, err := net.SplitHostPort(c.OSArgs.SPOEAgentAdvertiseAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return host == "localhost" || (ip != nil && (ip.IsLoopback() || ip.IsUnspecified()))
+}
+
+// checkSPOEAgent reports the annotations of ingress requiring the SPOE agent when
+// it is unreachable, HAProxy then rejecting authenticated requests and not mirroring them.
+func (c *HAProxyController) checkSPOEAgent(ingress *store.Ingress, annotation string) {
+	if !c.spoeAgentUnreachable() {
+		return
+	}
+	msg := fmt.Sprintf("%s requires the SPOE agent, unreachable by Dataplane API instances on loopback address '%s', set --spoe-agent-advertise-address", annotation, c.OSArgs.SPOEAgentAdvertiseAddr)
+	logger.Errorf("Ingress %s/%s: %s", ingress.Namespace, ingress.Name, msg)
+	c.recordEvent("ingress "+ingress.Namespace+"/"+ingress.Name, corev1.EventTypeWarning, "SPOEAgentUnreachable", msg)
+}
+
+// startSPOEAgent runs the embedded SPOE agent, only the first call starts it.
+func (c *HAProxyController) startSPOEAgent() {
+	c.spoeAgentOnce.Do(func() {
+		agent := spoe.NewAgent(c.OSArgs.SPOEAgentAddr)
+		tokenReview := spoe.TokenReview(c.k8s.API)
+		forwardAuth := spoe.ForwardAuth()
+		agent.Handle(spoe.MsgTokenReview, tokenReview)
+		agent.Handle(spoe.MsgTokenReviewAuthz, tokenReview)
+		agent.Handle(spoe.MsgForwardAuth, forwardAuth)
+		agent.Handle(spoe.MsgAuthRequest, forwardAuth)
+		agent.Handle(spoe.MsgMirror, spoe.Mirror())
+		c.spoeAgent = agent
+		go func() {
+			logger.Error(agent.ListenAndServe())
+		}()
+	})
 }
 
 // updateHAProxy is the control loop syncing HAProxy configuration
@@ -146,8 +353,12 @@ func (c *HAProxyController) updateHAProxy() {
 	reload, c.restart = c.handleGlobalConfig()
 	c.reload = c.reload || reload
 
-	if len(route.CustomRoutes) != 0 {
-		logger.Error(route.CustomRoutesReset(c.Client))
+	if c.customRoutes.Len() != 0 {
+		extraFrontends := make([]string, 0, len(c.Cfg.ExtraFrontends))
+		for name := range c.Cfg.ExtraFrontends {
+			extraFrontends = append(extraFrontends, name)
+		}
+		logger.Error(route.CustomRoutesReset(c.Client, extraFrontends...))
 	}
 
 	for _, namespace := range c.Store.Namespaces {
@@ -158,10 +369,18 @@ func (c *HAProxyController) updateHAProxy() {
 			if ingress.Status == DELETED {
 				continue
 			}
+			// Before the class check, as ingress.class can be set in the namespace ConfigMap
+			ingress = c.Store.WithNamespaceConfig(ingress)
 			if !c.igClassIsSupported(ingress) {
 				logger.Debugf("ingress '%s/%s' ignored: no matching IngressClass", ingress.Namespace, ingress.Name)
 				continue
 			}
+			if name := c.ingressExtraFrontend(ingress); name != "" {
+				if _, ok := c.Cfg.ExtraFrontends[name]; !ok {
+					logger.Errorf("Ingress '%s/%s': frontend '%s' not found, ingress ignored", ingress.Namespace, ingress.Name, name)
+					continue
+				}
+			}
 			if c.PublishService != nil && ingress.Status == ADDED {
 				select {
 				case c.statusChan <- status.SyncIngress{Ingress: ingress}:
@@ -170,7 +389,7 @@ func (c *HAProxyController) updateHAProxy() {
 				}
 			}
 			if ingress.DefaultBackend != nil {
-				if reload, err = c.setDefaultService(ingress, []string{c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS}); err != nil {
+				if reload, err = c.setDefaultService(ingress, c.ingressFrontends(ingress)); err != nil {
 					logger.Errorf("Ingress '%s/%s': default backend: %s", ingress.Namespace, ingress.Name, err)
 				} else {
 					c.reload = c.reload || reload
@@ -182,11 +401,15 @@ func (c *HAProxyController) updateHAProxy() {
 				if tls.Status == store.DELETED {
 					continue
 				}
-				_, err = c.Cfg.Certificates.HandleTLSSecret(c.Store, haproxy.SecretCtx{
+				var crtPath string
+				crtPath, err = c.Cfg.Certificates.HandleTLSSecret(c.Store, haproxy.SecretCtx{
 					DefaultNS:  ingress.Namespace,
 					SecretPath: tls.SecretName,
 					SecretType: haproxy.FT_CERT,
 				})
+				if err == nil {
+					c.Cfg.Certificates.AddHostCrt(tls.Host, crtPath)
+				}
 				logger.Error(err)
 			}
 			// Ingress annotations
@@ -210,12 +433,31 @@ func (c *HAProxyController) updateHAProxy() {
 		}
 	}
 
+	c.reload = c.customRoutes.Purge() || c.reload
+
+	// After ingresses as TLS policies apply to the certificates of their hosts
+	c.handleTLSPolicies()
+
 	for _, handler := range c.updateHandlers {
 		reload, err = handler.Update(c.Store, &c.Cfg, c.Client)
 		logger.Error(err)
 		c.reload = c.reload || reload
 	}
 
+	// After handlers as TCP services set backends config snippets
+	c.reload = annotations.PurgeSnippets(c.Client) || c.reload
+	for _, snippet := range annotations.ValidateSnippets(c.Client) {
+		logger.Errorf("%s config-snippet of %s rejected: %s", snippet.Section, snippet.Owner, snippet.Err)
+		c.recordEvent(snippet.Owner, corev1.EventTypeWarning, "InvalidConfigSnippet",
+			fmt.Sprintf("%s config-snippet rejected by HAProxy configuration check, previous one is kept: %s", snippet.Section, snippet.Err))
+	}
+
+	c.shrinkServerSlots()
+
+	if c.OSArgs.MetricsBindAddr != "" {
+		c.updateAnnotationMetrics()
+	}
+
 	err = c.Client.APICommitTransaction()
 	if err != nil {
 		logger.Error("unable to Sync HAProxy configuration !!")
@@ -225,7 +467,9 @@ func (c *HAProxyController) updateHAProxy() {
 	}
 
 	if !c.ready {
-		c.setToReady()
+		if err = c.setToReady(); err != nil {
+			logger.Errorf("unable to expose healthz frontend, retrying at next sync: %s", err)
+		}
 	}
 
 	switch {
@@ -241,6 +485,10 @@ func (c *HAProxyController) updateHAProxy() {
 		} else {
 			logger.Info("HAProxy reloaded")
 		}
+	case len(c.OSArgs.DataplaneURLs) != 0:
+		// Remote instances runtime API is not used, changes done at runtime
+		// are pushed with the configuration when it differs from last push.
+		logger.Error(c.haproxyService("reload"))
 	}
 
 	c.clean(false)
@@ -249,30 +497,80 @@ func (c *HAProxyController) updateHAProxy() {
 }
 
 // setToRready exposes readiness endpoint
-func (c *HAProxyController) setToReady() {
-	logger.Panic(c.clientAPIClosure(func() error {
+func (c *HAProxyController) setToReady() error {
+	err := c.clientAPIClosure(func() error {
 		return c.Client.FrontendBindEdit("healthz",
 			models.Bind{
 				Name:    "v4",
 				Address: "0.0.0.0:1042",
 			})
-	}))
+	})
+	if err != nil {
+		return err
+	}
 	if !c.OSArgs.DisableIPV6 {
-		logger.Panic(c.clientAPIClosure(func() error {
+		err = c.clientAPIClosure(func() error {
 			return c.Client.FrontendBindCreate("healthz",
 				models.Bind{
 					Name:    "v6",
 					Address: ":::1042",
 					V4v6:    true,
 				})
-		}))
+		})
+		if err != nil {
+			return err
+		}
 	}
 	logger.Debugf("healthz frontend exposed for readiness probe")
-	cm := c.Store.ConfigMaps.Main
-	if cm.Name != "" && !cm.Loaded {
-		logger.Warningf("Main configmap '%s/%s' not found", cm.Namespace, cm.Name)
+	for _, cm := range c.Store.ConfigMaps.MainSources {
+		if cm.Name != "" && !cm.Loaded {
+			logger.Warningf("Main configmap '%s/%s' not found", cm.Namespace, cm.Name)
+		}
 	}
 	c.ready = true
+	return nil
+}
+
+// recordEvent records a Kubernetes Event about the "<kind> <namespace>/<name>"
+// owner of an annotation, as set by annotations handlers.
+func (c *HAProxyController) recordEvent(owner, eventType, reason, message string) {
+	if c.recorder == nil {
+		return
+	}
+	parts := strings.Fields(owner)
+	if len(parts) != 2 {
+		return
+	}
+	ref := &corev1.ObjectReference{}
+	var ok bool
+	if ref.Namespace, ref.Name, ok = cutNamespacedName(parts[1]); !ok {
+		return
+	}
+	switch parts[0] {
+	case "ingress":
+		ref.Kind, ref.APIVersion = "Ingress", "networking.k8s.io/v1"
+	case "service":
+		ref.Kind, ref.APIVersion = "Service", "v1"
+	case "configmap":
+		ref.Kind, ref.APIVersion = "ConfigMap", "v1"
+	case "global":
+		ref.Kind, ref.APIVersion = "Global", "core.haproxy.org/v1alpha1"
+	case "backend":
+		ref.Kind, ref.APIVersion = "Backend", "core.haproxy.org/v1alpha1"
+	case "tlspolicy":
+		ref.Kind, ref.APIVersion = "TLSPolicy", "core.haproxy.org/v1alpha1"
+	default:
+		return
+	}
+	c.recorder.Event(ref, eventType, reason, message)
+}
+
+func cutNamespacedName(value string) (namespace, name string, ok bool) {
+	i := strings.IndexByte(value, '/')
+	if i <= 0 || i == len(value)-1 {
+		return "", "", false
+	}
+	return value[:i], value[i+1:], true
 }
 
 // clean controller state
@@ -0,0 +1,175 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/status"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+var errTransaction = errors.New("transaction failed")
+
+// fakeClient fails to start transactions, other API calls panic
+type fakeClient struct {
+	api.HAProxyClient
+	transactions int
+}
+
+func (c *fakeClient) APIStartTransaction() error {
+	c.transactions++
+	return errTransaction
+}
+
+func (c *fakeClient) APIDisposeTransaction() {}
+
+// fakeProcess records the HAProxy service actions
+type fakeProcess struct {
+	actions []string
+}
+
+func (p *fakeProcess) HaproxyService(action string) error {
+	p.actions = append(p.actions, action)
+	return nil
+}
+
+func (p *fakeProcess) UseAuxFile(useAuxFile bool) {}
+
+// newTestController returns a controller with fake dependencies
+// and the environment of a temporary directory
+func newTestController(t *testing.T) (*HAProxyController, *fakeClient, *fakeProcess) {
+	t.Helper()
+	dir := t.TempDir()
+	env := config.Env{
+		HAProxyBinary: filepath.Join(dir, "haproxy"),
+		MainCFGFile:   filepath.Join(dir, "haproxy.cfg"),
+		CfgDir:        dir,
+		RuntimeDir:    dir,
+		StateDir:      dir,
+	}
+	for _, file := range []string{env.HAProxyBinary, env.MainCFGFile} {
+		if err := os.WriteFile(file, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	osArgs := utils.OSArgs{NamespaceWhitelist: []string{"test"}}
+	s := store.NewK8sStore(osArgs)
+	s.NamespacesAccess.Whitelist["test"] = struct{}{}
+	s.SetDefaultAnnotation("full-sync-period", "1h")
+	client := &fakeClient{}
+	process := &fakeProcess{}
+	c := New(Options{
+		Cfg:     config.ControllerCfg{Env: env},
+		OSArgs:  osArgs,
+		Store:   &s,
+		Client:  client,
+		Process: process,
+	})
+	return c, client, process
+}
+
+func TestNewOptions(t *testing.T) {
+	c, client, process := newTestController(t)
+	if c.Client != client || c.haproxyProcess != process {
+		t.Errorf("New() did not keep the injected Client and Process")
+	}
+	if _, ok := c.Store.NamespacesAccess.Whitelist["test"]; !ok {
+		t.Errorf("New() did not keep the injected Store")
+	}
+	if c.OSArgs.SPOEAgentAddr == "" || c.svcSettings.ClusterDomain == "" || c.customRoutes == nil {
+		t.Errorf("New() did not set controller defaults: %+v", c)
+	}
+	other, _, _ := newTestController(t)
+	if other.customRoutes == c.customRoutes {
+		t.Errorf("New() controllers share their custom routes")
+	}
+}
+
+func TestStartError(t *testing.T) {
+	c, client, process := newTestController(t)
+	if err := c.Start(); !errors.Is(err, errTransaction) {
+		t.Fatalf("Start() error = %v, want %v", err, errTransaction)
+	}
+	if client.transactions != 1 || len(process.actions) != 0 {
+		t.Errorf("Start() failure: %d transactions and HAProxy actions %v, want 1 transaction and HAProxy not started", client.transactions, process.actions)
+	}
+	c.Stop()
+	if !reflect.DeepEqual(process.actions, []string{"stop"}) {
+		t.Errorf("Stop() HAProxy actions = %v, want [stop]", process.actions)
+	}
+}
+
+func TestStopWaitsForRoutines(t *testing.T) {
+	c, _, process := newTestController(t)
+	c.stop = make(chan struct{})
+	c.eventChan = make(chan SyncDataEvent)
+	c.statusChan = make(chan status.SyncIngress)
+	syncDone, statusDone := make(chan struct{}), make(chan struct{})
+	c.run(func() {
+		c.SyncData()
+		time.Sleep(10 * time.Millisecond)
+		close(syncDone)
+	})
+	c.run(func() {
+		status.UpdateIngress(nil, c.Store, c.statusChan, c.stop)
+		close(statusDone)
+	})
+	c.Stop()
+	for name, done := range map[string]chan struct{}{"SyncData": syncDone, "UpdateIngress": statusDone} {
+		select {
+		case <-done:
+		default:
+			t.Errorf("Stop() returned before %s", name)
+		}
+	}
+	if !reflect.DeepEqual(process.actions, []string{"stop"}) {
+		t.Errorf("Stop() HAProxy actions = %v, want [stop]", process.actions)
+	}
+}
+
+func TestSPOEAgentUnreachable(t *testing.T) {
+	tests := []struct {
+		dataplane   bool
+		addr        string
+		unreachable bool
+	}{
+		{false, "127.0.0.1:6061", false},
+		{true, "127.0.0.1:6061", true},
+		{true, "[::1]:6061", true},
+		{true, "localhost:6061", true},
+		{true, "0.0.0.0:6061", true},
+		{true, "10.0.0.1:6061", false},
+		{true, "spoe.haproxy-controller.svc:6061", false},
+	}
+	for _, test := range tests {
+		osArgs := utils.OSArgs{SPOEAgentAdvertiseAddr: test.addr}
+		if test.dataplane {
+			osArgs.DataplaneURLs = []string{"http://haproxy-0:5555"}
+		}
+		c := &HAProxyController{OSArgs: osArgs}
+		if unreachable := c.spoeAgentUnreachable(); unreachable != test.unreachable {
+			t.Errorf("spoeAgentUnreachable() with dataplane %t and agent on %s = %t, want %t", test.dataplane, test.addr, unreachable, test.unreachable)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+// fullSync syncs HAProxy with all resources and repairs its drift from them:
+// transactions left by failed syncs are discarded so that the configuration is
+// rebuilt from the one HAProxy runs with, and the runtime state of backend servers
+// changed outside the controller, e.g. via the runtime socket, is restored.
+func (c *HAProxyController) fullSync() {
+	logger.Debug("HAProxy full sync started")
+	if err := c.Client.APIDiscardTransactions(); err != nil {
+		logger.Errorf("unable to discard stale transactions: %s", err)
+	}
+	c.updateHAProxy()
+	// Remote instances runtime API is not used, nor is HAProxy run in test mode
+	if c.OSArgs.Test || len(c.OSArgs.DataplaneURLs) != 0 {
+		return
+	}
+	for _, ns := range c.Store.Namespaces {
+		for _, endpoints := range ns.Endpoints {
+			for _, portEndpoints := range endpoints.Ports {
+				if portEndpoints.BackendName != "" {
+					c.restoreServersState(portEndpoints.BackendName, portEndpoints)
+				}
+				for backend := range portEndpoints.PathBackends {
+					c.restoreServersState(backend, portEndpoints)
+				}
+			}
+		}
+	}
+}
+
+// restoreServersState sets the runtime address and admin state of the backend
+// servers of endpoints to the ones of their slots when they differ.
+func (c *HAProxyController) restoreServersState(backendName string, endpoints *store.PortEndpoints) {
+	servers, err := c.Client.BackendServersStateGet(backendName)
+	if err != nil {
+		logger.Errorf("backend '%s': unable to get servers state: %s", backendName, err)
+		return
+	}
+	current := make(map[string]*models.RuntimeServer, len(servers))
+	for _, server := range servers {
+		if server != nil {
+			current[server.Name] = server
+		}
+	}
+	for _, srv := range endpoints.HAProxySrvs {
+		server, ok := current[srv.Name]
+		if !ok {
+			continue
+		}
+		address, port, state := srv.Address, endpoints.AddrPort(srv.Address), "ready"
+		switch {
+		case srv.Address == "":
+			address, port, state = "127.0.0.1", 0, "maint"
+		case !srv.DrainSince.IsZero():
+			state = "drain"
+		}
+		// Port of disabled servers is not relevant
+		if server.Address != address || (srv.Address != "" && (server.Port == nil || *server.Port != port)) {
+			logger.Warningf("server '%s/%s' address changed outside of the controller, restoring it", backendName, srv.Name)
+			if err = c.Client.SetServerAddr(backendName, srv.Name, address, int(port)); err != nil {
+				logger.Error(err)
+			}
+		}
+		if server.AdminState != state {
+			logger.Warningf("server '%s/%s' state changed outside of the controller to '%s', restoring '%s'", backendName, srv.Name, server.AdminState, state)
+			if err = c.Client.SetServerState(backendName, srv.Name, state); err != nil {
+				logger.Error(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/rules"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+var frontendNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// handleExtraFrontends configures the HTTP frontends of the "frontends" annotation, one per line.
+// Example:
+//
+//	frontends: |
+//	  name:internal, port:8080
+//	  name:internal-tls, port:8443, ssl:true
+//
+// Each frontend has its own host and path maps, filled with the routes of the ingresses
+// selecting it with the "frontend" annotation, these ingresses not being exposed on
+// the main HTTP and HTTPS frontends.
+func (c *HAProxyController) handleExtraFrontends() (reload bool) {
+	frontends := c.extraFrontends()
+	for name, current := range c.Cfg.ExtraFrontends {
+		if frontend, ok := frontends[name]; ok && frontend == current {
+			continue
+		}
+		if _, err := c.Client.FrontendGet(name); err == nil {
+			logger.Error(c.Client.FrontendDelete(name))
+			logger.Debugf("Extra frontend '%s' deleted, reload required", name)
+			reload = true
+		}
+		c.Cfg.HAProxyRules.DeleteFrontend(name)
+		for _, mapName := range haproxy.RoutingMaps {
+			c.Cfg.MapFiles.SetPreserve(haproxy.FrontendMap(mapName, name), false)
+		}
+		delete(c.Cfg.ExtraFrontends, name)
+	}
+	names := make([]string, 0, len(frontends))
+	for name := range frontends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		frontend := frontends[name]
+		if _, ok := c.Cfg.ExtraFrontends[name]; !ok {
+			if _, err := c.Client.FrontendGet(name); err == nil {
+				logger.Errorf("frontends annotation: frontend '%s' already exists", name)
+				continue
+			}
+			if err := c.createExtraFrontend(name, frontend); err != nil {
+				logger.Errorf("frontends annotation: frontend '%s': %s", name, err)
+				continue
+			}
+			logger.Debugf("Extra frontend '%s' created, reload required", name)
+			reload = true
+			c.Cfg.ExtraFrontends[name] = frontend
+		}
+		// Map files are referenced by the frontend rules, even without routes
+		for _, mapName := range haproxy.RoutingMaps {
+			c.Cfg.MapFiles.SetPreserve(haproxy.FrontendMap(mapName, name), true)
+		}
+		logger.Error(c.Cfg.AddRoutingRules(name, name))
+		if frontend.SSL {
+			logger.Error(c.Cfg.HAProxyRules.AddRule(rules.SetHdr{
+				ForwardedProto: true,
+			}, "", name))
+		}
+	}
+	return reload
+}
+
+// createExtraFrontend creates an HTTP frontend listening on the port of the extra frontend,
+// with the same addresses as the main frontends and the same certificates for SSL.
+func (c *HAProxyController) createExtraFrontend(name string, frontend config.ExtraFrontend) error {
+	var errors utils.Errors
+	errors.Add(c.Client.FrontendCreate(models.Frontend{
+		Name: name,
+		Mode: "http",
+	}))
+	binds := map[string]string{}
+	if !c.OSArgs.DisableIPV4 {
+		binds["v4"] = c.OSArgs.IPV4BindAddr
+	}
+	if !c.OSArgs.DisableIPV6 {
+		binds["v6"] = c.OSArgs.IPV6BindAddr
+	}
+	for bindName, address := range binds {
+		errors.Add(c.Client.FrontendBindCreate(name, models.Bind{
+			Name:    bindName,
+			Address: address,
+			Port:    utils.PtrInt64(frontend.Port),
+		}))
+	}
+	if frontend.SSL {
+		errors.Add(c.Client.FrontendEnableSSLOffload(name, c.Cfg.Env.FrontendCertDir, true))
+	}
+	errors.Add(c.Client.BackendSwitchingRuleCreate(name, models.BackendSwitchingRule{
+		Name:  "%[var(txn.path_match),field(1,.)]",
+		Index: utils.PtrInt64(0),
+	}))
+	return errors.Result()
+}
+
+// extraFrontends returns the valid extra frontends of the "frontends" annotation by name
+func (c *HAProxyController) extraFrontends() map[string]config.ExtraFrontend {
+	frontends := make(map[string]config.ExtraFrontend)
+	annotation := c.Store.GetValueFromAnnotations("frontends", c.Store.Global.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	for _, line := range strings.Split(annotation, "\n") {
+		line = strings.Join(strings.Fields(line), "")
+		if line == "" {
+			continue
+		}
+		name, frontend, err := parseExtraFrontend(line)
+		if err != nil {
+			logger.Errorf("frontends annotation: %s in '%s'", err, line)
+			continue
+		}
+		if _, ok := frontends[name]; ok {
+			logger.Errorf("frontends annotation: duplicate frontend '%s'", name)
+			continue
+		}
+		frontends[name] = frontend
+	}
+	return frontends
+}
+
+func parseExtraFrontend(line string) (name string, frontend config.ExtraFrontend, err error) {
+	for _, param := range strings.Split(line, ",") {
+		if param == "" {
+			continue
+		}
+		parts := strings.SplitN(param, ":", 2)
+		if len(parts) != 2 {
+			return "", frontend, fmt.Errorf("incorrect param '%s'", param)
+		}
+		switch parts[0] {
+		case "name":
+			name = parts[1]
+		case "port":
+			frontend.Port, err = strconv.ParseInt(parts[1], 10, 64)
+			if err != nil || frontend.Port < 1 || frontend.Port > 65535 {
+				return "", frontend, fmt.Errorf("incorrect port '%s'", parts[1])
+			}
+		case "ssl":
+			if frontend.SSL, err = utils.GetBoolValue(parts[1], "ssl"); err != nil {
+				return "", frontend, err
+			}
+		default:
+			return "", frontend, fmt.Errorf("unknown param '%s'", parts[0])
+		}
+	}
+	switch {
+	case name == "":
+		return "", frontend, fmt.Errorf("missing name")
+	case !frontendNameRegex.MatchString(name):
+		return "", frontend, fmt.Errorf("incorrect name '%s'", name)
+	case frontend.Port == 0:
+		return "", frontend, fmt.Errorf("missing port")
+	}
+	return name, frontend, nil
+}
+
+// ingressExtraFrontend returns the extra frontend selected by the "frontend" annotation
+// of the ingress, empty when the ingress is exposed on the main frontends.
+func (c *HAProxyController) ingressExtraFrontend(ingress *store.Ingress) string {
+	return c.Store.GetValueFromAnnotations("frontend", ingress.Annotations)
+}
+
+// ingressFrontends returns the frontends exposing the ingress: its extra frontend
+// or the main HTTP and HTTPS frontends.
+func (c *HAProxyController) ingressFrontends(ingress *store.Ingress) []string {
+	if name := c.ingressExtraFrontend(ingress); name != "" {
+		return []string{name}
+	}
+	return []string{c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS}
+}
+
+// ingressFrontend returns the frontend exposing the ingress over HTTPS, when ssl is set,
+// or over plain HTTP, empty when the extra frontend of the ingress is of the other kind.
+func (c *HAProxyController) ingressFrontend(ingress *store.Ingress, ssl bool) string {
+	name := c.ingressExtraFrontend(ingress)
+	switch {
+	case name == "" && ssl:
+		return c.Cfg.FrontHTTPS
+	case name == "":
+		return c.Cfg.FrontHTTP
+	case c.Cfg.ExtraFrontends[name].SSL == ssl:
+		return name
+	}
+	return ""
+}
@@ -15,33 +15,164 @@
 package controller
 
 import (
+	"bytes"
 	"fmt"
 	"net"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/haproxytech/client-native/v2/misc"
 
+	"github.com/haproxytech/kubernetes-ingress/controller/annotations"
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/handler"
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/rules"
+	"github.com/haproxytech/kubernetes-ingress/controller/spoe"
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
 	"github.com/haproxytech/kubernetes-ingress/controller/utils"
 )
 
+var (
+	rateLimitTableRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+	httpHeaderNameRegex = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+	rateLimitKeyRegex   = regexp.MustCompile(`^(src|path|base|(hdr|cookie|url_param)\([a-zA-Z0-9_.-]+\))$`)
+	tableNameRegex      = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+	httpMethodRegex     = regexp.MustCompile(`^[A-Z]+$`)
+	cookieTokenRegex    = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+)
+
+const (
+	// abGroupVar holds the A/B testing group of the request
+	abGroupVar = "txn.ab_group"
+	// abDefaultGroup is the group assigned to clients routed to the ingress backend
+	abDefaultGroup = "default"
+)
+
 func (c *HAProxyController) handleIngressAnnotations(ingress *store.Ingress) {
+	c.handleAccessControlOrder(ingress)
+	c.handleClientTLSAuth(ingress)
 	c.handleSourceIPHeader(ingress)
 	c.handleBlacklisting(ingress)
 	c.handleWhitelisting(ingress)
+	c.handleDenyMethods(ingress)
+	c.handleDenyPaths(ingress)
+	c.handleTimeWindows(ingress)
 	c.handleRequestRateLimiting(ingress)
 	c.handleRequestBasicAuth(ingress)
+	c.handleRequestTokenReview(ingress)
+	c.handleRequestForwardAuth(ingress)
 	c.handleRequestHostRedirect(ingress)
 	c.handleRequestHTTPSRedirect(ingress)
+	c.handleRequestMirror(ingress)
 	c.handleRequestCapture(ingress)
+	c.handleStaticResponse(ingress)
+	c.handleFaultInjection(ingress)
 	c.handleRequestPathRewrite(ingress)
 	c.handleRequestSetHost(ingress)
+	c.handleRequestDelHdr(ingress)
 	c.handleRequestSetHdr(ingress)
+	c.handleRequestForwardedHdrs(ingress)
+	c.handleABTesting(ingress)
+	c.handleRequestTimeoutHdr(ingress)
+	c.handleResponseDelHdr(ingress)
 	c.handleResponseSetHdr(ingress)
+	c.handleResponseHSTS(ingress)
+	c.handleResponseSetStatus(ingress)
+	c.handleResponseLocationRewrite(ingress)
 	c.handleResponseCors(ingress)
+	// Custom frontend annotations
+	annotations.HandleFrontendAnnotations(ingress, c.Store, c.Client, &c.Cfg, ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+}
+
+// handleAccessControlOrder sets the evaluation order of the ingress
+// deny, rate limiting and authentication rules.
+func (c *HAProxyController) handleAccessControlOrder(ingress *store.Ingress) {
+	annOrder := c.Store.GetValueFromAnnotations("access-control-order", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annOrder == "" {
+		return
+	}
+	order, err := haproxy.ParseAccessControlOrder(annOrder)
+	if err != nil {
+		logger.Errorf("Ingress %s/%s: access-control-order annotation: %s", ingress.Namespace, ingress.Name, err)
+		return
+	}
+	logger.Tracef("Ingress %s/%s: Configuring access-control-order", ingress.Namespace, ingress.Name)
+	c.Cfg.HAProxyRules.SetAccessControlOrder(ingress.Namespace+"-"+ingress.Name, order)
+}
+
+// handleClientTLSAuth requires client certificates for the ingress hosts,
+// verified against the CA found in client-ca-secret.
+func (c *HAProxyController) handleClientTLSAuth(ingress *store.Ingress) {
+	annCA := c.Store.GetValueFromAnnotations("client-ca-secret", ingress.Annotations)
+	if annCA == "" {
+		return
+	}
+	caFile, err := c.Cfg.Certificates.HandleTLSSecret(c.Store, haproxy.SecretCtx{
+		DefaultNS:  ingress.Namespace,
+		SecretPath: annCA,
+		SecretType: haproxy.CA_CERT,
+	})
+	if err != nil {
+		logger.Errorf("Ingress %s/%s: client-ca-secret '%s': %s", ingress.Namespace, ingress.Name, annCA, err)
+		return
+	}
+	optional := false
+	annOptional := c.Store.GetValueFromAnnotations("client-crt-optional", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annOptional != "" {
+		if optional, err = utils.GetBoolValue(annOptional, "client-crt-optional"); err != nil {
+			logger.Error(err)
+			return
+		}
+	}
+	// Frontend certificate served for each ingress host
+	hostCrts := make(map[string]string)
+	for _, tls := range ingress.TLS {
+		if tls.Status == DELETED {
+			continue
+		}
+		crtFile, errCrt := c.Cfg.Certificates.HandleTLSSecret(c.Store, haproxy.SecretCtx{
+			DefaultNS:  ingress.Namespace,
+			SecretPath: tls.SecretName,
+			SecretType: haproxy.FT_CERT,
+		})
+		if errCrt != nil {
+			continue
+		}
+		hostCrts[tls.Host] = crtFile
+	}
+	var defaultCrt string
+	if secretAnn := c.Store.GetValueFromAnnotations("ssl-certificate", c.Store.ConfigMaps.Main.Annotations); secretAnn != "" {
+		defaultCrt, _ = c.Cfg.Certificates.HandleTLSSecret(c.Store, haproxy.SecretCtx{
+			SecretPath: secretAnn,
+			SecretType: haproxy.FT_DEFAULT_CERT,
+		})
+	}
+	// Configure annotation
+	logger.Tracef("Ingress %s/%s: Configuring client certificate authentication", ingress.Namespace, ingress.Name)
+	for _, rule := range ingress.Rules {
+		if rule.Status == DELETED || rule.Host == "" {
+			continue
+		}
+		crtFile, ok := hostCrts[rule.Host]
+		if !ok {
+			crtFile = defaultCrt
+			// Certificate of a TLS wildcard host matching the rule host
+			for tlsHost, tlsCrt := range hostCrts {
+				if utils.MatchHost(tlsHost, rule.Host) {
+					crtFile = tlsCrt
+					break
+				}
+			}
+		}
+		if crtFile == "" {
+			logger.Errorf("Ingress %s/%s: client-ca-secret: no certificate found for host '%s'", ingress.Namespace, ingress.Name, rule.Host)
+			continue
+		}
+		c.Cfg.Certificates.AddClientAuth(rule.Host, crtFile, caFile, optional)
+	}
 }
 
 func (c *HAProxyController) handleSourceIPHeader(ingress *store.Ingress) {
@@ -54,7 +185,7 @@ func (c *HAProxyController) handleSourceIPHeader(ingress *store.Ingress) {
 	reqSetSrc := rules.ReqSetSrc{
 		HeaderName: srcIPHeader,
 	}
-	logger.Error(c.Cfg.HAProxyRules.AddRule(reqSetSrc, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS))
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqSetSrc, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
 }
 
 func (c *HAProxyController) handleBlacklisting(ingress *store.Ingress) {
@@ -64,18 +195,9 @@ func (c *HAProxyController) handleBlacklisting(ingress *store.Ingress) {
 		return
 	}
 	// Validate annotation
-	mapName := "blacklist-" + utils.Hash([]byte(annBlacklist))
-	if !c.Cfg.MapFiles.Exists(mapName) {
-		for _, address := range strings.Split(annBlacklist, ",") {
-			address = strings.TrimSpace(address)
-			if ip := net.ParseIP(address); ip == nil {
-				if _, _, err := net.ParseCIDR(address); err != nil {
-					logger.Errorf("incorrect address '%s' in blacklist annotation in ingress '%s'", address, ingress.Name)
-					continue
-				}
-			}
-			c.Cfg.MapFiles.AppendRow(mapName, address)
-		}
+	mapName := c.addressesMap("blacklist", annBlacklist, ingress)
+	if mapName == "" {
+		return
 	}
 	// Configure annotation
 	logger.Tracef("Ingress %s/%s: Configuring blacklist annotation", ingress.Namespace, ingress.Name)
@@ -83,7 +205,7 @@ func (c *HAProxyController) handleBlacklisting(ingress *store.Ingress) {
 		SrcIPsMap: mapName,
 	}
 
-	frontends := []string{c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS}
+	frontends := c.ingressFrontends(ingress)
 	if c.sslPassthroughEnabled(ingress, nil) {
 		frontends = []string{c.Cfg.FrontHTTP, c.Cfg.FrontSSL}
 	}
@@ -97,30 +219,173 @@ func (c *HAProxyController) handleWhitelisting(ingress *store.Ingress) {
 		return
 	}
 	// Validate annotation
-	mapName := "whitelist-" + utils.Hash([]byte(annWhitelist))
-	if !c.Cfg.MapFiles.Exists(mapName) {
-		for _, address := range strings.Split(annWhitelist, ",") {
+	mapName := c.addressesMap("whitelist", annWhitelist, ingress)
+	if mapName == "" {
+		return
+	}
+	// Configure annotation
+	logger.Tracef("Ingress %s/%s: Configuring whitelist annotation", ingress.Namespace, ingress.Name)
+	reqWhitelist := rules.ReqDeny{
+		SrcIPsMap: mapName,
+		Whitelist: true,
+	}
+	frontends := c.ingressFrontends(ingress)
+	if c.sslPassthroughEnabled(ingress, nil) {
+		frontends = []string{c.Cfg.FrontHTTP, c.Cfg.FrontSSL}
+	}
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqWhitelist, ingress.Namespace+"-"+ingress.Name, frontends...))
+}
+
+// addressesMap fills the map file of the IP addresses and CIDRs of the annotation value,
+// a comma or newline separated list or a ConfigMap or Secret key reference holding it.
+// The map name is returned, empty if the referenced key cannot be fetched.
+func (c *HAProxyController) addressesMap(annotation, value string, ingress *store.Ingress) (mapName string) {
+	mapName = annotation + "-" + utils.Hash([]byte(value))
+	if c.Cfg.MapFiles.Exists(mapName) {
+		return mapName
+	}
+	if store.IsValueRef(value) {
+		ref := value
+		var err error
+		if value, err = c.Store.FetchValueRef(ref, ingress.Namespace); err != nil {
+			logger.Errorf("Ingress %s/%s: %s annotation: %s", ingress.Namespace, ingress.Name, annotation, err)
+			return ""
+		}
+	}
+	for _, line := range strings.Split(value, "\n") {
+		// Comments are allowed in referenced lists
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+		for _, address := range strings.Split(line, ",") {
 			address = strings.TrimSpace(address)
+			if address == "" {
+				continue
+			}
 			if ip := net.ParseIP(address); ip == nil {
 				if _, _, err := net.ParseCIDR(address); err != nil {
-					logger.Errorf("incorrect address '%s' in whitelist annotation in ingress '%s'", address, ingress.Name)
+					logger.Errorf("incorrect address '%s' in %s annotation in ingress '%s'", address, annotation, ingress.Name)
 					continue
 				}
 			}
 			c.Cfg.MapFiles.AppendRow(mapName, address)
 		}
 	}
+	return mapName
+}
+
+func (c *HAProxyController) handleDenyMethods(ingress *store.Ingress) {
+	//  Get annotation status
+	annDenyMethods := c.Store.GetValueFromAnnotations("deny-methods", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annDenyMethods == "" {
+		return
+	}
+	// Validate annotation
+	var methods []string
+	for _, method := range strings.Split(annDenyMethods, ",") {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if !httpMethodRegex.MatchString(method) {
+			logger.Errorf("incorrect method '%s' in deny-methods annotation in ingress '%s'", method, ingress.Name)
+			continue
+		}
+		methods = append(methods, method)
+	}
+	if len(methods) == 0 {
+		return
+	}
 	// Configure annotation
-	logger.Tracef("Ingress %s/%s: Configuring whitelist annotation", ingress.Namespace, ingress.Name)
-	reqWhitelist := rules.ReqDeny{
-		SrcIPsMap: mapName,
-		Whitelist: true,
+	logger.Tracef("Ingress %s/%s: Configuring deny-methods annotation", ingress.Namespace, ingress.Name)
+	reqDenyMethods := rules.ReqDenyHTTP{
+		Methods: methods,
 	}
-	frontends := []string{c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS}
-	if c.sslPassthroughEnabled(ingress, nil) {
-		frontends = []string{c.Cfg.FrontHTTP, c.Cfg.FrontSSL}
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqDenyMethods, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+}
+
+func (c *HAProxyController) handleDenyPaths(ingress *store.Ingress) {
+	//  Get annotation status
+	annDenyPaths := c.Store.GetValueFromAnnotations("deny-paths", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annDenyPaths == "" {
+		return
+	}
+	// Validate annotation
+	mapName := "deny-paths-" + utils.Hash([]byte(annDenyPaths))
+	if !c.Cfg.MapFiles.Exists(mapName) {
+		for _, path := range strings.Fields(annDenyPaths) {
+			if _, err := regexp.Compile(path); err != nil {
+				logger.Errorf("incorrect regex '%s' in deny-paths annotation in ingress '%s': %s", path, ingress.Name, err)
+				continue
+			}
+			c.Cfg.MapFiles.AppendRow(mapName, path)
+		}
+		if !c.Cfg.MapFiles.Exists(mapName) {
+			return
+		}
+	}
+	// Configure annotation
+	logger.Tracef("Ingress %s/%s: Configuring deny-paths annotation", ingress.Namespace, ingress.Name)
+	reqDenyPaths := rules.ReqDenyHTTP{
+		PathsMap: mapName,
+	}
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqDenyPaths, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+}
+
+// handleTimeWindows denies requests, or only the ones matching time-window-paths,
+// outside the time-window-allow windows or inside the time-window-deny ones.
+func (c *HAProxyController) handleTimeWindows(ingress *store.Ingress) {
+	annAllow := c.Store.GetValueFromAnnotations("time-window-allow", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	annDeny := c.Store.GetValueFromAnnotations("time-window-deny", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annAllow == "" && annDeny == "" {
+		return
+	}
+	// Validate annotations
+	annTimezone := c.Store.GetValueFromAnnotations("time-window-timezone", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	offset, err := utils.TimeZoneOffset(annTimezone)
+	if err != nil {
+		logger.Errorf("Ingress %s/%s: time-window-timezone annotation: %s", ingress.Namespace, ingress.Name, err)
+		return
+	}
+	var pathsMap string
+	if annPaths := c.Store.GetValueFromAnnotations("time-window-paths", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations); annPaths != "" {
+		pathsMap = "time-window-paths-" + utils.Hash([]byte(annPaths))
+		if !c.Cfg.MapFiles.Exists(pathsMap) {
+			for _, path := range strings.Fields(annPaths) {
+				if _, err = regexp.Compile(path); err != nil {
+					logger.Errorf("incorrect regex '%s' in time-window-paths annotation in ingress '%s': %s", path, ingress.Name, err)
+					continue
+				}
+				c.Cfg.MapFiles.AppendRow(pathsMap, path)
+			}
+			if !c.Cfg.MapFiles.Exists(pathsMap) {
+				return
+			}
+		}
+	}
+	// Configure annotations
+	for _, ann := range []struct {
+		name  string
+		value string
+		allow bool
+	}{
+		{"time-window-allow", annAllow, true},
+		{"time-window-deny", annDeny, false},
+	} {
+		if ann.value == "" {
+			continue
+		}
+		windows, errWindows := utils.ParseTimeWindows(ann.value)
+		if errWindows != nil {
+			logger.Errorf("Ingress %s/%s: %s annotation: %s", ingress.Namespace, ingress.Name, ann.name, errWindows)
+			continue
+		}
+		logger.Tracef("Ingress %s/%s: Configuring %s annotation", ingress.Namespace, ingress.Name, ann.name)
+		reqDenyTime := rules.ReqDenyTime{
+			Windows:  windows,
+			Offset:   offset,
+			Allow:    ann.allow,
+			PathsMap: pathsMap,
+		}
+		logger.Error(c.Cfg.HAProxyRules.AddRule(reqDenyTime, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
 	}
-	logger.Error(c.Cfg.HAProxyRules.AddRule(reqWhitelist, ingress.Namespace+"-"+ingress.Name, frontends...))
 }
 
 func (c *HAProxyController) handleRequestRateLimiting(ingress *store.Ingress) {
@@ -151,23 +416,85 @@ func (c *HAProxyController) handleRequestRateLimiting(ingress *store.Ingress) {
 		return
 	}
 
+	rateLimitKey := c.Store.GetValueFromAnnotations("rate-limit-key", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if !rateLimitKeyRegex.MatchString(rateLimitKey) {
+		logger.Errorf("Ingress %s/%s: incorrect rate-limit-key '%s'", ingress.Namespace, ingress.Name, rateLimitKey)
+		return
+	}
+	table := config.RateLimitTable{
+		Period:  *rateLimitPeriod,
+		Size:    rateLimitSize,
+		Key:     rateLimitKey,
+		Ingress: ingress.Namespace + "/" + ingress.Name,
+	}
+	tableName := fmt.Sprintf("RateLimit-%d", *rateLimitPeriod)
+	// Requests tracked by another key than the source address use their own table
+	if rateLimitKey != "src" {
+		tableName += "-" + strings.Trim(tableNameRegex.ReplaceAllString(rateLimitKey, "_"), "_")
+	}
+	current, shared := c.Cfg.RateLimitTables[tableName]
+	// rate-limit-group is an alias of rate-limit-table
+	annTable := "rate-limit-table"
+	namedTable := c.Store.GetValueFromAnnotations(annTable, ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if namedTable == "" {
+		annTable = "rate-limit-group"
+		namedTable = c.Store.GetValueFromAnnotations(annTable, ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	}
+	if namedTable != "" {
+		if !rateLimitTableRegex.MatchString(namedTable) {
+			logger.Errorf("Ingress %s/%s: incorrect %s '%s'", ingress.Namespace, ingress.Name, annTable, namedTable)
+			return
+		}
+		// Ingresses of a named table deliberately share its counters, thus they must agree on the table definition.
+		// Named tables are namespaced so that tenants do not share each other's counters.
+		tableName = fmt.Sprintf("RateLimit-%s.%s", ingress.Namespace, namedTable)
+		if current, shared = c.Cfg.RateLimitTables[tableName]; shared {
+			if err = checkRateLimitTable(current, table); err != nil {
+				logger.Errorf("Ingress %s/%s: %s '%s' defined by ingress %s: %s", ingress.Namespace, ingress.Name, annTable, namedTable, current.Ingress, err)
+				return
+			}
+		}
+	}
+
 	// Configure annotation
 	logger.Tracef("Ingress %s/%s: Configuring rate-limit-requests annotation", ingress.Namespace, ingress.Name)
-	tableName := fmt.Sprintf("RateLimit-%d", *rateLimitPeriod)
-	c.Cfg.RateLimitTables = append(c.Cfg.RateLimitTables, tableName)
+	switch {
+	case !shared:
+		c.Cfg.RateLimitTables[tableName] = table
+	case namedTable == "" && greaterSize(table.Size, current.Size):
+		// Tables shared by default are sized for their largest user, whatever the order ingresses are processed in
+		current.Size = table.Size
+		c.Cfg.RateLimitTables[tableName] = current
+	}
 	reqTrack := rules.ReqTrack{
-		TableName:   tableName,
-		TableSize:   rateLimitSize,
-		TablePeriod: rateLimitPeriod,
-		TrackKey:    "src",
+		TableName: tableName,
+		TrackKey:  rateLimitKey,
 	}
 	reqRateLimit := rules.ReqRateLimit{
 		TableName:      tableName,
 		ReqsLimit:      reqsLimit,
 		DenyStatusCode: rateLimitCode,
 	}
-	logger.Error(c.Cfg.HAProxyRules.AddRule(reqTrack, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS))
-	logger.Error(c.Cfg.HAProxyRules.AddRule(reqRateLimit, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS))
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqTrack, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqRateLimit, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+}
+
+// greaterSize returns true when table size a is greater than b, unset sizes being the smallest
+func greaterSize(a, b *int64) bool {
+	return a != nil && (b == nil || *a > *b)
+}
+
+// checkRateLimitTable returns an error when the definition of a shared rate limiting table conflicts with its current one
+func checkRateLimitTable(current, table config.RateLimitTable) error {
+	switch {
+	case current.Period != table.Period:
+		return fmt.Errorf("conflicting rate-limit-period %dms, table period is %dms", table.Period, current.Period)
+	case current.Key != table.Key:
+		return fmt.Errorf("conflicting rate-limit-key '%s', table key is '%s'", table.Key, current.Key)
+	case (current.Size == nil) != (table.Size == nil) || (current.Size != nil && *current.Size != *table.Size):
+		return fmt.Errorf("conflicting rate-limit-size")
+	}
+	return nil
 }
 
 func (c *HAProxyController) handleRequestBasicAuth(ingress *store.Ingress) {
@@ -176,14 +503,14 @@ func (c *HAProxyController) handleRequestBasicAuth(ingress *store.Ingress) {
 	authSecret := c.Store.GetValueFromAnnotations("auth-secret", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
 	authRealm := c.Store.GetValueFromAnnotations("auth-realm", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
 	switch {
-	case authType == "":
+	case authType == "" || authType == "token-review":
 		if ok, _ := c.Client.UserListExistsByGroup(userListName); ok {
 			logger.Tracef("Ingress %s/%s: Deleting HTTP Basic Authentication", ingress.Namespace, ingress.Name)
 			logger.Error(c.Client.UserListDeleteByGroup(userListName))
 		}
 		return
 	case authType != "basic-auth":
-		logger.Errorf("Ingress %s/%s: incorrect auth-type value '%s'. Only 'basic-auth' value is currently supported", ingress.Namespace, ingress.Name, authType)
+		logger.Errorf("Ingress %s/%s: incorrect auth-type value '%s'. Only 'basic-auth' and 'token-review' values are currently supported", ingress.Namespace, ingress.Name, authType)
 	case authSecret == "":
 		logger.Warningf("Ingress %s/%s: auth-type annotation active but no auth-secret provided. Service won't be accessible", ingress.Namespace, ingress.Name)
 	}
@@ -197,12 +524,21 @@ func (c *HAProxyController) handleRequestBasicAuth(ingress *store.Ingress) {
 			if secret.Status == DELETED {
 				logger.Warningf("Ingress %s/%s: Secret %s deleted but auth-type annotaiton still active", ingress.Namespace, ingress.Name, secret.Name)
 			}
-			for u, pwd := range secret.Data {
-				if pwd[len(pwd)-1] == '\n' {
-					logger.Warningf("Ingress %s/%s: basic-auth: password for user %s ends with '\\n'. Ignoring last character.", ingress.Namespace, ingress.Name, u)
-					pwd = pwd[:len(pwd)-1]
+			if htpasswd, ok := secret.Data[utils.HtpasswdKey]; ok && bytes.Contains(htpasswd, []byte(":")) {
+				// htpasswd format, "user:hash" lines under a single key
+				var errs []error
+				credentials, errs = utils.ParseHtpasswd(htpasswd)
+				for _, err = range errs {
+					logger.Warningf("Ingress %s/%s: basic-auth: secret %s: %s", ingress.Namespace, ingress.Name, secret.Name, err)
+				}
+			} else {
+				for u, pwd := range secret.Data {
+					if len(pwd) > 0 && pwd[len(pwd)-1] == '\n' {
+						logger.Warningf("Ingress %s/%s: basic-auth: password for user %s ends with '\\n'. Ignoring last character.", ingress.Namespace, ingress.Name, u)
+						pwd = pwd[:len(pwd)-1]
+					}
+					credentials[u] = pwd
 				}
-				credentials[u] = pwd
 			}
 		}
 	}
@@ -220,14 +556,237 @@ func (c *HAProxyController) handleRequestBasicAuth(ingress *store.Ingress) {
 	if authRealm != "" {
 		realm = strings.ReplaceAll(authRealm, " ", "-")
 	}
+	// Restricting authentication to auth-paths prefixes
+	var pathsMap string
+	if annPaths := c.Store.GetValueFromAnnotations("auth-paths", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations); annPaths != "" {
+		pathsMap = "auth-paths-" + utils.Hash([]byte(annPaths))
+		if !c.Cfg.MapFiles.Exists(pathsMap) {
+			for _, path := range strings.Fields(annPaths) {
+				if !strings.HasPrefix(path, "/") {
+					logger.Errorf("incorrect path '%s' in auth-paths annotation in ingress '%s'", path, ingress.Name)
+					continue
+				}
+				c.Cfg.MapFiles.AppendRow(pathsMap, path)
+			}
+			if !c.Cfg.MapFiles.Exists(pathsMap) {
+				// Protect every path rather than none
+				logger.Warningf("Ingress %s/%s: no valid path in auth-paths annotation, authentication required for all paths", ingress.Namespace, ingress.Name)
+				pathsMap = ""
+			}
+		}
+	}
 	// Adding HAProxy Rule
 	logger.Tracef("Ingress %s/%s: Configuring basic-auth annotation", ingress.Namespace, ingress.Name)
 	reqBasicAuth := rules.ReqBasicAuth{
 		Data:      credentials,
 		AuthRealm: realm,
 		AuthGroup: userListName,
+		PathsMap:  pathsMap,
 	}
-	logger.Error(c.Cfg.HAProxyRules.AddRule(reqBasicAuth, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS))
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqBasicAuth, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+}
+
+func (c *HAProxyController) handleRequestTokenReview(ingress *store.Ingress) {
+	authType := c.Store.GetValueFromAnnotations("auth-type", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if authType != "token-review" {
+		return
+	}
+	authorize := false
+	annAuthorize := c.Store.GetValueFromAnnotations("auth-token-review-authorize", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annAuthorize != "" {
+		var err error
+		if authorize, err = utils.GetBoolValue(annAuthorize, "auth-token-review-authorize"); err != nil {
+			logger.Error(err)
+			return
+		}
+	}
+	group := spoe.MsgTokenReview
+	if authorize {
+		group = spoe.MsgTokenReviewAuthz
+	}
+	// Configure annotation
+	logger.Tracef("Ingress %s/%s: Configuring token-review authentication", ingress.Namespace, ingress.Name)
+	c.checkSPOEAgent(ingress, "auth-type token-review")
+	c.Cfg.SPOE = true
+	reqAuth := rules.ReqAuthSPOE{
+		Engine:    handler.SPOE_ENGINE,
+		Group:     group,
+		ResultVar: "txn." + handler.SPOE_ENGINE + ".valid",
+	}
+	reqSetUser := rules.SetHdr{
+		HdrName:   "X-Remote-User",
+		HdrFormat: "%[var(txn." + handler.SPOE_ENGINE + ".user)]",
+	}
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqAuth, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqSetUser, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+}
+
+// handleRequestForwardAuth handles external authentication annotations: auth-url
+// and auth-request-service, the latter taking an in-cluster service as target.
+func (c *HAProxyController) handleRequestForwardAuth(ingress *store.Ingress) {
+	var err error
+	var forwardHeaders []string
+	group := spoe.MsgForwardAuth
+	authURL := c.Store.GetValueFromAnnotations("auth-url", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if authURL == "" {
+		annService := c.Store.GetValueFromAnnotations("auth-request-service", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+		if annService == "" {
+			return
+		}
+		annPath := c.Store.GetValueFromAnnotations("auth-request-path", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+		if authURL, err = c.authRequestURL(annService, annPath, ingress.Namespace); err != nil {
+			logger.Errorf("Ingress %s/%s: auth-request-service: %s", ingress.Namespace, ingress.Name, err)
+			return
+		}
+		annForwardHeaders := c.Store.GetValueFromAnnotations("auth-request-headers", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+		if forwardHeaders, err = parseHeaderNames(annForwardHeaders); err != nil {
+			logger.Errorf("Ingress %s/%s: auth-request-headers: %s", ingress.Namespace, ingress.Name, err)
+			return
+		}
+		group = spoe.MsgAuthRequest
+	}
+	// Validate annotations, values end up in HAProxy sample expressions
+	if err = validateAuthURL(authURL); err != nil {
+		logger.Errorf("Ingress %s/%s: auth-url: %s", ingress.Namespace, ingress.Name, err)
+		return
+	}
+	annSignin := c.Store.GetValueFromAnnotations("auth-signin", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annSignin != "" {
+		if err = validateAuthURL(annSignin); err != nil {
+			logger.Errorf("Ingress %s/%s: auth-signin: %s", ingress.Namespace, ingress.Name, err)
+			return
+		}
+	}
+	annRespHeaders := c.Store.GetValueFromAnnotations("auth-response-headers", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	respHeaders, err := parseHeaderNames(annRespHeaders)
+	if err != nil {
+		logger.Errorf("Ingress %s/%s: auth-response-headers: %s", ingress.Namespace, ingress.Name, err)
+		return
+	}
+	// Configure annotation
+	logger.Tracef("Ingress %s/%s: Configuring external authentication with %s", ingress.Namespace, ingress.Name, authURL)
+	c.checkSPOEAgent(ingress, "external authentication")
+	c.Cfg.SPOE = true
+	ingressRules := []haproxy.Rule{
+		rules.ReqSetVar{
+			Name:       "auth_url",
+			Scope:      "txn",
+			Expression: fmt.Sprintf("str(%s)", authURL),
+		},
+		rules.ReqForwardAuth{
+			Engine:    handler.SPOE_ENGINE,
+			Group:     group,
+			ResultVar: "txn." + handler.SPOE_ENGINE + ".valid",
+			StatusVar: "txn." + handler.SPOE_ENGINE + ".status",
+			SigninURL: annSignin,
+		},
+	}
+	if len(respHeaders) > 0 {
+		ingressRules = append(ingressRules, rules.ReqSetVar{
+			Name:       "auth_response_headers",
+			Scope:      "txn",
+			Expression: fmt.Sprintf("str(%s)", strings.Join(respHeaders, spoe.ForwardAuthHeadersSep)),
+		})
+	}
+	for _, header := range forwardHeaders {
+		c.Cfg.AuthRequestHeaders[strings.ToLower(header)] = struct{}{}
+	}
+	if len(forwardHeaders) > 0 {
+		ingressRules = append(ingressRules, rules.ReqSetVar{
+			Name:       "auth_forward_headers",
+			Scope:      "txn",
+			Expression: fmt.Sprintf("str(%s)", strings.Join(forwardHeaders, spoe.ForwardAuthHeadersSep)),
+		})
+	}
+	for _, header := range respHeaders {
+		hdrVar := "txn." + handler.SPOE_ENGINE + "." + spoe.ForwardAuthHeaderVar(header)
+		// Client copies are removed so that backends only get the headers set by the authentication service
+		ingressRules = append(ingressRules, rules.DelHdr{
+			HdrName: header,
+		}, rules.SetHdr{
+			HdrName:   header,
+			HdrFormat: "%[var(" + hdrVar + ")]",
+			CondTest:  "{ var(" + hdrVar + ") -m found }",
+		})
+	}
+	for _, rule := range ingressRules {
+		logger.Error(c.Cfg.HAProxyRules.AddRule(rule, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+	}
+}
+
+// authRequestURL returns the URL of the authentication service given
+// as "[namespace/]name:port", port being a service port number or name.
+func (c *HAProxyController) authRequestURL(service, path, defaultNs string) (string, error) {
+	svc, port := service, ""
+	if i := strings.LastIndex(service, ":"); i != -1 {
+		svc, port = service[:i], service[i+1:]
+	}
+	if port == "" {
+		return "", fmt.Errorf("'%s': missing service port", service)
+	}
+	ns, name := defaultNs, svc
+	if parts := strings.Split(svc, "/"); len(parts) == 2 {
+		ns, name = parts[0], parts[1]
+	} else if len(parts) > 2 {
+		return "", fmt.Errorf("'%s': incorrect service path", service)
+	}
+	namespace, ok := c.Store.Namespaces[ns]
+	if !ok {
+		return "", fmt.Errorf("namespace '%s' not found", ns)
+	}
+	k8sSvc, ok := namespace.Services[name]
+	if !ok || k8sSvc.Status == DELETED {
+		return "", fmt.Errorf("service '%s/%s' not found", ns, name)
+	}
+	var portNum int64
+	for _, p := range k8sSvc.Ports {
+		if p.Name == port || strconv.FormatInt(p.Port, 10) == port {
+			portNum = p.Port
+			break
+		}
+	}
+	if portNum == 0 {
+		return "", fmt.Errorf("service '%s/%s': port '%s' not found", ns, name, port)
+	}
+	host := fmt.Sprintf("%s.%s.svc", name, ns)
+	if k8sSvc.DNS != "" {
+		host = k8sSvc.DNS
+	}
+	if path == "" {
+		path = "/"
+	} else if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return fmt.Sprintf("http://%s:%d%s", host, portNum, path), nil
+}
+
+// parseHeaderNames parses a comma-separated list of HTTP header names
+func parseHeaderNames(value string) (headers []string, err error) {
+	for _, header := range strings.Split(value, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		if !httpHeaderNameRegex.MatchString(header) {
+			return nil, fmt.Errorf("incorrect header name '%s'", header)
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
+
+func validateAuthURL(value string) error {
+	authURL, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if authURL.Scheme != "http" && authURL.Scheme != "https" || authURL.Host == "" {
+		return fmt.Errorf("'%s' is not an absolute http(s) URL", value)
+	}
+	if strings.ContainsAny(value, " \t,()#\"'") {
+		return fmt.Errorf("'%s' contains unsupported characters", value)
+	}
+	return nil
 }
 
 func (c *HAProxyController) handleRequestHostRedirect(ingress *store.Ingress) {
@@ -239,6 +798,10 @@ func (c *HAProxyController) handleRequestHostRedirect(ingress *store.Ingress) {
 		logger.Error(err)
 		return
 	}
+	if annRedirectRegex := c.Store.GetValueFromAnnotations("request-redirect-regex", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations); annRedirectRegex != "" {
+		c.handleRequestRegexRedirect(ingress, annRedirectRegex, domainRedirectCode)
+		return
+	}
 	if annDomainRedirect == "" {
 		return
 	}
@@ -247,9 +810,42 @@ func (c *HAProxyController) handleRequestHostRedirect(ingress *store.Ingress) {
 		RedirectCode: domainRedirectCode,
 		Host:         annDomainRedirect,
 	}
-	logger.Error(c.Cfg.HAProxyRules.AddRule(reqDomainRedirect, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP))
+	if frontend := c.ingressFrontend(ingress, false); frontend != "" {
+		logger.Error(c.Cfg.HAProxyRules.AddRule(reqDomainRedirect, ingress.Namespace+"-"+ingress.Name, frontend))
+	}
 	reqDomainRedirect.SSLRequest = true
-	logger.Error(c.Cfg.HAProxyRules.AddRule(reqDomainRedirect, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTPS))
+	if frontend := c.ingressFrontend(ingress, true); frontend != "" {
+		logger.Error(c.Cfg.HAProxyRules.AddRule(reqDomainRedirect, ingress.Namespace+"-"+ingress.Name, frontend))
+	}
+}
+
+// handleRequestRegexRedirect configures request-redirect-regex redirections,
+// one "<regex> <target>" per line, evaluated in order.
+func (c *HAProxyController) handleRequestRegexRedirect(ingress *store.Ingress, annRedirectRegex string, redirectCode int64) {
+	var redirects []haproxy.Rule
+	for _, line := range strings.Split(annRedirectRegex, "\n") {
+		parts := strings.Fields(line)
+		if len(parts) == 0 {
+			continue
+		}
+		if len(parts) != 2 {
+			logger.Errorf("Ingress %s/%s: request-redirect-regex: incorrect value '%s', expected '<regex> <target>'", ingress.Namespace, ingress.Name, line)
+			return
+		}
+		if _, err := regexp.Compile(parts[0]); err != nil {
+			logger.Errorf("Ingress %s/%s: request-redirect-regex: incorrect regex '%s': %s", ingress.Namespace, ingress.Name, parts[0], err)
+			return
+		}
+		redirects = append(redirects, rules.ReqRedirectRegex{
+			RedirectCode: redirectCode,
+			PathMatch:    parts[0],
+			Target:       parts[1],
+		})
+	}
+	logger.Tracef("Ingress %s/%s: Configuring request-redirect-regex", ingress.Namespace, ingress.Name)
+	for _, redirect := range redirects {
+		logger.Error(c.Cfg.HAProxyRules.AddRule(redirect, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+	}
 }
 
 func (c *HAProxyController) handleRequestHTTPSRedirect(ingress *store.Ingress) {
@@ -271,7 +867,8 @@ func (c *HAProxyController) handleRequestHTTPSRedirect(ingress *store.Ingress) {
 	} else if tlsEnabled(ingress) {
 		toEnable = true
 	}
-	if !toEnable {
+	// Requests of extra frontends are not redirected to the main HTTPS frontend
+	if !toEnable || c.ingressExtraFrontend(ingress) != "" {
 		return
 	}
 	sslRedirectPort, err := strconv.Atoi(annSSLRedirectPort)
@@ -279,15 +876,65 @@ func (c *HAProxyController) handleRequestHTTPSRedirect(ingress *store.Ingress) {
 		logger.Error(err)
 		return
 	}
+	var excludeMap string
+	if annExclude := c.Store.GetValueFromAnnotations("ssl-redirect-exclude-paths", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations); annExclude != "" {
+		excludeMap = "ssl-redirect-exclude-" + utils.Hash([]byte(annExclude))
+		if !c.Cfg.MapFiles.Exists(excludeMap) {
+			for _, path := range strings.Fields(annExclude) {
+				if !strings.HasPrefix(path, "/") {
+					logger.Errorf("incorrect path '%s' in ssl-redirect-exclude-paths annotation in ingress '%s'", path, ingress.Name)
+					continue
+				}
+				c.Cfg.MapFiles.AppendRow(excludeMap, path)
+			}
+			if !c.Cfg.MapFiles.Exists(excludeMap) {
+				excludeMap = ""
+			}
+		}
+	}
 	// Configure redirection
 	reqSSLRedirect := rules.RequestRedirect{
-		RedirectCode: sslRedirectCode,
-		RedirectPort: sslRedirectPort,
-		SSLRedirect:  true,
+		RedirectCode:    sslRedirectCode,
+		RedirectPort:    sslRedirectPort,
+		SSLRedirect:     true,
+		ExcludePathsMap: excludeMap,
 	}
 	logger.Error(c.Cfg.HAProxyRules.AddRule(reqSSLRedirect, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP))
 }
 
+// handleRequestMirror sends a copy of the requests to the mirror service,
+// requests are replayed by the controller SPOE agent and responses discarded.
+func (c *HAProxyController) handleRequestMirror(ingress *store.Ingress) {
+	annMirror := c.Store.GetValueFromAnnotations("mirror-service", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annMirror == "" {
+		return
+	}
+	mirrorURL, err := c.authRequestURL(annMirror, "", ingress.Namespace)
+	if err != nil {
+		logger.Errorf("Ingress %s/%s: mirror-service: %s", ingress.Namespace, ingress.Name, err)
+		return
+	}
+	mirrorURL = strings.TrimSuffix(mirrorURL, "/")
+	logger.Tracef("Ingress %s/%s: Configuring request mirroring to %s", ingress.Namespace, ingress.Name, mirrorURL)
+	c.checkSPOEAgent(ingress, "mirror-service")
+	c.Cfg.SPOE = true
+	ingressRules := []haproxy.Rule{
+		rules.ReqSetVar{
+			Name:       "mirror_url",
+			Scope:      "txn",
+			Expression: fmt.Sprintf("str(%s)", mirrorURL),
+		},
+		rules.ReqMirror{
+			Engine:   handler.SPOE_ENGINE,
+			Group:    spoe.MsgMirror,
+			CondTest: fmt.Sprintf("{ pathq,length le %d } { req.hdrs_len le %d } { req.body_size le %d }", spoe.MirrorMaxURILen, spoe.MirrorMaxHeadersLen, spoe.MirrorMaxBodyLen),
+		},
+	}
+	for _, rule := range ingressRules {
+		logger.Error(c.Cfg.HAProxyRules.AddRule(rule, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+	}
+}
+
 func (c *HAProxyController) handleRequestCapture(ingress *store.Ingress) {
 	//  Get annotation status
 	annReqCapture := c.Store.GetValueFromAnnotations("request-capture", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
@@ -312,7 +959,7 @@ func (c *HAProxyController) handleRequestCapture(ingress *store.Ingress) {
 			Expression: sample,
 			CaptureLen: captureLen,
 		}
-		frontends := []string{c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS}
+		frontends := c.ingressFrontends(ingress)
 		if c.sslPassthroughEnabled(ingress, nil) {
 			frontends = []string{c.Cfg.FrontHTTP, c.Cfg.FrontSSL}
 		}
@@ -320,6 +967,79 @@ func (c *HAProxyController) handleRequestCapture(ingress *store.Ingress) {
 	}
 }
 
+func (c *HAProxyController) handleStaticResponse(ingress *store.Ingress) {
+	//  Get annotation status
+	annStaticResponse := c.Store.GetValueFromAnnotations("static-response", ingress.Annotations)
+	if annStaticResponse == "" {
+		return
+	}
+	//  Validate annotation
+	statusCode, err := strconv.ParseInt(annStaticResponse, 10, 64)
+	if err != nil || statusCode < 200 || statusCode > 509 {
+		logger.Errorf("Ingress %s/%s: incorrect value '%s' in static-response annotation", ingress.Namespace, ingress.Name, annStaticResponse)
+		return
+	}
+	annContentType := c.Store.GetValueFromAnnotations("static-response-content-type", ingress.Annotations)
+	annBody := c.Store.GetValueFromAnnotations("static-response-body", ingress.Annotations)
+
+	// Configure annotation
+	logger.Tracef("Ingress %s/%s: Configuring static-response annotation", ingress.Namespace, ingress.Name)
+	reqReturn := rules.ReqReturn{
+		StatusCode:  statusCode,
+		ContentType: annContentType,
+		Content:     annBody,
+	}
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqReturn, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+}
+
+// handleFaultInjection delays or aborts a percentage of the ingress
+// requests, the delay being implemented by the fault injection Lua script.
+func (c *HAProxyController) handleFaultInjection(ingress *store.Ingress) {
+	annDelay := c.Store.GetValueFromAnnotations("fault-delay", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	annAbortStatus := c.Store.GetValueFromAnnotations("fault-abort-status", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annDelay == "" && annAbortStatus == "" {
+		return
+	}
+	var fault rules.ReqFault
+	var err error
+	if annDelay != "" {
+		var delay *int64
+		if delay, err = utils.ParseTime(annDelay); err != nil || *delay <= 0 {
+			logger.Errorf("Ingress %s/%s: incorrect fault-delay value '%s'", ingress.Namespace, ingress.Name, annDelay)
+			return
+		}
+		fault.Delay = *delay
+		if fault.DelayPercent, err = c.faultPercent("fault-delay-percent", ingress); err != nil {
+			logger.Errorf("Ingress %s/%s: %s", ingress.Namespace, ingress.Name, err)
+			return
+		}
+	}
+	if annAbortStatus != "" {
+		if fault.AbortStatus, err = strconv.ParseInt(annAbortStatus, 10, 64); err != nil || fault.AbortStatus < 400 || fault.AbortStatus > 599 {
+			logger.Errorf("Ingress %s/%s: incorrect fault-abort-status value '%s', expecting a 4xx or 5xx status code", ingress.Namespace, ingress.Name, annAbortStatus)
+			return
+		}
+		if fault.AbortPercent, err = c.faultPercent("fault-abort-percent", ingress); err != nil {
+			logger.Errorf("Ingress %s/%s: %s", ingress.Namespace, ingress.Name, err)
+			return
+		}
+	}
+	logger.Tracef("Ingress %s/%s: Configuring fault injection", ingress.Namespace, ingress.Name)
+	if fault.Delay != 0 {
+		c.Cfg.FaultDelay = true
+	}
+	logger.Error(c.Cfg.HAProxyRules.AddRule(fault, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+}
+
+func (c *HAProxyController) faultPercent(annotation string, ingress *store.Ingress) (int64, error) {
+	ann := c.Store.GetValueFromAnnotations(annotation, ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	percent, err := strconv.ParseInt(strings.TrimSuffix(ann, "%"), 10, 64)
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("incorrect %s value '%s', expecting a percentage between 0 and 100", annotation, ann)
+	}
+	return percent, nil
+}
+
 func (c *HAProxyController) handleRequestSetHost(ingress *store.Ingress) {
 	//  Get annotation status
 	annSetHost := c.Store.GetValueFromAnnotations("set-host", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
@@ -332,7 +1052,7 @@ func (c *HAProxyController) handleRequestSetHost(ingress *store.Ingress) {
 		HdrName:   "Host",
 		HdrFormat: annSetHost,
 	}
-	logger.Error(c.Cfg.HAProxyRules.AddRule(reqSetHost, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS))
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqSetHost, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
 }
 
 func (c *HAProxyController) handleRequestPathRewrite(ingress *store.Ingress) {
@@ -361,7 +1081,127 @@ func (c *HAProxyController) handleRequestPathRewrite(ingress *store.Ingress) {
 		logger.Errorf("incorrect value '%s', path-rewrite takes 1 or 2 params ", annPathRewrite)
 		return
 	}
-	logger.Error(c.Cfg.HAProxyRules.AddRule(reqPathReWrite, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS))
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqPathReWrite, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+}
+
+// handleABTesting sets the A/B testing group of the request from the ab-cookie cookie,
+// clients without cookie being assigned to the ab-service group for ab-cookie-percent
+// of them when set. The routing itself is done by handleABRoute.
+func (c *HAProxyController) handleABTesting(ingress *store.Ingress) {
+	if c.Store.GetValueFromAnnotations("ab-service", ingress.Annotations) == "" {
+		return
+	}
+	cookieName, cookieValue, ok := c.abCookie(ingress)
+	if !ok {
+		return
+	}
+	ingressRules := []haproxy.Rule{
+		rules.ReqSetVar{
+			Name:       "ab_group",
+			Scope:      "txn",
+			Expression: fmt.Sprintf("req.cook(%s)", cookieName),
+			CondTest:   fmt.Sprintf("{ req.cook(%s) -m found }", cookieName),
+		},
+	}
+	annPercent := c.Store.GetValueFromAnnotations("ab-cookie-percent", ingress.Annotations)
+	if annPercent != "" {
+		percent, err := strconv.ParseInt(strings.TrimSuffix(annPercent, "%"), 10, 64)
+		if err != nil || percent < 0 || percent > 100 {
+			logger.Errorf("Ingress %s/%s: incorrect ab-cookie-percent value '%s', expecting a percentage between 0 and 100", ingress.Namespace, ingress.Name, annPercent)
+			return
+		}
+		ingressRules = append(ingressRules,
+			rules.ReqSetVar{
+				Name:       "ab_group",
+				Scope:      "txn",
+				Expression: fmt.Sprintf("str(%s)", cookieValue),
+				CondTest:   fmt.Sprintf("!{ req.cook(%s) -m found } { rand(100) lt %d }", cookieName, percent),
+			},
+			rules.ReqSetVar{
+				Name:       "ab_group",
+				Scope:      "txn",
+				Expression: fmt.Sprintf("str(%s)", abDefaultGroup),
+				CondTest:   fmt.Sprintf("!{ var(%s) -m found }", abGroupVar),
+			},
+			rules.ReqSetVar{
+				Name:       "ab_new",
+				Scope:      "txn",
+				Expression: "bool(true)",
+				CondTest:   fmt.Sprintf("!{ req.cook(%s) -m found }", cookieName),
+			},
+			rules.ResAddHdr{
+				HdrName:   "Set-Cookie",
+				HdrFormat: fmt.Sprintf("\"%s=%%[var(%s)]; Path=/\"", cookieName, abGroupVar),
+				CondTest:  "{ var(txn.ab_new) -m bool }",
+			},
+		)
+	}
+	logger.Tracef("Ingress %s/%s: Configuring A/B testing with cookie '%s'", ingress.Namespace, ingress.Name, cookieName)
+	for _, rule := range ingressRules {
+		logger.Error(c.Cfg.HAProxyRules.AddRule(rule, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+	}
+}
+
+// abCookie returns the validated ab-cookie and ab-cookie-value annotations
+func (c *HAProxyController) abCookie(ingress *store.Ingress) (name, value string, ok bool) {
+	name = c.Store.GetValueFromAnnotations("ab-cookie", ingress.Annotations)
+	value = c.Store.GetValueFromAnnotations("ab-cookie-value", ingress.Annotations)
+	if !cookieTokenRegex.MatchString(name) {
+		logger.Errorf("Ingress %s/%s: incorrect ab-cookie value '%s'", ingress.Namespace, ingress.Name, name)
+		return "", "", false
+	}
+	if !cookieTokenRegex.MatchString(value) || value == abDefaultGroup {
+		logger.Errorf("Ingress %s/%s: incorrect ab-cookie-value value '%s'", ingress.Namespace, ingress.Name, value)
+		return "", "", false
+	}
+	return name, value, true
+}
+
+func (c *HAProxyController) handleRequestDelHdr(ingress *store.Ingress) {
+	c.handleDelHdr(ingress, "request-del-header", false)
+}
+
+func (c *HAProxyController) handleResponseDelHdr(ingress *store.Ingress) {
+	c.handleDelHdr(ingress, "response-del-header", true)
+}
+
+// handleDelHdr deletes headers listed one per line in the annotation
+// as "<name> [-i] [<regex>]", the regex restricting deletion to matching values.
+func (c *HAProxyController) handleDelHdr(ingress *store.Ingress, annotation string, response bool) {
+	//  Get annotation status
+	annDelHdr := c.Store.GetValueFromAnnotations(annotation, ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annDelHdr == "" {
+		return
+	}
+	// Configure annotation
+	for _, param := range strings.Split(annDelHdr, "\n") {
+		fields := strings.Fields(param)
+		if len(fields) == 0 {
+			continue
+		}
+		delHdr := rules.DelHdr{
+			Response: response,
+			HdrName:  fields[0],
+		}
+		if len(fields) > 1 && fields[1] == "-i" {
+			delHdr.IgnoreCase = true
+			fields = fields[1:]
+		}
+		switch len(fields) {
+		case 1:
+		case 2:
+			if _, err := regexp.Compile(fields[1]); err != nil {
+				logger.Errorf("incorrect regex '%s' in %s annotation in ingress '%s': %s", fields[1], annotation, ingress.Name, err)
+				continue
+			}
+			delHdr.ValueMatch = fields[1]
+		default:
+			logger.Errorf("incorrect value '%s' in %s annotation", param, annotation)
+			continue
+		}
+		logger.Tracef("Ingress %s/%s: Configuring %s '%s'", ingress.Namespace, ingress.Name, annotation, param)
+		logger.Error(c.Cfg.HAProxyRules.AddRule(delHdr, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+	}
 }
 
 func (c *HAProxyController) handleRequestSetHdr(ingress *store.Ingress) {
@@ -385,8 +1225,73 @@ func (c *HAProxyController) handleRequestSetHdr(ingress *store.Ingress) {
 			HdrName:   param[:indexSpace],
 			HdrFormat: "\"" + param[indexSpace+1:] + "\"",
 		}
-		logger.Error(c.Cfg.HAProxyRules.AddRule(reqSetHdr, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS))
+		logger.Error(c.Cfg.HAProxyRules.AddRule(reqSetHdr, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+	}
+}
+
+// handleRequestForwardedHdrs sets the standard X-Forwarded-* and X-Real-IP headers,
+// optionally stripping client provided ones unless client is in forwarded-headers-trusted.
+func (c *HAProxyController) handleRequestForwardedHdrs(ingress *store.Ingress) {
+	annForwarded := c.Store.GetValueFromAnnotations("forwarded-headers", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annForwarded == "" {
+		return
+	}
+	enabled, err := utils.GetBoolValue(annForwarded, "forwarded-headers")
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	if !enabled {
+		return
+	}
+	var strip bool
+	if annStrip := c.Store.GetValueFromAnnotations("forwarded-headers-strip", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations); annStrip != "" {
+		if strip, err = utils.GetBoolValue(annStrip, "forwarded-headers-strip"); err != nil {
+			logger.Error(err)
+			return
+		}
 	}
+	var trustedMap string
+	if annTrusted := c.Store.GetValueFromAnnotations("forwarded-headers-trusted", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations); strip && annTrusted != "" {
+		if trustedMap = c.addressesMap("forwarded-headers-trusted", annTrusted, ingress); trustedMap == "" {
+			return
+		}
+	}
+	// Configure annotation
+	logger.Tracef("Ingress %s/%s: Configuring forwarded-headers annotation", ingress.Namespace, ingress.Name)
+	reqForwardedHdrs := rules.ReqForwardedHdrs{
+		Strip:      strip,
+		TrustedMap: trustedMap,
+	}
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqForwardedHdrs, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+}
+
+// handleRequestTimeoutHdr sends to backends the time they have to respond,
+// computed from timeout-server, so they can enforce matching internal deadlines.
+func (c *HAProxyController) handleRequestTimeoutHdr(ingress *store.Ingress) {
+	//  Get annotation status
+	annTimeoutHdr := c.Store.GetValueFromAnnotations("request-timeout-header", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annTimeoutHdr == "" {
+		return
+	}
+	// Validate annotation
+	if !httpHeaderNameRegex.MatchString(annTimeoutHdr) {
+		logger.Errorf("Ingress %s/%s: incorrect header name '%s' in request-timeout-header annotation", ingress.Namespace, ingress.Name, annTimeoutHdr)
+		return
+	}
+	annTimeout := c.Store.GetValueFromAnnotations("timeout-server", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	timeout, err := utils.ParseTime(annTimeout)
+	if err != nil {
+		logger.Errorf("Ingress %s/%s: request-timeout-header: incorrect timeout-server value '%s'", ingress.Namespace, ingress.Name, annTimeout)
+		return
+	}
+	// Configure annotation
+	logger.Tracef("Ingress %s/%s: Configuring request-timeout-header", ingress.Namespace, ingress.Name)
+	reqSetHdr := rules.SetHdr{
+		HdrName:   annTimeoutHdr,
+		HdrFormat: strconv.FormatInt(*timeout, 10),
+	}
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqSetHdr, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
 }
 
 func (c *HAProxyController) handleResponseSetHdr(ingress *store.Ingress) {
@@ -411,7 +1316,101 @@ func (c *HAProxyController) handleResponseSetHdr(ingress *store.Ingress) {
 			HdrFormat: "\"" + param[indexSpace+1:] + "\"",
 			Response:  true,
 		}
-		logger.Error(c.Cfg.HAProxyRules.AddRule(resSetHdr, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS))
+		logger.Error(c.Cfg.HAProxyRules.AddRule(resSetHdr, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+	}
+}
+
+func (c *HAProxyController) handleResponseHSTS(ingress *store.Ingress) {
+	//  Get and validate annotations
+	annHSTS := c.Store.GetValueFromAnnotations("hsts", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	enabled, err := utils.GetBoolValue(annHSTS, "hsts")
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	if !enabled {
+		return
+	}
+	annMaxAge := c.Store.GetValueFromAnnotations("hsts-max-age", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	maxAge, err := utils.ParseInt(annMaxAge)
+	if err != nil || maxAge < 0 {
+		logger.Errorf("Ingress %s/%s: incorrect value '%s' in hsts-max-age annotation", ingress.Namespace, ingress.Name, annMaxAge)
+		return
+	}
+	hdrValue := fmt.Sprintf("max-age=%d", maxAge)
+	for _, directive := range []struct{ annotation, value string }{
+		{"hsts-include-subdomains", "includeSubDomains"},
+		{"hsts-preload", "preload"},
+	} {
+		ann := c.Store.GetValueFromAnnotations(directive.annotation, ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+		set, errBool := utils.GetBoolValue(ann, directive.annotation)
+		if errBool != nil {
+			logger.Error(errBool)
+			return
+		}
+		if set {
+			hdrValue += "; " + directive.value
+		}
+	}
+	// Configure annotation
+	logger.Tracef("Ingress %s/%s: Configuring HSTS '%s'", ingress.Namespace, ingress.Name, hdrValue)
+	resSetHdr := rules.SetHdr{
+		HdrName:   "Strict-Transport-Security",
+		HdrFormat: "\"" + hdrValue + "\"",
+		Response:  true,
+	}
+	if frontend := c.ingressFrontend(ingress, true); frontend != "" {
+		logger.Error(c.Cfg.HAProxyRules.AddRule(resSetHdr, ingress.Namespace+"-"+ingress.Name, frontend))
+	}
+}
+
+func (c *HAProxyController) handleResponseSetStatus(ingress *store.Ingress) {
+	//  Get annotation status
+	annSetStatus := c.Store.GetValueFromAnnotations("response-set-status", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annSetStatus == "" {
+		return
+	}
+	//  Validate annotation
+	parts := strings.SplitN(strings.TrimSpace(annSetStatus), " ", 2)
+	status, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || status < 100 || status > 599 {
+		logger.Errorf("Ingress %s/%s: incorrect value '%s' in response-set-status annotation", ingress.Namespace, ingress.Name, annSetStatus)
+		return
+	}
+	resSetStatus := rules.ResSetStatus{Status: status}
+	if len(parts) == 2 {
+		resSetStatus.Reason = strings.Trim(strings.TrimSpace(parts[1]), "\"")
+	}
+	// Configure annotation
+	logger.Tracef("Ingress %s/%s: Configuring response-set-status '%s'", ingress.Namespace, ingress.Name, annSetStatus)
+	logger.Error(c.Cfg.HAProxyRules.AddRule(resSetStatus, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
+}
+
+// handleResponseLocationRewrite rewrites the prefix of Location headers sent by backends,
+// each annotation line holds "<backend-prefix> <external-prefix>".
+func (c *HAProxyController) handleResponseLocationRewrite(ingress *store.Ingress) {
+	//  Get annotation status
+	annRewrite := c.Store.GetValueFromAnnotations("response-redirect-location-rewrite", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annRewrite == "" {
+		return
+	}
+	// Configure annotation
+	for _, param := range strings.Split(annRewrite, "\n") {
+		parts := strings.Fields(param)
+		if len(parts) == 0 {
+			continue
+		}
+		if len(parts) != 2 {
+			logger.Errorf("Ingress %s/%s: incorrect value '%s' in response-redirect-location-rewrite annotation", ingress.Namespace, ingress.Name, param)
+			continue
+		}
+		logger.Tracef("Ingress %s/%s: Configuring Location rewrite '%s'", ingress.Namespace, ingress.Name, param)
+		resReplaceHdr := rules.ResReplaceHdr{
+			HdrName:   "Location",
+			HdrMatch:  "^" + regexp.QuoteMeta(parts[0]) + "(.*)",
+			HdrFormat: strings.ReplaceAll(parts[1], "%", "%%") + "\\1",
+		}
+		logger.Error(c.Cfg.HAProxyRules.AddRule(resReplaceHdr, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
 	}
 }
 
@@ -430,18 +1429,55 @@ func (c *HAProxyController) handleResponseCors(ingress *store.Ingress) {
 		return
 	}
 	logger.Tracef("Ingress %s/%s: Enabling Cors configuration", ingress.Namespace, ingress.Name)
-	acl, err := c.handleResponseCorsOrigin(ingress)
+	// CORS response headers, also used to answer preflight requests
+	hdrs := make(map[string]string)
+	acl, err := c.handleResponseCorsOrigin(ingress, hdrs)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	c.handleResponseCorsMethod(ingress, acl, hdrs)
+	c.handleResponseCorsCredential(ingress, acl, hdrs)
+	c.handleResponseCorsHeaders(ingress, acl, hdrs)
+	c.handleResponseCorsMaxAge(ingress, acl, hdrs)
+	c.handleResponseCorsExposeHeaders(ingress, acl)
+	c.handleRequestCorsPreflight(ingress, acl, hdrs)
+}
+
+// addCorsHdr adds the rule setting the CORS response header, which is
+// also added to hdrs to be sent in preflight responses.
+func (c *HAProxyController) addCorsHdr(ingress *store.Ingress, resSetHdr rules.SetHdr, hdrs map[string]string) error {
+	if hdrs != nil {
+		hdrs[resSetHdr.HdrName] = resSetHdr.HdrFormat
+	}
+	return c.Cfg.HAProxyRules.AddRule(resSetHdr, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...)
+}
+
+// handleRequestCorsPreflight answers CORS preflight requests with a 204
+// and the CORS headers when cors-preflight is enabled, instead of forwarding them to the backend.
+func (c *HAProxyController) handleRequestCorsPreflight(ingress *store.Ingress, acl string, hdrs map[string]string) {
+	annotation := c.Store.GetValueFromAnnotations("cors-preflight", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annotation == "" {
+		return
+	}
+	enabled, err := utils.GetBoolValue(annotation, "cors-preflight")
 	if err != nil {
 		logger.Error(err)
 		return
 	}
-	c.handleResponseCorsMethod(ingress, acl)
-	c.handleResponseCorsCredential(ingress, acl)
-	c.handleResponseCorsHeaders(ingress, acl)
-	c.handleResponseCorsMaxAge(ingress, acl)
+	if !enabled {
+		return
+	}
+	logger.Tracef("Ingress %s/%s: Configuring cors-preflight", ingress.Namespace, ingress.Name)
+	reqReturn := rules.ReqReturn{
+		StatusCode: 204,
+		Headers:    hdrs,
+		CondTest:   "{ method OPTIONS } { req.hdr(access-control-request-method) -m found } " + acl,
+	}
+	logger.Error(c.Cfg.HAProxyRules.AddRule(reqReturn, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...))
 }
 
-func (c *HAProxyController) handleResponseCorsOrigin(ingress *store.Ingress) (acl string, err error) {
+func (c *HAProxyController) handleResponseCorsOrigin(ingress *store.Ingress, hdrs map[string]string) (acl string, err error) {
 	annOrigin := c.Store.GetValueFromAnnotations("cors-allow-origin", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
 	if annOrigin == "" {
 		return acl, fmt.Errorf("cors-allow-origin not defined")
@@ -455,7 +1491,7 @@ func (c *HAProxyController) handleResponseCorsOrigin(ingress *store.Ingress) (ac
 		Name:       originVar,
 		Scope:      "txn",
 		Expression: "req.hdr(origin)",
-	}, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS)
+	}, ingress.Namespace+"-"+ingress.Name, c.ingressFrontends(ingress)...)
 	if err != nil {
 		return acl, err
 	}
@@ -474,14 +1510,14 @@ func (c *HAProxyController) handleResponseCorsOrigin(ingress *store.Ingress) (ac
 		resSetHdr.HdrFormat = "%[var(txn." + originVar + ")]"
 		resSetHdr.CondTest = acl
 	}
-	err = c.Cfg.HAProxyRules.AddRule(resSetHdr, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS)
+	err = c.addCorsHdr(ingress, resSetHdr, hdrs)
 	if err != nil {
 		return acl, err
 	}
 	return acl, nil
 }
 
-func (c *HAProxyController) handleResponseCorsMethod(ingress *store.Ingress, acl string) {
+func (c *HAProxyController) handleResponseCorsMethod(ingress *store.Ingress, acl string, hdrs map[string]string) {
 	annotation := c.Store.GetValueFromAnnotations("cors-allow-methods", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
 	if annotation == "" {
 		return
@@ -507,10 +1543,10 @@ func (c *HAProxyController) handleResponseCorsMethod(ingress *store.Ingress, acl
 		Response:  true,
 		CondTest:  acl,
 	}
-	logger.Error(c.Cfg.HAProxyRules.AddRule(resSetHdr, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS))
+	logger.Error(c.addCorsHdr(ingress, resSetHdr, hdrs))
 }
 
-func (c *HAProxyController) handleResponseCorsCredential(ingress *store.Ingress, acl string) {
+func (c *HAProxyController) handleResponseCorsCredential(ingress *store.Ingress, acl string, hdrs map[string]string) {
 	annotation := c.Store.GetValueFromAnnotations("cors-allow-credentials", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
 	if annotation == "" {
 		return
@@ -531,10 +1567,10 @@ func (c *HAProxyController) handleResponseCorsCredential(ingress *store.Ingress,
 		Response:  true,
 		CondTest:  acl,
 	}
-	logger.Error(c.Cfg.HAProxyRules.AddRule(resSetHdr, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS))
+	logger.Error(c.addCorsHdr(ingress, resSetHdr, hdrs))
 }
 
-func (c *HAProxyController) handleResponseCorsHeaders(ingress *store.Ingress, acl string) {
+func (c *HAProxyController) handleResponseCorsHeaders(ingress *store.Ingress, acl string, hdrs map[string]string) {
 	annotation := c.Store.GetValueFromAnnotations("cors-allow-headers", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
 	if annotation == "" {
 		return
@@ -547,10 +1583,26 @@ func (c *HAProxyController) handleResponseCorsHeaders(ingress *store.Ingress, ac
 		Response:  true,
 		CondTest:  acl,
 	}
-	logger.Error(c.Cfg.HAProxyRules.AddRule(resSetHdr, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS))
+	logger.Error(c.addCorsHdr(ingress, resSetHdr, hdrs))
+}
+
+func (c *HAProxyController) handleResponseCorsExposeHeaders(ingress *store.Ingress, acl string) {
+	annotation := c.Store.GetValueFromAnnotations("cors-expose-headers", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
+	if annotation == "" {
+		return
+	}
+	logger.Tracef("Ingress %s/%s: Configuring cors-expose-headers", ingress.Namespace, ingress.Name)
+	value := strings.Join(strings.Fields(annotation), "") // strip spaces
+	resSetHdr := rules.SetHdr{
+		HdrName:   "Access-Control-Expose-Headers",
+		HdrFormat: "\"" + value + "\"",
+		Response:  true,
+		CondTest:  acl,
+	}
+	logger.Error(c.addCorsHdr(ingress, resSetHdr, nil))
 }
 
-func (c *HAProxyController) handleResponseCorsMaxAge(ingress *store.Ingress, acl string) {
+func (c *HAProxyController) handleResponseCorsMaxAge(ingress *store.Ingress, acl string, hdrs map[string]string) {
 	logger.Trace("Cors max age processing")
 	annotation := c.Store.GetValueFromAnnotations("cors-max-age", ingress.Annotations, c.Store.ConfigMaps.Main.Annotations)
 	if annotation == "" {
@@ -573,7 +1625,7 @@ func (c *HAProxyController) handleResponseCorsMaxAge(ingress *store.Ingress, acl
 		Response:  true,
 		CondTest:  acl,
 	}
-	logger.Error(c.Cfg.HAProxyRules.AddRule(resSetHdr, ingress.Namespace+"-"+ingress.Name, c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS))
+	logger.Error(c.addCorsHdr(ingress, resSetHdr, hdrs))
 }
 
 func tlsEnabled(ingress *store.Ingress) bool {
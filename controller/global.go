@@ -16,6 +16,7 @@ package controller
 
 import (
 	"strings"
+	"sync/atomic"
 
 	"github.com/go-test/deep"
 
@@ -24,6 +25,7 @@ import (
 	"github.com/haproxytech/kubernetes-ingress/controller/annotations"
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
 )
 
 func (c *HAProxyController) handleGlobalConfig() (reload, restart bool) {
@@ -44,13 +46,18 @@ func (c *HAProxyController) handleGlobalConfig() (reload, restart bool) {
 	}
 	oldGlobal = *global
 	oldDefaults = *defaults
+	oldTunes := c.globalTunes()
 	annotations.HandleGlobalAnnotations(
 		global,
 		defaults,
 		c.Store,
 		c.Client,
-		c.Store.ConfigMaps.Main.Annotations,
 	)
+	c.updateGracePeriod()
+	if result := deep.Equal(oldTunes, c.globalTunes()); len(result) != 0 {
+		reload = true
+		logger.Debugf("Global tune parameters updated: %s\nReload required", result)
+	}
 	result := deep.Equal(&oldGlobal, global)
 	if len(result) != 0 {
 		if err = c.Client.GlobalPushConfiguration(global); err != nil {
@@ -70,11 +77,25 @@ func (c *HAProxyController) handleGlobalConfig() (reload, restart bool) {
 		logger.Debugf("Defaults config updated: %s\nReload required", result)
 	}
 	c.handleDefaultCert()
+	reload = c.handleExtraFrontends() || reload
 	reload = c.handleDefaultService() || reload
 
 	return reload, restart
 }
 
+// globalTunes returns the values of the tune.* global parameters set by annotations
+func (c *HAProxyController) globalTunes() []string {
+	tunes := make([]string, len(annotations.TuneParams))
+	for i, name := range annotations.TuneParams {
+		value, err := c.Client.GlobalTuneGet(name)
+		if err != nil {
+			logger.Error(err)
+		}
+		tunes[i] = value
+	}
+	return tunes
+}
+
 // handleDefaultService configures HAProy default backend provided via cli param "default-backend-service"
 func (c *HAProxyController) handleDefaultService() (reload bool) {
 	dsvcData := c.Store.GetValueFromAnnotations("default-backend-service")
@@ -110,7 +131,7 @@ func (c *HAProxyController) handleDefaultService() (reload bool) {
 			IsDefaultBackend: true,
 		},
 	}
-	reload, err := c.setDefaultService(ingress, []string{c.Cfg.FrontHTTP, c.Cfg.FrontHTTPS})
+	reload, err := c.setDefaultService(ingress, c.Cfg.HTTPFrontends())
 	if err != nil {
 		logger.Errorf("default service '%s/%s': %s", namespace.Name, service.Name, err)
 		return
@@ -130,3 +151,18 @@ func (c *HAProxyController) handleDefaultCert() {
 	})
 	logger.Error(err)
 }
+
+// updateGracePeriod sets the time HAProxy keeps serving requests, reported as not ready,
+// before being soft-stopped when the controller stops.
+func (c *HAProxyController) updateGracePeriod() {
+	var grace int64
+	if ann := c.Store.GetValueFromAnnotations("grace-period", c.Store.Global.Annotations, c.Store.ConfigMaps.Main.Annotations); ann != "" {
+		value, err := utils.ParseTime(ann)
+		if err != nil || *value < 0 {
+			logger.Errorf("grace-period annotation: incorrect value '%s'", ann)
+		} else {
+			grace = *value
+		}
+	}
+	atomic.StoreInt64(&c.gracePeriod, grace)
+}
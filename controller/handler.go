@@ -25,9 +25,17 @@ type UpdateHandler interface {
 	Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error)
 }
 
-func (c *HAProxyController) initHandlers() {
+func (c *HAProxyController) initHandlers() error {
 	// handlers executed only once at controller initialization
-	logger.Panic(c.clientAPIClosure(c.startupHandlers))
+	if err := c.clientAPIClosure(c.startupHandlers); err != nil {
+		return err
+	}
+
+	// UDP services are proxied by the controller, which is not in the pods of Dataplane API instances
+	udpProxy := c.udpProxy
+	if len(c.OSArgs.DataplaneURLs) != 0 {
+		udpProxy = nil
+	}
 
 	// handlers executed at reconciliation loop
 	c.updateHandlers = []UpdateHandler{
@@ -40,8 +48,15 @@ func (c *HAProxyController) initHandlers() {
 			IPv6:     !c.OSArgs.DisableIPV6,
 			Port:     c.OSArgs.HTTPSBindPort,
 		},
+		&handler.BindAddresses{
+			HTTP:  !c.OSArgs.DisableHTTP,
+			HTTPS: !c.OSArgs.DisableHTTPS,
+		},
+		handler.HTTPSOnly{},
 		handler.ProxyProtocol{},
-		handler.ErrorFile{},
+		handler.AcceptProxy{},
+		handler.FrontendLimits{},
+		&handler.ErrorFile{},
 		handler.TCPServices{
 			SetDefaultService: c.setDefaultService,
 			CertDir:           c.Cfg.Env.FrontendCertDir,
@@ -49,14 +64,35 @@ func (c *HAProxyController) initHandlers() {
 			AddrIPv4:          c.OSArgs.IPV4BindAddr,
 			IPv6:              !c.OSArgs.DisableIPV6,
 			AddrIPv6:          c.OSArgs.IPV6BindAddr,
+			UDPProxy:          udpProxy,
 		},
 		handler.PatternFiles{},
+		handler.Peers{},
+		handler.Resolvers{},
+		handler.LogForward{},
+		handler.Stats{},
+		handler.H1CaseAdjust{},
+		handler.Threads{},
+		handler.FaultInjection{},
 		handler.Refresh{},
 	}
 	if c.OSArgs.PprofEnabled {
 		c.updateHandlers = append(c.updateHandlers, handler.Pprof{})
 	}
+	if c.OSArgs.PrometheusBindPort != 0 {
+		c.updateHandlers = append(c.updateHandlers, handler.Prometheus{
+			Port: c.OSArgs.PrometheusBindPort,
+			IPv4: !c.OSArgs.DisableIPV4,
+			IPv6: !c.OSArgs.DisableIPV6,
+		})
+	}
+	c.updateHandlers = append(c.updateHandlers, handler.SPOE{
+		AgentAddr:  c.OSArgs.SPOEAgentAdvertiseAddr,
+		Enabled:    c.OSArgs.SPOEAgent,
+		StartAgent: c.startSPOEAgent,
+	})
 	c.updateHandlers = append(c.updateHandlers, handler.Refresh{})
+	return nil
 }
 
 func (c *HAProxyController) startupHandlers() error {
@@ -68,6 +104,7 @@ func (c *HAProxyController) startupHandlers() error {
 			IPv6:      !c.OSArgs.DisableIPV6,
 			HTTPPort:  c.OSArgs.HTTPBindPort,
 			HTTPSPort: c.OSArgs.HTTPSBindPort,
+			StatsPort: c.OSArgs.StatsBindPort,
 			IPv4Addr:  c.OSArgs.IPV4BindAddr,
 			IPv6Addr:  c.OSArgs.IPV6BindAddr,
 		}}
@@ -0,0 +1,95 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/rules"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// AcceptProxy requires the PROXY protocol on the binds of the public frontends,
+// as sent by cloud network load balancers, connections being only accepted from
+// the trusted addresses of the "accept-proxy" annotation.
+// The PROXY protocol source address is used everywhere but in "tcp-request connection"
+// rules, so allowlists, rate limiting and logs apply to the actual client address
+// while the trusted addresses are checked against the load balancer address.
+type AcceptProxy struct{}
+
+func (h AcceptProxy) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	annAcceptProxy := k.GetValueFromAnnotations("accept-proxy", k.ConfigMaps.Main.Annotations)
+	// With ssl-passthrough, the HTTPS frontend already receives the PROXY protocol
+	// from the local ssl-passthrough backend, the public frontend being the SSL one.
+	// Extra frontends, usually not exposed through the load balancer, are not affected.
+	frontends := []string{cfg.FrontHTTP, cfg.FrontHTTPS}
+	if cfg.SSLPassthrough {
+		frontends = []string{cfg.FrontHTTP, cfg.FrontSSL}
+	}
+	mapName := "accept-proxy-" + utils.Hash([]byte(annAcceptProxy))
+	if annAcceptProxy != "" && !cfg.MapFiles.Exists(mapName) {
+		for _, address := range strings.Split(annAcceptProxy, ",") {
+			address = strings.TrimSpace(address)
+			if ip := net.ParseIP(address); ip == nil {
+				if _, _, err = net.ParseCIDR(address); err != nil {
+					logger.Errorf("incorrect address '%s' in accept-proxy annotation", address)
+					continue
+				}
+			}
+			cfg.MapFiles.AppendRow(mapName, address)
+		}
+	}
+	enabled := cfg.MapFiles.Exists(mapName)
+	for _, frontend := range frontends {
+		if h.updateBinds(api, frontend, enabled) {
+			reload = true
+		}
+	}
+	if !enabled {
+		return reload, nil
+	}
+	// "tcp-request connection" rules see the load balancer address
+	err = cfg.HAProxyRules.AddRule(rules.ReqRejectConnection{
+		CondTest: fmt.Sprintf("!{ src -f %s }", haproxy.GetMapPath(mapName)),
+	}, "", frontends...)
+	return reload, err
+}
+
+// updateBinds sets accept-proxy on all the binds of the frontend
+func (h AcceptProxy) updateBinds(api api.HAProxyClient, frontendName string, enabled bool) (reload bool) {
+	binds, err := api.FrontendBindsGet(frontendName)
+	if err != nil {
+		return false
+	}
+	for _, bind := range binds {
+		if bind.AcceptProxy == enabled {
+			continue
+		}
+		bind.AcceptProxy = enabled
+		if err = api.FrontendBindEdit(frontendName, *bind); err != nil {
+			logger.Error(err)
+			continue
+		}
+		logger.Debugf("Frontend '%s' bind '%s' accept-proxy set to %t, reload required", frontendName, bind.Name, enabled)
+		reload = true
+	}
+	return reload
+}
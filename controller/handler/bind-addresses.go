@@ -0,0 +1,184 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+// BindAddresses sets the addresses the public HTTP and HTTPS frontends listen on
+// from the "http-bind-addresses" and "https-bind-addresses" annotations, instead of
+// the ipv4-bind-address and ipv6-bind-address controller arguments. Extra frontends
+// use the addresses of their kind. Binds are restored once annotations are removed.
+type BindAddresses struct {
+	HTTP  bool
+	HTTPS bool
+	// binds of frontends before their addresses were set by annotations
+	defaults map[string][]bindAddress
+}
+
+type bindAddress struct {
+	name    string
+	address string
+	v4v6    bool
+	v6only  bool
+}
+
+func (h *BindAddresses) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	if h.defaults == nil {
+		h.defaults = make(map[string][]bindAddress)
+	}
+	addresses := make(map[bool][]bindAddress, 2)
+	for ssl, name := range map[bool]string{false: "http-bind-addresses", true: "https-bind-addresses"} {
+		value := k.GetValueFromAnnotations(name, k.ConfigMaps.Main.Annotations)
+		if value == "" {
+			continue
+		}
+		if addresses[ssl], err = parseBindAddresses(value); err != nil {
+			logger.Errorf("%s annotation: %s", name, err)
+		}
+	}
+	// Public frontends with their kind
+	frontends := make(map[string]bool)
+	if h.HTTP {
+		frontends[cfg.FrontHTTP] = false
+	}
+	// With ssl-passthrough, HTTPS frontend only listens on loopback
+	// for the ssl-passthrough backend, its binds being set by HTTPS handler.
+	if h.HTTPS && cfg.SSLPassthrough {
+		frontends[cfg.FrontSSL] = true
+	} else if h.HTTPS {
+		frontends[cfg.FrontHTTPS] = true
+	}
+	for name, frontend := range cfg.ExtraFrontends {
+		frontends[name] = frontend.SSL
+	}
+	for name := range h.defaults {
+		if _, ok := frontends[name]; !ok {
+			delete(h.defaults, name)
+		}
+	}
+	for name, ssl := range frontends {
+		if h.updateBinds(api, name, addresses[ssl]) {
+			reload = true
+		}
+	}
+	return reload, nil
+}
+
+// updateBinds makes the frontend listen on addresses, or on its default
+// addresses when empty, other bind settings being kept.
+func (h *BindAddresses) updateBinds(api api.HAProxyClient, frontendName string, addresses []bindAddress) (reload bool) {
+	binds, err := api.FrontendBindsGet(frontendName)
+	if err != nil || len(binds) == 0 {
+		return false
+	}
+	defaults, customized := h.defaults[frontendName]
+	switch {
+	case len(addresses) != 0 && !customized:
+		for _, bind := range binds {
+			defaults = append(defaults, bindAddress{name: bind.Name, address: bind.Address, v4v6: bind.V4v6, v6only: bind.V6only})
+		}
+		h.defaults[frontendName] = defaults
+	case len(addresses) == 0 && customized:
+		addresses = defaults
+		delete(h.defaults, frontendName)
+	case len(addresses) == 0:
+		return false
+	}
+	// New binds have the settings of existing ones, such as SSL and port
+	template := *binds[0]
+	current := make(map[string]bool, len(binds))
+	for _, bind := range binds {
+		current[bind.Name] = true
+	}
+	wanted := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		wanted[address.name] = true
+		bind := template
+		for _, b := range binds {
+			if b.Name == address.name {
+				bind = *b
+			}
+		}
+		if current[address.name] && bind.Address == address.address && bind.V4v6 == address.v4v6 && bind.V6only == address.v6only {
+			continue
+		}
+		bind.Name, bind.Address, bind.V4v6, bind.V6only = address.name, address.address, address.v4v6, address.v6only
+		if current[address.name] {
+			err = api.FrontendBindEdit(frontendName, bind)
+		} else {
+			err = api.FrontendBindCreate(frontendName, bind)
+		}
+		if err != nil {
+			logger.Errorf("frontend '%s' bind '%s': %s", frontendName, address.name, err)
+			continue
+		}
+		logger.Debugf("Frontend '%s' bind '%s' address set to '%s', reload required", frontendName, address.name, address.address)
+		reload = true
+	}
+	for _, bind := range binds {
+		if wanted[bind.Name] {
+			continue
+		}
+		if err = api.FrontendBindDelete(frontendName, bind.Name); err != nil {
+			logger.Error(err)
+			continue
+		}
+		logger.Debugf("Frontend '%s' bind '%s' deleted, reload required", frontendName, bind.Name)
+		reload = true
+	}
+	return reload
+}
+
+// parseBindAddresses parses a comma separated list of IPv4 or IPv6 addresses,
+// each optionally followed by the "v4v6" or "v6only" option.
+// Example: "10.0.0.10, 2001:db8::10", ":: v4v6"
+func parseBindAddresses(value string) (addresses []bindAddress, err error) {
+	for i, param := range strings.Split(value, ",") {
+		fields := strings.Fields(param)
+		if len(fields) == 0 {
+			continue
+		}
+		address := bindAddress{
+			name:    fmt.Sprintf("bind-%d", i+1),
+			address: fields[0],
+		}
+		if address.address != "*" && net.ParseIP(address.address) == nil {
+			return nil, fmt.Errorf("incorrect address '%s'", address.address)
+		}
+		for _, option := range fields[1:] {
+			switch option {
+			case "v4v6":
+				address.v4v6 = true
+			case "v6only":
+				address.v6only = true
+			default:
+				return nil, fmt.Errorf("unknown option '%s' for address '%s'", option, address.address)
+			}
+		}
+		if address.v4v6 && address.v6only {
+			return nil, fmt.Errorf("v4v6 and v6only options are exclusive for address '%s'", address.address)
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
@@ -15,97 +15,147 @@
 package handler
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"path/filepath"
+	"sort"
 	"strconv"
-
-	"github.com/haproxytech/client-native/v2/models"
+	"strings"
 
 	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
 )
 
+// errorFilesSection is the http-errors section of the Errorfiles ConfigMap
+// entries whose key is a status code, used by all proxies through defaults.
+const errorFilesSection = "configmap-errorfiles"
+
+// ErrorFile writes the entries of the Errorfiles ConfigMap to the errorfiles directory.
+// Entries whose key is a status code are served instead of the corresponding HAProxy errors,
+// other ones being only referenced by the backend-error-pages annotation.
 type ErrorFile struct {
-	files     files
-	updateAPI bool
+	files files
 }
 
-func (h ErrorFile) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
-	h.files.dir = cfg.Env.ErrFileDir
+func (h *ErrorFile) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
 	if k.ConfigMaps.Errorfiles == nil {
 		return false, nil
 	}
-
-	for code, v := range k.ConfigMaps.Errorfiles.Annotations {
-		_, ok := h.files.data[code]
-		if ok {
-			err = h.files.updateFile(code, v)
+	if h.files.data == nil {
+		h.files.dir = cfg.Env.ErrFileDir
+		h.files.data = make(map[string]file)
+	}
+	if k.ConfigMaps.Errorfiles.Loaded {
+		for key, v := range k.ConfigMaps.Errorfiles.Annotations {
+			if utils.CheckErrorFileCode(key) == nil {
+				v = errorFileResponse(key, v)
+			}
+			if _, ok := h.files.data[key]; ok {
+				err = h.files.updateFile(key, v)
+				if err != nil {
+					logger.Errorf("failed updating errorfile '%s': %s", key, err)
+				}
+				continue
+			}
+			err = h.files.newFile(key, v)
 			if err != nil {
-				logger.Errorf("failed updating errorfile for code '%s': %s", code, err)
+				logger.Errorf("failed creating errorfile '%s': %s", key, err)
 			}
-			continue
-		}
-		err = checkCode(code)
-		if err != nil {
-			logger.Errorf("failed creating errorfile for code '%s': %s", code, err)
-		}
-		err = h.files.newFile(code, v)
-		if err != nil {
-			logger.Errorf("failed creating errorfile for code '%s': %s", code, err)
 		}
-		h.updateAPI = true
 	}
 
-	var apiInput = []*models.Errorfile{}
-	for code, f := range h.files.data {
+	var codes []string
+	for key, f := range h.files.data {
 		if !f.inUse {
-			h.updateAPI = true
-			err = h.files.deleteFile(code)
+			err = h.files.deleteFile(key)
 			if err != nil {
-				logger.Errorf("failed deleting errorfile for code '%s': %s", code, err)
+				logger.Errorf("failed deleting errorfile '%s': %s", key, err)
 			}
 			continue
 		}
 		if f.updated {
-			logger.Debugf("updating errorfile for code '%s': reload required", code)
+			// Errorfiles are only loaded at startup
+			logger.Debugf("updating errorfile '%s': reload required", key)
 			reload = true
 		}
-		c, _ := strconv.Atoi(code) // code already checked in newCode
-		apiInput = append(apiInput, &models.Errorfile{
-			Code: int64(c),
-			File: filepath.Join(h.files.dir, code),
-		})
 		f.inUse = false
 		f.updated = false
-	}
-	// HAProxy config update
-	if h.updateAPI {
-		defaults, err := api.DefaultsGetConfiguration()
-		if err != nil {
-			logger.Error(err)
-			return reload, err
-		}
-		defaults.ErrorFiles = apiInput
-		if err = api.DefaultsPushConfiguration(defaults); err != nil {
-			logger.Error(err)
-			return reload, err
+		h.files.data[key] = f
+		if utils.CheckErrorFileCode(key) == nil {
+			codes = append(codes, key)
 		}
-		h.updateAPI = false
 	}
-	return reload, nil
+	sort.Strings(codes)
+	lines := make([]string, 0, len(codes))
+	for _, code := range codes {
+		lines = append(lines, fmt.Sprintf("errorfile %s %s", code, filepath.Join(h.files.dir, code)))
+	}
+	return h.updateSection(api, lines) || reload, nil
 }
 
-func checkCode(code string) error {
-	var codes = [15]string{"200", "400", "401", "403", "404", "405", "407", "408", "410", "425", "429", "500", "502", "503", "504"}
-	var c string
-	for _, c = range codes {
-		if code == c {
-			break
-		}
+// updateSection sets the errorfiles of the http-errors section used by defaults,
+// which is removed when there is none.
+func (h *ErrorFile) updateSection(api api.HAProxyClient, lines []string) (reload bool) {
+	current, err := api.HTTPErrorsGet(errorFilesSection)
+	if err != nil {
+		logger.Error(err)
+		return false
+	}
+	section := ""
+	if len(lines) != 0 {
+		section = errorFilesSection
+	}
+	currentSection, err := api.DefaultsErrorFilesGet()
+	if err != nil {
+		logger.Error(err)
+		return false
+	}
+	if strings.Join(current, "\n") == strings.Join(lines, "\n") && currentSection == section {
+		return false
+	}
+	defaults, err := api.DefaultsGetConfiguration()
+	if err != nil {
+		logger.Error(err)
+		return false
+	}
+	// Errorfiles used to be set directly in defaults
+	if len(defaults.ErrorFiles) != 0 {
+		defaults.ErrorFiles = nil
+		logger.Error(api.DefaultsPushConfiguration(defaults))
 	}
-	if c != code {
-		return fmt.Errorf("HTTP error code '%s' not supported", code)
+	// The section must exist as long as defaults reference it
+	if section == "" {
+		logger.Error(api.DefaultsErrorFilesSet(""))
+		logger.Error(api.HTTPErrorsDelete(errorFilesSection))
+	} else {
+		logger.Error(api.HTTPErrorsSet(errorFilesSection, lines))
+		logger.Error(api.DefaultsErrorFilesSet(section))
+	}
+	logger.Debugf("http-errors section '%s' updated, reload required", errorFilesSection)
+	return true
+}
+
+// errorFileResponse returns the errorfile content of the status code, content
+// being either a full HTTP response or only its body. In the latter case, the
+// response is built with the Content-Type detected from the body.
+func errorFileResponse(code, content string) string {
+	if strings.HasPrefix(content, "HTTP/") {
+		return content
+	}
+	status, _ := strconv.Atoi(code)
+	return fmt.Sprintf("HTTP/1.0 %d %s\r\nCache-Control: no-cache\r\nConnection: close\r\nContent-Type: %s\r\n\r\n%s",
+		status, http.StatusText(status), contentType(content), content)
+}
+
+// contentType detects the media type of the body, JSON not being
+// part of the types detected by http.DetectContentType.
+func contentType(body string) string {
+	trimmed := strings.TrimSpace(body)
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		return "application/json"
 	}
-	return nil
+	return http.DetectContentType([]byte(body))
 }
@@ -0,0 +1,82 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/google/renameio"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/rules"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+// FaultInjection loads the Lua script implementing the fault-delay
+// annotation when ingress annotations require it.
+type FaultInjection struct{}
+
+const faultInjectionScript = `-- Pauses the request for the given number of milliseconds
+core.register_action("%s", {"http-req"}, function(txn, delay)
+    core.msleep(tonumber(delay))
+end, 1)
+`
+
+func (h FaultInjection) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	luaFile := filepath.Join(cfg.Env.CfgDir, "fault-injection.lua")
+	luaLoads, err := api.GlobalLuaLoadsGet()
+	if err != nil {
+		return false, err
+	}
+	loaded := false
+	otherLoads := make([]string, 0, len(luaLoads))
+	for _, file := range luaLoads {
+		if file == luaFile {
+			loaded = true
+			continue
+		}
+		otherLoads = append(otherLoads, file)
+	}
+	if !cfg.FaultDelay {
+		if !loaded {
+			return false, nil
+		}
+		if err = api.GlobalLuaLoadsSet(otherLoads); err != nil {
+			return false, err
+		}
+		logger.Debug("fault injection script unloaded, reload required")
+		return true, nil
+	}
+	content := []byte(fmt.Sprintf(faultInjectionScript, rules.FaultDelayAction))
+	if current, errRead := ioutil.ReadFile(luaFile); errRead != nil || !bytes.Equal(current, content) {
+		if err = renameio.WriteFile(luaFile, content, 0644); err != nil {
+			return false, err
+		}
+		logger.Debug("fault injection script updated, reload required")
+		reload = true
+	}
+	if !loaded {
+		if err = api.GlobalLuaLoadsSet(append(otherLoads, luaFile)); err != nil {
+			return false, err
+		}
+		logger.Debug("fault injection script loaded, reload required")
+		reload = true
+	}
+	return reload, nil
+}
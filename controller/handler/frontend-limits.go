@@ -0,0 +1,178 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"fmt"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/rules"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// FrontendLimits configures HTTP/HTTPS frontends connection limits (maxconn, sessions rate,
+// backlog), client side timeouts and early load shedding.
+type FrontendLimits struct{}
+
+type frontendSettings struct {
+	maxconn            *int64
+	clientTimeout      *int64
+	httpRequestTimeout *int64
+	clientFinTimeout   string
+	backlog            string
+	rateLimitSessions  string
+}
+
+func (h FrontendLimits) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	var settings frontendSettings
+	annotations := []map[string]string{k.Global.Annotations, k.ConfigMaps.Main.Annotations}
+	if ann := k.GetValueFromAnnotations("frontend-maxconn", annotations...); ann != "" {
+		var v int64
+		if v, err = utils.ParseInt(ann); err != nil {
+			logger.Errorf("frontend-maxconn annotation: %s", err)
+		} else {
+			settings.maxconn = &v
+		}
+	}
+	if ann := k.GetValueFromAnnotations("frontend-rate-limit-sessions", annotations...); ann != "" {
+		if v, errParse := utils.ParseInt(ann); errParse != nil || v <= 0 {
+			logger.Errorf("frontend-rate-limit-sessions annotation: incorrect value '%s'", ann)
+		} else {
+			settings.rateLimitSessions = ann
+		}
+	}
+	settings.clientTimeout = h.timeout(k, "frontend-timeout-client", annotations)
+	settings.httpRequestTimeout = h.timeout(k, "frontend-timeout-http-request", annotations)
+	if timeout := h.timeout(k, "frontend-timeout-client-fin", annotations); timeout != nil {
+		settings.clientFinTimeout = fmt.Sprintf("%dms", *timeout)
+	}
+	if ann := k.GetValueFromAnnotations("frontend-backlog", annotations...); ann != "" {
+		if _, err = utils.ParseInt(ann); err != nil {
+			logger.Errorf("frontend-backlog annotation: %s", err)
+		} else {
+			settings.backlog = ann
+		}
+	}
+	for _, frontendName := range cfg.HTTPFrontends() {
+		if h.updateFrontend(api, frontendName, settings) {
+			reload = true
+		}
+		if h.updateRateLimitSessions(api, frontendName, settings.rateLimitSessions) {
+			reload = true
+		}
+		if h.updateClientFinTimeout(api, frontendName, settings.clientFinTimeout) {
+			reload = true
+		}
+	}
+	// Load shedding
+	h.addRejectRule(k, cfg, "frontend-reject-conn-above", "fe_conn")
+	h.addRejectRule(k, cfg, "frontend-reject-rate-above", "fe_sess_rate")
+	return reload, nil
+}
+
+// timeout returns the timeout in milliseconds of the annotation, nil when unset or invalid
+func (h FrontendLimits) timeout(k store.K8s, annotation string, annotations []map[string]string) *int64 {
+	ann := k.GetValueFromAnnotations(annotation, annotations...)
+	if ann == "" {
+		return nil
+	}
+	timeout, err := utils.ParseTime(ann)
+	if err != nil || *timeout <= 0 {
+		logger.Errorf("%s annotation: incorrect value '%s'", annotation, ann)
+		return nil
+	}
+	return timeout
+}
+
+func (h FrontendLimits) updateFrontend(api api.HAProxyClient, frontendName string, settings frontendSettings) (reload bool) {
+	frontend, err := api.FrontendGet(frontendName)
+	if err != nil {
+		return false
+	}
+	if !utils.EqualInt64Ptr(frontend.Maxconn, settings.maxconn) ||
+		!utils.EqualInt64Ptr(frontend.ClientTimeout, settings.clientTimeout) ||
+		!utils.EqualInt64Ptr(frontend.HTTPRequestTimeout, settings.httpRequestTimeout) {
+		frontend.Maxconn = settings.maxconn
+		frontend.ClientTimeout = settings.clientTimeout
+		frontend.HTTPRequestTimeout = settings.httpRequestTimeout
+		if err = api.FrontendEdit(frontend); err != nil {
+			logger.Error(err)
+			return false
+		}
+		logger.Debugf("Frontend '%s' limits updated, reload required", frontendName)
+		reload = true
+	}
+	binds, err := api.FrontendBindsGet(frontendName)
+	if err != nil {
+		return reload
+	}
+	for _, bind := range binds {
+		if bind.Backlog == settings.backlog {
+			continue
+		}
+		bind.Backlog = settings.backlog
+		if err = api.FrontendBindEdit(frontendName, *bind); err != nil {
+			logger.Error(err)
+			continue
+		}
+		logger.Debugf("Frontend '%s' bind '%s' backlog updated, reload required", frontendName, bind.Name)
+		reload = true
+	}
+	return reload
+}
+
+func (h FrontendLimits) updateClientFinTimeout(api api.HAProxyClient, frontendName, timeout string) (reload bool) {
+	current, err := api.FrontendClientFinTimeoutGet(frontendName)
+	if err != nil || current == timeout {
+		return false
+	}
+	if err = api.FrontendClientFinTimeoutSet(frontendName, timeout); err != nil {
+		logger.Error(err)
+		return false
+	}
+	logger.Debugf("Frontend '%s' client-fin timeout updated, reload required", frontendName)
+	return true
+}
+
+func (h FrontendLimits) updateRateLimitSessions(api api.HAProxyClient, frontendName, rate string) (reload bool) {
+	current, err := api.FrontendRateLimitSessionsGet(frontendName)
+	if err != nil || current == rate {
+		return false
+	}
+	if err = api.FrontendRateLimitSessionsSet(frontendName, rate); err != nil {
+		logger.Error(err)
+		return false
+	}
+	logger.Debugf("Frontend '%s' sessions rate limit updated, reload required", frontendName)
+	return true
+}
+
+func (h FrontendLimits) addRejectRule(k store.K8s, cfg *config.ControllerCfg, annotation, fetch string) {
+	ann := k.GetValueFromAnnotations(annotation, k.Global.Annotations, k.ConfigMaps.Main.Annotations)
+	if ann == "" {
+		return
+	}
+	threshold, err := utils.ParseInt(ann)
+	if err != nil || threshold <= 0 {
+		logger.Errorf("%s annotation: incorrect value '%s'", annotation, ann)
+		return
+	}
+	rule := rules.ReqRejectConnection{
+		CondTest: fmt.Sprintf("{ %s gt %d }", fetch, threshold),
+	}
+	logger.Error(cfg.HAProxyRules.AddRule(rule, "", cfg.HTTPFrontends()...))
+}
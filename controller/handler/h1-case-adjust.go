@@ -0,0 +1,95 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/renameio"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+// H1CaseAdjust generates the HAProxy h1-case-adjust-file from the h1-case-adjust
+// ConfigMap annotation. Header names are then sent with the configured case
+// to the backends enabling h1-case-adjust-backend.
+type H1CaseAdjust struct{}
+
+var headerNameRegex = regexp.MustCompile(`^[a-zA-Z0-9!#$%&'*+.^_|~-]+$`)
+
+func (h H1CaseAdjust) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	cfgFile := filepath.Join(cfg.Env.CfgDir, "h1-case-adjust")
+	currentFile, err := api.GlobalH1CaseAdjustFileGet()
+	if err != nil {
+		return false, err
+	}
+	headers := h.getHeaders(k)
+	if len(headers) == 0 {
+		if currentFile == "" {
+			return false, nil
+		}
+		if err = api.GlobalH1CaseAdjustFileSet(""); err != nil {
+			return false, err
+		}
+		logger.Error(os.Remove(cfgFile))
+		logger.Debug("h1-case-adjust disabled, reload required")
+		return true, nil
+	}
+	var buf bytes.Buffer
+	for _, header := range headers {
+		buf.WriteString(strings.ToLower(header) + " " + header + "\n")
+	}
+	content := buf.Bytes()
+	if current, errRead := ioutil.ReadFile(cfgFile); errRead != nil || !bytes.Equal(current, content) {
+		if err = renameio.WriteFile(cfgFile, content, 0644); err != nil {
+			return false, err
+		}
+		logger.Debug("h1-case-adjust file updated, reload required")
+		reload = true
+	}
+	if currentFile != cfgFile {
+		if err = api.GlobalH1CaseAdjustFileSet(cfgFile); err != nil {
+			return false, err
+		}
+		logger.Debug("h1-case-adjust enabled, reload required")
+		reload = true
+	}
+	return reload, nil
+}
+
+// getHeaders returns the header names, in their expected case, of h1-case-adjust annotation
+func (h H1CaseAdjust) getHeaders(k store.K8s) (headers []string) {
+	ann := k.GetValueFromAnnotations("h1-case-adjust", k.ConfigMaps.Main.Annotations)
+	seen := make(map[string]struct{})
+	for _, header := range strings.Fields(strings.ReplaceAll(ann, ",", " ")) {
+		if !headerNameRegex.MatchString(header) {
+			logger.Errorf("h1-case-adjust annotation: incorrect header name '%s'", header)
+			continue
+		}
+		if _, ok := seen[strings.ToLower(header)]; ok {
+			continue
+		}
+		seen[strings.ToLower(header)] = struct{}{}
+		headers = append(headers, header)
+	}
+	return headers
+}
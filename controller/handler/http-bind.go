@@ -30,6 +30,7 @@ type HTTPBind struct {
 	IPv6      bool
 	HTTPPort  int64
 	HTTPSPort int64
+	StatsPort int64
 	IPv4Addr  string
 	IPv6Addr  string
 }
@@ -47,14 +48,24 @@ func (h HTTPBind) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxy
 	if h.IPv4 {
 		protos["v4"] = h.IPv4Addr
 	}
+	// stats frontend listens on all addresses
+	statsBind := models.Bind{
+		Name:    "v4",
+		Address: "*",
+		Port:    utils.PtrInt64(h.StatsPort),
+	}
+	if err = api.FrontendBindEdit(cfg.FrontStats, statsBind); err != nil {
+		errors.Add(api.FrontendBindCreate(cfg.FrontStats, statsBind))
+	}
 	if h.IPv6 {
 		protos["v6"] = h.IPv6Addr
 
 		// IPv6 not disabled, so add v6 listening to stats frontend
-		errors.Add(api.FrontendBindCreate("stats",
+		errors.Add(api.FrontendBindCreate(cfg.FrontStats,
 			models.Bind{
 				Name:    "v6",
-				Address: ":::1024",
+				Address: "::",
+				Port:    utils.PtrInt64(h.StatsPort),
 				V4v6:    false,
 			}))
 	}
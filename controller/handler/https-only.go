@@ -0,0 +1,60 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/rules"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+const acmeChallengePath = "/.well-known/acme-challenge/"
+
+// HTTPSOnly keeps application traffic off the cleartext HTTP frontend with the "https-only"
+// annotation: "true" disables the frontend, which then doesn't listen, while "acme" only
+// serves ACME HTTP-01 challenges, any other request being denied.
+type HTTPSOnly struct{}
+
+func (h HTTPSOnly) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	var disabled, acme bool
+	switch value := k.GetValueFromAnnotations("https-only", k.ConfigMaps.Main.Annotations); value {
+	case "", "false":
+	case "true":
+		disabled = true
+	case "acme":
+		acme = true
+	default:
+		logger.Errorf("https-only annotation: incorrect value '%s'", value)
+	}
+	current, err := api.FrontendDisabledGet(cfg.FrontHTTP)
+	if err != nil {
+		return false, err
+	}
+	if current != disabled {
+		if err = api.FrontendDisabledSet(cfg.FrontHTTP, disabled); err != nil {
+			return false, err
+		}
+		logger.Debugf("Frontend '%s' disabled set to %t, reload required", cfg.FrontHTTP, disabled)
+		reload = true
+	}
+	if acme {
+		// Denied before ssl-redirect, HTTPS being expected to be used directly
+		err = cfg.HAProxyRules.AddRule(rules.ReqDenyHTTP{
+			ExcludePathPrefix: acmeChallengePath,
+		}, "", cfg.FrontHTTP)
+	}
+	return reload, err
+}
@@ -15,9 +15,14 @@
 package handler
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
 
+	"github.com/google/renameio"
 	"github.com/haproxytech/client-native/v2/models"
 
 	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
@@ -130,6 +135,69 @@ func (h HTTPS) handleClientTLSAuth(k store.K8s, cfg *config.ControllerCfg, api a
 	return
 }
 
+// handleCrtList switches HTTPS binds from certificates directory to a crt-list
+// when ingresses require client certificate authentication for their hosts.
+func (h HTTPS) handleCrtList(cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	binds, err := api.FrontendBindsGet(cfg.FrontHTTPS)
+	if err != nil {
+		return
+	}
+	crtList := filepath.Join(cfg.Env.CertDir, "frontend.crt-list")
+	crtCertificate := h.CertDir
+	lines := cfg.Certificates.FrontendCrtList()
+	if lines == nil {
+		crtList = ""
+	} else {
+		crtCertificate = ""
+		content := []byte(strings.Join(lines, "\n") + "\n")
+		if current, errRead := ioutil.ReadFile(crtList); errRead != nil || !bytes.Equal(current, content) {
+			if err = renameio.WriteFile(crtList, content, 0644); err != nil {
+				return
+			}
+			logger.Debug("Frontend crt-list updated, reload required")
+			reload = true
+		}
+	}
+	for i := range binds {
+		if binds[i].CrtList == crtList && binds[i].SslCertificate == crtCertificate {
+			continue
+		}
+		binds[i].CrtList = crtList
+		binds[i].SslCertificate = crtCertificate
+		if err = api.FrontendBindEdit(cfg.FrontHTTPS, *binds[i]); err != nil {
+			return false, err
+		}
+		logger.Debugf("Frontend '%s' bind '%s' certificates updated, reload required", cfg.FrontHTTPS, binds[i].Name)
+		reload = true
+	}
+	return reload, nil
+}
+
+// handleCurves sets the elliptic curves of HTTPS binds, from ssl-default-bind-curves annotation
+func (h HTTPS) handleCurves(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	binds, err := api.FrontendBindsGet(cfg.FrontHTTPS)
+	if err != nil {
+		return
+	}
+	curves := k.GetValueFromAnnotations("ssl-default-bind-curves", k.Global.Annotations, k.ConfigMaps.Main.Annotations)
+	if strings.ContainsAny(curves, " \t") {
+		logger.Errorf("ssl-default-bind-curves annotation: curves must be a colon separated list")
+		curves = ""
+	}
+	for i := range binds {
+		if binds[i].Curves == curves {
+			continue
+		}
+		binds[i].Curves = curves
+		if err = api.FrontendBindEdit(cfg.FrontHTTPS, *binds[i]); err != nil {
+			return false, err
+		}
+		logger.Debugf("Frontend '%s' bind '%s' curves updated, reload required", cfg.FrontHTTPS, binds[i].Name)
+		reload = true
+	}
+	return reload, nil
+}
+
 func (h HTTPS) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
 	if !h.Enabled {
 		logger.Debugf("Cannot proceed with SSL Passthrough update, HTTPS is disabled")
@@ -148,6 +216,16 @@ func (h HTTPS) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyCli
 			return r, err
 		}
 		reload = reload || r
+		r, err = h.handleCrtList(cfg, api)
+		if err != nil {
+			return r, err
+		}
+		reload = reload || r
+		r, err = h.handleCurves(k, cfg, api)
+		if err != nil {
+			return r, err
+		}
+		reload = reload || r
 	} else if cfg.HTTPS {
 		logger.Panic(api.FrontendDisableSSLOffload(cfg.FrontHTTPS))
 		cfg.HTTPS = false
@@ -233,9 +311,28 @@ func (h HTTPS) disableSSLPassthrough(cfg *config.ControllerCfg, api api.HAProxyC
 }
 
 func (h HTTPS) toggleSSLPassthrough(passthrough bool, cfg *config.ControllerCfg, api api.HAProxyClient) (err error) {
-	for _, bind := range h.bindList(passthrough) {
+	// Binds set from bind addresses annotations are replaced
+	current, err := api.FrontendBindsGet(cfg.FrontHTTPS)
+	if err != nil {
+		return err
+	}
+	binds := h.bindList(passthrough)
+	for _, bind := range current {
+		found := false
+		for _, b := range binds {
+			found = found || b.Name == bind.Name
+		}
+		if !found {
+			if err = api.FrontendBindDelete(cfg.FrontHTTPS, bind.Name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, bind := range binds {
 		if err = api.FrontendBindEdit(cfg.FrontHTTPS, bind); err != nil {
-			return err
+			if err = api.FrontendBindCreate(cfg.FrontHTTPS, bind); err != nil {
+				return err
+			}
 		}
 	}
 	if cfg.HTTPS {
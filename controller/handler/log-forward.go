@@ -0,0 +1,199 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// LogForward manages the log-forward sections defined by the "log-forward" annotation,
+// one section per line, making HAProxy receive syslog messages over TCP ("bind") and
+// UDP ("dgram-bind") and forward them to the "log" targets.
+// Example:
+//  log-forward: |
+//    name:syslog, dgram-bind:0.0.0.0:514, bind:0.0.0.0:514, log:10.0.0.10:514 local0, log:ring@logs local0
+type LogForward struct{}
+
+var logForwardName = regexp.MustCompile(`^[a-zA-Z0-9_.:-]+$`)
+
+type logForwardSection struct {
+	name          string
+	binds         []string
+	dgramBinds    []string
+	logs          []string
+	maxconn       string
+	backlog       string
+	timeoutClient string
+}
+
+func (h LogForward) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	sections := h.parse(k.GetValueFromAnnotations("log-forward", k.Global.Annotations, k.ConfigMaps.Main.Annotations))
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		lines = append(lines, sections[name].lines()...)
+	}
+	current, err := api.LogForwardsGet()
+	if err != nil {
+		return false, err
+	}
+	if strings.Join(current, "\n") == strings.Join(lines, "\n") {
+		return false, nil
+	}
+	if err = api.LogForwardsSet(lines); err != nil {
+		return false, err
+	}
+	logger.Debugf("log-forward sections updated, reload required")
+	return true, nil
+}
+
+// parse returns the log-forward sections of the annotation by name,
+// invalid sections are skipped.
+func (h LogForward) parse(input string) map[string]logForwardSection {
+	sections := make(map[string]logForwardSection)
+	for _, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		section, err := h.parseSection(line)
+		if err != nil {
+			logger.Errorf("log-forward annotation: %s in '%s'", err, line)
+			continue
+		}
+		if _, ok := sections[section.name]; ok {
+			logger.Errorf("log-forward annotation: duplicate section '%s'", section.name)
+			continue
+		}
+		sections[section.name] = section
+	}
+	return sections
+}
+
+func (h LogForward) parseSection(line string) (section logForwardSection, err error) {
+	for _, param := range strings.Split(line, ",") {
+		param = strings.TrimSpace(param)
+		if param == "" {
+			continue
+		}
+		parts := strings.SplitN(param, ":", 2)
+		if len(parts) != 2 {
+			return section, fmt.Errorf("incorrect param '%s'", param)
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.Join(strings.Fields(parts[1]), " ")
+		switch key {
+		case "name":
+			if !logForwardName.MatchString(value) {
+				return section, fmt.Errorf("incorrect name '%s'", value)
+			}
+			section.name = value
+		case "bind", "dgram-bind":
+			if err = checkBindAddress(value); err != nil {
+				return section, err
+			}
+			if key == "bind" {
+				section.binds = append(section.binds, value)
+			} else {
+				section.dgramBinds = append(section.dgramBinds, value)
+			}
+		case "log":
+			// Facility is mandatory, though only used when messages have none
+			if len(strings.Fields(value)) == 1 {
+				value += " local0"
+			}
+			section.logs = append(section.logs, value)
+		case "maxconn", "backlog":
+			if v, errParse := strconv.ParseInt(value, 10, 64); errParse != nil || v <= 0 {
+				return section, fmt.Errorf("incorrect %s '%s'", key, value)
+			}
+			if key == "maxconn" {
+				section.maxconn = value
+			} else {
+				section.backlog = value
+			}
+		case "timeout-client":
+			var timeout *int64
+			if timeout, err = utils.ParseTime(value); err != nil {
+				return section, err
+			}
+			section.timeoutClient = fmt.Sprintf("%dms", *timeout)
+		default:
+			return section, fmt.Errorf("unknown param '%s'", key)
+		}
+	}
+	switch {
+	case section.name == "":
+		return section, errors.New("missing name")
+	case len(section.binds) == 0 && len(section.dgramBinds) == 0:
+		return section, errors.New("no bind or dgram-bind address")
+	case len(section.logs) == 0:
+		return section, errors.New("no log target")
+	}
+	return section, nil
+}
+
+// checkBindAddress checks a bind "<address>:<port>", address being optional
+func checkBindAddress(value string) error {
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return fmt.Errorf("incorrect bind address '%s'", value)
+	}
+	if host != "" && host != "*" && net.ParseIP(host) == nil {
+		return fmt.Errorf("incorrect bind address '%s'", value)
+	}
+	if p, errPort := strconv.Atoi(port); errPort != nil || p < 1 || p > 65535 {
+		return fmt.Errorf("incorrect bind port '%s'", port)
+	}
+	return nil
+}
+
+// lines returns the section header followed by its directives
+func (s logForwardSection) lines() []string {
+	lines := []string{"log-forward " + s.name}
+	for _, bind := range s.binds {
+		lines = append(lines, "bind "+bind)
+	}
+	for _, bind := range s.dgramBinds {
+		lines = append(lines, "dgram-bind "+bind)
+	}
+	if s.maxconn != "" {
+		lines = append(lines, "maxconn "+s.maxconn)
+	}
+	if s.backlog != "" {
+		lines = append(lines, "backlog "+s.backlog)
+	}
+	if s.timeoutClient != "" {
+		lines = append(lines, "timeout client "+s.timeoutClient)
+	}
+	for _, log := range s.logs {
+		lines = append(lines, "log "+log)
+	}
+	return lines
+}
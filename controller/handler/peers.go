@@ -0,0 +1,187 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// Peers fills the peers section used by stick tables with the controller replicas
+// provided by the Peers custom resource, so stick tables are replicated between them.
+// Without Peers resource the section only holds the local instance which keeps
+// stick tables content across reloads.
+type Peers struct{}
+
+//nolint: golint,stylecheck
+const (
+	PEERS_SECTION    = "localinstance"
+	PEERS_LOCAL_NAME = "local"
+	PEERS_LOCAL_ADDR = "127.0.0.1"
+)
+
+func (h Peers) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	localPeer, peers := h.getPeers(k)
+	currentLocalPeer, err := api.GlobalLocalPeerGet()
+	if err != nil {
+		return false, err
+	}
+	currentPeers, err := api.PeerEntriesGet(PEERS_SECTION)
+	if err != nil {
+		return false, err
+	}
+	if currentLocalPeer == localPeer && equalPeers(currentPeers, peers) {
+		return false, nil
+	}
+	for _, peer := range currentPeers {
+		if err = api.PeerEntryDelete(PEERS_SECTION, peer.Name); err != nil {
+			return false, err
+		}
+	}
+	for _, peer := range peers {
+		if err = api.PeerEntryCreate(PEERS_SECTION, *peer); err != nil {
+			return false, err
+		}
+	}
+	if err = api.GlobalLocalPeerSet(localPeer); err != nil {
+		return false, err
+	}
+	logger.Debugf("peers section updated with %d peers, reload required", len(peers))
+	return true, nil
+}
+
+// getPeers returns the local peer name and the peers entries to configure
+func (h Peers) getPeers(k store.K8s) (localPeer string, peers models.PeerEntries) {
+	localPeer = PEERS_LOCAL_NAME
+	peers = models.PeerEntries{{
+		Name:    PEERS_LOCAL_NAME,
+		Address: utils.PtrString(PEERS_LOCAL_ADDR),
+		Port:    utils.PtrInt64(store.PEERS_DEFAULT_PORT),
+	}}
+	if !k.Peers.Loaded {
+		return
+	}
+	if k.Peers.Service == "" {
+		logger.Errorf("peers '%s/%s': service is missing", k.Peers.Namespace, k.Peers.Name)
+		return
+	}
+	var endpoints *store.Endpoints
+	if ns, ok := k.Namespaces[k.Peers.Namespace]; ok {
+		endpoints = ns.Endpoints[k.Peers.Service]
+	}
+	if endpoints == nil || endpoints.Status == store.DELETED {
+		logger.Warningf("peers '%s/%s': no endpoints for service '%s'", k.Peers.Namespace, k.Peers.Name, k.Peers.Service)
+		return
+	}
+	addresses := endpointsAddresses(endpoints)
+	localAddrs, err := localAddresses()
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	local := ""
+	for _, address := range addresses {
+		if _, ok := localAddrs[address]; ok {
+			local = address
+			break
+		}
+	}
+	if local == "" {
+		// Controller pod is not ready yet
+		logger.Debugf("peers '%s/%s': local instance not found in '%s' service endpoints", k.Peers.Namespace, k.Peers.Name, k.Peers.Service)
+		return
+	}
+	localPeer = peerName(local)
+	peers = make(models.PeerEntries, 0, len(addresses))
+	for _, address := range addresses {
+		peers = append(peers, &models.PeerEntry{
+			Name:    peerName(address),
+			Address: utils.PtrString(address),
+			Port:    utils.PtrInt64(k.Peers.Port),
+		})
+	}
+	return localPeer, peers
+}
+
+// endpointsAddresses returns the sorted list of endpoints addresses,
+// whether or not they are already used by HAProxy backend servers.
+func endpointsAddresses(endpoints *store.Endpoints) []string {
+	addressesMap := make(map[string]struct{})
+	for _, portEndpoints := range endpoints.Ports {
+		for address := range portEndpoints.AddrNew {
+			addressesMap[address] = struct{}{}
+		}
+		for _, srv := range portEndpoints.HAProxySrvs {
+			if srv.Address != "" {
+				addressesMap[srv.Address] = struct{}{}
+			}
+		}
+	}
+	addresses := make([]string, 0, len(addressesMap))
+	for address := range addressesMap {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+func localAddresses() (map[string]struct{}, error) {
+	interfaceAddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+	addresses := make(map[string]struct{}, len(interfaceAddrs))
+	for _, addr := range interfaceAddrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			addresses[ipNet.IP.String()] = struct{}{}
+		}
+	}
+	return addresses, nil
+}
+
+// peerName derives the peer name from its address so that every
+// replica uses the same name for a given peer.
+func peerName(address string) string {
+	return "peer_" + strings.NewReplacer(".", "_", ":", "_").Replace(address)
+}
+
+func equalPeers(a, b models.PeerEntries) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name ||
+			!equalStringPtr(a[i].Address, b[i].Address) ||
+			!utils.EqualInt64Ptr(a[i].Port, b[i].Port) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
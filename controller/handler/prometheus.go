@@ -0,0 +1,111 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// Prometheus exposes HAProxy built-in prometheus exporter on a dedicated frontend,
+// restricted to the sources of the prometheus-allowlist ConfigMap annotation.
+// Sources are kept in a map file, updated at runtime, thus frontend rules never change.
+type Prometheus struct {
+	Port int64
+	IPv4 bool
+	IPv6 bool
+}
+
+const (
+	prometheusFrontend  = "prometheus"
+	prometheusAllowlist = "prometheus-allowlist"
+)
+
+func (h Prometheus) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	for _, src := range h.allowlist(k) {
+		cfg.MapFiles.AppendRow(prometheusAllowlist, src)
+	}
+	if _, err = api.FrontendGet(prometheusFrontend); err == nil {
+		return false, nil
+	}
+	var errors utils.Errors
+	errors.Add(api.FrontendCreate(models.Frontend{
+		Name: prometheusFrontend,
+		Mode: "http",
+	}))
+	if h.IPv4 {
+		errors.Add(api.FrontendBindCreate(prometheusFrontend, models.Bind{
+			Name:    "v4",
+			Address: "*",
+			Port:    utils.PtrInt64(h.Port),
+		}))
+	}
+	if h.IPv6 {
+		errors.Add(api.FrontendBindCreate(prometheusFrontend, models.Bind{
+			Name:    "v6",
+			Address: "::",
+			Port:    utils.PtrInt64(h.Port),
+		}))
+	}
+	errors.Add(
+		api.FrontendHTTPRequestRuleCreate(prometheusFrontend, models.HTTPRequestRule{
+			Index:    utils.PtrInt64(0),
+			Type:     "deny",
+			Cond:     "unless",
+			CondTest: fmt.Sprintf("{ src -f %s }", haproxy.GetMapPath(prometheusAllowlist)),
+		}, ""),
+		api.FrontendHTTPRequestRuleCreate(prometheusFrontend, models.HTTPRequestRule{
+			Index:       utils.PtrInt64(1),
+			Type:        "use-service",
+			ServiceName: "prometheus-exporter",
+			Cond:        "if",
+			CondTest:    "{ path /metrics }",
+		}, ""),
+	)
+	if err = errors.Result(); err != nil {
+		return false, err
+	}
+	logger.Debug("prometheus frontend created, reload required")
+	return true, nil
+}
+
+// allowlist returns the IP addresses and networks allowed to scrape metrics, all when not restricted
+func (h Prometheus) allowlist(k store.K8s) (sources []string) {
+	annotation := k.GetValueFromAnnotations("prometheus-allowlist", k.ConfigMaps.Main.Annotations)
+	for _, src := range strings.FieldsFunc(annotation, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' }) {
+		if _, _, err := net.ParseCIDR(src); err != nil && net.ParseIP(src) == nil {
+			logger.Errorf("prometheus-allowlist annotation: incorrect address '%s'", src)
+			continue
+		}
+		sources = append(sources, src)
+	}
+	if len(sources) == 0 {
+		if annotation != "" {
+			logger.Warning("prometheus-allowlist annotation: no valid address, prometheus metrics denied to all clients")
+			return []string{"127.0.0.1"}
+		}
+		return []string{"0.0.0.0/0", "::/0"}
+	}
+	return sources
+}
@@ -33,6 +33,11 @@ func (p ProxyProtocol) Update(k store.K8s, cfg *config.ControllerCfg, api api.HA
 	if annProxyProtocol == "" {
 		return false, nil
 	}
+	// The PROXY protocol header is already consumed by the binds
+	if k.GetValueFromAnnotations("accept-proxy", k.ConfigMaps.Main.Annotations) != "" {
+		logger.Warning("proxy-protocol annotation ignored, accept-proxy annotation is set")
+		return false, nil
+	}
 	// Validate annotation
 	mapName := "proxy-protocol-" + utils.Hash([]byte(annProxyProtocol))
 	if !cfg.MapFiles.Exists(mapName) {
@@ -49,9 +54,9 @@ func (p ProxyProtocol) Update(k store.K8s, cfg *config.ControllerCfg, api api.HA
 	}
 	// Configure Annotation
 	logger.Trace("Configuring ProxyProtcol annotation")
-	frontends := []string{cfg.FrontHTTP, cfg.FrontHTTPS}
+	frontends := cfg.HTTPFrontends()
 	if cfg.SSLPassthrough {
-		frontends = []string{cfg.FrontHTTP, cfg.FrontSSL}
+		frontends[1] = cfg.FrontSSL
 	}
 	err = cfg.HAProxyRules.AddRule(rules.ReqProxyProtocol{SrcIPsMap: mapName}, "", frontends...)
 	return false, err
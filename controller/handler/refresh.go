@@ -15,6 +15,11 @@
 package handler
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/haproxytech/client-native/v2/models"
+
 	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
@@ -31,32 +36,149 @@ func (h Refresh) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyC
 	if cleanCrts {
 		reload = cfg.Certificates.Refresh() || reload
 	}
+	// Before rules as request tracking rules reference the tables
+	reload = h.refreshRateLimitTables(api, cfg) || reload
 	reload = cfg.HAProxyRules.Refresh(api) || reload
 	reload = cfg.MapFiles.Refresh(api) || reload
-	h.clearBackends(api, cfg)
+	h.clearBackends(k, api, cfg)
 	return
 }
 
 // Remove unused backends
-func (h Refresh) clearBackends(api api.HAProxyClient, cfg *config.ControllerCfg) {
+func (h Refresh) clearBackends(k store.K8s, api api.HAProxyClient, cfg *config.ControllerCfg) {
 	if cfg.SSLPassthrough {
 		// SSL default backend
 		cfg.ActiveBackends[cfg.BackSSL] = struct{}{}
 	}
 	// Ratelimting backends
-	for _, rateLimitTable := range cfg.RateLimitTables {
+	for rateLimitTable := range cfg.RateLimitTables {
 		cfg.ActiveBackends[rateLimitTable] = struct{}{}
 	}
+	gracePeriod := h.backendRemovalGracePeriod(k)
+	h.undrainBackends(api, cfg)
 	allBackends, err := api.BackendsGet()
 	if err != nil {
 		return
 	}
 	for _, backend := range allBackends {
-		if _, ok := cfg.ActiveBackends[backend.Name]; !ok {
-			logger.Debugf("Deleting backend '%s'", backend.Name)
-			if err := api.BackendDelete(backend.Name); err != nil {
-				logger.Panic(err)
+		if _, ok := cfg.ActiveBackends[backend.Name]; ok {
+			continue
+		}
+		if gracePeriod > 0 {
+			draining, ok := cfg.DrainingBackends[backend.Name]
+			if !ok {
+				h.drainBackend(api, cfg, backend.Name)
+				continue
+			}
+			if time.Since(draining.Since) < gracePeriod {
+				continue
 			}
 		}
+		delete(cfg.DrainingBackends, backend.Name)
+		logger.Debugf("Deleting backend '%s'", backend.Name)
+		if err := api.BackendDelete(backend.Name); err != nil {
+			logger.Panic(err)
+		}
+		// Backend dedicated cache
+		if _, err := api.CacheGet(backend.Name); err == nil {
+			logger.Error(api.CacheDelete(backend.Name))
+		}
+	}
+}
+
+// refreshRateLimitTables creates the stick-tables of rate limiting, as backends of the same name,
+// and updates the ones whose definition changed.
+func (h Refresh) refreshRateLimitTables(api api.HAProxyClient, cfg *config.ControllerCfg) (reload bool) {
+	for name, table := range cfg.RateLimitTables {
+		// Keys other than source addresses, like headers or cookies, are stored as strings.
+		stickTable := &models.BackendStickTable{
+			Peers: "localinstance",
+			Type:  "ip",
+			Size:  table.Size,
+			Store: fmt.Sprintf("http_req_rate(%d)", table.Period),
+		}
+		if table.Key != "src" {
+			stickTable.Type = "string"
+			stickTable.Keylen = utils.PtrInt64(64)
+		}
+		backend, err := api.BackendGet(name)
+		switch {
+		case err != nil:
+			err = api.BackendCreate(models.Backend{
+				Name:       name,
+				StickTable: stickTable,
+			})
+		case !equalStickTable(backend.StickTable, stickTable):
+			backend.StickTable = stickTable
+			err = api.BackendEdit(*backend)
+		default:
+			continue
+		}
+		if err != nil {
+			logger.Errorf("rate limiting table '%s': %s", name, err)
+			continue
+		}
+		logger.Debugf("Rate limiting table '%s' updated, reload required", name)
+		reload = true
+	}
+	return reload
+}
+
+func equalStickTable(a, b *models.BackendStickTable) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Type == b.Type && a.Store == b.Store &&
+		utils.EqualInt64Ptr(a.Size, b.Size) && utils.EqualInt64Ptr(a.Keylen, b.Keylen)
+}
+
+// backendRemovalGracePeriod returns the time unused backends are kept in drain before removal
+func (h Refresh) backendRemovalGracePeriod(k store.K8s) time.Duration {
+	annGracePeriod := k.GetValueFromAnnotations("backend-removal-grace-period", k.ConfigMaps.Main.Annotations)
+	if annGracePeriod == "" {
+		return 0
+	}
+	gracePeriod, err := utils.ParseTime(annGracePeriod)
+	if err != nil || *gracePeriod < 0 {
+		logger.Errorf("incorrect value '%s' in backend-removal-grace-period annotation", annGracePeriod)
+		return 0
+	}
+	return time.Duration(*gracePeriod) * time.Millisecond
+}
+
+// drainBackend sets the ready servers of an unused backend in drain mode,
+// so that only persistent connections are still forwarded to them.
+func (h Refresh) drainBackend(api api.HAProxyClient, cfg *config.ControllerCfg, backendName string) {
+	draining := &config.DrainingBackend{Since: time.Now()}
+	servers, err := api.BackendServersGet(backendName)
+	if err != nil {
+		logger.Error(err)
+	}
+	for _, server := range servers {
+		// Skip free server slots
+		if server.Maintenance == "enabled" {
+			continue
+		}
+		if err = api.SetServerState(backendName, server.Name, "drain"); err != nil {
+			logger.Error(err)
+			continue
+		}
+		draining.Servers = append(draining.Servers, server.Name)
+	}
+	cfg.DrainingBackends[backendName] = draining
+	logger.Debugf("Draining unused backend '%s' before deletion", backendName)
+}
+
+// undrainBackends restores draining backends which are used again
+func (h Refresh) undrainBackends(api api.HAProxyClient, cfg *config.ControllerCfg) {
+	for backendName, draining := range cfg.DrainingBackends {
+		if _, ok := cfg.ActiveBackends[backendName]; !ok {
+			continue
+		}
+		for _, server := range draining.Servers {
+			logger.Error(api.SetServerState(backendName, server, "ready"))
+		}
+		delete(cfg.DrainingBackends, backendName)
+		logger.Debugf("Backend '%s' used again, drain cancelled", backendName)
 	}
 }
@@ -0,0 +1,223 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-test/deep"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// Resolvers manages the resolvers sections defined by the "resolvers" annotation,
+// one section per line, which can be used by ExternalName services with the "resolver" annotation.
+// The "kubernetes" section of haproxy.cfg, using the pod nameservers, is not managed.
+// Example:
+//  resolvers: |
+//    name:dns, nameserver:10.0.0.10:53, nameserver:10.0.0.11:53, hold-valid:10s, timeout-retry:1s
+type Resolvers struct{}
+
+// defaultResolvers is the resolvers section defined in haproxy.cfg
+const defaultResolvers = "kubernetes"
+
+type resolversSection struct {
+	resolver    models.Resolver
+	nameservers models.Nameservers
+}
+
+func (h Resolvers) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	sections := h.parse(k.GetValueFromAnnotations("resolvers", k.ConfigMaps.Main.Annotations))
+	current, err := api.ResolversGet()
+	if err != nil {
+		return false, err
+	}
+	for _, resolver := range current {
+		if _, ok := sections[resolver.Name]; ok || resolver.Name == defaultResolvers {
+			continue
+		}
+		if err = api.ResolverDelete(resolver.Name); err != nil {
+			return reload, err
+		}
+		logger.Debugf("resolvers section '%s' deleted, reload required", resolver.Name)
+		reload = true
+	}
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		updated, errSection := h.updateSection(api, sections[name])
+		if errSection != nil {
+			logger.Errorf("resolvers section '%s': %s", name, errSection)
+			continue
+		}
+		reload = reload || updated
+	}
+	return reload, nil
+}
+
+// updateSection creates or updates a resolvers section and replaces its nameservers when they changed
+func (h Resolvers) updateSection(api api.HAProxyClient, section resolversSection) (reload bool, err error) {
+	name := section.resolver.Name
+	current, errGet := api.ResolverGet(name)
+	switch {
+	case errGet != nil:
+		if err = api.ResolverCreate(section.resolver); err != nil {
+			return false, err
+		}
+		reload = true
+	case len(deep.Equal(current, &section.resolver)) != 0:
+		if err = api.ResolverEdit(section.resolver); err != nil {
+			return false, err
+		}
+		reload = true
+	}
+	nameservers, _ := api.NameserversGet(name)
+	if len(deep.Equal(nameservers, section.nameservers)) == 0 {
+		if reload {
+			logger.Debugf("resolvers section '%s' updated, reload required", name)
+		}
+		return reload, nil
+	}
+	for _, nameserver := range nameservers {
+		if err = api.NameserverDelete(name, nameserver.Name); err != nil {
+			return reload, err
+		}
+	}
+	for _, nameserver := range section.nameservers {
+		if err = api.NameserverCreate(name, *nameserver); err != nil {
+			return reload, err
+		}
+	}
+	logger.Debugf("resolvers section '%s' updated, reload required", name)
+	return true, nil
+}
+
+// parse returns the resolvers sections of the annotation by name,
+// invalid sections are skipped.
+func (h Resolvers) parse(input string) map[string]resolversSection {
+	sections := make(map[string]resolversSection)
+	for _, line := range strings.Split(input, "\n") {
+		line = strings.Join(strings.Fields(line), "")
+		if line == "" {
+			continue
+		}
+		section, err := h.parseSection(line)
+		if err != nil {
+			logger.Errorf("resolvers annotation: %s in '%s'", err, line)
+			continue
+		}
+		if _, ok := sections[section.resolver.Name]; ok {
+			logger.Errorf("resolvers annotation: duplicate section '%s'", section.resolver.Name)
+			continue
+		}
+		sections[section.resolver.Name] = section
+	}
+	return sections
+}
+
+func (h Resolvers) parseSection(line string) (section resolversSection, err error) {
+	resolver := &section.resolver
+	for _, param := range strings.Split(line, ",") {
+		if param == "" {
+			continue
+		}
+		parts := strings.SplitN(param, ":", 2)
+		if len(parts) != 2 {
+			return section, fmt.Errorf("incorrect param '%s'", param)
+		}
+		key, value := parts[0], parts[1]
+		switch key {
+		case "name":
+			resolver.Name = value
+		case "nameserver":
+			var nameserver *models.Nameserver
+			if nameserver, err = parseNameserver(value); err != nil {
+				return section, err
+			}
+			nameserver.Name = fmt.Sprintf("ns%d", len(section.nameservers)+1)
+			section.nameservers = append(section.nameservers, nameserver)
+		case "parse-resolv-conf":
+			resolver.ParseResolvConf, err = utils.GetBoolValue(value, key)
+		case "hold-nx":
+			resolver.HoldNx, err = utils.ParseTime(value)
+		case "hold-obsolete":
+			resolver.HoldObsolete, err = utils.ParseTime(value)
+		case "hold-other":
+			resolver.HoldOther, err = utils.ParseTime(value)
+		case "hold-refused":
+			resolver.HoldRefused, err = utils.ParseTime(value)
+		case "hold-timeout":
+			resolver.HoldTimeout, err = utils.ParseTime(value)
+		case "hold-valid":
+			resolver.HoldValid, err = utils.ParseTime(value)
+		case "timeout-resolve", "timeout-retry":
+			var timeout *int64
+			if timeout, err = utils.ParseTime(value); err == nil {
+				if key == "timeout-resolve" {
+					resolver.TimeoutResolve = *timeout
+				} else {
+					resolver.TimeoutRetry = *timeout
+				}
+			}
+		case "resolve-retries":
+			resolver.ResolveRetries, err = strconv.ParseInt(value, 10, 64)
+		case "accepted-payload-size":
+			resolver.AcceptedPayloadSize, err = strconv.ParseInt(value, 10, 64)
+		default:
+			err = fmt.Errorf("unknown param '%s'", key)
+		}
+		if err != nil {
+			return section, err
+		}
+	}
+	switch {
+	case resolver.Name == "":
+		return section, errors.New("missing name")
+	case resolver.Name == defaultResolvers:
+		return section, fmt.Errorf("'%s' section is reserved", defaultResolvers)
+	case len(section.nameservers) == 0 && !resolver.ParseResolvConf:
+		return section, errors.New("no nameserver")
+	}
+	return section, resolver.Validate(nil)
+}
+
+// parseNameserver parses a nameserver "<address>[:<port>]", port defaulting to 53
+func parseNameserver(value string) (*models.Nameserver, error) {
+	address, port := value, "53"
+	if host, p, err := net.SplitHostPort(value); err == nil {
+		address, port = host, p
+	}
+	if net.ParseIP(address) == nil {
+		return nil, fmt.Errorf("incorrect nameserver address '%s'", address)
+	}
+	portNum, err := strconv.ParseInt(port, 10, 64)
+	if err != nil || portNum < 1 || portNum > 65535 {
+		return nil, fmt.Errorf("incorrect nameserver port '%s'", port)
+	}
+	return &models.Nameserver{Address: &address, Port: &portNum}, nil
+}
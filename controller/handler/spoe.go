@@ -0,0 +1,192 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/renameio"
+	"github.com/haproxytech/client-native/v2/models"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/spoe"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// SPOE configures HAProxy to reach the SPOE agent embedded in the controller:
+// SPOE configuration file, agent backend and frontends SPOE filter.
+// This is done when Enabled is set or when ingress annotations require the agent,
+// in which case StartAgent is called to run the agent.
+type SPOE struct {
+	AgentAddr  string
+	Enabled    bool
+	StartAgent func()
+}
+
+//nolint: golint,stylecheck
+const (
+	SPOE_ENGINE  = "auth"
+	SPOE_BACKEND = "spoe-auth"
+)
+
+const spoeConfig = `[%[1]s]
+spoe-agent %[1]s-agent
+    groups %[3]s %[4]s %[5]s %[6]s %[7]s
+    option var-prefix %[1]s
+    option set-on-error error
+    timeout hello 2s
+    timeout idle 2m
+    timeout processing 3s
+    use-backend %[2]s
+
+spoe-message %[3]s
+    args authorization=req.hdr(authorization)
+
+spoe-message %[4]s
+    args authorization=req.hdr(authorization) path=path method=method
+
+spoe-message %[5]s
+    args url=var(txn.auth_url) response_headers=var(txn.auth_response_headers) method=method host=req.hdr(host) uri=pathq ssl=ssl_fc authorization=req.fhdr(authorization) cookie=req.fhdr(cookie)
+
+spoe-message %[6]s
+    args url=var(txn.auth_url) response_headers=var(txn.auth_response_headers) forward_headers=var(txn.auth_forward_headers)%[8]s method=method host=req.hdr(host) uri=pathq ssl=ssl_fc authorization=req.fhdr(authorization) cookie=req.fhdr(cookie)
+
+spoe-message %[7]s
+    args url=var(txn.mirror_url) method=method uri=pathq headers=req.hdrs body=req.body
+
+spoe-group %[3]s
+    messages %[3]s
+
+spoe-group %[4]s
+    messages %[4]s
+
+spoe-group %[5]s
+    messages %[5]s
+
+spoe-group %[6]s
+    messages %[6]s
+
+spoe-group %[7]s
+    messages %[7]s
+`
+
+func (h SPOE) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	if !h.Enabled && !cfg.SPOE {
+		return h.removeFilters(cfg, api), nil
+	}
+	if h.StartAgent != nil {
+		h.StartAgent()
+	}
+	cfgFile := filepath.Join(cfg.Env.CfgDir, "spoe-"+SPOE_ENGINE+".cfg")
+	// Only the headers forwarded to auth-request-service are sent: the agent does
+	// not support fragmented frames, so the message must fit in a single frame
+	authRequestHeaders := make([]string, 0, len(cfg.AuthRequestHeaders))
+	for header := range cfg.AuthRequestHeaders {
+		authRequestHeaders = append(authRequestHeaders, header)
+	}
+	sort.Strings(authRequestHeaders)
+	var authRequestArgs strings.Builder
+	for _, header := range authRequestHeaders {
+		fmt.Fprintf(&authRequestArgs, " %s=req.fhdr(%s)", spoe.ForwardAuthHeaderArg(header), header)
+	}
+	content := []byte(fmt.Sprintf(spoeConfig, SPOE_ENGINE, SPOE_BACKEND, spoe.MsgTokenReview, spoe.MsgTokenReviewAuthz, spoe.MsgForwardAuth, spoe.MsgAuthRequest, spoe.MsgMirror, authRequestArgs.String()))
+	if current, errRead := ioutil.ReadFile(cfgFile); errRead != nil || !bytes.Equal(current, content) {
+		if err = renameio.WriteFile(cfgFile, content, 0644); err != nil {
+			return
+		}
+		logger.Debug("SPOE configuration file updated, reload required")
+		reload = true
+	}
+	// Agent backend
+	if _, err = api.BackendGet(SPOE_BACKEND); err != nil {
+		err = api.BackendCreate(models.Backend{
+			Name: SPOE_BACKEND,
+			Mode: "tcp",
+		})
+		if err != nil {
+			return
+		}
+		err = api.BackendServerCreate(SPOE_BACKEND, models.Server{
+			Name:    "agent",
+			Address: h.AgentAddr,
+		})
+		if err != nil {
+			return
+		}
+		logger.Debug("SPOE agent backend created, reload required")
+		reload = true
+	}
+	cfg.ActiveBackends[SPOE_BACKEND] = struct{}{}
+	// SPOE filter
+	for _, frontend := range cfg.HTTPFrontends() {
+		filters, errFilters := api.FrontendFiltersGet(frontend)
+		if errFilters != nil {
+			continue
+		}
+		found := false
+		for _, filter := range filters {
+			if filter.Type == "spoe" && filter.SpoeEngine == SPOE_ENGINE {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		err = api.FrontendFilterCreate(frontend, models.Filter{
+			Index:      utils.PtrInt64(0),
+			Type:       "spoe",
+			SpoeEngine: SPOE_ENGINE,
+			SpoeConfig: cfgFile,
+		})
+		if err != nil {
+			return
+		}
+		logger.Debugf("SPOE filter added to frontend '%s', reload required", frontend)
+		reload = true
+	}
+	return reload, nil
+}
+
+// removeFilters removes the SPOE filter from frontends, the agent backend
+// being no more marked as active it is deleted by the Refresh handler.
+func (h SPOE) removeFilters(cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool) {
+	for _, frontend := range cfg.HTTPFrontends() {
+		filters, err := api.FrontendFiltersGet(frontend)
+		if err != nil {
+			continue
+		}
+		for i := len(filters) - 1; i >= 0; i-- {
+			filter := filters[i]
+			if filter.Type != "spoe" || filter.SpoeEngine != SPOE_ENGINE || filter.Index == nil {
+				continue
+			}
+			if err = api.FrontendFilterDelete(frontend, *filter.Index); err != nil {
+				logger.Error(err)
+				continue
+			}
+			logger.Debugf("SPOE filter removed from frontend '%s', reload required", frontend)
+			reload = true
+		}
+	}
+	return reload
+}
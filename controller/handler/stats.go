@@ -0,0 +1,91 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"sort"
+	"strings"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// Stats configures the stats page of the stats frontend: its uri, refresh
+// interval and basic authentication with the credentials of a Secret.
+type Stats struct{}
+
+func (h Stats) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	settings := []string{"enable"}
+	annotations := k.ConfigMaps.Main.Annotations
+	uri := k.GetValueFromAnnotations("stats-uri", annotations)
+	if !strings.HasPrefix(uri, "/") || strings.ContainsAny(uri, " \t") {
+		logger.Errorf("stats-uri annotation: incorrect value '%s'", uri)
+		uri = "/"
+	}
+	settings = append(settings, "uri "+uri)
+	refresh := k.GetValueFromAnnotations("stats-refresh", annotations)
+	if _, errTime := utils.ParseTime(refresh); errTime != nil {
+		logger.Errorf("stats-refresh annotation: %s", errTime)
+	} else {
+		settings = append(settings, "refresh "+refresh)
+	}
+	if authSecret := k.GetValueFromAnnotations("stats-auth-secret", annotations); authSecret != "" {
+		credentials := h.credentials(k, authSecret)
+		if len(credentials) > 0 {
+			settings = append(settings, "realm HAProxy-Statistics")
+			settings = append(settings, credentials...)
+		} else {
+			// Denying access rather than exposing statistics without authentication
+			logger.Errorf("stats-auth-secret annotation: no valid credentials in secret '%s', stats page disabled", authSecret)
+			settings = nil
+		}
+	}
+	current, err := api.FrontendStatsGet(cfg.FrontStats)
+	if err != nil {
+		return false, err
+	}
+	if strings.Join(current, "\n") == strings.Join(settings, "\n") {
+		return false, nil
+	}
+	if err = api.FrontendStatsSet(cfg.FrontStats, settings); err != nil {
+		return false, err
+	}
+	logger.Debug("stats page updated, reload required")
+	return true, nil
+}
+
+// credentials returns the "auth <user>:<password>" settings of the secret users, sorted by user
+func (h Stats) credentials(k store.K8s, authSecret string) (credentials []string) {
+	secret, err := k.FetchSecret(authSecret, k.ConfigMaps.Main.Namespace)
+	if secret == nil {
+		logger.Errorf("stats-auth-secret annotation: %s", err)
+		return nil
+	}
+	if secret.Status == store.DELETED {
+		return nil
+	}
+	for user, password := range secret.Data {
+		pwd := strings.TrimSuffix(string(password), "\n")
+		if strings.ContainsAny(user, ": \t") || pwd == "" || strings.ContainsAny(pwd, " \t\n") {
+			logger.Warningf("stats-auth-secret annotation: ignoring incorrect credentials of user '%s'", user)
+			continue
+		}
+		credentials = append(credentials, "auth "+user+":"+pwd)
+	}
+	sort.Strings(credentials)
+	return credentials
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 
@@ -9,6 +10,7 @@ import (
 	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/udp"
 	"github.com/haproxytech/kubernetes-ingress/controller/utils"
 )
 
@@ -19,12 +21,16 @@ type TCPServices struct {
 	CertDir           string
 	AddrIPv4          string
 	AddrIPv6          string
+	// UDPProxy serves the services with udp option, HAProxy not proxying UDP.
+	// It is nil when HAProxy does not run in the controller pod, udp services being rejected.
+	UDPProxy *udp.Proxy
 }
 
 type tcpSvcParser struct {
 	service    *store.Service
 	port       int64
 	sslOffload bool
+	udp        bool
 }
 
 func (t TCPServices) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
@@ -33,6 +39,7 @@ func (t TCPServices) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAPr
 	}
 	reload = t.clearFrontends(api, k)
 	var p tcpSvcParser
+	udpListeners := make(map[string][]string)
 	for port, tcpSvcAnn := range k.ConfigMaps.TCPServices.Annotations {
 		frontendName := fmt.Sprintf("tcp-%s", port)
 		p, err = t.parseTCPService(k, tcpSvcAnn)
@@ -40,6 +47,14 @@ func (t TCPServices) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAPr
 			logger.Error(err)
 			continue
 		}
+		if p.udp {
+			if t.UDPProxy == nil {
+				logger.Errorf("tcp-services: UDP service on port %s ignored, the controller UDP proxy does not run with --dataplane-url", port)
+				continue
+			}
+			t.addUDPListeners(udpListeners, k, port, p)
+			continue
+		}
 		frontend, errGet := api.FrontendGet(frontendName)
 		// Create Frontend
 		if errGet != nil {
@@ -55,13 +70,16 @@ func (t TCPServices) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAPr
 			logger.Errorf("TCP frontend '%s': update failed: %s", frontendName, err)
 		}
 	}
+	if t.UDPProxy != nil {
+		logger.Error(t.UDPProxy.Update(udpListeners))
+	}
 	return reload, nil
 }
 
 func (t TCPServices) parseTCPService(store store.K8s, input string) (p tcpSvcParser, err error) {
 	// parts[0]: Service Name
 	// parts[1]: Service Port
-	// parts[2]: SSL option
+	// parts[2]: SSL or UDP option
 	parts := strings.Split(input, ":")
 	if len(parts) < 2 {
 		err = fmt.Errorf("incorrect format '%s', 'ServiceName:ServicePort' is required", input)
@@ -70,8 +88,11 @@ func (t TCPServices) parseTCPService(store store.K8s, input string) (p tcpSvcPar
 	svcName := strings.Split(parts[0], "/")
 	svcPort := parts[1]
 	if len(parts) > 2 {
-		if parts[2] == "ssl" {
+		switch parts[2] {
+		case "ssl":
 			p.sslOffload = true
+		case "udp":
+			p.udp = true
 		}
 	}
 	if len(svcName) != 2 {
@@ -103,7 +124,9 @@ func (t TCPServices) clearFrontends(api api.HAProxyClient, k store.K8s) (cleared
 		return
 	}
 	for _, ft := range frontends {
-		_, isRequired := k.ConfigMaps.TCPServices.Annotations[strings.TrimPrefix(ft.Name, "tcp-")]
+		tcpSvcAnn, isRequired := k.ConfigMaps.TCPServices.Annotations[strings.TrimPrefix(ft.Name, "tcp-")]
+		// UDP services are not served by HAProxy frontends
+		isRequired = isRequired && !strings.HasSuffix(tcpSvcAnn, ":udp")
 		isTCPSvc := strings.HasPrefix(ft.Name, "tcp-")
 		if isTCPSvc && !isRequired {
 			err = api.FrontendDelete(ft.Name)
@@ -118,6 +141,32 @@ func (t TCPServices) clearFrontends(api api.HAProxyClient, k store.K8s) (cleared
 	return
 }
 
+// addUDPListeners adds the listening addresses of a UDP service bind port
+// to listeners, with the endpoints of the UDP service port as targets.
+func (t TCPServices) addUDPListeners(listeners map[string][]string, k store.K8s, bindPort string, p tcpSvcParser) {
+	if p.service.Status == store.DELETED {
+		return
+	}
+	var svcPort *store.ServicePort
+	for i, sp := range p.service.Ports {
+		if sp.Port == p.port && sp.Protocol == "UDP" {
+			svcPort = &p.service.Ports[i]
+			break
+		}
+	}
+	if svcPort == nil {
+		logger.Errorf("tcp-services: service '%s/%s' has no UDP port %d", p.service.Namespace, p.service.Name, p.port)
+		return
+	}
+	targets := k.GetServicePortAddresses(p.service.Namespace, p.service.Name, svcPort.Name)
+	if t.IPv4 {
+		listeners[net.JoinHostPort(t.AddrIPv4, bindPort)] = targets
+	}
+	if t.IPv6 {
+		listeners[net.JoinHostPort(t.AddrIPv6, bindPort)] = targets
+	}
+}
+
 func (t TCPServices) createTCPFrontend(api api.HAProxyClient, frontendName, bindPort string, sslOffload bool) (frontend models.Frontend, reload bool, err error) {
 	// Create Frontend
 	frontend = models.Frontend{
@@ -0,0 +1,71 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"strconv"
+
+	config "github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// Threads configures thread-groups and numa-cpu-mapping global directives,
+// which are not part of the global model, to pin HAProxy threads with cpu-map.
+type Threads struct{}
+
+func (h Threads) Update(k store.K8s, cfg *config.ControllerCfg, api api.HAProxyClient) (reload bool, err error) {
+	var groups, mapping string
+	if ann := k.GetValueFromAnnotations("thread-groups", k.Global.Annotations, k.ConfigMaps.Main.Annotations); ann != "" {
+		if v, errConv := strconv.Atoi(ann); errConv != nil || v < 1 {
+			logger.Errorf("thread-groups annotation: incorrect value '%s'", ann)
+		} else {
+			groups = ann
+		}
+	}
+	if ann := k.GetValueFromAnnotations("numa-cpu-mapping", k.Global.Annotations, k.ConfigMaps.Main.Annotations); ann != "" {
+		if enabled, errBool := utils.GetBoolValue(ann, "numa-cpu-mapping"); errBool != nil {
+			logger.Errorf("numa-cpu-mapping annotation: %s", errBool)
+		} else if enabled {
+			mapping = "enabled"
+		} else {
+			mapping = "disabled"
+		}
+	}
+	currentGroups, err := api.GlobalThreadGroupsGet()
+	if err != nil {
+		return false, err
+	}
+	if currentGroups != groups {
+		if err = api.GlobalThreadGroupsSet(groups); err != nil {
+			return false, err
+		}
+		logger.Debugf("thread-groups updated to '%s', reload required", groups)
+		reload = true
+	}
+	currentMapping, err := api.GlobalNumaCPUMappingGet()
+	if err != nil {
+		return reload, err
+	}
+	if currentMapping != mapping {
+		if err = api.GlobalNumaCPUMappingSet(mapping); err != nil {
+			return reload, err
+		}
+		logger.Debugf("numa-cpu-mapping updated to '%s', reload required", mapping)
+		reload = true
+	}
+	return reload, nil
+}
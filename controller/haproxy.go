@@ -26,7 +26,7 @@ func (c *HAProxyController) haproxyService(action string) (err error) {
 	return c.haproxyProcess.HaproxyService(action)
 }
 
-func (c *HAProxyController) haproxyStartup() {
+func (c *HAProxyController) haproxyStartup() error {
 	//nolint:gosec //checks on HAProxyBinary should be done in configuration module.
 	cmd := exec.Command(c.Cfg.Env.HAProxyBinary, "-v")
 	haproxyInfo, err := cmd.Output()
@@ -36,11 +36,18 @@ func (c *HAProxyController) haproxyStartup() {
 	} else {
 		logger.Error(err)
 	}
-	if c.OSArgs.UseWiths6Overlay {
+	switch {
+	case c.haproxyProcess != nil:
+	case len(c.OSArgs.DataplaneURLs) != 0:
+		c.haproxyProcess, err = process.NewDataplaneControl(c.Cfg.Env, c.OSArgs)
+		if err != nil {
+			return err
+		}
+	case c.OSArgs.UseWiths6Overlay:
 		c.haproxyProcess = process.NewControlOverS6(c.Cfg.Env, c.OSArgs, c.Client)
-	} else {
+	default:
 		c.haproxyProcess = process.NewDirectControl(c.Cfg.Env, c.OSArgs, c.Client)
 	}
 	logger.Printf("Starting HAProxy with %s", c.Cfg.Env.MainCFGFile)
-	logger.Panic(c.haproxyService("start"))
+	return c.haproxyService("start")
 }
@@ -1,6 +1,13 @@
 package api
 
 import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
 	clientnative "github.com/haproxytech/client-native/v2"
 	"github.com/haproxytech/client-native/v2/configuration"
 	"github.com/haproxytech/client-native/v2/models"
@@ -13,25 +20,58 @@ import (
 type HAProxyClient interface {
 	APIStartTransaction() error
 	APICommitTransaction() error
+	APICheckTransaction() error
 	APIDisposeTransaction()
+	APIDiscardTransactions() error
 	BackendsGet() (models.Backends, error)
 	BackendGet(backendName string) (*models.Backend, error)
 	BackendCreate(backend models.Backend) error
 	BackendEdit(backend models.Backend) error
 	BackendDelete(backendName string) error
+	BackendCfgSnippetGet(backendName string) ([]string, error)
 	BackendCfgSnippetSet(backendName string, value *[]string) error
+	BackendCacheGet(backendName string) (cacheName string, err error)
+	BackendCacheSet(backendName string, cacheName string) error
+	BackendErrorFilesGet(backendName string) ([]types.ErrorFile, error)
+	BackendErrorFilesSet(backendName string, errorFiles []types.ErrorFile) error
+	BackendFullconnGet(backendName string) (fullconn string, err error)
+	BackendFullconnSet(backendName string, fullconn string) error
+	BackendH1CaseAdjustGet(backendName string) (enabled bool, err error)
+	BackendH1CaseAdjustSet(backendName string, enabled bool) error
 	BackendHTTPRequestRuleCreate(backend string, rule models.HTTPRequestRule) error
+	BackendRetryOnGet(backendName string) (conditions string, err error)
+	BackendRetryOnSet(backendName string, conditions string) error
 	BackendRuleDeleteAll(backend string)
 	BackendServerDeleteAll(backendName string) (deleteServers bool)
+	BackendStickRulesGet(backendName string) (models.StickRules, error)
+	BackendStickRuleCreate(backendName string, rule models.StickRule) error
+	BackendStickRuleDelete(backendName string, index int64) error
+	BackendServersGet(backendName string) (models.Servers, error)
+	BackendServersStateGet(backendName string) (models.RuntimeServers, error)
+	BackendTCPCheckGet(backendName string) (rules []string, err error)
+	BackendTCPCheckSet(backendName string, rules []string) error
+	BackendTransparentProxyGet(backendName string) (enabled bool, err error)
+	BackendTransparentProxySet(backendName string, enabled bool) error
 	BackendServerCreate(backendName string, data models.Server) error
 	BackendServerEdit(backendName string, data models.Server) error
 	BackendServerDelete(backendName string, serverName string) error
+	BackendServerTemplatesGet(backendName string) (models.ServerTemplates, error)
+	BackendServerTemplateCreate(backendName string, data models.ServerTemplate) error
+	BackendServerTemplateDelete(backendName string, prefix string) error
 	BackendSwitchingRuleCreate(frontend string, rule models.BackendSwitchingRule) error
 	BackendSwitchingRuleDeleteAll(frontend string)
+	CacheGet(name string) (*Cache, error)
+	CacheSet(cache Cache) error
+	CacheDelete(name string) error
 	DefaultsGetConfiguration() (*models.Defaults, error)
 	DefaultsPushConfiguration(*models.Defaults) error
+	DefaultsErrorFilesGet() (section string, err error)
+	DefaultsErrorFilesSet(section string) error
 	ExecuteRaw(command string) (result []string, err error)
+	FrontendCfgSnippetGet(frontendName string) ([]string, error)
 	FrontendCfgSnippetSet(frontendName string, value *[]string) error
+	FrontendClientFinTimeoutGet(frontendName string) (timeout string, err error)
+	FrontendClientFinTimeoutSet(frontendName string, timeout string) error
 	FrontendCreate(frontend models.Frontend) error
 	FrontendDelete(frontendName string) error
 	FrontendsGet() (models.Frontends, error)
@@ -39,22 +79,67 @@ type HAProxyClient interface {
 	FrontendEdit(frontend models.Frontend) error
 	FrontendEnableSSLOffload(frontendName string, certDir string, alpn bool) (err error)
 	FrontendDisableSSLOffload(frontendName string) (err error)
+	FrontendDisabledGet(frontendName string) (disabled bool, err error)
+	FrontendDisabledSet(frontendName string, disabled bool) error
+	FrontendRateLimitSessionsGet(frontendName string) (rate string, err error)
+	FrontendRateLimitSessionsSet(frontendName string, rate string) error
 	FrontendBindsGet(frontend string) (models.Binds, error)
 	FrontendBindCreate(frontend string, bind models.Bind) error
 	FrontendBindEdit(frontend string, bind models.Bind) error
+	FrontendBindDelete(frontend string, bindName string) error
+	FrontendFiltersGet(frontend string) (models.Filters, error)
+	FrontendFilterCreate(frontend string, filter models.Filter) error
+	FrontendFilterDelete(frontend string, index int64) error
+	FrontendFilterDeleteAll(frontend string)
 	FrontendHTTPRequestRuleCreate(frontend string, rule models.HTTPRequestRule, ingressACL string) error
 	FrontendHTTPResponseRuleCreate(frontend string, rule models.HTTPResponseRule, ingressACL string) error
 	FrontendTCPRequestRuleCreate(frontend string, rule models.TCPRequestRule, ingressACL string) error
 	FrontendRuleDeleteAll(frontend string)
+	FrontendStatsGet(frontendName string) ([]string, error)
+	FrontendStatsSet(frontendName string, values []string) error
 	GlobalCreateLogTarget(*models.LogTarget) error
 	GlobalDeleteLogTargets()
 	GlobalGetConfiguration() (*models.Global, error)
 	GlobalPushConfiguration(*models.Global) error
 	GlobalCfgSnippet(snippet *types.StringSliceC) error
+	GlobalCfgSnippetGet() ([]string, error)
+	GlobalLocalPeerGet() (string, error)
+	GlobalLocalPeerSet(name string) error
+	GlobalH1CaseAdjustFileGet() (file string, err error)
+	GlobalH1CaseAdjustFileSet(file string) error
+	GlobalLuaLoadsGet() (files []string, err error)
+	GlobalLuaLoadsSet(files []string) error
+	GlobalNumaCPUMappingGet() (mapping string, err error)
+	GlobalNumaCPUMappingSet(mapping string) error
+	GlobalThreadGroupsGet() (groups string, err error)
+	GlobalThreadGroupsSet(groups string) error
+	GlobalTuneGet(name string) (value string, err error)
+	GlobalTuneSet(name, value string) error
 	GetMap(mapFile string) (*models.Map, error)
+	HTTPErrorsGet(name string) ([]string, error)
+	HTTPErrorsSet(name string, lines []string) error
+	HTTPErrorsDelete(name string) error
 	SetMapContent(mapFile string, payload string) error
+	SetMapContentVersioned(mapFile string, rows []string) error
+	PeerEntriesGet(peerSection string) (models.PeerEntries, error)
+	PeerEntryCreate(peerSection string, peer models.PeerEntry) error
+	PeerEntryDelete(peerSection string, name string) error
+	LogForwardsGet() ([]string, error)
+	LogForwardsSet(lines []string) error
+	NameserversGet(resolverName string) (models.Nameservers, error)
+	NameserverCreate(resolverName string, nameserver models.Nameserver) error
+	NameserverDelete(resolverName string, name string) error
+	ResolversGet() (models.Resolvers, error)
+	ResolverGet(name string) (*models.Resolver, error)
+	ResolverCreate(resolver models.Resolver) error
+	ResolverEdit(resolver models.Resolver) error
+	ResolverDelete(name string) error
+	RingGet(name string) ([]string, error)
+	RingSet(name string, lines []string) error
+	RingDelete(name string) error
 	SetServerAddr(backendName string, serverName string, ip string, port int) error
 	SetServerState(backendName string, serverName string, state string) error
+	SetServerWeight(backendName string, serverName string, weight string) error
 	ServerGet(serverName, backendNa string) (*models.Server, error)
 	SyncBackendSrvs(oldEndpoints, newEndpoints *store.PortEndpoints) error
 	UserListDeleteByGroup(group string) error
@@ -64,6 +149,8 @@ type HAProxyClient interface {
 
 type clientNative struct {
 	nativeAPI                   clientnative.HAProxyClient
+	programPath                 string
+	configFile                  string
 	activeTransaction           string
 	activeTransactionHasChanges bool
 }
@@ -98,6 +185,8 @@ func Init(transactionDir, configFile, programPath, runtimeSocket string) (client
 			Configuration: &confClient,
 			Runtime:       &runtimeClient,
 		},
+		programPath: programPath,
+		configFile:  configFile,
 	}
 	return &cn, nil
 }
@@ -128,7 +217,63 @@ func (c *clientNative) APICommitTransaction() error {
 	return err
 }
 
+// APICheckTransaction checks the configuration of the active transaction
+// with "haproxy -c", as done when committing it, without committing it.
+// The returned error holds the alerts reported by HAProxy.
+func (c *clientNative) APICheckTransaction() error {
+	if !c.activeTransactionHasChanges {
+		return nil
+	}
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile("", "haproxy-check-*.cfg")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = config.Save(f.Name()); err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	// #nosec G204
+	cmd := exec.Command(c.programPath, "-c", "-f", f.Name())
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err == nil {
+		return nil
+	}
+	var alerts []string
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		if strings.Contains(line, "[ALERT]") {
+			alerts = append(alerts, strings.TrimSpace(line))
+		}
+	}
+	if len(alerts) == 0 {
+		return err
+	}
+	return errors.New(strings.Join(alerts, "\n"))
+}
+
 func (c *clientNative) APIDisposeTransaction() {
 	c.activeTransaction = ""
 	c.activeTransactionHasChanges = false
 }
+
+// APIDiscardTransactions deletes the transactions left in progress, such as the one
+// of a sync interrupted before its commit, and reloads the configuration file so that
+// next transactions start from the configuration HAProxy runs with.
+func (c *clientNative) APIDiscardTransactions() error {
+	for _, transaction := range c.nativeAPI.Configuration.GetParserTransactions() {
+		if transaction.ID == c.activeTransaction || transaction.Status != models.TransactionStatusInProgress {
+			continue
+		}
+		if err := c.nativeAPI.Configuration.DeleteTransaction(transaction.ID); err != nil {
+			return err
+		}
+	}
+	return c.nativeAPI.Configuration.LoadData(c.configFile)
+}
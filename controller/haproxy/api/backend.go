@@ -43,7 +43,39 @@ func (c *clientNative) BackendCfgSnippetSet(backendName string, value *[]string)
 	} else {
 		err = config.Set("backend", backendName, "config-snippet", types.StringSliceC{Value: *value})
 	}
+	if err == nil {
+		c.activeTransactionHasChanges = true
+	}
+	return err
+}
+
+func (c *clientNative) BackendErrorFilesGet(backendName string) ([]types.ErrorFile, error) {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return nil, err
+	}
+	data, err := config.Get("backend", backendName, "errorfile")
 	if err != nil {
+		// No errorfile
+		return nil, nil
+	}
+	errorFiles, _ := data.([]types.ErrorFile)
+	return errorFiles, nil
+}
+
+// BackendErrorFilesSet sets the errorfile directives of the backend, which are
+// not part of backend model, they are all removed when errorFiles is empty.
+func (c *clientNative) BackendErrorFilesSet(backendName string, errorFiles []types.ErrorFile) error {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return err
+	}
+	if len(errorFiles) == 0 {
+		err = config.Set("backend", backendName, "errorfile", nil)
+	} else {
+		err = config.Set("backend", backendName, "errorfile", errorFiles)
+	}
+	if err == nil {
 		c.activeTransactionHasChanges = true
 	}
 	return err
@@ -72,6 +104,11 @@ func (c *clientNative) BackendRuleDeleteAll(backend string) {
 	}
 }
 
+func (c *clientNative) BackendServersGet(backendName string) (models.Servers, error) {
+	_, servers, err := c.nativeAPI.Configuration.GetServers(backendName, c.activeTransaction)
+	return servers, err
+}
+
 func (c *clientNative) BackendServerCreate(backendName string, data models.Server) error {
 	c.activeTransactionHasChanges = true
 	return c.nativeAPI.Configuration.CreateServer(backendName, &data, c.activeTransaction, 0)
@@ -87,6 +124,36 @@ func (c *clientNative) BackendServerDelete(backendName string, serverName string
 	return c.nativeAPI.Configuration.DeleteServer(serverName, backendName, c.activeTransaction, 0)
 }
 
+func (c *clientNative) BackendServerTemplatesGet(backendName string) (models.ServerTemplates, error) {
+	_, templates, err := c.nativeAPI.Configuration.GetServerTemplates(backendName, c.activeTransaction)
+	return templates, err
+}
+
+func (c *clientNative) BackendServerTemplateCreate(backendName string, data models.ServerTemplate) error {
+	c.activeTransactionHasChanges = true
+	return c.nativeAPI.Configuration.CreateServerTemplate(backendName, &data, c.activeTransaction, 0)
+}
+
+func (c *clientNative) BackendServerTemplateDelete(backendName string, prefix string) error {
+	c.activeTransactionHasChanges = true
+	return c.nativeAPI.Configuration.DeleteServerTemplate(prefix, backendName, c.activeTransaction, 0)
+}
+
+func (c *clientNative) BackendStickRulesGet(backendName string) (models.StickRules, error) {
+	_, rules, err := c.nativeAPI.Configuration.GetStickRules(backendName, c.activeTransaction)
+	return rules, err
+}
+
+func (c *clientNative) BackendStickRuleCreate(backendName string, rule models.StickRule) error {
+	c.activeTransactionHasChanges = true
+	return c.nativeAPI.Configuration.CreateStickRule(backendName, &rule, c.activeTransaction, 0)
+}
+
+func (c *clientNative) BackendStickRuleDelete(backendName string, index int64) error {
+	c.activeTransactionHasChanges = true
+	return c.nativeAPI.Configuration.DeleteStickRule(index, backendName, c.activeTransaction, 0)
+}
+
 func (c *clientNative) BackendSwitchingRuleCreate(frontend string, rule models.BackendSwitchingRule) error {
 	c.activeTransactionHasChanges = true
 	return c.nativeAPI.Configuration.CreateBackendSwitchingRule(frontend, &rule, c.activeTransaction, 0)
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/haproxytech/client-native/v2/models"
+	parser "github.com/haproxytech/config-parser/v4"
+	"github.com/haproxytech/config-parser/v4/types"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// Cache is a HAProxy cache section, cache sections are not part of
+// client-native models thus they are handled with config-parser.
+type Cache struct {
+	Name         string
+	TotalMaxSize int64 // megabytes
+	MaxAge       int64 // seconds
+}
+
+func (c *clientNative) CacheGet(name string) (*Cache, error) {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return nil, err
+	}
+	if !sectionExists(config, parser.Cache, name) {
+		return nil, fmt.Errorf("cache '%s' does not exist", name)
+	}
+	cache := &Cache{Name: name}
+	if data, errGet := config.Get(parser.Cache, name, "total-max-size"); errGet == nil {
+		if value, ok := data.(*types.Int64C); ok {
+			cache.TotalMaxSize = value.Value
+		}
+	}
+	if data, errGet := config.Get(parser.Cache, name, "max-age"); errGet == nil {
+		if value, ok := data.(*types.Int64C); ok {
+			cache.MaxAge = value.Value
+		}
+	}
+	return cache, nil
+}
+
+// CacheSet creates the cache section or updates its settings
+func (c *clientNative) CacheSet(cache Cache) error {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return err
+	}
+	c.activeTransactionHasChanges = true
+	if !sectionExists(config, parser.Cache, cache.Name) {
+		if err = config.SectionsCreate(parser.Cache, cache.Name); err != nil {
+			return err
+		}
+	}
+	if err = config.Set(parser.Cache, cache.Name, "total-max-size", types.Int64C{Value: cache.TotalMaxSize}); err != nil {
+		return err
+	}
+	return config.Set(parser.Cache, cache.Name, "max-age", types.Int64C{Value: cache.MaxAge})
+}
+
+func (c *clientNative) CacheDelete(name string) error {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return err
+	}
+	c.activeTransactionHasChanges = true
+	return config.SectionsDelete(parser.Cache, name)
+}
+
+// BackendCacheGet returns the cache used by the backend cache-use rule
+func (c *clientNative) BackendCacheGet(backendName string) (cacheName string, err error) {
+	_, rules, err := c.nativeAPI.Configuration.GetHTTPRequestRules("backend", backendName, c.activeTransaction)
+	if err != nil {
+		return "", err
+	}
+	for _, rule := range rules {
+		if rule.Type == "cache-use" {
+			return rule.CacheName, nil
+		}
+	}
+	return "", nil
+}
+
+// BackendCacheSet sets the backend cache-use and cache-store rules for the given
+// cache, or removes them when cacheName is empty.
+// The cache-store action is not supported by config-parser, thus kept as an unprocessed line.
+func (c *clientNative) BackendCacheSet(backendName string, cacheName string) error {
+	_, rules, err := c.nativeAPI.Configuration.GetHTTPRequestRules("backend", backendName, c.activeTransaction)
+	if err != nil {
+		return err
+	}
+	c.activeTransactionHasChanges = true
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].Type != "cache-use" || rules[i].Index == nil {
+			continue
+		}
+		if err = c.nativeAPI.Configuration.DeleteHTTPRequestRule(*rules[i].Index, "backend", backendName, c.activeTransaction, 0); err != nil {
+			return err
+		}
+	}
+	storeLine := ""
+	if cacheName != "" {
+		err = c.nativeAPI.Configuration.CreateHTTPRequestRule("backend", backendName, &models.HTTPRequestRule{
+			Index:     utils.PtrInt64(0),
+			Type:      "cache-use",
+			CacheName: cacheName,
+		}, c.activeTransaction, 0)
+		if err != nil {
+			return err
+		}
+		storeLine = "http-response cache-store " + cacheName
+	}
+	return c.unprocessedLineSet(parser.Backends, backendName, "http-response cache-store ", storeLine)
+}
+
+func sectionExists(config parser.Parser, section parser.Section, name string) bool {
+	sections, err := config.SectionsGet(section)
+	if err != nil {
+		return false
+	}
+	for _, s := range sections {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
@@ -2,8 +2,12 @@ package api
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/haproxytech/client-native/v2/models"
+	parser "github.com/haproxytech/config-parser/v4"
+	"github.com/haproxytech/config-parser/v4/common"
+	stats "github.com/haproxytech/config-parser/v4/parsers/stats/settings"
 	"github.com/haproxytech/config-parser/v4/types"
 )
 
@@ -17,12 +21,42 @@ func (c *clientNative) FrontendCfgSnippetSet(frontendName string, value *[]strin
 	} else {
 		err = config.Set("frontend", frontendName, "config-snippet", types.StringSliceC{Value: *value})
 	}
-	if err != nil {
+	if err == nil {
 		c.activeTransactionHasChanges = true
 	}
 	return err
 }
 
+func (c *clientNative) FrontendClientFinTimeoutGet(frontendName string) (timeout string, err error) {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return "", err
+	}
+	data, errGet := config.Get(parser.Frontends, frontendName, "timeout client-fin")
+	if errGet != nil {
+		return "", nil
+	}
+	if value, ok := data.(*types.SimpleTimeout); ok {
+		return value.Value, nil
+	}
+	return "", nil
+}
+
+// FrontendClientFinTimeoutSet sets the frontend inactivity timeout on the client side once
+// the client or the server closed its half of the connection, removing it when empty.
+func (c *clientNative) FrontendClientFinTimeoutSet(frontendName string, timeout string) error {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return err
+	}
+	var data common.ParserData
+	if timeout != "" {
+		data = &types.SimpleTimeout{Value: timeout}
+	}
+	c.activeTransactionHasChanges = true
+	return config.Set(parser.Frontends, frontendName, "timeout client-fin", data)
+}
+
 func (c *clientNative) FrontendCreate(frontend models.Frontend) error {
 	c.activeTransactionHasChanges = true
 	return c.nativeAPI.Configuration.CreateFrontend(&frontend, c.activeTransaction, 0)
@@ -80,7 +114,9 @@ func (c *clientNative) FrontendDisableSSLOffload(frontendName string) (err error
 		bind.SslCafile = ""
 		bind.Verify = ""
 		bind.SslCertificate = ""
+		bind.CrtList = ""
 		bind.Alpn = ""
+		bind.Curves = ""
 		err = c.FrontendBindEdit(frontendName, *bind)
 	}
 	if err != nil {
@@ -104,6 +140,11 @@ func (c *clientNative) FrontendBindEdit(frontend string, bind models.Bind) error
 	return c.nativeAPI.Configuration.EditBind(bind.Name, frontend, &bind, c.activeTransaction, 0)
 }
 
+func (c *clientNative) FrontendBindDelete(frontend string, bindName string) error {
+	c.activeTransactionHasChanges = true
+	return c.nativeAPI.Configuration.DeleteBind(bindName, frontend, c.activeTransaction, 0)
+}
+
 func (c *clientNative) FrontendHTTPRequestRuleCreate(frontend string, rule models.HTTPRequestRule, ingressACL string) error {
 	c.activeTransactionHasChanges = true
 	if ingressACL != "" {
@@ -154,3 +195,87 @@ func (c *clientNative) FrontendRuleDeleteAll(frontend string) {
 	}
 	// No usage of TCPResponseRules yet.
 }
+
+func (c *clientNative) FrontendFiltersGet(frontend string) (models.Filters, error) {
+	_, filters, err := c.nativeAPI.Configuration.GetFilters("frontend", frontend, c.activeTransaction)
+	return filters, err
+}
+
+func (c *clientNative) FrontendFilterCreate(frontend string, filter models.Filter) error {
+	c.activeTransactionHasChanges = true
+	return c.nativeAPI.Configuration.CreateFilter("frontend", frontend, &filter, c.activeTransaction, 0)
+}
+
+func (c *clientNative) FrontendFilterDelete(frontend string, index int64) error {
+	c.activeTransactionHasChanges = true
+	return c.nativeAPI.Configuration.DeleteFilter(index, "frontend", frontend, c.activeTransaction, 0)
+}
+
+func (c *clientNative) FrontendFilterDeleteAll(frontend string) {
+	c.activeTransactionHasChanges = true
+	for {
+		err := c.nativeAPI.Configuration.DeleteFilter(0, "frontend", frontend, c.activeTransaction, 0)
+		if err != nil {
+			break
+		}
+	}
+}
+
+// FrontendStatsGet returns the stats settings of the frontend, e.g. "uri /", without "stats" keyword
+func (c *clientNative) FrontendStatsGet(frontendName string) ([]string, error) {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return nil, err
+	}
+	data, err := config.Get(parser.Frontends, frontendName, "stats")
+	if err != nil {
+		// No stats setting
+		return nil, nil
+	}
+	settings, _ := data.([]types.StatsSettings)
+	values := make([]string, 0, len(settings))
+	for _, s := range settings {
+		values = append(values, s.String())
+	}
+	return values, nil
+}
+
+// FrontendStatsSet replaces the stats settings of the frontend, stats authentication
+// being not supported by client-native they are handled with config-parser.
+func (c *clientNative) FrontendStatsSet(frontendName string, values []string) error {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return err
+	}
+	settings := make([]types.StatsSettings, 0, len(values))
+	for _, value := range values {
+		parts := append([]string{"stats"}, strings.Fields(value)...)
+		if len(parts) < 2 {
+			continue
+		}
+		var setting types.StatsSettings
+		switch parts[1] {
+		case "enable", "hide-version", "show-legends":
+			setting = &stats.OneWord{}
+		case "uri":
+			setting = &stats.URI{}
+		case "refresh":
+			setting = &stats.Refresh{}
+		case "realm":
+			setting = &stats.Realm{}
+		case "auth":
+			setting = &stats.Auth{}
+		default:
+			return fmt.Errorf("unsupported stats setting '%s'", parts[1])
+		}
+		if err = setting.Parse(parts, ""); err != nil {
+			return fmt.Errorf("stats %s: %w", parts[1], err)
+		}
+		settings = append(settings, setting)
+	}
+	c.activeTransactionHasChanges = true
+	if len(settings) == 0 {
+		return config.Set(parser.Frontends, frontendName, "stats", nil)
+	}
+	return config.Set(parser.Frontends, frontendName, "stats", settings)
+}
@@ -21,6 +21,9 @@ func (c *clientNative) GlobalCfgSnippet(value *types.StringSliceC) error {
 		return err
 	}
 	err = config.Set(parser.Global, parser.GlobalSectionName, "config-snippet", value)
+	if err == nil {
+		c.activeTransactionHasChanges = true
+	}
 	return err
 }
 
@@ -49,3 +52,62 @@ func (c *clientNative) GlobalGetConfiguration() (global *models.Global, err erro
 func (c *clientNative) GlobalPushConfiguration(global *models.Global) error {
 	return c.nativeAPI.Configuration.PushGlobalConfiguration(global, c.activeTransaction, 0)
 }
+
+func (c *clientNative) GlobalLocalPeerGet() (name string, err error) {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return "", err
+	}
+	data, err := config.Get(parser.Global, parser.GlobalSectionName, "localpeer")
+	if err != nil {
+		// localpeer is not set
+		return "", nil
+	}
+	localPeer, ok := data.(*types.StringC)
+	if !ok {
+		return "", nil
+	}
+	return localPeer.Value, nil
+}
+
+func (c *clientNative) GlobalLocalPeerSet(name string) error {
+	c.activeTransactionHasChanges = true
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return err
+	}
+	return config.Set(parser.Global, parser.GlobalSectionName, "localpeer", &types.StringC{Value: name})
+}
+
+func (c *clientNative) GlobalLuaLoadsGet() (files []string, err error) {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return nil, err
+	}
+	data, err := config.Get(parser.Global, parser.GlobalSectionName, "lua-load")
+	if err != nil {
+		// no lua-load
+		return nil, nil
+	}
+	luaLoads, _ := data.([]types.LuaLoad)
+	for _, luaLoad := range luaLoads {
+		files = append(files, luaLoad.File)
+	}
+	return files, nil
+}
+
+func (c *clientNative) GlobalLuaLoadsSet(files []string) error {
+	c.activeTransactionHasChanges = true
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return config.Set(parser.Global, parser.GlobalSectionName, "lua-load", nil)
+	}
+	luaLoads := make([]types.LuaLoad, 0, len(files))
+	for _, file := range files {
+		luaLoads = append(luaLoads, types.LuaLoad{File: file})
+	}
+	return config.Set(parser.Global, parser.GlobalSectionName, "lua-load", luaLoads)
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	parser "github.com/haproxytech/config-parser/v4"
+)
+
+// http-errors sections are not part of client-native models and config-parser
+// keeps their directives as unprocessed lines, thus they are handled as such.
+
+// HTTPErrorsGet returns the directives of the http-errors section, nil when the section does not exist
+func (c *clientNative) HTTPErrorsGet(name string) ([]string, error) {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return nil, err
+	}
+	if !sectionExists(config, parser.HTTPErrors, name) {
+		return nil, nil
+	}
+	return c.unprocessedLinesGet(parser.HTTPErrors, name, "")
+}
+
+// HTTPErrorsSet creates the http-errors section or replaces its directives
+func (c *clientNative) HTTPErrorsSet(name string, lines []string) error {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return err
+	}
+	if !sectionExists(config, parser.HTTPErrors, name) {
+		c.activeTransactionHasChanges = true
+		if err = config.SectionsCreate(parser.HTTPErrors, name); err != nil {
+			return err
+		}
+	}
+	return c.unprocessedLinesSet(parser.HTTPErrors, name, "", lines)
+}
+
+func (c *clientNative) HTTPErrorsDelete(name string) error {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return err
+	}
+	if !sectionExists(config, parser.HTTPErrors, name) {
+		return nil
+	}
+	c.activeTransactionHasChanges = true
+	return config.SectionsDelete(parser.HTTPErrors, name)
+}
@@ -0,0 +1,23 @@
+package api
+
+// Log-forward sections are unknown to config-parser, which would keep their
+// header and directives as unprocessed lines of the preceding section. They are
+// thus written as the lines of a ring section without directives of its own, each
+// "log-forward" header ending the ring section in HAProxy configuration.
+
+// LogForwardRing is the ring section carrying log-forward sections
+const LogForwardRing = "log-forwards"
+
+// LogForwardsGet returns the lines of the log-forward sections, headers included
+func (c *clientNative) LogForwardsGet() ([]string, error) {
+	return c.RingGet(LogForwardRing)
+}
+
+// LogForwardsSet replaces the log-forward sections by the given lines, each
+// section starting with its "log-forward <name>" header.
+func (c *clientNative) LogForwardsSet(lines []string) error {
+	if len(lines) == 0 {
+		return c.RingDelete(LogForwardRing)
+	}
+	return c.RingSet(LogForwardRing, lines)
+}
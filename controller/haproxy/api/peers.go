@@ -0,0 +1,20 @@
+package api
+
+import (
+	"github.com/haproxytech/client-native/v2/models"
+)
+
+func (c *clientNative) PeerEntriesGet(peerSection string) (models.PeerEntries, error) {
+	_, peers, err := c.nativeAPI.Configuration.GetPeerEntries(peerSection, c.activeTransaction)
+	return peers, err
+}
+
+func (c *clientNative) PeerEntryCreate(peerSection string, peer models.PeerEntry) error {
+	c.activeTransactionHasChanges = true
+	return c.nativeAPI.Configuration.CreatePeerEntry(peerSection, &peer, c.activeTransaction, 0)
+}
+
+func (c *clientNative) PeerEntryDelete(peerSection string, name string) error {
+	c.activeTransactionHasChanges = true
+	return c.nativeAPI.Configuration.DeletePeerEntry(name, peerSection, c.activeTransaction, 0)
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"github.com/haproxytech/client-native/v2/models"
+)
+
+func (c *clientNative) ResolversGet() (models.Resolvers, error) {
+	_, resolvers, err := c.nativeAPI.Configuration.GetResolvers(c.activeTransaction)
+	return resolvers, err
+}
+
+func (c *clientNative) ResolverGet(name string) (*models.Resolver, error) {
+	_, resolver, err := c.nativeAPI.Configuration.GetResolver(name, c.activeTransaction)
+	if err != nil {
+		return nil, err
+	}
+	return resolver, nil
+}
+
+func (c *clientNative) ResolverCreate(resolver models.Resolver) error {
+	c.activeTransactionHasChanges = true
+	return c.nativeAPI.Configuration.CreateResolver(&resolver, c.activeTransaction, 0)
+}
+
+func (c *clientNative) ResolverEdit(resolver models.Resolver) error {
+	c.activeTransactionHasChanges = true
+	return c.nativeAPI.Configuration.EditResolver(resolver.Name, &resolver, c.activeTransaction, 0)
+}
+
+func (c *clientNative) ResolverDelete(name string) error {
+	c.activeTransactionHasChanges = true
+	return c.nativeAPI.Configuration.DeleteResolver(name, c.activeTransaction, 0)
+}
+
+func (c *clientNative) NameserversGet(resolverName string) (models.Nameservers, error) {
+	_, nameservers, err := c.nativeAPI.Configuration.GetNameservers(resolverName, c.activeTransaction)
+	return nameservers, err
+}
+
+func (c *clientNative) NameserverCreate(resolverName string, nameserver models.Nameserver) error {
+	c.activeTransactionHasChanges = true
+	return c.nativeAPI.Configuration.CreateNameserver(resolverName, &nameserver, c.activeTransaction, 0)
+}
+
+func (c *clientNative) NameserverDelete(resolverName string, name string) error {
+	c.activeTransactionHasChanges = true
+	return c.nativeAPI.Configuration.DeleteNameserver(name, resolverName, c.activeTransaction, 0)
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	parser "github.com/haproxytech/config-parser/v4"
+)
+
+// Ring sections are not part of client-native models and config-parser
+// keeps their directives as unprocessed lines, thus they are handled as such.
+
+// RingGet returns the directives of the ring section, nil when the ring does not exist
+func (c *clientNative) RingGet(name string) ([]string, error) {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return nil, err
+	}
+	if !sectionExists(config, parser.Ring, name) {
+		return nil, nil
+	}
+	return c.unprocessedLinesGet(parser.Ring, name, "")
+}
+
+// RingSet creates the ring section or replaces its directives
+func (c *clientNative) RingSet(name string, lines []string) error {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return err
+	}
+	if !sectionExists(config, parser.Ring, name) {
+		c.activeTransactionHasChanges = true
+		if err = config.SectionsCreate(parser.Ring, name); err != nil {
+			return err
+		}
+	}
+	return c.unprocessedLinesSet(parser.Ring, name, "", lines)
+}
+
+func (c *clientNative) RingDelete(name string) error {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return err
+	}
+	if !sectionExists(config, parser.Ring, name) {
+		return nil
+	}
+	c.activeTransactionHasChanges = true
+	return config.SectionsDelete(parser.Ring, name)
+}
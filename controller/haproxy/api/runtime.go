@@ -1,16 +1,31 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/haproxytech/client-native/v2/models"
 
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
 	"github.com/haproxytech/kubernetes-ingress/controller/utils"
 )
 
+// Maximum size of a map payload sent in a single runtime command
+const mapPayloadMaxSize = 8192
+
 func (c *clientNative) ExecuteRaw(command string) (result []string, err error) {
 	return c.nativeAPI.Runtime.ExecuteRaw(command)
 }
 
+// BackendServersStateGet returns the runtime state of backend servers,
+// which may differ from their configuration after runtime updates.
+func (c *clientNative) BackendServersStateGet(backendName string) (models.RuntimeServers, error) {
+	return c.nativeAPI.Runtime.GetServersState(backendName)
+}
+
 func (c *clientNative) SetServerAddr(backendName string, serverName string, ip string, port int) error {
 	return c.nativeAPI.Runtime.SetServerAddr(backendName, serverName, ip, port)
 }
@@ -19,6 +34,10 @@ func (c *clientNative) SetServerState(backendName string, serverName string, sta
 	return c.nativeAPI.Runtime.SetServerState(backendName, serverName, state)
 }
 
+func (c *clientNative) SetServerWeight(backendName string, serverName string, weight string) error {
+	return c.nativeAPI.Runtime.SetServerWeight(backendName, serverName, weight)
+}
+
 func (c *clientNative) SetMapContent(mapFile string, payload string) error {
 	err := c.nativeAPI.Runtime.ClearMap(mapFile, false)
 	if err != nil {
@@ -27,28 +46,105 @@ func (c *clientNative) SetMapContent(mapFile string, payload string) error {
 	return c.nativeAPI.Runtime.AddMapPayload(mapFile, payload)
 }
 
+// SetMapContentVersioned replaces the content of a map loaded by HAProxy with the given rows.
+// Rows are added to a new version of the map which is committed once complete,
+// so lookups never see a partially updated map.
+func (c *clientNative) SetMapContentVersioned(mapFile string, rows []string) error {
+	result, err := c.ExecuteRaw("prepare map " + mapFile)
+	if err != nil {
+		return err
+	}
+	if len(result) != 1 {
+		return fmt.Errorf("prepare map: unexpected number of runtime results: %d", len(result))
+	}
+	// Expected response: "New version created: <version>"
+	parts := strings.Split(strings.TrimSpace(result[0]), ":")
+	version := strings.TrimSpace(parts[len(parts)-1])
+	if _, err = strconv.ParseUint(version, 10, 64); len(parts) != 2 || err != nil {
+		return fmt.Errorf("prepare map: %s", strings.TrimSpace(result[0]))
+	}
+	var payload strings.Builder
+	addPayload := func() error {
+		if payload.Len() == 0 {
+			return nil
+		}
+		defer payload.Reset()
+		return c.runtimeExecute(fmt.Sprintf("add map @%s %s <<\n%s\n", version, mapFile, payload.String()))
+	}
+	for _, row := range rows {
+		if payload.Len()+len(row) >= mapPayloadMaxSize {
+			if err = addPayload(); err != nil {
+				return err
+			}
+		}
+		payload.WriteString(row)
+		payload.WriteByte('\n')
+	}
+	if err = addPayload(); err != nil {
+		return err
+	}
+	return c.runtimeExecute(fmt.Sprintf("commit map @%s %s", version, mapFile))
+}
+
+// runtimeExecute executes a runtime command which does not output anything on success
+func (c *clientNative) runtimeExecute(command string) error {
+	result, err := c.ExecuteRaw(command)
+	if err != nil {
+		return err
+	}
+	for _, r := range result {
+		if r = strings.TrimSpace(r); r != "" {
+			return errors.New(r)
+		}
+	}
+	return nil
+}
+
 func (c *clientNative) GetMap(mapFile string) (*models.Map, error) {
 	return c.nativeAPI.Runtime.GetMap(mapFile)
 }
 
 // SyncBackendSrvs syncs states and addresses of a backend servers with corresponding endpoints.
+// Dedicated backends of ingress paths sharing the same servers are synced as well.
 func (c *clientNative) SyncBackendSrvs(oldEndpoints, newEndpoints *store.PortEndpoints) error {
-	if oldEndpoints.BackendName == "" {
+	var backends []string
+	if oldEndpoints.BackendName != "" {
+		backends = append(backends, oldEndpoints.BackendName)
+	}
+	for backend := range oldEndpoints.PathBackends {
+		backends = append(backends, backend)
+	}
+	if len(backends) == 0 {
 		return nil
 	}
 	newEndpoints.HAProxySrvs = oldEndpoints.HAProxySrvs
 	newEndpoints.BackendName = oldEndpoints.BackendName
+	newEndpoints.DrainDelay = oldEndpoints.DrainDelay
+	newEndpoints.PathBackends = oldEndpoints.PathBackends
 	haproxySrvs := newEndpoints.HAProxySrvs
 	newAddresses := newEndpoints.AddrNew
-	portChanged := newEndpoints.Port != oldEndpoints.Port
 	// Disable stale entries from HAProxySrvs
 	// and provide list of Disabled Srvs
 	var disabled []*store.HAProxySrv
 	var errors utils.Errors
 	for i, srv := range haproxySrvs {
-		srv.Modified = portChanged || srv.Modified
+		srv.Modified = oldEndpoints.AddrPort(srv.Address) != newEndpoints.AddrPort(srv.Address) || srv.Modified
 		if _, ok := newAddresses[srv.Address]; ok {
 			delete(newAddresses, srv.Address)
+			if !srv.DrainSince.IsZero() {
+				// Address is back before the end of drain
+				srv.DrainSince = time.Time{}
+				srv.Modified = true
+			}
+		} else if srv.Address != "" && newEndpoints.DrainDelay > 0 {
+			// Srv keeps its address until drain-delay expires,
+			// it is then disabled by the endpoints handler
+			if srv.DrainSince.IsZero() {
+				srv.DrainSince = time.Now()
+				for _, backend := range backends {
+					errors.Add(c.SetServerState(backend, srv.Name, "drain"))
+				}
+			}
 		} else {
 			haproxySrvs[i].Address = ""
 			haproxySrvs[i].Modified = true
@@ -72,19 +168,21 @@ func (c *clientNative) SyncBackendSrvs(oldEndpoints, newEndpoints *store.PortEnd
 		if !srv.Modified {
 			continue
 		}
-		if srv.Address == "" {
-			// logger.Tracef("server '%s/%s' changed status to %v", newEndpoints.BackendName, srv.Name, "maint")
-			addrErr = c.SetServerAddr(newEndpoints.BackendName, srv.Name, "127.0.0.1", 0)
-			stateErr = c.SetServerState(newEndpoints.BackendName, srv.Name, "maint")
-		} else {
-			// logger.Tracef("server '%s/%s' changed status to %v", newEndpoints.BackendName, srv.Name, "ready")
-			addrErr = c.SetServerAddr(newEndpoints.BackendName, srv.Name, srv.Address, int(newEndpoints.Port))
-			stateErr = c.SetServerState(newEndpoints.BackendName, srv.Name, "ready")
-		}
-		if addrErr != nil || stateErr != nil {
-			newEndpoints.DynUpdateFailed = true
-			errors.Add(addrErr)
-			errors.Add(stateErr)
+		for _, backend := range backends {
+			if srv.Address == "" {
+				// logger.Tracef("server '%s/%s' changed status to %v", backend, srv.Name, "maint")
+				addrErr = c.SetServerAddr(backend, srv.Name, "127.0.0.1", 0)
+				stateErr = c.SetServerState(backend, srv.Name, "maint")
+			} else {
+				// logger.Tracef("server '%s/%s' changed status to %v", backend, srv.Name, "ready")
+				addrErr = c.SetServerAddr(backend, srv.Name, srv.Address, int(newEndpoints.AddrPort(srv.Address)))
+				stateErr = c.SetServerState(backend, srv.Name, "ready")
+			}
+			if addrErr != nil || stateErr != nil {
+				newEndpoints.DynUpdateFailed = true
+				errors.Add(addrErr)
+				errors.Add(stateErr)
+			}
 		}
 	}
 	return errors.Result()
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	clientnative "github.com/haproxytech/client-native/v2"
+	"github.com/haproxytech/client-native/v2/runtime"
+)
+
+// fakeRuntime is a runtime socket recording the commands it receives, replied with respond
+type fakeRuntime struct {
+	respond  func(command string) string
+	commands []string
+	mu       sync.Mutex
+}
+
+func (r *fakeRuntime) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		reader := bufio.NewReader(conn)
+		command, _ := reader.ReadString('\n')
+		command = strings.TrimPrefix(command, "set severity-output number;")
+		// Payload of a command ends with an empty line
+		for strings.Contains(command, "<<") && !strings.HasSuffix(command, "\n\n") {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				break
+			}
+			command += line
+		}
+		command = strings.TrimSuffix(command, "\n")
+		r.mu.Lock()
+		r.commands = append(r.commands, command)
+		r.mu.Unlock()
+		_, _ = conn.Write([]byte(r.respond(command)))
+		conn.Close()
+	}
+}
+
+// newRuntimeClient returns a client of a fake runtime socket
+func newRuntimeClient(t *testing.T, respond func(command string) string) (*clientNative, *fakeRuntime) {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "haproxy-runtime-api.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	fake := &fakeRuntime{respond: respond}
+	go fake.serve(listener)
+	runtimeClient := runtime.Client{}
+	if err = runtimeClient.InitWithSockets(map[int]string{0: socket}); err != nil {
+		t.Fatal(err)
+	}
+	fake.mu.Lock()
+	fake.commands = nil
+	fake.mu.Unlock()
+	return &clientNative{nativeAPI: clientnative.HAProxyClient{Runtime: &runtimeClient}}, fake
+}
+
+// mapResponses replies to map commands, failing the one starting with failing
+func mapResponses(failing string) func(command string) string {
+	return func(command string) string {
+		if failing != "" && strings.HasPrefix(command, failing) {
+			return "Unknown map identifier.\n"
+		}
+		if strings.HasPrefix(command, "prepare map") {
+			return "New version created: 3\n"
+		}
+		return "\n"
+	}
+}
+
+func TestSetMapContentVersioned(t *testing.T) {
+	var rows []string
+	for i := 0; i < 1000; i++ {
+		rows = append(rows, fmt.Sprintf("example.com/path-%04d backend-%04d", i, i))
+	}
+	tests := []struct {
+		name    string
+		rows    []string
+		failing string
+		adds    int
+		commit  bool
+	}{
+		{name: "empty map", commit: true},
+		{name: "single payload", rows: rows[:10], adds: 1, commit: true},
+		{name: "split payloads", rows: rows, adds: 5, commit: true},
+		{name: "prepare failed", rows: rows, failing: "prepare map"},
+		{name: "add failed", rows: rows, failing: "add map", adds: 1},
+		{name: "commit failed", rows: rows[:10], failing: "commit map", adds: 1, commit: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, fake := newRuntimeClient(t, mapResponses(test.failing))
+			err := client.SetMapContentVersioned("/etc/haproxy/maps/host.map", test.rows)
+			if (err != nil) != (test.failing != "") {
+				t.Errorf("SetMapContentVersioned() error = %v, want failure %t", err, test.failing != "")
+			}
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if len(fake.commands) == 0 || fake.commands[0] != "prepare map /etc/haproxy/maps/host.map" {
+				t.Fatalf("commands %q, want a prepare map first", fake.commands)
+			}
+			var payload strings.Builder
+			adds, commit := 0, false
+			for _, command := range fake.commands[1:] {
+				switch {
+				case strings.HasPrefix(command, "add map @3 /etc/haproxy/maps/host.map <<\n"):
+					adds++
+					if len(command) > mapPayloadMaxSize+100 {
+						t.Errorf("add map command of %d bytes", len(command))
+					}
+					payload.WriteString(strings.TrimPrefix(command, "add map @3 /etc/haproxy/maps/host.map <<\n"))
+				case command == "commit map @3 /etc/haproxy/maps/host.map":
+					commit = true
+				default:
+					t.Errorf("unexpected command %q", command)
+				}
+			}
+			if adds != test.adds || commit != test.commit {
+				t.Errorf("%d add map and commit %t, want %d and %t", adds, commit, test.adds, test.commit)
+			}
+			if test.failing == "" {
+				if want := strings.Join(test.rows, "\n"); strings.TrimSuffix(payload.String(), "\n") != want {
+					t.Errorf("added rows differ from the map rows")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	parser "github.com/haproxytech/config-parser/v4"
+	"github.com/haproxytech/config-parser/v4/types"
+)
+
+func (c *clientNative) BackendCfgSnippetGet(backendName string) ([]string, error) {
+	return c.cfgSnippetGet(parser.Backends, backendName)
+}
+
+func (c *clientNative) FrontendCfgSnippetGet(frontendName string) ([]string, error) {
+	return c.cfgSnippetGet(parser.Frontends, frontendName)
+}
+
+func (c *clientNative) GlobalCfgSnippetGet() ([]string, error) {
+	return c.cfgSnippetGet(parser.Global, parser.GlobalSectionName)
+}
+
+// cfgSnippetGet returns the config snippet lines of the section, nil if none
+func (c *clientNative) cfgSnippetGet(section parser.Section, sectionName string) ([]string, error) {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return nil, err
+	}
+	data, err := config.Get(section, sectionName, "config-snippet")
+	if err != nil {
+		// No config snippet
+		return nil, nil
+	}
+	snippet, ok := data.(*types.StringSliceC)
+	if !ok || snippet == nil {
+		return nil, nil
+	}
+	return snippet.Value, nil
+}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	parser "github.com/haproxytech/config-parser/v4"
+	"github.com/haproxytech/config-parser/v4/common"
+	"github.com/haproxytech/config-parser/v4/types"
+)
+
+// Directives unsupported by config-parser are kept as unprocessed lines
+// of their section, these helpers manage such directives by keyword.
+
+func (c *clientNative) GlobalH1CaseAdjustFileGet() (file string, err error) {
+	line, err := c.unprocessedLineGet(parser.Global, parser.GlobalSectionName, "h1-case-adjust-file ")
+	return strings.TrimSpace(strings.TrimPrefix(line, "h1-case-adjust-file ")), err
+}
+
+func (c *clientNative) GlobalH1CaseAdjustFileSet(file string) error {
+	line := ""
+	if file != "" {
+		line = "h1-case-adjust-file " + file
+	}
+	return c.unprocessedLineSet(parser.Global, parser.GlobalSectionName, "h1-case-adjust-file ", line)
+}
+
+func (c *clientNative) GlobalThreadGroupsGet() (groups string, err error) {
+	line, err := c.unprocessedLineGet(parser.Global, parser.GlobalSectionName, "thread-groups ")
+	return strings.TrimSpace(strings.TrimPrefix(line, "thread-groups ")), err
+}
+
+// GlobalThreadGroupsSet sets the number of thread groups, threads being evenly spread among them
+func (c *clientNative) GlobalThreadGroupsSet(groups string) error {
+	line := ""
+	if groups != "" {
+		line = "thread-groups " + groups
+	}
+	return c.unprocessedLineSet(parser.Global, parser.GlobalSectionName, "thread-groups ", line)
+}
+
+// GlobalNumaCPUMappingGet returns "enabled" or "disabled" when numa-cpu-mapping is explicitly set
+func (c *clientNative) GlobalNumaCPUMappingGet() (mapping string, err error) {
+	line, err := c.unprocessedLineGet(parser.Global, parser.GlobalSectionName, "numa-cpu-mapping")
+	if err != nil || line != "" {
+		return "enabled", err
+	}
+	line, err = c.unprocessedLineGet(parser.Global, parser.GlobalSectionName, "no numa-cpu-mapping")
+	if line != "" {
+		return "disabled", err
+	}
+	return "", err
+}
+
+// GlobalNumaCPUMappingSet sets whether threads are bound to the CPUs of the first NUMA node
+// with active threads, mapping being "enabled", "disabled" or empty for HAProxy default.
+func (c *clientNative) GlobalNumaCPUMappingSet(mapping string) error {
+	enabled, disabled := "", ""
+	switch mapping {
+	case "enabled":
+		enabled = "numa-cpu-mapping"
+	case "disabled":
+		disabled = "no numa-cpu-mapping"
+	}
+	if err := c.unprocessedLineSet(parser.Global, parser.GlobalSectionName, "numa-cpu-mapping", enabled); err != nil {
+		return err
+	}
+	return c.unprocessedLineSet(parser.Global, parser.GlobalSectionName, "no numa-cpu-mapping", disabled)
+}
+
+// GlobalTuneGet returns the value of a tune.* global parameter,
+// numeric ones known to config-parser are not unprocessed lines.
+func (c *clientNative) GlobalTuneGet(name string) (value string, err error) {
+	if name == "tune.bufsize" || name == "tune.maxrewrite" {
+		var config parser.Parser
+		config, err = c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+		if err != nil {
+			return "", err
+		}
+		data, errGet := config.Get(parser.Global, parser.GlobalSectionName, name)
+		if errGet != nil {
+			return "", nil
+		}
+		if number, ok := data.(*types.Int64C); ok {
+			return strconv.FormatInt(number.Value, 10), nil
+		}
+		return "", nil
+	}
+	line, err := c.unprocessedLineGet(parser.Global, parser.GlobalSectionName, name+" ")
+	return strings.TrimSpace(strings.TrimPrefix(line, name+" ")), err
+}
+
+// GlobalTuneSet sets the value of a tune.* global parameter, removing it when value is empty
+func (c *clientNative) GlobalTuneSet(name, value string) error {
+	if name == "tune.bufsize" || name == "tune.maxrewrite" {
+		config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+		if err != nil {
+			return err
+		}
+		var data common.ParserData
+		if value != "" {
+			number, errConv := strconv.ParseInt(value, 10, 64)
+			if errConv != nil {
+				return errConv
+			}
+			data = &types.Int64C{Value: number}
+		}
+		c.activeTransactionHasChanges = true
+		return config.Set(parser.Global, parser.GlobalSectionName, name, data)
+	}
+	line := ""
+	if value != "" {
+		line = name + " " + value
+	}
+	return c.unprocessedLineSet(parser.Global, parser.GlobalSectionName, name+" ", line)
+}
+
+func (c *clientNative) DefaultsErrorFilesGet() (section string, err error) {
+	line, err := c.unprocessedLineGet(parser.Defaults, parser.DefaultSectionName, "errorfiles ")
+	return strings.TrimSpace(strings.TrimPrefix(line, "errorfiles ")), err
+}
+
+// DefaultsErrorFilesSet makes proxies serve the errorfiles of the given http-errors section
+func (c *clientNative) DefaultsErrorFilesSet(section string) error {
+	line := ""
+	if section != "" {
+		line = "errorfiles " + section
+	}
+	return c.unprocessedLineSet(parser.Defaults, parser.DefaultSectionName, "errorfiles ", line)
+}
+
+func (c *clientNative) FrontendDisabledGet(frontendName string) (disabled bool, err error) {
+	line, err := c.unprocessedLineGet(parser.Frontends, frontendName, "disabled")
+	return line != "", err
+}
+
+// FrontendDisabledSet disables the frontend, which is then not started and doesn't listen
+func (c *clientNative) FrontendDisabledSet(frontendName string, disabled bool) error {
+	line := ""
+	if disabled {
+		line = "disabled"
+	}
+	return c.unprocessedLineSet(parser.Frontends, frontendName, "disabled", line)
+}
+
+func (c *clientNative) FrontendRateLimitSessionsGet(frontendName string) (rate string, err error) {
+	line, err := c.unprocessedLineGet(parser.Frontends, frontendName, "rate-limit sessions ")
+	return strings.TrimSpace(strings.TrimPrefix(line, "rate-limit sessions ")), err
+}
+
+// FrontendRateLimitSessionsSet limits the rate at which the frontend accepts new sessions per second,
+// connections above it being left in the system backlog.
+func (c *clientNative) FrontendRateLimitSessionsSet(frontendName string, rate string) error {
+	line := ""
+	if rate != "" {
+		line = "rate-limit sessions " + rate
+	}
+	return c.unprocessedLineSet(parser.Frontends, frontendName, "rate-limit sessions ", line)
+}
+
+func (c *clientNative) BackendH1CaseAdjustGet(backendName string) (enabled bool, err error) {
+	line, err := c.unprocessedLineGet(parser.Backends, backendName, "option h1-case-adjust-bogus-server")
+	return line != "", err
+}
+
+func (c *clientNative) BackendH1CaseAdjustSet(backendName string, enabled bool) error {
+	line := ""
+	if enabled {
+		line = "option h1-case-adjust-bogus-server"
+	}
+	return c.unprocessedLineSet(parser.Backends, backendName, "option h1-case-adjust-bogus-server", line)
+}
+
+func (c *clientNative) BackendTransparentProxyGet(backendName string) (enabled bool, err error) {
+	line, err := c.unprocessedLineGet(parser.Backends, backendName, "source ")
+	return line != "", err
+}
+
+// BackendTransparentProxySet makes backend connect to servers with the client
+// source address, which requires HAProxy to run with NET_ADMIN capability.
+func (c *clientNative) BackendTransparentProxySet(backendName string, enabled bool) error {
+	line := ""
+	if enabled {
+		line = "source 0.0.0.0 usesrc clientip"
+	}
+	return c.unprocessedLineSet(parser.Backends, backendName, "source ", line)
+}
+
+func (c *clientNative) BackendFullconnGet(backendName string) (fullconn string, err error) {
+	line, err := c.unprocessedLineGet(parser.Backends, backendName, "fullconn ")
+	return strings.TrimSpace(strings.TrimPrefix(line, "fullconn ")), err
+}
+
+// BackendFullconnSet sets the backend load at which servers reach their maxconn,
+// servers with minconn being dynamically limited below this load.
+func (c *clientNative) BackendFullconnSet(backendName string, fullconn string) error {
+	line := ""
+	if fullconn != "" {
+		line = "fullconn " + fullconn
+	}
+	return c.unprocessedLineSet(parser.Backends, backendName, "fullconn ", line)
+}
+
+func (c *clientNative) BackendRetryOnGet(backendName string) (conditions string, err error) {
+	line, err := c.unprocessedLineGet(parser.Backends, backendName, "retry-on ")
+	return strings.TrimSpace(strings.TrimPrefix(line, "retry-on ")), err
+}
+
+// BackendRetryOnSet sets the conditions on which failed requests are retried
+func (c *clientNative) BackendRetryOnSet(backendName string, conditions string) error {
+	line := ""
+	if conditions != "" {
+		line = "retry-on " + conditions
+	}
+	return c.unprocessedLineSet(parser.Backends, backendName, "retry-on ", line)
+}
+
+func (c *clientNative) BackendTCPCheckGet(backendName string) (rules []string, err error) {
+	return c.unprocessedLinesGet(parser.Backends, backendName, "tcp-check ")
+}
+
+// BackendTCPCheckSet sets the tcp-check rules of the backend, used by "option tcp-check"
+func (c *clientNative) BackendTCPCheckSet(backendName string, rules []string) error {
+	return c.unprocessedLinesSet(parser.Backends, backendName, "tcp-check ", rules)
+}
+
+// unprocessedLineGet returns the unprocessed line of the section starting with prefix
+func (c *clientNative) unprocessedLineGet(section parser.Section, sectionName, prefix string) (string, error) {
+	lines, err := c.unprocessedLinesGet(section, sectionName, prefix)
+	if err != nil || len(lines) == 0 {
+		return "", err
+	}
+	return lines[0], nil
+}
+
+// unprocessedLineSet replaces the unprocessed lines of the section starting with
+// prefix by the given line, they are only removed when line is empty.
+func (c *clientNative) unprocessedLineSet(section parser.Section, sectionName, prefix, line string) error {
+	var lines []string
+	if line != "" {
+		lines = []string{line}
+	}
+	return c.unprocessedLinesSet(section, sectionName, prefix, lines)
+}
+
+// unprocessedLinesGet returns the unprocessed lines of the section starting with prefix
+func (c *clientNative) unprocessedLinesGet(section parser.Section, sectionName, prefix string) ([]string, error) {
+	lines, err := c.unprocessedLines(section, sectionName)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, line := range lines {
+		if strings.HasPrefix(line.Value, prefix) {
+			values = append(values, line.Value)
+		}
+	}
+	return values, nil
+}
+
+// unprocessedLinesSet replaces the unprocessed lines of the section starting with
+// prefix by the given lines, in the same order.
+func (c *clientNative) unprocessedLinesSet(section parser.Section, sectionName, prefix string, values []string) error {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return err
+	}
+	lines, err := c.unprocessedLines(section, sectionName)
+	if err != nil {
+		return err
+	}
+	newLines := make([]types.UnProcessed, 0, len(lines)+len(values))
+	for _, l := range lines {
+		if !strings.HasPrefix(l.Value, prefix) {
+			newLines = append(newLines, l)
+		}
+	}
+	for _, value := range values {
+		newLines = append(newLines, types.UnProcessed{Value: value})
+	}
+	c.activeTransactionHasChanges = true
+	if len(newLines) == 0 {
+		return config.Set(section, sectionName, "", nil)
+	}
+	return config.Set(section, sectionName, "", newLines)
+}
+
+func (c *clientNative) unprocessedLines(section parser.Section, sectionName string) ([]types.UnProcessed, error) {
+	config, err := c.nativeAPI.Configuration.GetParser(c.activeTransaction)
+	if err != nil {
+		return nil, err
+	}
+	data, err := config.Get(section, sectionName, "")
+	if err != nil {
+		// No unprocessed line
+		return nil, nil
+	}
+	lines, _ := data.([]types.UnProcessed)
+	return lines, nil
+}
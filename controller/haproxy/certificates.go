@@ -1,20 +1,68 @@
 package haproxy
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"path"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
 )
 
 type Certificates struct {
-	frontend map[string]*cert
-	backend  map[string]*cert
-	ca       map[string]*cert
+	frontend   map[string]*cert
+	backend    map[string]*cert
+	ca         map[string]*cert
+	clientAuth map[string]clientAuth
+	// hostCrts are the frontend certificates of ingresses TLS hosts
+	hostCrts map[string]string
+	// hostTLS are the TLS settings of hosts defined by TLS policies
+	hostTLS map[string]TLSOptions
+}
+
+// TLSOptions are the TLS settings of a frontend host, set in its crt-list line
+type TLSOptions struct {
+	MinVersion   string
+	Ciphers      string
+	Ciphersuites string
+	CAFile       string
+	Verify       string
+}
+
+// String returns the crt-list SSL options of the settings
+func (o TLSOptions) String() string {
+	var options []string
+	for _, option := range []struct{ keyword, value string }{
+		{"ssl-min-ver", o.MinVersion},
+		{"ciphers", o.Ciphers},
+		{"ciphersuites", o.Ciphersuites},
+		{"ca-file", o.CAFile},
+		{"verify", o.Verify},
+	} {
+		if option.value != "" {
+			options = append(options, option.keyword+" "+option.value)
+		}
+	}
+	return strings.Join(options, " ")
+}
+
+// clientAuth holds client certificate authentication settings of a frontend host
+type clientAuth struct {
+	crtPath string
+	caPath  string
+	verify  string
 }
 
 type cert struct {
@@ -51,9 +99,12 @@ func NewCertificates(caDir, ftDir, bdDir string) *Certificates {
 	backendCertDir = bdDir
 	caCertDir = caDir
 	return &Certificates{
-		frontend: make(map[string]*cert),
-		backend:  make(map[string]*cert),
-		ca:       make(map[string]*cert),
+		frontend:   make(map[string]*cert),
+		backend:    make(map[string]*cert),
+		ca:         make(map[string]*cert),
+		clientAuth: make(map[string]clientAuth),
+		hostCrts:   make(map[string]string),
+		hostTLS:    make(map[string]TLSOptions),
 	}
 }
 
@@ -124,6 +175,177 @@ func (c *Certificates) Clean() {
 		c.ca[i].inUse = false
 		c.ca[i].updated = false
 	}
+	c.clientAuth = make(map[string]clientAuth)
+	c.hostCrts = make(map[string]string)
+	c.hostTLS = make(map[string]TLSOptions)
+}
+
+// denyAllCAName is the name of the CA certificate of DenyAllCA
+const denyAllCAName = "deny-all"
+
+// DenyAllCA returns the path of a CA certificate whose private key is discarded once generated,
+// so that no client certificate is verified by it. Hosts requiring client authentication whose
+// CA is not available use it to reject all clients rather than accepting them unauthenticated.
+func (c *Certificates) DenyAllCA() (caPath string, err error) {
+	if crt, ok := c.ca[denyAllCAName]; ok {
+		crt.inUse = true
+		return crt.path, nil
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "haproxy-ingress deny-all CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(100, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return "", err
+	}
+	crt := &cert{
+		path:    path.Join(caCertDir, denyAllCAName+".pem"),
+		name:    denyAllCAName,
+		inUse:   true,
+		updated: true,
+	}
+	if err = writeCert(crt.path, []byte(""), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})); err != nil {
+		return "", err
+	}
+	c.ca[denyAllCAName] = crt
+	return crt.path, nil
+}
+
+// AddHostCrt sets crtPath as the frontend certificate of host, from the TLS section of an ingress
+func (c *Certificates) AddHostCrt(host, crtPath string) {
+	if host != "" {
+		c.hostCrts[host] = crtPath
+	}
+}
+
+// SetHostTLS applies the TLS settings of options to host, which may be a wildcard host
+// applying them to the subdomains that have no settings of their own.
+func (c *Certificates) SetHostTLS(host string, options TLSOptions) {
+	c.hostTLS[host] = options
+}
+
+// matchHostTLS returns the TLS settings of host, or the ones of the wildcard host matching it
+func (c *Certificates) matchHostTLS(host string) (options TLSOptions, ok bool) {
+	if options, ok = c.hostTLS[host]; ok {
+		return options, true
+	}
+	for pattern, options := range c.hostTLS {
+		if utils.MatchHost(pattern, host) {
+			return options, true
+		}
+	}
+	return options, false
+}
+
+// hostCrt returns the certificate served for host: its own, the one of the wildcard
+// TLS host matching it, or the default certificate.
+func (c *Certificates) hostCrt(host, defaultCrt string) string {
+	if crtPath, ok := c.hostCrts[host]; ok {
+		return crtPath
+	}
+	for tlsHost, crtPath := range c.hostCrts {
+		if utils.MatchHost(tlsHost, host) {
+			return crtPath
+		}
+	}
+	return defaultCrt
+}
+
+// AddClientAuth requires clients connecting to host to present a certificate verified
+// against caPath, crtPath being the frontend certificate served for host.
+func (c *Certificates) AddClientAuth(host, crtPath, caPath string, optional bool) {
+	verify := "required"
+	if optional {
+		verify = "optional"
+	}
+	c.clientAuth[host] = clientAuth{
+		crtPath: crtPath,
+		caPath:  caPath,
+		verify:  verify,
+	}
+}
+
+// FrontendCrtList returns the lines of a crt-list loading all frontend certificates
+// with client authentication settings for hosts configured with AddClientAuth, and
+// the settings of hosts configured with SetHostTLS, resolved by hostname.
+// It returns nil when no host has specific settings, in which case
+// certificates directory can be used instead.
+func (c *Certificates) FrontendCrtList() (lines []string) {
+	if len(c.clientAuth) == 0 && len(c.hostTLS) == 0 {
+		return nil
+	}
+	var defaultCrts, crts, hosts []string
+	for name, crt := range c.frontend {
+		if !crt.inUse {
+			continue
+		}
+		if strings.HasPrefix(name, "0_") {
+			defaultCrts = append(defaultCrts, crt.path)
+		} else {
+			crts = append(crts, crt.path)
+		}
+	}
+	sort.Strings(defaultCrts)
+	sort.Strings(crts)
+	var defaultCrt string
+	if len(defaultCrts) != 0 {
+		defaultCrt = defaultCrts[0]
+	}
+	hostLines := make(map[string]string)
+	for host, options := range c.hostTLS {
+		if crtPath := c.hostCrt(host, defaultCrt); crtPath != "" {
+			hostLines[host] = fmt.Sprintf("%s [%s] %s", crtPath, options, host)
+		}
+	}
+	// Hosts with their own certificate are not matched by the line of a wildcard host
+	for host, crtPath := range c.hostCrts {
+		if _, ok := hostLines[host]; ok {
+			continue
+		}
+		if options, ok := c.matchHostTLS(host); ok {
+			hostLines[host] = fmt.Sprintf("%s [%s] %s", crtPath, options, host)
+		}
+	}
+	for host, auth := range c.clientAuth {
+		options, _ := c.matchHostTLS(host)
+		// Client authentication of TLS policies prevails over ingresses one
+		if options.CAFile == "" {
+			options.CAFile, options.Verify = auth.caPath, auth.verify
+		}
+		hostLines[host] = fmt.Sprintf("%s [%s] %s", auth.crtPath, options, host)
+	}
+	for host, line := range hostLines {
+		// Settings without options are the ones of the bind line
+		if strings.Contains(line, " [] ") {
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+	sort.Strings(hosts)
+	// First certificate is the default one, then host specific lines
+	// come first to take precedence over certificates own SNI names.
+	lines = append(lines, defaultCrts...)
+	for _, host := range hosts {
+		lines = append(lines, hostLines[host])
+	}
+	return append(lines, crts...)
 }
 
 func (c *Certificates) FrontendCertsEnabled() bool {
@@ -15,12 +15,16 @@
 package haproxy
 
 import (
+	"bufio"
 	"hash/fnv"
 	"os"
 	"path"
+	"path/filepath"
 	"sort"
 	"strings"
 
+	"github.com/google/renameio"
+
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
 )
 
@@ -30,43 +34,110 @@ var mapDir string
 
 //nolint:golint,stylecheck
 const (
-	MAP_SNI         = "sni"
-	MAP_HOST        = "host"
-	MAP_PATH_EXACT  = "path-exact"
-	MAP_PATH_PREFIX = "path-prefix"
+	MAP_SNI               = "sni"
+	MAP_HOST              = "host"
+	MAP_PATH_EXACT        = "path-exact"
+	MAP_PATH_PREFIX_EXACT = "path-prefix-exact"
+	MAP_PATH_PREFIX       = "path-prefix"
 )
 
+// RoutingMaps are the maps of the host and path routes of a frontend
+var RoutingMaps = []string{MAP_HOST, MAP_PATH_EXACT, MAP_PATH_PREFIX_EXACT, MAP_PATH_PREFIX}
+
 type mapFile struct {
 	rows     []string
 	hash     uint64
 	preserve bool
 }
 
-func (mf *mapFile) getContent() (string, uint64) {
-	var b strings.Builder
-	sort.Strings(mf.rows)
+// getHash sorts the map rows and returns their hash. Rows are sorted by decreasing
+// key length, map_beg lookups returning the first matching row: the longest prefix.
+func (mf *mapFile) getHash() uint64 {
+	sort.Slice(mf.rows, func(i, j int) bool {
+		keyI, keyJ := mapKey(mf.rows[i]), mapKey(mf.rows[j])
+		if len(keyI) != len(keyJ) {
+			return len(keyI) > len(keyJ)
+		}
+		return mf.rows[i] < mf.rows[j]
+	})
+	h := fnv.New64a()
 	for _, r := range mf.rows {
-		b.WriteString(r)
-		b.WriteRune('\n')
+		_, _ = h.Write([]byte(r))
+		_, _ = h.Write([]byte{'\n'})
 	}
-	content := b.String()
-	h := fnv.New64a()
-	_, _ = h.Write([]byte(content))
-	return content, h.Sum64()
+	return h.Sum64()
+}
+
+// mapKey returns the key of a map row
+func mapKey(row string) string {
+	if i := strings.IndexAny(row, " \t"); i != -1 {
+		return row[:i]
+	}
+	return row
+}
+
+// write streams the map rows to a temporary file which is then renamed to filename,
+// so HAProxy never reads a partially written map file.
+func (mf *mapFile) write(filename string) error {
+	f, err := renameio.TempFile(filepath.Dir(filename), filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Cleanup()
+	}()
+	if err = f.Chmod(0644); err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, r := range mf.rows {
+		if _, err = w.WriteString(r); err != nil {
+			return err
+		}
+		if err = w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	return f.CloseAtomicallyReplace()
 }
 
 func NewMapFiles(path string) *Maps {
 	mapDir = path
 	var maps Maps = map[string]*mapFile{
 		// Map files required for HAProxy Rules
-		MAP_SNI:         {preserve: true},
-		MAP_HOST:        {preserve: true},
-		MAP_PATH_EXACT:  {preserve: true},
-		MAP_PATH_PREFIX: {preserve: true},
+		MAP_SNI:               {preserve: true},
+		MAP_HOST:              {preserve: true},
+		MAP_PATH_EXACT:        {preserve: true},
+		MAP_PATH_PREFIX_EXACT: {preserve: true},
+		MAP_PATH_PREFIX:       {preserve: true},
 	}
 	return &maps
 }
 
+// FrontendMap returns the name of the map of an extra frontend,
+// main frontends using the map of the given name.
+func FrontendMap(name, extraFrontend string) string {
+	if extraFrontend == "" {
+		return name
+	}
+	return name + "-" + extraFrontend
+}
+
+// SetPreserve sets whether the map file is kept when empty,
+// as required for map files used by HAProxy rules.
+func (m Maps) SetPreserve(name string, preserve bool) {
+	if m[name] == nil {
+		if !preserve {
+			return
+		}
+		m[name] = &mapFile{}
+	}
+	m[name].preserve = preserve
+}
+
 func (m Maps) Exists(name string) bool {
 	return m[name] != nil && len(m[name].rows) != 0
 }
@@ -88,40 +159,37 @@ func (m Maps) Clean() {
 	}
 }
 
+// Refresh writes updated map files to disk.
+// Maps already loaded by HAProxy are also updated at runtime with an atomic
+// prepare/add/commit sequence, a reload is only required when it fails.
 func (m Maps) Refresh(client api.HAProxyClient) (reload bool) {
 	for name, mapFile := range m {
-		content, hash := mapFile.getContent()
+		hash := mapFile.getHash()
 		if mapFile.hash == hash {
 			continue
 		}
+		loaded := mapFile.hash != 0
 		mapFile.hash = hash
-		var f *os.File
-		var err error
 		filename := GetMapPath(name)
-		if content == "" && !mapFile.preserve {
+		if len(mapFile.rows) == 0 && !mapFile.preserve {
 			logger.Error(os.Remove(filename))
 			delete(m, name)
 			continue
-		} else if f, err = os.Create(filename); err != nil {
-			logger.Error(err)
-			continue
 		}
-		defer f.Close()
-		if _, err = f.WriteString(content); err != nil {
+		if err := mapFile.write(filename); err != nil {
 			logger.Error(err)
 			continue
 		}
-		logger.Error(f.Sync())
+		if loaded {
+			err := client.SetMapContentVersioned(filename, mapFile.rows)
+			if err == nil {
+				logger.Debugf("Map file '%s' updated at runtime", name)
+				continue
+			}
+			logger.Debugf("Map file '%s': runtime update failed: %s", name, err)
+		}
 		reload = true
 		logger.Debugf("Map file '%s' updated, reload required", name)
-		// if err = client.SetMapContent(name, content); err != nil {
-		// 	if strings.HasPrefix(err.Error(), "maps dir doesn't exists") {
-		// 		logger.Debugf("creating Map file %s", name)
-		// 	} else {
-		// 		logger.Warningf("dynamic update of '%s' Map file failed: %s", name, err.Error()[:200])
-		// 	}
-		// 	reload = true
-		// }
 	}
 	return reload
 }
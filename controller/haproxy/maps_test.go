@@ -0,0 +1,126 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package haproxy
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+)
+
+// fakeRuntime records the runtime map updates, failing them when err is set.
+// Other API calls panic.
+type fakeRuntime struct {
+	api.HAProxyClient
+	err     error
+	updates map[string][]string
+}
+
+func (c *fakeRuntime) SetMapContentVersioned(mapFile string, rows []string) error {
+	c.updates[mapFile] = append([]string(nil), rows...)
+	return c.err
+}
+
+// mapContent returns the rows of a map file on disk
+func mapContent(t *testing.T, name string) []string {
+	t.Helper()
+	content, err := os.ReadFile(GetMapPath(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+func TestMapsRefresh(t *testing.T) {
+	maps := NewMapFiles(t.TempDir())
+	prefixRows := []string{
+		"example.com/ default",
+		"example.com/api/v1 api-v1",
+		"example.com/b b",
+		"example.com/api api",
+		"example.com/a a",
+	}
+	// Longest prefixes first, rows of keys of the same length in lexical order
+	sortedRows := []string{
+		"example.com/api/v1 api-v1",
+		"example.com/api api",
+		"example.com/a a",
+		"example.com/b b",
+		"example.com/ default",
+	}
+	tests := []struct {
+		name    string
+		rows    []string
+		err     error
+		reload  bool
+		updated bool
+	}{
+		{name: "new map", rows: prefixRows, reload: true},
+		{name: "unchanged map", rows: sortedRows},
+		{name: "updated at runtime", rows: prefixRows[:3], updated: true},
+		{name: "runtime update failed", rows: prefixRows[1:], err: errors.New("unknown map"), reload: true, updated: true},
+		{name: "runtime update after reload", rows: prefixRows, updated: true},
+	}
+	for _, test := range tests {
+		client := &fakeRuntime{err: test.err, updates: make(map[string][]string)}
+		maps.Clean()
+		for _, row := range test.rows {
+			maps.AppendRow(MAP_PATH_PREFIX, row)
+		}
+		if reload := maps.Refresh(client); reload != test.reload {
+			t.Errorf("%s: Refresh() = %t, want %t", test.name, reload, test.reload)
+		}
+		var want []string
+		for _, row := range sortedRows {
+			for _, r := range test.rows {
+				if r == row {
+					want = append(want, row)
+				}
+			}
+		}
+		if rows := mapContent(t, MAP_PATH_PREFIX); !reflect.DeepEqual(rows, want) {
+			t.Errorf("%s: map file rows %q, want %q", test.name, rows, want)
+		}
+		rows, updated := client.updates[GetMapPath(MAP_PATH_PREFIX)]
+		if updated != test.updated || (updated && !reflect.DeepEqual(rows, want)) {
+			t.Errorf("%s: runtime update %t with %q, want %t with %q", test.name, updated, rows, test.updated, want)
+		}
+		if len(client.updates) > 1 {
+			t.Errorf("%s: %d maps updated at runtime, want only %s", test.name, len(client.updates), MAP_PATH_PREFIX)
+		}
+	}
+}
+
+func TestMapsPreserve(t *testing.T) {
+	maps := NewMapFiles(t.TempDir())
+	client := &fakeRuntime{updates: make(map[string][]string)}
+	maps.AppendRow("custom", "key value")
+	maps.Refresh(client)
+	maps.Clean()
+	maps.Refresh(client)
+	if _, err := os.Stat(GetMapPath("custom")); !os.IsNotExist(err) {
+		t.Errorf("empty custom map file not removed: %v", err)
+	}
+	if maps.Exists("custom") {
+		t.Error("empty custom map still exists")
+	}
+	if content := mapContent(t, MAP_HOST); len(content) != 1 || content[0] != "" {
+		t.Errorf("empty %s map file content %q, want it empty", MAP_HOST, content)
+	}
+}
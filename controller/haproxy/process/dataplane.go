@@ -0,0 +1,324 @@
+package process
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/configuration"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// dataplaneCrtList is the storage name of the crt-list replacing the frontend
+// certificates directory, files being flattened in Dataplane API general storage.
+const dataplaneCrtList = "dataplane-frontend.crt-list"
+
+// dataplaneControl configures HAProxy instances which are not run by the controller,
+// through their Dataplane API: the configuration file and the files it references
+// (certificates, maps, patterns, errorfiles...) are pushed on reload, the remote
+// Dataplane API then reloading HAProxy.
+type dataplaneControl struct {
+	Env        configuration.Env
+	OSArgs     utils.OSArgs
+	client     *http.Client
+	instances  []*dataplaneInstance
+	useAuxFile bool
+}
+
+type dataplaneInstance struct {
+	url      *url.URL
+	user     string
+	password string
+	// hashes of files in general storage and of the configuration last pushed
+	files      map[string]string
+	configHash string
+}
+
+func NewDataplaneControl(env configuration.Env, oSArgs utils.OSArgs) (Process, error) {
+	d := &dataplaneControl{
+		Env:    env,
+		OSArgs: oSArgs,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, rawURL := range oSArgs.DataplaneURLs {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("dataplane-url: %w", err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("dataplane-url: incorrect URL '%s'", u.Redacted())
+		}
+		instance := &dataplaneInstance{url: u}
+		if u.User != nil {
+			instance.user = u.User.Username()
+			instance.password, _ = u.User.Password()
+			u.User = nil
+		}
+		d.instances = append(d.instances, instance)
+	}
+	return d, nil
+}
+
+func (d *dataplaneControl) HaproxyService(action string) (err error) {
+	if d.OSArgs.Test {
+		logger.Infof("HAProxy would be %sed now", action)
+		return nil
+	}
+	switch action {
+	case "start":
+		// HAProxy instances are run by their own deployment
+		for _, instance := range d.instances {
+			logger.Printf("Configuring HAProxy via Dataplane API at %s", instance.url)
+		}
+		return nil
+	case "stop":
+		return nil
+	case "reload", "restart":
+		// Dataplane API has no restart, HAProxy master process re-executes itself on reload
+		files, config, err := d.localFiles()
+		if err != nil {
+			return err
+		}
+		var errors utils.Errors
+		for _, instance := range d.instances {
+			if err = d.push(instance, files, config); err != nil {
+				errors.Add(fmt.Errorf("dataplane API %s: %w", instance.url, err))
+			}
+		}
+		return errors.Result()
+	default:
+		return fmt.Errorf("unknown command '%s'", action)
+	}
+}
+
+func (d *dataplaneControl) UseAuxFile(useAuxFile bool) {
+	if useAuxFile && !d.useAuxFile {
+		logger.Warningf("auxiliary configuration file '%s' is not supported with Dataplane API, ignoring it", d.Env.AuxCFGFile)
+	}
+	d.useAuxFile = useAuxFile
+}
+
+// push uploads to the instance the files which changed since last push, then the
+// configuration so that HAProxy is reloaded with them, nothing being done when
+// neither the files nor the configuration changed.
+func (d *dataplaneControl) push(instance *dataplaneInstance, files map[string][]byte, config []byte) (err error) {
+	if instance.files == nil {
+		// Files uploaded before a controller restart are replaced
+		var names []string
+		if names, err = d.storageList(instance); err != nil {
+			return err
+		}
+		instance.files = make(map[string]string)
+		for _, name := range names {
+			if _, ok := files[name]; ok {
+				instance.files[name] = ""
+			}
+		}
+	}
+	changed := false
+	for name, content := range files {
+		hash := hashContent(content)
+		current, exists := instance.files[name]
+		if exists && current == hash {
+			continue
+		}
+		if err = d.storageUpload(instance, name, content, exists); err != nil {
+			return err
+		}
+		instance.files[name] = hash
+		changed = true
+	}
+	configHash := hashContent(config)
+	if changed || configHash != instance.configHash {
+		if err = d.request(instance, http.MethodPost, "/v2/services/haproxy/configuration/raw?skip_version=true", "text/plain", bytes.NewReader(config), nil); err != nil {
+			return err
+		}
+		logger.Debugf("Configuration pushed to Dataplane API %s", instance.url)
+		instance.configHash = configHash
+	}
+	// Removed once no longer referenced by the configuration
+	for name := range instance.files {
+		if _, ok := files[name]; ok {
+			continue
+		}
+		if err = d.request(instance, http.MethodDelete, "/v2/services/haproxy/storage/general/"+url.PathEscape(name), "", nil, nil); err != nil {
+			return err
+		}
+		delete(instance.files, name)
+	}
+	return nil
+}
+
+// localFiles returns the files referenced by HAProxy configuration by storage name,
+// and the configuration with local paths replaced by their general storage path.
+func (d *dataplaneControl) localFiles() (files map[string][]byte, config []byte, err error) {
+	files = make(map[string][]byte)
+	paths := make(map[string]string)
+	addFile := func(file string) error {
+		content, errRead := ioutil.ReadFile(file)
+		if errRead != nil {
+			return errRead
+		}
+		name := d.storageName(file)
+		files[name] = content
+		paths[file] = path.Join(d.OSArgs.DataplaneStorageDir, name)
+		return nil
+	}
+	// Files written by the controller at the root of configuration directory
+	entries, err := ioutil.ReadDir(d.Env.CfgDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	mainCFGFile := filepath.Clean(d.Env.MainCFGFile)
+	for _, entry := range entries {
+		file := filepath.Join(d.Env.CfgDir, entry.Name())
+		if !entry.Mode().IsRegular() || file == filepath.Clean(d.Env.AuxCFGFile) || strings.HasPrefix(file, mainCFGFile) {
+			continue
+		}
+		if err = addFile(file); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, dir := range []string{d.Env.CertDir, d.Env.MapDir, d.Env.PatternDir, d.Env.ErrFileDir} {
+		err = filepath.Walk(dir, func(file string, info os.FileInfo, errWalk error) error {
+			if errWalk != nil || !info.Mode().IsRegular() {
+				return errWalk
+			}
+			return addFile(file)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	// Longest paths first so that paths sharing a prefix are replaced with their own
+	locals := make([]string, 0, len(paths))
+	for local := range paths {
+		locals = append(locals, local)
+	}
+	sort.Slice(locals, func(i, j int) bool { return len(locals[i]) > len(locals[j]) })
+	oldNew := make([]string, 0, 2*len(locals))
+	for _, local := range locals {
+		oldNew = append(oldNew, local, paths[local])
+	}
+	replacer := strings.NewReplacer(oldNew...)
+	for name, content := range files {
+		if strings.HasSuffix(name, ".crt-list") {
+			files[name] = []byte(replacer.Replace(string(content)))
+		}
+	}
+	// Frontend certificates directory is loaded via a crt-list of its certificates
+	var crtList []string
+	for _, local := range locals {
+		if filepath.Dir(local) == filepath.Clean(d.Env.FrontendCertDir) && strings.HasSuffix(local, ".pem") {
+			crtList = append(crtList, paths[local])
+		}
+	}
+	sort.Strings(crtList)
+	files[dataplaneCrtList] = []byte(strings.Join(crtList, "\n") + "\n")
+
+	content, err := ioutil.ReadFile(d.Env.MainCFGFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	crtDir := " crt " + filepath.Clean(d.Env.FrontendCertDir)
+	crtListParam := " crt-list " + path.Join(d.OSArgs.DataplaneStorageDir, dataplaneCrtList)
+	lines := strings.Split(replacer.Replace(string(content)), "\n")
+	for i, line := range lines {
+		line = strings.Replace(line+" ", crtDir+" ", crtListParam+" ", -1)
+		line = strings.Replace(line, crtDir+"/ ", crtListParam+" ", -1)
+		lines[i] = line[:len(line)-1]
+	}
+	return files, []byte(strings.Join(lines, "\n")), nil
+}
+
+// storageName flattens the path of the file relative to configuration directory,
+// general storage having no subdirectories.
+func (d *dataplaneControl) storageName(file string) string {
+	name, err := filepath.Rel(d.Env.CfgDir, file)
+	if err != nil || strings.HasPrefix(name, "..") {
+		name = strings.TrimPrefix(file, string(filepath.Separator))
+	}
+	return strings.Replace(filepath.ToSlash(name), "/", "_", -1)
+}
+
+func (d *dataplaneControl) storageList(instance *dataplaneInstance) (names []string, err error) {
+	var storage []struct {
+		StorageName string `json:"storage_name"`
+	}
+	if err = d.request(instance, http.MethodGet, "/v2/services/haproxy/storage/general", "", nil, &storage); err != nil {
+		return nil, err
+	}
+	for _, file := range storage {
+		names = append(names, file.StorageName)
+	}
+	return names, nil
+}
+
+// storageUpload creates or replaces the file in general storage without reloading
+// HAProxy, which is reloaded once the configuration is pushed.
+func (d *dataplaneControl) storageUpload(instance *dataplaneInstance, name string, content []byte, exists bool) error {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file_upload", name)
+	if err != nil {
+		return err
+	}
+	if _, err = part.Write(content); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	if exists {
+		return d.request(instance, http.MethodPut, "/v2/services/haproxy/storage/general/"+url.PathEscape(name)+"?skip_reload=true", writer.FormDataContentType(), body, nil)
+	}
+	return d.request(instance, http.MethodPost, "/v2/services/haproxy/storage/general", writer.FormDataContentType(), body, nil)
+}
+
+// request sends a request to the Dataplane API of the instance, decoding
+// the JSON response in result when not nil.
+func (d *dataplaneControl) request(instance *dataplaneInstance, method, uri, contentType string, body io.Reader, result interface{}) error {
+	target := *instance.url
+	target.Path = strings.TrimSuffix(target.Path, "/")
+	req, err := http.NewRequest(method, target.String()+uri, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if instance.user != "" {
+		req.SetBasicAuth(instance.user, instance.password)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s: %s: %s", method, uri, resp.Status, strings.TrimSpace(string(message)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func hashContent(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/haproxytech/kubernetes-ingress/controller/configuration"
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
@@ -56,11 +57,19 @@ func (d *directControl) HaproxyService(action string) (err error) {
 			logger.Error("haproxy already stopped")
 			return processErr
 		}
+		// Soft-stop: listeners are closed and HAProxy exits once current
+		// connections are over, or when hard-stop-after expires.
 		if err = process.Signal(syscall.SIGUSR1); err != nil {
 			return err
 		}
-		_, err = process.Wait()
-		return err
+		if _, err = process.Wait(); err == nil {
+			return nil
+		}
+		// The daemonized master process is not a child process, it can't be waited for
+		for process.Signal(syscall.Signal(0)) == nil {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return nil
 	case "reload":
 		logger.Error(saveServerState(d.Env.StateDir, d.API))
 		if processErr != nil {
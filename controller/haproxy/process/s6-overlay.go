@@ -45,10 +45,17 @@ func (d *s6Control) HaproxyService(action string) (err error) {
 		cmd.Stderr = os.Stderr
 		return cmd.Start()*/
 	case "stop":
-		cmd = exec.Command("s6-svc", "-d", "/var/run/s6/services/haproxy")
+		// Soft-stop with SIGUSR1, HAProxy not being restarted once it exits
+		cmd = exec.Command("s6-svc", "-O", "-1", "/var/run/s6/services/haproxy")
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
-		return cmd.Start()
+		if err = cmd.Run(); err != nil {
+			return err
+		}
+		cmd = exec.Command("s6-svwait", "-D", "/var/run/s6/services/haproxy")
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
 	case "reload":
 		logger.Error(saveServerState(d.Env.StateDir, d.API))
 		cmd = exec.Command("s6-svc", "-2", "/var/run/s6/services/haproxy")
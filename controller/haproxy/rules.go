@@ -3,6 +3,8 @@ package haproxy
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/haproxytech/client-native/v2/models"
 
@@ -27,41 +29,88 @@ type RuleType int
 
 //nolint: golint,stylecheck
 const (
-	REQ_ACCEPT_CONTENT RuleType = iota
+	REQ_REJECT_CONNECTION RuleType = iota
+	REQ_ACCEPT_CONTENT
 	REQ_INSPECT_DELAY
 	REQ_PROXY_PROTOCOL
 	REQ_SET_VAR
 	REQ_SET_SRC
 	REQ_DENY
 	REQ_TRACK
-	REQ_AUTH
 	REQ_RATELIMIT
+	REQ_AUTH
+	REQ_MIRROR
 	REQ_CAPTURE
 	REQ_REQUEST_REDIRECT
+	REQ_RETURN
+	REQ_FAULT
 	REQ_FORWARDED_PROTO
 	REQ_SET_HEADER
 	REQ_SET_HOST
 	REQ_PATH_REWRITE
 	RES_SET_HEADER
+	RES_REPLACE_HEADER
+	RES_SET_STATUS
 )
 
 var constLookup = map[RuleType]string{
-	REQ_ACCEPT_CONTENT:   "REQ_ACCEPT_CONTENT",
-	REQ_INSPECT_DELAY:    "REQ_INSPECT_DELAY",
-	REQ_PROXY_PROTOCOL:   "REQ_PROXY_PROTOCOL",
-	REQ_SET_VAR:          "REQ_SET_VAR",
-	REQ_SET_SRC:          "REQ_SET_SRC",
-	REQ_DENY:             "REQ_DENY",
-	REQ_TRACK:            "REQ_TRACK",
-	REQ_AUTH:             "REQ_AUTH",
-	REQ_RATELIMIT:        "REQ_RATELIMIT",
-	REQ_CAPTURE:          "REQ_CAPTURE",
-	REQ_REQUEST_REDIRECT: "REQ_REQUEST_REDIRECT",
-	REQ_FORWARDED_PROTO:  "REQ_FORWARDED_PROTO",
-	REQ_SET_HEADER:       "REQ_SET_HEADER",
-	REQ_SET_HOST:         "REQ_SET_HOST",
-	REQ_PATH_REWRITE:     "REQ_PATH_REWRITE",
-	RES_SET_HEADER:       "RES_SET_HEADER",
+	REQ_REJECT_CONNECTION: "REQ_REJECT_CONNECTION",
+	REQ_ACCEPT_CONTENT:    "REQ_ACCEPT_CONTENT",
+	REQ_INSPECT_DELAY:     "REQ_INSPECT_DELAY",
+	REQ_PROXY_PROTOCOL:    "REQ_PROXY_PROTOCOL",
+	REQ_SET_VAR:           "REQ_SET_VAR",
+	REQ_SET_SRC:           "REQ_SET_SRC",
+	REQ_DENY:              "REQ_DENY",
+	REQ_TRACK:             "REQ_TRACK",
+	REQ_RATELIMIT:         "REQ_RATELIMIT",
+	REQ_AUTH:              "REQ_AUTH",
+	REQ_MIRROR:            "REQ_MIRROR",
+	REQ_CAPTURE:           "REQ_CAPTURE",
+	REQ_REQUEST_REDIRECT:  "REQ_REQUEST_REDIRECT",
+	REQ_RETURN:            "REQ_RETURN",
+	REQ_FAULT:             "REQ_FAULT",
+	REQ_FORWARDED_PROTO:   "REQ_FORWARDED_PROTO",
+	REQ_SET_HEADER:        "REQ_SET_HEADER",
+	REQ_SET_HOST:          "REQ_SET_HOST",
+	REQ_PATH_REWRITE:      "REQ_PATH_REWRITE",
+	RES_SET_HEADER:        "RES_SET_HEADER",
+	RES_REPLACE_HEADER:    "RES_REPLACE_HEADER",
+	RES_SET_STATUS:        "RES_SET_STATUS",
+}
+
+// accessControlPhases are the groups of access control rules whose
+// evaluation order can be changed with SetAccessControlOrder.
+var accessControlPhases = map[string][]RuleType{
+	"deny":       {REQ_DENY},
+	"rate-limit": {REQ_TRACK, REQ_RATELIMIT},
+	"auth":       {REQ_AUTH},
+}
+
+// DefaultAccessControlOrder is the evaluation order of access control rules
+// when not configured: deny lists, then rate limiting, then authentication.
+var DefaultAccessControlOrder = []string{"deny", "rate-limit", "auth"}
+
+// ParseAccessControlOrder validates a comma separated list of access control
+// phases, phases missing from the list keep their default relative order after the listed ones.
+func ParseAccessControlOrder(value string) (order []string, err error) {
+	seen := make(map[string]struct{}, len(accessControlPhases))
+	for _, phase := range strings.Split(value, ",") {
+		phase = strings.TrimSpace(phase)
+		if _, ok := accessControlPhases[phase]; !ok {
+			return nil, fmt.Errorf("unknown access control phase '%s'", phase)
+		}
+		if _, ok := seen[phase]; ok {
+			return nil, fmt.Errorf("duplicate access control phase '%s'", phase)
+		}
+		seen[phase] = struct{}{}
+		order = append(order, phase)
+	}
+	for _, phase := range DefaultAccessControlOrder {
+		if _, ok := seen[phase]; !ok {
+			order = append(order, phase)
+		}
+	}
+	return order, nil
 }
 
 // RuleStatus describing Rule creation
@@ -83,6 +132,9 @@ const (
 type Rules struct {
 	frontendRules  map[string]*ruleset
 	ingressRuleIDs map[string][]RuleID
+	// ingressRanks holds the evaluation rank of each RuleType
+	// for ingresses with a custom access control order.
+	ingressRanks map[string]map[RuleType]int
 }
 
 type ruleset struct {
@@ -103,6 +155,8 @@ func NewRules() *Rules {
 		frontendRules: make(map[string]*ruleset),
 		// ruleIDs grouped by ingressName
 		ingressRuleIDs: make(map[string][]RuleID),
+		// RuleType ranks grouped by ingressName
+		ingressRanks: make(map[string]map[RuleType]int),
 	}
 }
 
@@ -156,6 +210,52 @@ func (r Rules) Clean(frontends ...string) {
 	for ingress := range r.ingressRuleIDs {
 		r.ingressRuleIDs[ingress] = r.ingressRuleIDs[ingress][:0]
 	}
+	for ingress := range r.ingressRanks {
+		delete(r.ingressRanks, ingress)
+	}
+}
+
+// SetAccessControlOrder sets the evaluation order of the access control
+// rules of the given ingress, order being validated by ParseAccessControlOrder.
+func (r Rules) SetAccessControlOrder(ingressName string, order []string) {
+	ranks := ruleTypeRanks(order)
+	if ranks == nil {
+		delete(r.ingressRanks, ingressName)
+		return
+	}
+	r.ingressRanks[ingressName] = ranks
+}
+
+// ruleTypeRanks returns the evaluation rank of each RuleType when access
+// control rules are evaluated in the given order, nil for the default order.
+func ruleTypeRanks(order []string) map[RuleType]int {
+	isDefault := len(order) == len(DefaultAccessControlOrder)
+	for i := 0; isDefault && i < len(order); i++ {
+		isDefault = order[i] == DefaultAccessControlOrder[i]
+	}
+	if isDefault {
+		return nil
+	}
+	ranks := make(map[RuleType]int, len(constLookup))
+	rank := 0
+	for ruleType := REQ_REJECT_CONNECTION; ruleType <= RES_SET_STATUS; ruleType++ {
+		switch {
+		case ruleType == REQ_DENY:
+			// Access control rules are contiguous, starting with REQ_DENY
+			for _, phase := range order {
+				for _, phaseType := range accessControlPhases[phase] {
+					ranks[phaseType] = rank
+					rank++
+				}
+			}
+		case ruleType > REQ_DENY && ruleType <= REQ_AUTH:
+			continue
+		default:
+			ranks[ruleType] = rank
+			rank++
+		}
+	}
+	return ranks
 }
 
 func (r Rules) GetIngressRuleIDs(ingress string) (ruleIDs []RuleID) {
@@ -164,6 +264,7 @@ func (r Rules) GetIngressRuleIDs(ingress string) (ruleIDs []RuleID) {
 }
 
 func (r Rules) Refresh(client api.HAProxyClient) (reload bool) {
+	ruleIngresses := r.ruleIngresses()
 	for feName, ftRules := range r.frontendRules {
 		fe, err := client.FrontendGet(feName)
 		if err != nil {
@@ -175,38 +276,76 @@ func (r Rules) Refresh(client api.HAProxyClient) (reload bool) {
 			ACLVar = TCPACLVar
 		}
 		client.FrontendRuleDeleteAll(feName)
+		ruleSet := r.orderedRules(ftRules, ruleIngresses)
 		// All rules are created with Index 0,
 		// Which means first rule inserted will be last in the list of HAProxy rules after iteration
 		// Thus iteration is done in reverse to preserve order between the defined rules in
 		// controller and the resulting order in HAProxy configuration.
-		for ruleType := RES_SET_HEADER; ruleType >= REQ_ACCEPT_CONTENT; ruleType-- {
-			ruleSet := ftRules.rules[ruleType]
-			for i := len(ruleSet) - 1; i >= 0; i-- {
-				ingressACL := ""
-				id := getID(ruleSet[i])
-				if ftRules.status[id] == TO_DELETE {
-					delete(ftRules.status, id)
-					ruleSet = append(ruleSet[:i], ruleSet[i+1:]...)
-					logger.Debugf("HAProxy rule '%s' deleted", constLookup[ruleType])
-					continue
-				}
-				if ftRules.status[id]&INGRESS != 0 {
-					ingressACL = fmt.Sprintf("{ var(%s) -m dom %s }", ACLVar, id)
-				}
-				err := ruleSet[i].Create(client, &fe, ingressACL)
-				if err != nil {
-					logger.Errorf("%s: %s", constLookup[ruleType], err)
-				} else if ftRules.status[id]&TO_CREATE != 0 {
-					reload = true
-					logger.Debugf("New HAProxy rule '%s' created, reload required", constLookup[ruleType])
-				}
+		for i := len(ruleSet) - 1; i >= 0; i-- {
+			ruleType := ruleSet[i].GetType()
+			ingressACL := ""
+			id := getID(ruleSet[i])
+			if ftRules.status[id]&INGRESS != 0 {
+				ingressACL = fmt.Sprintf("{ var(%s) -m dom %s }", ACLVar, id)
+			}
+			err := ruleSet[i].Create(client, &fe, ingressACL)
+			if err != nil {
+				logger.Errorf("%s: %s", constLookup[ruleType], err)
+			} else if ftRules.status[id]&TO_CREATE != 0 {
+				reload = true
+				logger.Debugf("New HAProxy rule '%s' created, reload required", constLookup[ruleType])
 			}
-			ftRules.rules[ruleType] = ruleSet
 		}
 	}
 	return reload
 }
 
+// orderedRules removes deleted rules from the ruleset and returns remaining
+// rules in evaluation order: by RuleType, unless the ingress defining the rule
+// has a custom access control order, then by insertion order.
+func (r Rules) orderedRules(ftRules *ruleset, ruleIngresses map[RuleID]string) (ordered []Rule) {
+	for ruleType := REQ_REJECT_CONNECTION; ruleType <= RES_SET_STATUS; ruleType++ {
+		ruleSet := ftRules.rules[ruleType]
+		for i := 0; i < len(ruleSet); i++ {
+			id := getID(ruleSet[i])
+			if ftRules.status[id] == TO_DELETE {
+				delete(ftRules.status, id)
+				ruleSet = append(ruleSet[:i], ruleSet[i+1:]...)
+				i--
+				logger.Debugf("HAProxy rule '%s' deleted", constLookup[ruleType])
+				continue
+			}
+			ordered = append(ordered, ruleSet[i])
+		}
+		ftRules.rules[ruleType] = ruleSet
+	}
+	if len(r.ingressRanks) == 0 {
+		return ordered
+	}
+	rank := func(rule Rule) int {
+		ruleType := rule.GetType()
+		if ranks, ok := r.ingressRanks[ruleIngresses[getID(rule)]]; ok {
+			return ranks[ruleType]
+		}
+		return int(ruleType)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return rank(ordered[i]) < rank(ordered[j])
+	})
+	return ordered
+}
+
+// ruleIngresses returns the ingress of each rule added with an ingress name
+func (r Rules) ruleIngresses() map[RuleID]string {
+	ruleIngresses := make(map[RuleID]string)
+	for ingress, ids := range r.ingressRuleIDs {
+		for _, id := range ids {
+			ruleIngresses[id] = ingress
+		}
+	}
+	return ruleIngresses
+}
+
 func getID(rule Rule) RuleID {
 	b, _ := json.Marshal(rule)
 	b = append(b, byte(rule.GetType()))
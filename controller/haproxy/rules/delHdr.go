@@ -0,0 +1,80 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// DelHdr deletes the HdrName request or response header,
+// only when its value matches ValueMatch regex if set.
+type DelHdr struct {
+	Response   bool
+	HdrName    string
+	ValueMatch string
+	IgnoreCase bool
+}
+
+func (r DelHdr) GetType() haproxy.RuleType {
+	if r.Response {
+		return haproxy.RES_SET_HEADER
+	}
+	return haproxy.REQ_SET_HEADER
+}
+
+func (r DelHdr) Create(client api.HAProxyClient, frontend *models.Frontend, ingressACL string) error {
+	if frontend.Mode == "tcp" {
+		return fmt.Errorf("HTTP headers cannot be deleted in TCP mode")
+	}
+	var cond, condTest string
+	if r.ValueMatch != "" {
+		fetch := "req.hdr"
+		if r.Response {
+			fetch = "res.hdr"
+		}
+		flags := "-m reg"
+		if r.IgnoreCase {
+			flags += " -i"
+		}
+		cond = "if"
+		condTest = fmt.Sprintf("{ %s(%s) %s %s }", fetch, r.HdrName, flags, r.ValueMatch)
+	}
+	// RES_SET_HEADER
+	if r.Response {
+		httpRule := models.HTTPResponseRule{
+			Index:    utils.PtrInt64(0),
+			Type:     "del-header",
+			HdrName:  r.HdrName,
+			Cond:     cond,
+			CondTest: condTest,
+		}
+		return client.FrontendHTTPResponseRuleCreate(frontend.Name, httpRule, ingressACL)
+	}
+	// REQ_SET_HEADER
+	httpRule := models.HTTPRequestRule{
+		Index:    utils.PtrInt64(0),
+		Type:     "del-header",
+		HdrName:  r.HdrName,
+		Cond:     cond,
+		CondTest: condTest,
+	}
+	return client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL)
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// ReqAuthSPOE sends the request to a SPOE agent and rejects
+// it with a 401 when the agent does not set ResultVar to true.
+type ReqAuthSPOE struct {
+	Engine    string
+	Group     string
+	ResultVar string
+}
+
+func (r ReqAuthSPOE) GetType() haproxy.RuleType {
+	return haproxy.REQ_AUTH
+}
+
+func (r ReqAuthSPOE) Create(client api.HAProxyClient, frontend *models.Frontend, ingressACL string) error {
+	if frontend.Mode == "tcp" {
+		return fmt.Errorf("SPOE authentication cannot be configured in TCP mode")
+	}
+	// Rules are inserted at index 0, so the reject rule is created first
+	hdrName := "WWW-Authenticate"
+	hdrFmt := "Bearer"
+	httpRule := models.HTTPRequestRule{
+		Index:               utils.PtrInt64(0),
+		Type:                "return",
+		ReturnStatusCode:    utils.PtrInt64(401),
+		ReturnContentType:   utils.PtrString("\"text/plain\""),
+		ReturnContentFormat: "string",
+		ReturnContent:       "\"401 Unauthorized\"",
+		ReturnHeaders:       []*models.HTTPRequestRuleReturnHdrsItems0{{Name: &hdrName, Fmt: &hdrFmt}},
+		Cond:                "if",
+		CondTest:            fmt.Sprintf("!{ var(%s) -m bool }", r.ResultVar),
+	}
+	if err := client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL); err != nil {
+		return err
+	}
+	httpRule = models.HTTPRequestRule{
+		Index:      utils.PtrInt64(0),
+		Type:       "send-spoe-group",
+		SpoeEngine: r.Engine,
+		SpoeGroup:  r.Group,
+	}
+	return client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL)
+}
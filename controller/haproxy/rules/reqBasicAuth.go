@@ -10,10 +10,13 @@ import (
 	"github.com/haproxytech/kubernetes-ingress/controller/utils"
 )
 
+// ReqBasicAuth requires basic authentication, only for request paths
+// starting with one of the PathsMap prefixes if set.
 type ReqBasicAuth struct {
 	AuthGroup string
 	AuthRealm string
 	Data      map[string][]byte
+	PathsMap  string
 }
 
 func (r ReqBasicAuth) GetType() haproxy.RuleType {
@@ -28,6 +31,9 @@ func (r ReqBasicAuth) Create(client api.HAProxyClient, frontend *models.Frontend
 		Cond:      "if",
 		CondTest:  fmt.Sprintf("!{ http_auth_group(%s) authenticated-users }", r.AuthGroup),
 	}
+	if r.PathsMap != "" {
+		httpRule.CondTest = fmt.Sprintf("{ path_beg -f %s } %s", haproxy.GetMapPath(r.PathsMap), httpRule.CondTest)
+	}
 	if err = client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL); err != nil {
 		return
 	}
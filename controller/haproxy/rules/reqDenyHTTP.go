@@ -0,0 +1,66 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// ReqDenyHTTP denies with a 403 requests using one of Methods
+// or whose path matches one of the regular expressions in PathsMap.
+// Requests whose path begins with ExcludePathPrefix are not denied,
+// all other requests are when it is the only field set.
+type ReqDenyHTTP struct {
+	Methods           []string
+	PathsMap          string
+	ExcludePathPrefix string
+}
+
+func (r ReqDenyHTTP) GetType() haproxy.RuleType {
+	return haproxy.REQ_DENY
+}
+
+func (r ReqDenyHTTP) Create(client api.HAProxyClient, frontend *models.Frontend, ingressACL string) error {
+	if frontend.Mode == "tcp" {
+		return fmt.Errorf("HTTP deny rules cannot be configured in TCP mode")
+	}
+	var condTest string
+	switch {
+	case len(r.Methods) > 0:
+		condTest = fmt.Sprintf("{ method %s }", strings.Join(r.Methods, " "))
+	case r.PathsMap != "":
+		condTest = fmt.Sprintf("{ path_reg -f %s }", haproxy.GetMapPath(r.PathsMap))
+	case r.ExcludePathPrefix == "":
+		return fmt.Errorf("no method or path to deny")
+	}
+	if r.ExcludePathPrefix != "" {
+		condTest = strings.TrimSpace(fmt.Sprintf("%s !{ path_beg %s }", condTest, r.ExcludePathPrefix))
+	}
+	httpRule := models.HTTPRequestRule{
+		Index:      utils.PtrInt64(0),
+		Type:       "deny",
+		DenyStatus: utils.PtrInt64(403),
+		Cond:       "if",
+		CondTest:   condTest,
+	}
+	return client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL)
+}
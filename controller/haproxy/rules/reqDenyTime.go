@@ -0,0 +1,69 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// ReqDenyTime denies with a 403 requests received during one of the time Windows,
+// or outside all of them when Allow is set. Offset is added in seconds to UTC time
+// to get the windows timezone time. When PathsMap is set, only requests whose path
+// matches one of the regular expressions in PathsMap are concerned.
+type ReqDenyTime struct {
+	Windows  []utils.TimeWindow
+	Offset   int64
+	Allow    bool
+	PathsMap string
+}
+
+func (r ReqDenyTime) GetType() haproxy.RuleType {
+	return haproxy.REQ_DENY
+}
+
+func (r ReqDenyTime) Create(client api.HAProxyClient, frontend *models.Frontend, ingressACL string) error {
+	if frontend.Mode == "tcp" {
+		return fmt.Errorf("time based deny rules cannot be configured in TCP mode")
+	}
+	if len(r.Windows) == 0 {
+		return fmt.Errorf("no time window")
+	}
+	var ranges []string
+	for _, w := range r.Windows {
+		ranges = append(ranges, w.Ranges()...)
+	}
+	condTest := fmt.Sprintf("{ date(%d),utime(%%u%%H%%M) -m int %s }", r.Offset, strings.Join(ranges, " "))
+	if r.Allow {
+		condTest = "!" + condTest
+	}
+	if r.PathsMap != "" {
+		condTest = fmt.Sprintf("{ path_reg -f %s } %s", haproxy.GetMapPath(r.PathsMap), condTest)
+	}
+	httpRule := models.HTTPRequestRule{
+		Index:      utils.PtrInt64(0),
+		Type:       "deny",
+		DenyStatus: utils.PtrInt64(403),
+		Cond:       "if",
+		CondTest:   condTest,
+	}
+	return client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL)
+}
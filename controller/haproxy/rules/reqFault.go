@@ -0,0 +1,87 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// FaultDelayAction is the Lua action, registered by the fault injection
+// script, pausing the request for the number of milliseconds given as argument.
+const FaultDelayAction = "fault_delay"
+
+// ReqFault injects faults into a percentage of the requests: a delay of Delay
+// milliseconds for DelayPercent of them, then a response with AbortStatus
+// status code for AbortPercent of them.
+type ReqFault struct {
+	Delay        int64
+	DelayPercent int64
+	AbortStatus  int64
+	AbortPercent int64
+}
+
+func (r ReqFault) GetType() haproxy.RuleType {
+	return haproxy.REQ_FAULT
+}
+
+func (r ReqFault) Create(client api.HAProxyClient, frontend *models.Frontend, ingressACL string) error {
+	if frontend.Mode == "tcp" {
+		return fmt.Errorf("fault injection cannot be configured in TCP mode")
+	}
+	// Rules are inserted at index 0, so the abort rule is created first
+	if r.AbortStatus != 0 {
+		httpRule := models.HTTPRequestRule{
+			Index:               utils.PtrInt64(0),
+			Type:                "return",
+			ReturnStatusCode:    utils.PtrInt64(r.AbortStatus),
+			ReturnContentType:   utils.PtrString("\"text/plain\""),
+			ReturnContentFormat: "string",
+			ReturnContent:       strconv.Quote(fmt.Sprintf("%d fault injected", r.AbortStatus)),
+		}
+		setPercentCond(&httpRule, r.AbortPercent)
+		if err := client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL); err != nil {
+			return err
+		}
+	}
+	if r.Delay != 0 {
+		httpRule := models.HTTPRequestRule{
+			Index:     utils.PtrInt64(0),
+			Type:      "lua",
+			LuaAction: FaultDelayAction,
+			LuaParams: strconv.FormatInt(r.Delay, 10),
+		}
+		setPercentCond(&httpRule, r.DelayPercent)
+		if err := client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPercentCond restricts the rule to a random percentage of the requests
+func setPercentCond(httpRule *models.HTTPRequestRule, percent int64) {
+	if percent >= 100 {
+		return
+	}
+	httpRule.Cond = "if"
+	httpRule.CondTest = fmt.Sprintf("{ rand(100) lt %d }", percent)
+}
@@ -0,0 +1,94 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// ReqForwardAuth sends the request to a SPOE agent performing external authentication.
+// When the agent does not set ResultVar to true the request is rejected with a 401
+// (or redirected to SigninURL) if StatusVar is 401, and with a 403 otherwise.
+type ReqForwardAuth struct {
+	Engine    string
+	Group     string
+	ResultVar string
+	StatusVar string
+	SigninURL string
+}
+
+func (r ReqForwardAuth) GetType() haproxy.RuleType {
+	return haproxy.REQ_AUTH
+}
+
+func (r ReqForwardAuth) Create(client api.HAProxyClient, frontend *models.Frontend, ingressACL string) error {
+	if frontend.Mode == "tcp" {
+		return fmt.Errorf("forward authentication cannot be configured in TCP mode")
+	}
+	notValid := fmt.Sprintf("!{ var(%s) -m bool }", r.ResultVar)
+	unauthorized := fmt.Sprintf("%s { var(%s) -m int 401 }", notValid, r.StatusVar)
+	// Rules are inserted at index 0, so they are created in reverse order
+	httpRule := models.HTTPRequestRule{
+		Index:               utils.PtrInt64(0),
+		Type:                "return",
+		ReturnStatusCode:    utils.PtrInt64(403),
+		ReturnContentType:   utils.PtrString("\"text/plain\""),
+		ReturnContentFormat: "string",
+		ReturnContent:       "\"403 Forbidden\"",
+		Cond:                "if",
+		CondTest:            notValid,
+	}
+	if err := client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL); err != nil {
+		return err
+	}
+	if r.SigninURL != "" {
+		httpRule = models.HTTPRequestRule{
+			Index:      utils.PtrInt64(0),
+			Type:       "redirect",
+			RedirCode:  utils.PtrInt64(302),
+			RedirValue: r.SigninURL + "?rd=%[ssl_fc,iif(https,http)]://%[req.hdr(host)]%[pathq,url_enc]",
+			RedirType:  "location",
+			Cond:       "if",
+			CondTest:   unauthorized,
+		}
+	} else {
+		httpRule = models.HTTPRequestRule{
+			Index:               utils.PtrInt64(0),
+			Type:                "return",
+			ReturnStatusCode:    utils.PtrInt64(401),
+			ReturnContentType:   utils.PtrString("\"text/plain\""),
+			ReturnContentFormat: "string",
+			ReturnContent:       "\"401 Unauthorized\"",
+			Cond:                "if",
+			CondTest:            unauthorized,
+		}
+	}
+	if err := client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL); err != nil {
+		return err
+	}
+	httpRule = models.HTTPRequestRule{
+		Index:      utils.PtrInt64(0),
+		Type:       "send-spoe-group",
+		SpoeEngine: r.Engine,
+		SpoeGroup:  r.Group,
+	}
+	return client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL)
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// ReqForwardedHdrs sets X-Forwarded-Proto, X-Forwarded-Port, X-Forwarded-Host
+// and X-Real-IP request headers when they are not provided by the client.
+// With Strip, client copies are removed first, except for clients in TrustedMap if set.
+type ReqForwardedHdrs struct {
+	Strip      bool
+	TrustedMap string
+}
+
+var forwardedHdrs = []struct {
+	name   string
+	format string
+	cond   string
+}{
+	{"X-Forwarded-Proto", "https", "{ ssl_fc }"},
+	{"X-Forwarded-Proto", "http", "!{ ssl_fc }"},
+	{"X-Forwarded-Port", "%[dst_port]", ""},
+	{"X-Forwarded-Host", "%[req.hdr(host)]", ""},
+	{"X-Real-IP", "%[src]", ""},
+}
+
+func (r ReqForwardedHdrs) GetType() haproxy.RuleType {
+	return haproxy.REQ_FORWARDED_PROTO
+}
+
+func (r ReqForwardedHdrs) Create(client api.HAProxyClient, frontend *models.Frontend, ingressACL string) error {
+	if frontend.Mode == "tcp" {
+		return fmt.Errorf("HTTP headers cannot be set in TCP mode")
+	}
+	// Rules are inserted at index 0, so they are created in reverse order
+	for i := len(forwardedHdrs) - 1; i >= 0; i-- {
+		hdr := forwardedHdrs[i]
+		condTest := fmt.Sprintf("!{ req.hdr(%s) -m found }", hdr.name)
+		if hdr.cond != "" {
+			condTest = hdr.cond + " " + condTest
+		}
+		httpRule := models.HTTPRequestRule{
+			Index:     utils.PtrInt64(0),
+			Type:      "set-header",
+			HdrName:   hdr.name,
+			HdrFormat: hdr.format,
+			Cond:      "if",
+			CondTest:  condTest,
+		}
+		if err := client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL); err != nil {
+			return err
+		}
+	}
+	if !r.Strip {
+		return nil
+	}
+	for i := len(forwardedHdrs) - 1; i >= 0; i-- {
+		hdr := forwardedHdrs[i]
+		if i > 0 && forwardedHdrs[i-1].name == hdr.name {
+			continue
+		}
+		httpRule := models.HTTPRequestRule{
+			Index:   utils.PtrInt64(0),
+			Type:    "del-header",
+			HdrName: hdr.name,
+		}
+		if r.TrustedMap != "" {
+			httpRule.Cond = "if"
+			httpRule.CondTest = fmt.Sprintf("!{ src -f %s }", haproxy.GetMapPath(r.TrustedMap))
+		}
+		if err := client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
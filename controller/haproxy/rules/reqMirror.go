@@ -0,0 +1,54 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// ReqMirror sends a copy of the request to the SPOE agent which replays it
+// to the mirror service, the response to the client is not affected.
+type ReqMirror struct {
+	Engine   string
+	Group    string
+	CondTest string
+}
+
+func (r ReqMirror) GetType() haproxy.RuleType {
+	return haproxy.REQ_MIRROR
+}
+
+func (r ReqMirror) Create(client api.HAProxyClient, frontend *models.Frontend, ingressACL string) error {
+	if frontend.Mode == "tcp" {
+		return fmt.Errorf("request mirroring cannot be configured in TCP mode")
+	}
+	httpRule := models.HTTPRequestRule{
+		Index:      utils.PtrInt64(0),
+		Type:       "send-spoe-group",
+		SpoeEngine: r.Engine,
+		SpoeGroup:  r.Group,
+	}
+	if r.CondTest != "" {
+		httpRule.Cond = "if"
+		httpRule.CondTest = r.CondTest
+	}
+	return client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL)
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// ReqRedirectRegex redirects requests whose path matches the PathMatch regular expression to Target:
+//   - "scheme://": same host and URI with the given scheme
+//   - "scheme://host": given host keeping the URI
+//   - otherwise a location where \1 to \9 are replaced by PathMatch capture groups,
+//     the query string being kept.
+type ReqRedirectRegex struct {
+	RedirectCode int64
+	PathMatch    string
+	Target       string
+}
+
+// redirectRegexVar is set when the path of the request matches a ReqRedirectRegex PathMatch
+const redirectRegexVar = "redirect_regex"
+
+func (r ReqRedirectRegex) GetType() haproxy.RuleType {
+	return haproxy.REQ_REQUEST_REDIRECT
+}
+
+func (r ReqRedirectRegex) Create(client api.HAProxyClient, frontend *models.Frontend, ingressACL string) error {
+	if frontend.Mode == "tcp" {
+		return fmt.Errorf("request redirection cannot be configured in TCP mode")
+	}
+	pathCond := fmt.Sprintf("{ path_reg %s }", r.PathMatch)
+	if location, ok := r.uriLocation(); ok {
+		httpRule := models.HTTPRequestRule{
+			Index:      utils.PtrInt64(0),
+			Type:       "redirect",
+			RedirCode:  utils.PtrInt64(r.RedirectCode),
+			RedirValue: location,
+			RedirType:  "location",
+			Cond:       "if",
+			CondTest:   pathCond,
+		}
+		return client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL)
+	}
+	// Path is replaced with the location, which is then used for the redirection.
+	// Rules are inserted at index 0, so they are created in reverse order.
+	varCond := fmt.Sprintf("{ var(txn.%s) -m bool }", redirectRegexVar)
+	httpRules := []models.HTTPRequestRule{
+		{
+			Index:      utils.PtrInt64(0),
+			Type:       "redirect",
+			RedirCode:  utils.PtrInt64(r.RedirectCode),
+			RedirValue: "%[url]",
+			RedirType:  "location",
+			Cond:       "if",
+			CondTest:   varCond,
+		},
+		{
+			Index:     utils.PtrInt64(0),
+			Type:      "replace-path",
+			PathMatch: r.PathMatch,
+			PathFmt:   r.Target,
+			Cond:      "if",
+			CondTest:  varCond,
+		},
+		{
+			Index:    utils.PtrInt64(0),
+			Type:     "set-var",
+			VarName:  redirectRegexVar,
+			VarScope: "txn",
+			VarExpr:  "bool(true)",
+			Cond:     "if",
+			CondTest: pathCond,
+		},
+	}
+	for _, httpRule := range httpRules {
+		if err := client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uriLocation returns the redirection location of scheme only and host
+// targets, which keep the request URI, ok being false for other targets.
+func (r ReqRedirectRegex) uriLocation() (location string, ok bool) {
+	i := strings.Index(r.Target, "://")
+	if i <= 0 {
+		return "", false
+	}
+	host := r.Target[i+3:]
+	switch {
+	case host == "":
+		return r.Target + "%[hdr(host)]%[capture.req.uri]", true
+	case !strings.Contains(host, "/"):
+		return r.Target + "%[capture.req.uri]", true
+	}
+	return "", false
+}
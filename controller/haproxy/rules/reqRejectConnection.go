@@ -0,0 +1,44 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// ReqRejectConnection rejects incoming connections matching CondTest
+// before any processing, used to shed load early.
+type ReqRejectConnection struct {
+	CondTest string
+}
+
+func (r ReqRejectConnection) GetType() haproxy.RuleType {
+	return haproxy.REQ_REJECT_CONNECTION
+}
+
+func (r ReqRejectConnection) Create(client api.HAProxyClient, frontend *models.Frontend, ingressACL string) error {
+	tcpRule := models.TCPRequestRule{
+		Index:    utils.PtrInt64(0),
+		Type:     "connection",
+		Action:   "reject",
+		Cond:     "if",
+		CondTest: r.CondTest,
+	}
+	return client.FrontendTCPRequestRuleCreate(frontend.Name, tcpRule, ingressACL)
+}
@@ -10,12 +10,15 @@ import (
 	"github.com/haproxytech/kubernetes-ingress/controller/utils"
 )
 
+// RequestRedirect redirects requests to Host, or to HTTPS when SSLRedirect is set.
+// Requests whose path begins with one of the prefixes in ExcludePathsMap are not redirected.
 type RequestRedirect struct {
-	RedirectCode int64
-	RedirectPort int
-	Host         string
-	SSLRequest   bool
-	SSLRedirect  bool
+	RedirectCode    int64
+	RedirectPort    int
+	Host            string
+	SSLRequest      bool
+	SSLRedirect     bool
+	ExcludePathsMap string
 }
 
 func (r RequestRedirect) GetType() haproxy.RuleType {
@@ -43,5 +46,9 @@ func (r RequestRedirect) Create(client api.HAProxyClient, frontend *models.Front
 		RedirValue: rule,
 		RedirType:  "location",
 	}
+	if r.ExcludePathsMap != "" {
+		httpRule.Cond = "unless"
+		httpRule.CondTest = fmt.Sprintf("{ path_beg -f %s }", haproxy.GetMapPath(r.ExcludePathsMap))
+	}
 	return client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL)
 }
@@ -0,0 +1,80 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// ReqReturn answers requests with StatusCode, Headers and Content,
+// only when CondTest is verified if set. There is no content when
+// ContentType is empty.
+type ReqReturn struct {
+	StatusCode  int64
+	ContentType string
+	Content     string
+	Headers     map[string]string
+	CondTest    string
+}
+
+func (r ReqReturn) GetType() haproxy.RuleType {
+	return haproxy.REQ_RETURN
+}
+
+func (r ReqReturn) Create(client api.HAProxyClient, frontend *models.Frontend, ingressACL string) error {
+	if frontend.Mode == "tcp" {
+		return fmt.Errorf("static response cannot be configured in TCP mode")
+	}
+	httpRule := models.HTTPRequestRule{
+		Index:            utils.PtrInt64(0),
+		Type:             "return",
+		ReturnStatusCode: utils.PtrInt64(r.StatusCode),
+	}
+	if r.ContentType != "" {
+		contentType, err := utils.QuoteHAProxy(r.ContentType)
+		if err != nil {
+			return fmt.Errorf("static response content type: %w", err)
+		}
+		content, err := utils.QuoteHAProxy(r.Content)
+		if err != nil {
+			return fmt.Errorf("static response content: %w", err)
+		}
+		httpRule.ReturnContentType = utils.PtrString(contentType)
+		httpRule.ReturnContentFormat = "string"
+		httpRule.ReturnContent = content
+	}
+	names := make([]string, 0, len(r.Headers))
+	for name := range r.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		hdrName := name
+		hdrFmt := r.Headers[name]
+		httpRule.ReturnHeaders = append(httpRule.ReturnHeaders, &models.HTTPRequestRuleReturnHdrsItems0{Name: &hdrName, Fmt: &hdrFmt})
+	}
+	if r.CondTest != "" {
+		httpRule.Cond = "if"
+		httpRule.CondTest = r.CondTest
+	}
+	return client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL)
+}
@@ -10,11 +10,11 @@ import (
 	"github.com/haproxytech/kubernetes-ingress/controller/utils"
 )
 
+// ReqTrack tracks requests by TrackKey in the TableName stick-table,
+// tables being created from the configuration RateLimitTables.
 type ReqTrack struct {
-	TableName   string
-	TablePeriod *int64
-	TableSize   *int64
-	TrackKey    string
+	TableName string
+	TrackKey  string
 }
 
 func (r ReqTrack) GetType() haproxy.RuleType {
@@ -25,22 +25,6 @@ func (r ReqTrack) Create(client api.HAProxyClient, frontend *models.Frontend, in
 	if frontend.Mode == "tcp" {
 		return fmt.Errorf("request Track cannot be configured in TCP mode")
 	}
-	// Create tracking table.
-	if _, err := client.BackendGet(r.TableName); err != nil {
-		err = client.BackendCreate(models.Backend{
-			Name: r.TableName,
-			StickTable: &models.BackendStickTable{
-				Peers: "localinstance",
-				Type:  "ip",
-				Size:  r.TableSize,
-				Store: fmt.Sprintf("http_req_rate(%d)", *r.TablePeriod),
-			},
-		})
-		if err != nil {
-			return err
-		}
-	}
-	// Create rule
 	httpRule := models.HTTPRequestRule{
 		Index:         utils.PtrInt64(0),
 		Type:          "track-sc0",
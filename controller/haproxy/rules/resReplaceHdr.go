@@ -0,0 +1,51 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// ResReplaceHdr replaces the value of HdrName response header matching
+// HdrMatch regex with HdrFormat which may hold regex back-references.
+type ResReplaceHdr struct {
+	HdrName   string
+	HdrMatch  string
+	HdrFormat string
+}
+
+func (r ResReplaceHdr) GetType() haproxy.RuleType {
+	return haproxy.RES_REPLACE_HEADER
+}
+
+func (r ResReplaceHdr) Create(client api.HAProxyClient, frontend *models.Frontend, ingressACL string) error {
+	if frontend.Mode == "tcp" {
+		return fmt.Errorf("HTTP headers cannot be replaced in TCP mode")
+	}
+	httpRule := models.HTTPResponseRule{
+		Index:     utils.PtrInt64(0),
+		Type:      "replace-header",
+		HdrName:   r.HdrName,
+		HdrMatch:  r.HdrMatch,
+		HdrFormat: r.HdrFormat,
+	}
+	return client.FrontendHTTPResponseRuleCreate(frontend.Name, httpRule, ingressACL)
+}
@@ -0,0 +1,49 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+type ResSetStatus struct {
+	Status int64
+	Reason string
+}
+
+func (r ResSetStatus) GetType() haproxy.RuleType {
+	return haproxy.RES_SET_STATUS
+}
+
+func (r ResSetStatus) Create(client api.HAProxyClient, frontend *models.Frontend, ingressACL string) error {
+	if frontend.Mode == "tcp" {
+		return fmt.Errorf("response status cannot be set in TCP mode")
+	}
+	httpRule := models.HTTPResponseRule{
+		Index:  utils.PtrInt64(0),
+		Type:   "set-status",
+		Status: r.Status,
+	}
+	if r.Reason != "" {
+		httpRule.StatusReason = fmt.Sprintf("%q", r.Reason)
+	}
+	return client.FrontendHTTPResponseRuleCreate(frontend.Name, httpRule, ingressACL)
+}
@@ -50,7 +50,10 @@ func (r SetHdr) Create(client api.HAProxyClient, frontend *models.Frontend, ingr
 			Type:      "set-header",
 			HdrName:   r.HdrName,
 			HdrFormat: r.HdrFormat,
-			CondTest:  r.CondTest,
+		}
+		if r.CondTest != "" {
+			httpRule.Cond = "if"
+			httpRule.CondTest = r.CondTest
 		}
 		return client.FrontendHTTPResponseRuleCreate(frontend.Name, httpRule, ingressACL)
 	}
@@ -61,5 +64,9 @@ func (r SetHdr) Create(client api.HAProxyClient, frontend *models.Frontend, ingr
 		HdrName:   r.HdrName,
 		HdrFormat: r.HdrFormat,
 	}
+	if r.CondTest != "" {
+		httpRule.Cond = "if"
+		httpRule.CondTest = r.CondTest
+	}
 	return client.FrontendHTTPRequestRuleCreate(frontend.Name, httpRule, ingressACL)
 }
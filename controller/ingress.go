@@ -15,8 +15,14 @@
 package controller
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/haproxytech/client-native/v2/models"
 
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/rules"
 	"github.com/haproxytech/kubernetes-ingress/controller/route"
 	"github.com/haproxytech/kubernetes-ingress/controller/service"
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
@@ -34,32 +40,57 @@ func (c *HAProxyController) igClassIsSupported(ingress *store.Ingress) bool {
 	var igClass *store.IngressClass
 	igClassAnn = c.Store.GetValueFromAnnotations("ingress.class", ingress.Annotations)
 
-	// If ingress class is unassigned and the controller is controlling any resource without explicit ingress class then support it.
-	if igClassAnn == "" && c.OSArgs.EmptyIngressClass {
-		return true
+	if igClassAnn == "" && ingress.Class == "" {
+		return c.emptyIgClassIsSupported()
 	}
 
 	if igClassAnn == "" || igClassAnn != c.OSArgs.IngressClass {
 		igClass = c.Store.IngressClasses[ingress.Class]
 		if igClass != nil && igClass.Status != DELETED && igClass.Controller == CONTROLLER_CLASS {
-			// Corresponding IngresClass was updated so Ingress resource should be re-processed
-			// This is particularly important if the Ingress was skipped due to mismatching ingrssClass
-			if igClass.Status != EMPTY {
-				ingress.Status = MODIFIED
-			}
 			return true
 		}
 	}
-	if igClassAnn == c.OSArgs.IngressClass {
+	if igClassAnn != "" && igClassAnn == c.OSArgs.IngressClass {
+		return true
+	}
+	return false
+}
+
+// emptyIgClassIsSupported returns true if an ingress without class is handled by the controller,
+// which is the case when one of its IngressClasses is the cluster default.
+// Ingresses are re-processed on IngressClass changes by the store, not by this check.
+func (c *HAProxyController) emptyIgClassIsSupported() bool {
+	// If the controller is controlling any resource without explicit ingress class then support it,
+	// as done before default IngressClass support with --legacy-empty-ingress-class.
+	if c.OSArgs.EmptyIngressClass || c.OSArgs.LegacyEmptyIngressClass {
 		return true
 	}
+	for _, igClass := range c.Store.IngressClasses {
+		if igClass.Status != DELETED && igClass.Controller == CONTROLLER_CLASS && igClass.Default {
+			return true
+		}
+	}
 	return false
 }
 
 func (c *HAProxyController) handleIngressPath(ingress *store.Ingress, host string, path *store.IngressPath) (reload bool, err error) {
+	if response := c.backendStaticResponse(ingress, path); response != nil {
+		return false, c.handleStaticResponsePath(ingress, host, path, response)
+	}
 	sslPassthrough := c.sslPassthroughEnabled(ingress, path)
-	svc, err := service.NewCtx(c.Store, ingress, path, sslPassthrough)
+	svc, err := service.NewCtx(c.Store, c.svcSettings, ingress, path, sslPassthrough)
 	if err != nil {
+		// Static responses are served by HAProxy rules, route is still needed
+		// to match the ingress ACL but no backend service is required.
+		if c.Store.GetValueFromAnnotations("static-response", ingress.Annotations) != "" {
+			err = route.AddHostPathRoute(route.Route{
+				Host:         host,
+				Path:         path,
+				HAProxyRules: c.Cfg.HAProxyRules.GetIngressRuleIDs(ingress.Namespace + "-" + ingress.Name),
+				BackendName:  "static-response",
+				Frontend:     c.ingressExtraFrontend(ingress),
+			}, c.Cfg.MapFiles)
+		}
 		return
 	}
 	if svc.GetStatus() == DELETED {
@@ -78,17 +109,14 @@ func (c *HAProxyController) handleIngressPath(ingress *store.Ingress, host strin
 		HAProxyRules:   c.Cfg.HAProxyRules.GetIngressRuleIDs(ingress.Namespace + "-" + ingress.Name),
 		BackendName:    backendName,
 		SSLPassthrough: sslPassthrough,
+		Frontend:       c.ingressExtraFrontend(ingress),
 	}
 	routeACLAnn := c.Store.GetValueFromAnnotations("route-acl", svc.GetService().Annotations)
 	if routeACLAnn == "" {
-		if _, ok := route.CustomRoutes[backendName]; ok {
-			delete(route.CustomRoutes, backendName)
-			logger.Debugf("Custom Route to backend '%s' deleted, reload required", backendName)
-			routeReload = true
-		}
+		routeReload = c.customRoutes.Delete(backendName)
 		err = route.AddHostPathRoute(ingRoute, c.Cfg.MapFiles)
 	} else {
-		routeReload, err = route.AddCustomRoute(ingRoute, routeACLAnn, c.Client)
+		routeReload, err = c.customRoutes.Add(ingRoute, routeACLAnn, c.Client)
 	}
 	if err != nil {
 		return
@@ -96,10 +124,80 @@ func (c *HAProxyController) handleIngressPath(ingress *store.Ingress, host strin
 	c.Cfg.ActiveBackends[backendName] = struct{}{}
 	// Endpoints
 	endpointsReload := svc.HandleEndpoints(c.Client, c.Store, c.Cfg.Certificates)
-	return backendReload || endpointsReload || routeReload, err
+	// A/B testing
+	var abReload bool
+	if !sslPassthrough {
+		abReload = c.handleABRoute(ingress, host, path)
+	}
+	return backendReload || endpointsReload || routeReload || abReload, err
+}
+
+// handleABRoute routes requests of the ingress path to the ab-service backend
+// when the ab-cookie cookie, or the group assigned by ab-cookie-percent,
+// has the ab-cookie-value value.
+func (c *HAProxyController) handleABRoute(ingress *store.Ingress, host string, path *store.IngressPath) (reload bool) {
+	annService := c.Store.GetValueFromAnnotations("ab-service", ingress.Annotations)
+	if annService == "" {
+		return false
+	}
+	_, cookieValue, ok := c.abCookie(ingress)
+	if !ok {
+		return false
+	}
+	i := strings.LastIndex(annService, ":")
+	if i == -1 {
+		logger.Errorf("Ingress '%s/%s': ab-service: '%s': missing service port", ingress.Namespace, ingress.Name, annService)
+		return false
+	}
+	abPath := &store.IngressPath{
+		SvcName:       annService[:i],
+		Path:          path.Path,
+		PathTypeMatch: path.PathTypeMatch,
+		Status:        path.Status,
+	}
+	if port, err := strconv.ParseInt(annService[i+1:], 10, 64); err == nil {
+		abPath.SvcPortInt = port
+	} else {
+		abPath.SvcPortString = annService[i+1:]
+	}
+	svc, err := service.NewCtx(c.Store, c.svcSettings, ingress, abPath, false)
+	if err != nil {
+		logger.Errorf("Ingress '%s/%s': ab-service: %s", ingress.Namespace, ingress.Name, err)
+		return false
+	}
+	if svc.GetStatus() == DELETED {
+		return false
+	}
+	backendReload, backendName, err := svc.HandleBackend(c.Client, c.Store)
+	if err != nil {
+		logger.Errorf("Ingress '%s/%s': ab-service: %s", ingress.Namespace, ingress.Name, err)
+		return false
+	}
+	routeReload, err := c.customRoutes.Add(route.Route{
+		Host:        host,
+		Path:        abPath,
+		BackendName: backendName,
+		Frontend:    c.ingressExtraFrontend(ingress),
+	}, fmt.Sprintf("var(%s) -m str %s", abGroupVar, cookieValue), c.Client)
+	if err != nil {
+		logger.Errorf("Ingress '%s/%s': ab-service: %s", ingress.Namespace, ingress.Name, err)
+		return false
+	}
+	c.Cfg.ActiveBackends[backendName] = struct{}{}
+	endpointsReload := svc.HandleEndpoints(c.Client, c.Store, c.Cfg.Certificates)
+	return backendReload || routeReload || endpointsReload
 }
 
 func (c *HAProxyController) setDefaultService(ingress *store.Ingress, frontends []string) (reload bool, err error) {
+	// Requests without route get the static response, before reaching the default backend
+	if response := c.backendStaticResponse(ingress, ingress.DefaultBackend); response != nil {
+		return false, c.Cfg.HAProxyRules.AddRule(rules.ReqReturn{
+			StatusCode:  response.StatusCode,
+			ContentType: response.ContentType,
+			Content:     response.Body,
+			CondTest:    fmt.Sprintf("!{ var(%s) -m found }", haproxy.HTTPACLVar),
+		}, "", frontends...)
+	}
 	var frontend models.Frontend
 	var ftReload bool
 	frontend, err = c.Client.FrontendGet(frontends[0])
@@ -110,7 +208,7 @@ func (c *HAProxyController) setDefaultService(ingress *store.Ingress, frontends
 	if frontend.Mode == "tcp" {
 		tcpService = true
 	}
-	svc, err := service.NewCtx(c.Store, ingress, ingress.DefaultBackend, tcpService)
+	svc, err := service.NewCtx(c.Store, c.svcSettings, ingress, ingress.DefaultBackend, tcpService)
 	if err != nil {
 		return
 	}
@@ -121,20 +219,21 @@ func (c *HAProxyController) setDefaultService(ingress *store.Ingress, frontends
 	if err != nil {
 		return
 	}
-	if frontend.DefaultBackend != backendName {
+	for _, frontendName := range frontends {
+		frontend, _ = c.Client.FrontendGet(frontendName)
+		if frontend.DefaultBackend == backendName {
+			continue
+		}
 		if frontend.Name == c.Cfg.FrontHTTP {
 			logger.Infof("Setting http default backend to '%s'", backendName)
 		}
-		for _, frontendName := range frontends {
-			frontend, _ := c.Client.FrontendGet(frontendName)
-			frontend.DefaultBackend = backendName
-			err = c.Client.FrontendEdit(frontend)
-			if err != nil {
-				return
-			}
-			ftReload = true
-			logger.Debugf("Setting '%s' default backend to '%s'", frontendName, backendName)
+		frontend.DefaultBackend = backendName
+		err = c.Client.FrontendEdit(frontend)
+		if err != nil {
+			return
 		}
+		ftReload = true
+		logger.Debugf("Setting '%s' default backend to '%s'", frontendName, backendName)
 	}
 	c.Cfg.ActiveBackends[backendName] = struct{}{}
 	endpointsReload := svc.HandleEndpoints(c.Client, c.Store, c.Cfg.Certificates)
@@ -142,7 +241,46 @@ func (c *HAProxyController) setDefaultService(ingress *store.Ingress, frontends
 	return reload, err
 }
 
+// backendStaticResponse returns the static response of the Backend custom resource
+// referenced by the ingress path as resource, nil if none.
+func (c *HAProxyController) backendStaticResponse(ingress *store.Ingress, path *store.IngressPath) *store.StaticResponse {
+	if path.BackendResource == "" {
+		return nil
+	}
+	backend, err := c.Store.GetBackend(path.BackendResource, ingress.Namespace)
+	if err != nil {
+		return nil
+	}
+	return backend.StaticResponse
+}
+
+// handleStaticResponsePath routes the ingress path to the static response of its Backend resource,
+// the response rule being owned by the path route so that other ingress paths are not affected.
+func (c *HAProxyController) handleStaticResponsePath(ingress *store.Ingress, host string, path *store.IngressPath, response *store.StaticResponse) error {
+	owner := fmt.Sprintf("%s-%s/backend/%s", ingress.Namespace, ingress.Name, path.BackendResource)
+	err := c.Cfg.HAProxyRules.AddRule(rules.ReqReturn{
+		StatusCode:  response.StatusCode,
+		ContentType: response.ContentType,
+		Content:     response.Body,
+	}, owner, c.ingressFrontends(ingress)...)
+	if err != nil {
+		return err
+	}
+	ruleIDs := append([]haproxy.RuleID{}, c.Cfg.HAProxyRules.GetIngressRuleIDs(ingress.Namespace+"-"+ingress.Name)...)
+	return route.AddHostPathRoute(route.Route{
+		Host:         host,
+		Path:         path,
+		HAProxyRules: append(ruleIDs, c.Cfg.HAProxyRules.GetIngressRuleIDs(owner)...),
+		BackendName:  "static-response",
+		Frontend:     c.ingressExtraFrontend(ingress),
+	}, c.Cfg.MapFiles)
+}
+
 func (c *HAProxyController) sslPassthroughEnabled(ingress *store.Ingress, path *store.IngressPath) bool {
+	// Extra frontends do not support SSL passthrough
+	if c.ingressExtraFrontend(ingress) != "" {
+		return false
+	}
 	var annSSLPassthrough string
 	var service *store.Service
 	ok := false
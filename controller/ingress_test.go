@@ -0,0 +1,106 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+func TestIngressClassNamespaceConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotation  string
+		namespaceCM string
+		supported   bool
+	}{
+		{"no class", "", "", false},
+		{"namespace class", "", "haproxy", true},
+		{"other namespace class", "", "nginx", false},
+		{"ingress class", "haproxy", "", true},
+		{"ingress class over namespace one", "haproxy", "nginx", true},
+		{"other ingress class over namespace one", "nginx", "haproxy", false},
+	}
+	for _, test := range tests {
+		osArgs := utils.OSArgs{IngressClass: "haproxy"}
+		c := &HAProxyController{OSArgs: osArgs, Store: store.NewK8sStore(osArgs)}
+		ns := c.Store.GetNamespace("team-a")
+		if test.namespaceCM != "" {
+			ns.ConfigMaps[store.NamespaceConfigMapName] = &store.ConfigMap{
+				Namespace:   "team-a",
+				Name:        store.NamespaceConfigMapName,
+				Annotations: map[string]string{"ingress.class": test.namespaceCM},
+			}
+		}
+		ingress := &store.Ingress{Namespace: "team-a", Name: "app", Annotations: map[string]string{}}
+		if test.annotation != "" {
+			ingress.Annotations["ingress.class"] = test.annotation
+		}
+		if supported := c.igClassIsSupported(c.Store.WithNamespaceConfig(ingress)); supported != test.supported {
+			t.Errorf("%s: igClassIsSupported() = %t, want %t", test.name, supported, test.supported)
+		}
+	}
+}
+
+func TestEmptyIngressClass(t *testing.T) {
+	defaultClass := store.IngressClass{Name: "haproxy", Controller: CONTROLLER_CLASS, Default: true}
+	nonDefaultClass := store.IngressClass{Name: "haproxy", Controller: CONTROLLER_CLASS}
+	otherDefaultClass := store.IngressClass{Name: "nginx", Controller: "k8s.io/ingress-nginx", Default: true}
+	event := func(class store.IngressClass, status store.Status) *store.IngressClass {
+		class.Status = status
+		return &class
+	}
+	tests := []struct {
+		name      string
+		osArgs    utils.OSArgs
+		events    []*store.IngressClass
+		supported bool
+	}{
+		{"no IngressClass", utils.OSArgs{}, nil, false},
+		{"default IngressClass", utils.OSArgs{}, []*store.IngressClass{event(defaultClass, store.ADDED)}, true},
+		{"non-default IngressClass", utils.OSArgs{}, []*store.IngressClass{event(nonDefaultClass, store.ADDED)}, false},
+		{"default IngressClass of another controller", utils.OSArgs{}, []*store.IngressClass{event(otherDefaultClass, store.ADDED)}, false},
+		{"IngressClass no more default", utils.OSArgs{}, []*store.IngressClass{event(defaultClass, store.ADDED), event(nonDefaultClass, store.MODIFIED)}, false},
+		{"deleted default IngressClass", utils.OSArgs{}, []*store.IngressClass{event(defaultClass, store.ADDED), event(defaultClass, store.DELETED)}, false},
+		{"re-created default IngressClass", utils.OSArgs{}, []*store.IngressClass{event(defaultClass, store.ADDED), event(defaultClass, store.DELETED), event(defaultClass, store.ADDED)}, true},
+		{"empty-ingress-class", utils.OSArgs{IngressClass: "haproxy", EmptyIngressClass: true}, nil, true},
+		{"legacy-empty-ingress-class", utils.OSArgs{LegacyEmptyIngressClass: true}, nil, true},
+		{"legacy-empty-ingress-class with ingress.class", utils.OSArgs{IngressClass: "haproxy", LegacyEmptyIngressClass: true}, []*store.IngressClass{event(nonDefaultClass, store.ADDED)}, true},
+	}
+	for _, test := range tests {
+		c := &HAProxyController{OSArgs: test.osArgs, Store: store.NewK8sStore(test.osArgs)}
+		ns := c.Store.GetNamespace("default")
+		ingress := &store.Ingress{Namespace: "default", Name: "app", Annotations: map[string]string{}, Status: store.ADDED}
+		c.Store.EventIngress(ns, ingress, test.osArgs.IngressClass)
+		for _, igClass := range test.events {
+			c.Store.Clean()
+			if !c.Store.EventIngressClass(igClass) {
+				t.Errorf("%s: EventIngressClass(%s %s) did not require an update", test.name, igClass.Name, igClass.Status)
+			}
+			if ingress.Status != store.MODIFIED {
+				t.Errorf("%s: ingress status after IngressClass %s = %s, want %s", test.name, igClass.Status, ingress.Status, store.MODIFIED)
+			}
+		}
+		status := ingress.Status
+		if supported := c.igClassIsSupported(ingress); supported != test.supported {
+			t.Errorf("%s: igClassIsSupported() = %t, want %t", test.name, supported, test.supported)
+		}
+		if ingress.Status != status {
+			t.Errorf("%s: igClassIsSupported() changed ingress status from %s to %s", test.name, status, ingress.Status)
+		}
+	}
+}
@@ -15,13 +15,22 @@
 package controller
 
 import (
+	"context"
 	"errors"
+	"strconv"
 
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 
 	ingstatus "github.com/haproxytech/kubernetes-ingress/controller/status"
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
@@ -36,9 +45,14 @@ const (
 
 var ErrIgnored = errors.New("ignored resource")
 
+// labelServiceImportName is the label of the EndpointSlices imported
+// from other clusters, holding the name of their ServiceImport
+const labelServiceImportName = "multicluster.kubernetes.io/service-name"
+
 // K8s is structure with all data required to synchronize with k8s
 type K8s struct {
 	API                        *kubernetes.Clientset
+	DynamicAPI                 dynamic.Interface
 	Logger                     utils.Logger
 	DisableServiceExternalName bool // CVE-2021-25740
 }
@@ -58,8 +72,13 @@ func GetKubernetesClient(disableServiceExternalName bool) (*K8s, error) {
 	if err != nil {
 		logger.Panic(err)
 	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		logger.Panic(err)
+	}
 	return &K8s{
 		API:                        clientset,
+		DynamicAPI:                 dynamicClient,
 		Logger:                     k8sLogger,
 		DisableServiceExternalName: disableServiceExternalName,
 	}, nil
@@ -83,538 +102,398 @@ func GetRemoteKubernetesClient(kubeconfig string, disableServiceExternalName boo
 	if err != nil {
 		logger.Panic(err)
 	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		logger.Panic(err)
+	}
 	return &K8s{
 		API:                        clientset,
+		DynamicAPI:                 dynamicClient,
 		Logger:                     k8sLogger,
 		DisableServiceExternalName: disableServiceExternalName,
 	}, nil
 }
 
-func (k *K8s) EventsNamespaces(channel chan SyncDataEvent, stop chan struct{}, informer cache.SharedIndexInformer) {
-	informer.AddEventHandler(
-		cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				data, ok := obj.(*corev1.Namespace)
-				if !ok {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", NAMESPACE, obj)
-					return
-				}
-				status := ADDED
-				if data.ObjectMeta.GetDeletionTimestamp() != nil {
-					// detect services that are in terminating state
-					status = DELETED
-				}
-				item := &store.Namespace{
-					Name:      data.GetName(),
-					Endpoints: make(map[string]*store.Endpoints),
-					Services:  make(map[string]*store.Service),
-					Ingresses: make(map[string]*store.Ingress),
-					Secret:    make(map[string]*store.Secret),
-					Status:    status,
-				}
-				k.Logger.Tracef("%s %s: %s", NAMESPACE, item.Status, item.Name)
-				channel <- SyncDataEvent{SyncType: NAMESPACE, Namespace: item.Name, Data: item}
-			},
-			DeleteFunc: func(obj interface{}) {
-				data, ok := obj.(*corev1.Namespace)
-				if !ok {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", NAMESPACE, obj)
-					return
-				}
-				status := DELETED
-				item := &store.Namespace{
-					Name:      data.GetName(),
-					Endpoints: make(map[string]*store.Endpoints),
-					Services:  make(map[string]*store.Service),
-					Ingresses: make(map[string]*store.Ingress),
-					Secret:    make(map[string]*store.Secret),
-					Status:    status,
-				}
-				k.Logger.Tracef("%s %s: %s", NAMESPACE, item.Status, item.Name)
-				channel <- SyncDataEvent{SyncType: NAMESPACE, Namespace: item.Name, Data: item}
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				data1, ok := oldObj.(*corev1.Namespace)
-				if !ok {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", NAMESPACE, oldObj)
-					return
-				}
-				data2, ok := newObj.(*corev1.Namespace)
-				if !ok {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", NAMESPACE, newObj)
-					return
-				}
-				status := MODIFIED
-				item1 := &store.Namespace{
-					Name:   data1.GetName(),
-					Status: status,
-				}
-				item2 := &store.Namespace{
-					Name:   data2.GetName(),
-					Status: status,
-				}
-				if item1.Name == item2.Name {
-					return
-				}
-				k.Logger.Tracef("%s %s: %s", SERVICE, item2.Status, item2.Name)
-				channel <- SyncDataEvent{SyncType: NAMESPACE, Namespace: item2.Name, Data: item2}
-			},
-		},
-	)
-	go informer.Run(stop)
+// EventRecorder returns a recorder of the Kubernetes Events reported by the controller
+func (k *K8s) EventRecorder() record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: k.API.CoreV1().Events("")})
+	return broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "haproxy-ingress-controller"})
 }
 
-func (k *K8s) EventsEndpoints(channel chan SyncDataEvent, stop chan struct{}, informer cache.SharedIndexInformer) {
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: func(obj interface{}) {
-			item, err := k.convertToEndpoints(obj, ADDED)
-			if errors.Is(err, ErrIgnored) {
-				return
-			}
-			k.Logger.Tracef("%s %s: %s", ENDPOINTS, item.Status, item.Service)
-			channel <- SyncDataEvent{SyncType: ENDPOINTS, Namespace: item.Namespace, Data: item}
-		},
-		DeleteFunc: func(obj interface{}) {
-			item, err := k.convertToEndpoints(obj, DELETED)
-			if errors.Is(err, ErrIgnored) {
-				return
-			}
-			k.Logger.Tracef("%s %s: %s", ENDPOINTS, item.Status, item.Service)
-			channel <- SyncDataEvent{SyncType: ENDPOINTS, Namespace: item.Namespace, Data: item}
-		},
-		UpdateFunc: func(oldObj, newObj interface{}) {
-			item1, err := k.convertToEndpoints(oldObj, EMPTY)
-			if errors.Is(err, ErrIgnored) {
-				return
+// Converters of informers resources, see ResourceQueue
+
+func (k *K8s) NamespaceEvent(obj interface{}, status store.Status) (SyncDataEvent, error) {
+	data, ok := obj.(*corev1.Namespace)
+	if !ok {
+		k.Logger.Errorf("%s: Invalid data from k8s api, %s", NAMESPACE, obj)
+		return SyncDataEvent{}, ErrIgnored
+	}
+	if data.ObjectMeta.GetDeletionTimestamp() != nil {
+		// detect namespaces that are in terminating state
+		status = DELETED
+	}
+	item := &store.Namespace{
+		Name:   data.GetName(),
+		Status: status,
+	}
+	return SyncDataEvent{SyncType: NAMESPACE, Namespace: item.Name, Data: item}, nil
+}
+
+func (k *K8s) EndpointSliceEvent(obj interface{}, status store.Status) (SyncDataEvent, error) {
+	item, err := k.convertToEndpointSlice(obj, status)
+	if err != nil {
+		return SyncDataEvent{}, err
+	}
+	return SyncDataEvent{SyncType: ENDPOINTS, Namespace: item.Namespace, Data: item}, nil
+}
+
+func (k *K8s) PodEvent(obj interface{}, status store.Status) (SyncDataEvent, error) {
+	item, err := k.convertToPod(obj, status)
+	if err != nil {
+		return SyncDataEvent{}, err
+	}
+	return SyncDataEvent{SyncType: POD, Namespace: item.Namespace, Data: item}, nil
+}
+
+func (k *K8s) IngressClassEvent(obj interface{}, status store.Status) (SyncDataEvent, error) {
+	item, err := store.ConvertToIngressClass(obj)
+	if err != nil {
+		k.Logger.Errorf("%s: Invalid data from k8s api, %s", INGRESS_CLASS, obj)
+		return SyncDataEvent{}, ErrIgnored
+	}
+	if status == DELETED {
+		item.Status = DELETED
+	}
+	return SyncDataEvent{SyncType: INGRESS_CLASS, Data: item}, nil
+}
+
+func (k *K8s) IngressEvent(obj interface{}, status store.Status) (SyncDataEvent, error) {
+	item, err := store.ConvertToIngress(obj)
+	if err != nil {
+		k.Logger.Errorf("%s: Invalid data from k8s api, %s", INGRESS, obj)
+		return SyncDataEvent{}, ErrIgnored
+	}
+	if status == DELETED {
+		item.Status = DELETED
+	}
+	return SyncDataEvent{SyncType: INGRESS, Namespace: item.Namespace, Data: item}, nil
+}
+
+// ServiceEvent returns the converter of services, the publish service being
+// also sent to ingChan for the status of ingresses to be updated.
+func (k *K8s) ServiceEvent(ingChan chan ingstatus.SyncIngress, publishSvc *utils.NamespaceValue) Converter {
+	return func(obj interface{}, status store.Status) (SyncDataEvent, error) {
+		data, ok := obj.(*corev1.Service)
+		if !ok {
+			k.Logger.Errorf("%s: Invalid data from k8s api, %s", SERVICE, obj)
+			return SyncDataEvent{}, ErrIgnored
+		}
+		if data.Spec.Type == corev1.ServiceTypeExternalName && k.DisableServiceExternalName {
+			// Ingresses using this service will have no backend servers
+			if status != DELETED {
+				k.Logger.Warningf("%s '%s/%s': forwarding to ExternalName services is disabled by --disable-service-external-name", SERVICE, data.GetNamespace(), data.GetName())
 			}
-			item2, _ := k.convertToEndpoints(newObj, MODIFIED)
-			if item2.Equal(item1) {
-				return
+			return SyncDataEvent{}, ErrIgnored
+		}
+		if data.ObjectMeta.GetDeletionTimestamp() != nil {
+			// detect services that are in terminating state
+			status = DELETED
+		}
+		item := &store.Service{
+			Namespace:   data.GetNamespace(),
+			Name:        data.GetName(),
+			Annotations: store.CopyAnnotations(data.ObjectMeta.Annotations),
+			Ports:       []store.ServicePort{},
+			Status:      status,
+		}
+		if data.Spec.Type == corev1.ServiceTypeExternalName {
+			item.DNS = data.Spec.ExternalName
+		}
+		item.Headless = data.Spec.ClusterIP == corev1.ClusterIPNone
+		for _, sp := range data.Spec.Ports {
+			item.Ports = append(item.Ports, store.ServicePort{
+				Name:       sp.Name,
+				Protocol:   string(sp.Protocol),
+				Port:       int64(sp.Port),
+				TargetPort: int64(sp.TargetPort.IntVal),
+			})
+		}
+		if publishSvc != nil && publishSvc.Namespace == data.Namespace && publishSvc.Name == data.Name {
+			select {
+			case ingChan <- ingstatus.SyncIngress{Service: data}:
+			default:
+				k.Logger.Errorf("%s '%s/%s': unable to sync ingresses status: sync channel full", SERVICE, data.GetNamespace(), data.GetName())
 			}
-			// fix modified state for ones that are deleted,new,same
-			k.Logger.Tracef("%s %s: %s", ENDPOINTS, item2.Status, item2.Service)
-			channel <- SyncDataEvent{SyncType: ENDPOINTS, Namespace: item2.Namespace, Data: item2}
-		},
-	})
-	go informer.Run(stop)
+		}
+		return SyncDataEvent{SyncType: SERVICE, Namespace: item.Namespace, Data: item}, nil
+	}
 }
 
-func (k *K8s) convertToEndpoints(obj interface{}, status store.Status) (*store.Endpoints, error) {
-	data, ok := obj.(*corev1.Endpoints)
+func (k *K8s) ConfigMapEvent(obj interface{}, status store.Status) (SyncDataEvent, error) {
+	data, ok := obj.(*corev1.ConfigMap)
 	if !ok {
-		k.Logger.Errorf("%s: Invalid data from k8s api, %s", ENDPOINTS, obj)
-		return nil, ErrIgnored
+		k.Logger.Errorf("%s: Invalid data from k8s api, %s", CONFIGMAP, obj)
+		return SyncDataEvent{}, ErrIgnored
 	}
-	if data.GetNamespace() == "kube-system" {
-		if data.ObjectMeta.Name == "kube-controller-manager" ||
-			data.ObjectMeta.Name == "kube-scheduler" ||
-			data.ObjectMeta.Name == "kubernetes-dashboard" ||
-			data.ObjectMeta.Name == "kube-dns" {
-			return nil, ErrIgnored
-		}
+	if data.ObjectMeta.GetDeletionTimestamp() != nil {
+		// detect configmaps that are in terminating state
+		status = DELETED
+	}
+	item := &store.ConfigMap{
+		Namespace:   data.GetNamespace(),
+		Name:        data.GetName(),
+		Annotations: store.CopyAnnotations(data.Data),
+		Status:      status,
+	}
+	return SyncDataEvent{SyncType: CONFIGMAP, Namespace: item.Namespace, Data: item}, nil
+}
+
+func (k *K8s) SecretEvent(obj interface{}, status store.Status) (SyncDataEvent, error) {
+	data, ok := obj.(*corev1.Secret)
+	if !ok {
+		k.Logger.Errorf("%s: Invalid data from k8s api, %s", SECRET, obj)
+		return SyncDataEvent{}, ErrIgnored
 	}
 	if data.ObjectMeta.GetDeletionTimestamp() != nil {
-		// detect endpoints that are in terminating state
+		// detect secrets that are in terminating state
 		status = DELETED
 	}
-	item := &store.Endpoints{
+	item := &store.Secret{
 		Namespace: data.GetNamespace(),
-		Service:   data.GetName(),
-		Ports:     make(map[string]*store.PortEndpoints),
+		Name:      data.GetName(),
+		Data:      data.Data,
 		Status:    status,
 	}
-	for _, subset := range data.Subsets {
-		for _, port := range subset.Ports {
-			addresses := make(map[string]struct{})
-			for _, address := range subset.Addresses {
-				addresses[address.IP] = struct{}{}
-			}
-			item.Ports[port.Name] = &store.PortEndpoints{
-				Port:        int64(port.Port),
-				AddrCount:   len(addresses),
-				AddrNew:     addresses,
-				HAProxySrvs: make([]*store.HAProxySrv, 0, len(addresses)),
-			}
-		}
-	}
-	return item, nil
+	return SyncDataEvent{SyncType: SECRET, Namespace: item.Namespace, Data: item}, nil
 }
 
-func (k *K8s) EventsIngressClass(channel chan SyncDataEvent, stop chan struct{}, informer cache.SharedIndexInformer) {
-	informer.AddEventHandler(
-		cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				item, err := store.ConvertToIngressClass(obj)
-				if err != nil {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", INGRESS_CLASS, obj)
-					return
-				}
-				k.Logger.Tracef("%s %s: %s", INGRESS_CLASS, item.Status, item.Name)
-				channel <- SyncDataEvent{SyncType: INGRESS_CLASS, Data: item}
-			},
-			DeleteFunc: func(obj interface{}) {
-				item, err := store.ConvertToIngressClass(obj)
-				if err != nil {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", INGRESS_CLASS, obj)
-					return
-				}
-				item.Status = DELETED
-				k.Logger.Tracef("%s %s: %s", INGRESS_CLASS, item.Status, item.Name)
-				channel <- SyncDataEvent{SyncType: INGRESS_CLASS, Data: item}
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				item1, err := store.ConvertToIngressClass(oldObj)
-				if err != nil {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", INGRESS_CLASS, oldObj)
-					return
-				}
-				item1.Status = MODIFIED
+func (k *K8s) PeersEvent(obj interface{}, status store.Status) (SyncDataEvent, error) {
+	item, err := store.ConvertToPeers(obj, status)
+	if err != nil {
+		return SyncDataEvent{}, err
+	}
+	return SyncDataEvent{SyncType: PEERS, Namespace: item.Namespace, Data: item}, nil
+}
 
-				item2, err := store.ConvertToIngressClass(newObj)
-				if err != nil {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", INGRESS, oldObj)
-					return
-				}
-				item1.Status = MODIFIED
+// ServiceImportEvent converts ServiceImports into services, their backends
+// being populated from the EndpointSlices imported from other clusters.
+func (k *K8s) ServiceImportEvent(obj interface{}, status store.Status) (SyncDataEvent, error) {
+	item, err := store.ConvertToServiceImport(obj, status)
+	if err != nil {
+		return SyncDataEvent{}, err
+	}
+	return SyncDataEvent{SyncType: SERVICE, Namespace: item.Namespace, Data: item}, nil
+}
 
-				if item2.Equal(item1) {
-					return
-				}
-				k.Logger.Tracef("%s %s: %s", INGRESS_CLASS, item2.Status, item2.Name)
-				channel <- SyncDataEvent{SyncType: INGRESS_CLASS, Data: item2}
-			},
-		},
-	)
-	go informer.Run(stop)
+func (k *K8s) BackendEvent(obj interface{}, status store.Status) (SyncDataEvent, error) {
+	item, err := store.ConvertToBackend(obj, status)
+	if err != nil {
+		return SyncDataEvent{}, err
+	}
+	return SyncDataEvent{SyncType: BACKEND, Namespace: item.Namespace, Data: item}, nil
 }
 
-func (k *K8s) EventsIngresses(channel chan SyncDataEvent, stop chan struct{}, informer cache.SharedIndexInformer) {
-	informer.AddEventHandler(
-		cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				item, err := store.ConvertToIngress(obj)
-				if err != nil {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", INGRESS, obj)
-					return
-				}
-				k.Logger.Tracef("%s %s: %s", INGRESS, item.Status, item.Name)
-				channel <- SyncDataEvent{SyncType: INGRESS, Namespace: item.Namespace, Data: item}
-			},
-			DeleteFunc: func(obj interface{}) {
-				item, err := store.ConvertToIngress(obj)
-				if err != nil {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", INGRESS, obj)
-					return
-				}
-				item.Status = DELETED
-				k.Logger.Tracef("%s %s: %s", INGRESS, item.Status, item.Name)
-				channel <- SyncDataEvent{SyncType: INGRESS, Namespace: item.Namespace, Data: item}
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				item1, err := store.ConvertToIngress(oldObj)
-				if err != nil {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", INGRESS, oldObj)
-					return
-				}
-				item1.Status = MODIFIED
+func (k *K8s) TLSPolicyEvent(obj interface{}, status store.Status) (SyncDataEvent, error) {
+	item, err := store.ConvertToTLSPolicy(obj, status)
+	if err != nil {
+		return SyncDataEvent{}, err
+	}
+	return SyncDataEvent{SyncType: TLS_POLICY, Namespace: item.Namespace, Data: item}, nil
+}
 
-				item2, err := store.ConvertToIngress(newObj)
-				if err != nil {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", INGRESS, oldObj)
-					return
-				}
-				item1.Status = MODIFIED
+func (k *K8s) GlobalEvent(obj interface{}, status store.Status) (SyncDataEvent, error) {
+	item, err := store.ConvertToGlobal(obj, status)
+	if err != nil {
+		return SyncDataEvent{}, err
+	}
+	return SyncDataEvent{SyncType: GLOBAL, Namespace: item.Namespace, Data: item}, nil
+}
 
-				if item2.Equal(item1) {
-					return
-				}
-				k.Logger.Tracef("%s %s: %s", INGRESS, item2.Status, item2.Name)
-				channel <- SyncDataEvent{SyncType: INGRESS, Namespace: item2.Namespace, Data: item2}
-			},
-		},
-	)
-	go informer.Run(stop)
+// endpointSliceEndpoint is an EndpointSlice endpoint of discovery.k8s.io v1 or v1beta1 API
+type endpointSliceEndpoint struct {
+	addresses   []string
+	ready       *bool
+	serving     *bool
+	terminating *bool
+	targetRef   *corev1.ObjectReference
+	zone        string
+	zoneHints   []string
 }
 
-func (k *K8s) EventsServices(channel chan SyncDataEvent, ingChan chan ingstatus.SyncIngress, stop chan struct{}, informer cache.SharedIndexInformer, publishSvc *utils.NamespaceValue) {
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: func(obj interface{}) {
-			data, ok := obj.(*corev1.Service)
-			if !ok {
-				k.Logger.Errorf("%s: Invalid data from k8s api, %s", SERVICE, obj)
-				return
-			}
-			if data.Spec.Type == corev1.ServiceTypeExternalName && k.DisableServiceExternalName {
-				k.Logger.Tracef("forwarding to ExternalName Services for %v is disabled", data)
-				return
-			}
-			status := ADDED
-			if data.ObjectMeta.GetDeletionTimestamp() != nil {
-				// detect services that are in terminating state
-				status = DELETED
-			}
-			item := &store.Service{
-				Namespace:   data.GetNamespace(),
-				Name:        data.GetName(),
-				Annotations: store.CopyAnnotations(data.ObjectMeta.Annotations),
-				Ports:       []store.ServicePort{},
-				Status:      status,
-			}
-			if data.Spec.Type == corev1.ServiceTypeExternalName {
-				item.DNS = data.Spec.ExternalName
+func (k *K8s) convertToEndpointSlice(obj interface{}, status store.Status) (*store.EndpointSlice, error) {
+	var meta metav1.Object
+	var addressType string
+	var endpoints []endpointSliceEndpoint
+	var ports []discoveryv1.EndpointPort
+	switch data := obj.(type) {
+	case *discoveryv1.EndpointSlice:
+		meta, addressType, ports = data, string(data.AddressType), data.Ports
+		for _, e := range data.Endpoints {
+			endpoint := endpointSliceEndpoint{e.Addresses, e.Conditions.Ready, e.Conditions.Serving, e.Conditions.Terminating, e.TargetRef, "", nil}
+			if e.Zone != nil {
+				endpoint.zone = *e.Zone
 			}
-			for _, sp := range data.Spec.Ports {
-				item.Ports = append(item.Ports, store.ServicePort{
-					Name:     sp.Name,
-					Protocol: string(sp.Protocol),
-					Port:     int64(sp.Port),
-				})
-			}
-			k.Logger.Tracef("%s %s: %s", SERVICE, item.Status, item.Name)
-			channel <- SyncDataEvent{SyncType: SERVICE, Namespace: item.Namespace, Data: item}
-			if publishSvc != nil && publishSvc.Namespace == data.Namespace && publishSvc.Name == data.Name {
-				ingChan <- ingstatus.SyncIngress{Service: data}
-			}
-		},
-		DeleteFunc: func(obj interface{}) {
-			data, ok := obj.(*corev1.Service)
-			if !ok {
-				k.Logger.Errorf("%s: Invalid data from k8s api, %s", SERVICE, obj)
-				return
-			}
-			if data.Spec.Type == corev1.ServiceTypeExternalName && k.DisableServiceExternalName {
-				return
-			}
-			status := DELETED
-			item := &store.Service{
-				Namespace:   data.GetNamespace(),
-				Name:        data.GetName(),
-				Annotations: store.CopyAnnotations(data.ObjectMeta.Annotations),
-				Status:      status,
-			}
-			if data.Spec.Type == corev1.ServiceTypeExternalName {
-				item.DNS = data.Spec.ExternalName
-			}
-			k.Logger.Tracef("%s %s: %s", SERVICE, item.Status, item.Name)
-			channel <- SyncDataEvent{SyncType: SERVICE, Namespace: item.Namespace, Data: item}
-			if publishSvc != nil && publishSvc.Namespace == data.Namespace && publishSvc.Name == data.Name {
-				ingChan <- ingstatus.SyncIngress{Service: data}
-			}
-		},
-		UpdateFunc: func(oldObj, newObj interface{}) {
-			data1, ok := oldObj.(*corev1.Service)
-			if !ok {
-				k.Logger.Errorf("%s: Invalid data from k8s api, %s", SERVICE, oldObj)
-				return
+			if e.Hints != nil {
+				for _, zone := range e.Hints.ForZones {
+					endpoint.zoneHints = append(endpoint.zoneHints, zone.Name)
+				}
 			}
-			if data1.Spec.Type == corev1.ServiceTypeExternalName && k.DisableServiceExternalName {
-				k.Logger.Tracef("forwarding to ExternalName Services for %v is disabled", data1)
-				return
+			endpoints = append(endpoints, endpoint)
+		}
+	case *discoveryv1beta1.EndpointSlice:
+		meta, addressType = data, string(data.AddressType)
+		for _, e := range data.Endpoints {
+			endpoint := endpointSliceEndpoint{e.Addresses, e.Conditions.Ready, e.Conditions.Serving, e.Conditions.Terminating, e.TargetRef, e.Topology[corev1.LabelTopologyZone], nil}
+			if e.Hints != nil {
+				for _, zone := range e.Hints.ForZones {
+					endpoint.zoneHints = append(endpoint.zoneHints, zone.Name)
+				}
 			}
-			data2, ok := newObj.(*corev1.Service)
-			if !ok {
-				k.Logger.Errorf("%s: Invalid data from k8s api, %s", SERVICE, newObj)
-				return
+			endpoints = append(endpoints, endpoint)
+		}
+		for _, p := range data.Ports {
+			ports = append(ports, discoveryv1.EndpointPort{Name: p.Name, Port: p.Port})
+		}
+	default:
+		k.Logger.Errorf("%s: Invalid data from k8s api, %s", ENDPOINTS, obj)
+		return nil, ErrIgnored
+	}
+	service := meta.GetLabels()[discoveryv1.LabelServiceName]
+	// Slices imported from the clusters of a ClusterSet are the endpoints of their ServiceImport
+	if serviceImport := meta.GetLabels()[labelServiceImportName]; serviceImport != "" {
+		service = store.ServiceImportName(serviceImport)
+	}
+	// Slices not managed for a service, and FQDN ones, are not used
+	if service == "" || (addressType != string(discoveryv1.AddressTypeIPv4) && addressType != string(discoveryv1.AddressTypeIPv6)) {
+		return nil, ErrIgnored
+	}
+	if meta.GetNamespace() == "kube-system" {
+		if service == "kube-controller-manager" ||
+			service == "kube-scheduler" ||
+			service == "kubernetes-dashboard" ||
+			service == "kube-dns" {
+			return nil, ErrIgnored
+		}
+	}
+	if meta.GetDeletionTimestamp() != nil {
+		// detect endpoint slices that are in terminating state
+		status = DELETED
+	}
+	item := &store.EndpointSlice{
+		Namespace: meta.GetNamespace(),
+		Name:      meta.GetName(),
+		Service:   service,
+		Ports:     make(map[string]*store.SlicePort),
+		Status:    status,
+	}
+	for _, port := range ports {
+		// Port is unset when the slice applies to all ports, which services don't use
+		if port.Port == nil {
+			continue
+		}
+		slicePort := &store.SlicePort{
+			Port:      int64(*port.Port),
+			Ready:     make(map[string]struct{}),
+			Serving:   make(map[string]struct{}),
+			PodNames:  make(map[string]string),
+			Zones:     make(map[string]string),
+			ZoneHints: make(map[string][]string),
+		}
+		for _, endpoint := range endpoints {
+			if len(endpoint.addresses) == 0 {
+				continue
 			}
-			if data2.Spec.Type == corev1.ServiceTypeExternalName && k.DisableServiceExternalName {
-				k.Logger.Tracef("forwarding to ExternalName Services for %v is disabled", data2)
-				return
+			// Addresses are fungible, consumers being allowed to only use the first one
+			address := endpoint.addresses[0]
+			// Unknown conditions are to be interpreted as ready and serving
+			ready := endpoint.ready == nil || *endpoint.ready
+			serving := ready
+			if endpoint.serving != nil {
+				serving = *endpoint.serving
 			}
-			if publishSvc != nil && publishSvc.Namespace == data2.Namespace && publishSvc.Name == data2.Name {
-				ingChan <- ingstatus.SyncIngress{Service: data2}
+			terminating := endpoint.terminating != nil && *endpoint.terminating
+			switch {
+			case ready && !terminating:
+				slicePort.Ready[address] = struct{}{}
+			case serving && terminating:
+				slicePort.Serving[address] = struct{}{}
+			default:
+				continue
 			}
-			status := MODIFIED
-			item1 := &store.Service{
-				Namespace:   data1.GetNamespace(),
-				Name:        data1.GetName(),
-				Annotations: store.CopyAnnotations(data1.ObjectMeta.Annotations),
-				Ports:       []store.ServicePort{},
-				Status:      status,
+			if endpoint.targetRef != nil && endpoint.targetRef.Kind == "Pod" {
+				slicePort.PodNames[address] = endpoint.targetRef.Name
 			}
-			if data1.Spec.Type == corev1.ServiceTypeExternalName {
-				item1.DNS = data1.Spec.ExternalName
+			if endpoint.zone != "" {
+				slicePort.Zones[address] = endpoint.zone
 			}
-			for _, sp := range data1.Spec.Ports {
-				item1.Ports = append(item1.Ports, store.ServicePort{
-					Name:     sp.Name,
-					Protocol: string(sp.Protocol),
-					Port:     int64(sp.Port),
-				})
+			if len(endpoint.zoneHints) != 0 {
+				slicePort.ZoneHints[address] = endpoint.zoneHints
 			}
+		}
+		portName := ""
+		if port.Name != nil {
+			portName = *port.Name
+		}
+		item.Ports[portName] = slicePort
+	}
+	return item, nil
+}
 
-			item2 := &store.Service{
-				Namespace:   data2.GetNamespace(),
-				Name:        data2.GetName(),
-				Annotations: store.CopyAnnotations(data2.ObjectMeta.Annotations),
-				Ports:       []store.ServicePort{},
-				Status:      status,
-			}
-			if data2.Spec.Type == corev1.ServiceTypeExternalName {
-				item2.DNS = data2.Spec.ExternalName
-			}
-			for _, sp := range data2.Spec.Ports {
-				item2.Ports = append(item2.Ports, store.ServicePort{
-					Name:     sp.Name,
-					Protocol: string(sp.Protocol),
-					Port:     int64(sp.Port),
-				})
-			}
-			if item2.Equal(item1) {
-				return
-			}
-			k.Logger.Tracef("%s %s: %s", SERVICE, item2.Status, item2.Name)
-			channel <- SyncDataEvent{SyncType: SERVICE, Namespace: item2.Namespace, Data: item2}
-		},
-	})
-	go informer.Run(stop)
+// convertToPod keeps the IP of the pod and the weight of its backend servers
+// set by the pod "server-weight" annotation, from 0 to 256 as HAProxy weights.
+func (k *K8s) convertToPod(obj interface{}, status store.Status) (*store.Pod, error) {
+	data, ok := obj.(*corev1.Pod)
+	if !ok {
+		k.Logger.Errorf("%s: Invalid data from k8s api, %s", POD, obj)
+		return nil, ErrIgnored
+	}
+	item := &store.Pod{
+		Namespace: data.GetNamespace(),
+		Name:      data.GetName(),
+		IP:        data.Status.PodIP,
+		Status:    status,
+	}
+	if value, found := store.CopyAnnotations(data.GetAnnotations())["server-weight"]; found {
+		weight, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || weight < 0 || weight > 256 {
+			k.Logger.Errorf("%s '%s/%s': server-weight: incorrect value '%s'", POD, item.Namespace, item.Name, value)
+		} else {
+			item.Weight = &weight
+		}
+	}
+	return item, nil
 }
 
-func (k *K8s) EventsConfigfMaps(channel chan SyncDataEvent, stop chan struct{}, informer cache.SharedIndexInformer) {
-	informer.AddEventHandler(
-		cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				data, ok := obj.(*corev1.ConfigMap)
-				if !ok {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", CONFIGMAP, obj)
-					return
-				}
-				status := ADDED
-				if data.ObjectMeta.GetDeletionTimestamp() != nil {
-					// detect services that are in terminating state
-					status = DELETED
-				}
-				item := &store.ConfigMap{
-					Namespace:   data.GetNamespace(),
-					Name:        data.GetName(),
-					Annotations: store.CopyAnnotations(data.Data),
-					Status:      status,
-				}
-				k.Logger.Tracef("%s %s: %s", CONFIGMAP, item.Status, item.Name)
-				channel <- SyncDataEvent{SyncType: CONFIGMAP, Namespace: item.Namespace, Data: item}
-			},
-			DeleteFunc: func(obj interface{}) {
-				data, ok := obj.(*corev1.ConfigMap)
-				if !ok {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", CONFIGMAP, obj)
-					return
-				}
-				status := DELETED
-				item := &store.ConfigMap{
-					Namespace:   data.GetNamespace(),
-					Name:        data.GetName(),
-					Annotations: store.CopyAnnotations(data.Data),
-					Status:      status,
-				}
-				k.Logger.Tracef("%s %s: %s", CONFIGMAP, item.Status, item.Name)
-				channel <- SyncDataEvent{SyncType: CONFIGMAP, Namespace: item.Namespace, Data: item}
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				data1, ok := oldObj.(*corev1.ConfigMap)
-				if !ok {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", CONFIGMAP, oldObj)
-					return
-				}
-				data2, ok := newObj.(*corev1.ConfigMap)
-				if !ok {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", CONFIGMAP, newObj)
-					return
-				}
-				status := MODIFIED
-				item1 := &store.ConfigMap{
-					Namespace:   data1.GetNamespace(),
-					Name:        data1.GetName(),
-					Annotations: store.CopyAnnotations(data1.Data),
-					Status:      status,
-				}
-				item2 := &store.ConfigMap{
-					Namespace:   data2.GetNamespace(),
-					Name:        data2.GetName(),
-					Annotations: store.CopyAnnotations(data2.Data),
-					Status:      status,
-				}
-				if item2.Equal(item1) {
-					return
-				}
-				k.Logger.Tracef("%s %s: %s", CONFIGMAP, item2.Status, item2.Name)
-				channel <- SyncDataEvent{SyncType: CONFIGMAP, Namespace: item2.Namespace, Data: item2}
-			},
-		},
-	)
-	go informer.Run(stop)
+
+// GetPodZone returns the zone of the node running the given pod, read from its topology label
+func (k *K8s) GetPodZone(namespace, name string) (string, error) {
+	pod, err := k.API.CoreV1().Pods(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if pod.Spec.NodeName == "" {
+		return "", errors.New("pod not scheduled")
+	}
+	node, err := k.API.CoreV1().Nodes().Get(context.Background(), pod.Spec.NodeName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	return node.Labels[corev1.LabelTopologyZone], nil
 }
 
-func (k *K8s) EventsSecrets(channel chan SyncDataEvent, stop chan struct{}, informer cache.SharedIndexInformer) {
-	informer.AddEventHandler(
-		cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				data, ok := obj.(*corev1.Secret)
-				if !ok {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", SECRET, obj)
-					return
-				}
-				status := ADDED
-				if data.ObjectMeta.GetDeletionTimestamp() != nil {
-					// detect services that are in terminating state
-					status = DELETED
-				}
-				item := &store.Secret{
-					Namespace: data.GetNamespace(),
-					Name:      data.GetName(),
-					Data:      data.Data,
-					Status:    status,
-				}
-				k.Logger.Tracef("%s %s: %s", SECRET, item.Status, item.Name)
-				channel <- SyncDataEvent{SyncType: SECRET, Namespace: item.Namespace, Data: item}
-			},
-			DeleteFunc: func(obj interface{}) {
-				data, ok := obj.(*corev1.Secret)
-				if !ok {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", SECRET, obj)
-					return
-				}
-				status := DELETED
-				item := &store.Secret{
-					Namespace: data.GetNamespace(),
-					Name:      data.GetName(),
-					Data:      data.Data,
-					Status:    status,
-				}
-				k.Logger.Tracef("%s %s: %s", SECRET, item.Status, item.Name)
-				channel <- SyncDataEvent{SyncType: SECRET, Namespace: item.Namespace, Data: item}
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				data1, ok := oldObj.(*corev1.Secret)
-				if !ok {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", SECRET, oldObj)
-					return
-				}
-				data2, ok := newObj.(*corev1.Secret)
-				if !ok {
-					k.Logger.Errorf("%s: Invalid data from k8s api, %s", SECRET, newObj)
-					return
-				}
-				status := MODIFIED
-				item1 := &store.Secret{
-					Namespace: data1.GetNamespace(),
-					Name:      data1.GetName(),
-					Data:      data1.Data,
-					Status:    status,
-				}
-				item2 := &store.Secret{
-					Namespace: data2.GetNamespace(),
-					Name:      data2.GetName(),
-					Data:      data2.Data,
-					Status:    status,
-				}
-				if item2.Equal(item1) {
-					return
-				}
-				k.Logger.Tracef("%s %s: %s", SECRET, item2.Status, item2.Name)
-				channel <- SyncDataEvent{SyncType: SECRET, Namespace: item2.Namespace, Data: item2}
-			},
-		},
-	)
-	go informer.Run(stop)
+// IsCustomResourceSupported returns true when the resource is served by the API server, i.e. its CRD is installed
+func (k *K8s) IsCustomResourceSupported(resource schema.GroupVersionResource) bool {
+	resources, err := k.API.Discovery().ServerResourcesForGroupVersion(resource.GroupVersion().String())
+	if err != nil {
+		return false
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == resource.Resource {
+			return true
+		}
+	}
+	return false
 }
 
 func (k *K8s) IsNetworkingV1Beta1ApiSupported() bool {
@@ -0,0 +1,70 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"net/http"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/metrics"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+// serveMetrics exposes the controller metrics on "/metrics"
+func (c *HAProxyController) serveMetrics() {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	logger.Infof("Controller metrics available on %s/metrics", c.OSArgs.MetricsBindAddr)
+	logger.Error(http.ListenAndServe(c.OSArgs.MetricsBindAddr, mux))
+}
+
+// updateAnnotationMetrics counts the resources using each annotation known by the controller
+func (c *HAProxyController) updateAnnotationMetrics() {
+	usage := metrics.AnnotationUsage{}
+	count := func(resource string, annotations map[string]string) {
+		for name := range annotations {
+			if !store.IsKnownAnnotation(name) {
+				continue
+			}
+			if usage[resource] == nil {
+				usage[resource] = map[string]int{}
+			}
+			usage[resource][name]++
+		}
+	}
+	if c.Store.ConfigMaps.Main.Loaded {
+		count("configmap", c.Store.ConfigMaps.Main.Annotations)
+	}
+	for _, namespace := range c.Store.Namespaces {
+		if !namespace.Relevant {
+			continue
+		}
+		if cm, ok := namespace.ConfigMaps[store.NamespaceConfigMapName]; ok {
+			count("namespace-configmap", cm.Annotations)
+		}
+		for _, ingress := range namespace.Ingresses {
+			if ingress.Status == DELETED || !c.igClassIsSupported(c.Store.WithNamespaceConfig(ingress)) {
+				continue
+			}
+			count("ingress", ingress.Annotations)
+		}
+		for _, service := range namespace.Services {
+			if service.Status == DELETED {
+				continue
+			}
+			count("service", service.Annotations)
+		}
+	}
+	metrics.SetAnnotationUsage(usage)
+}
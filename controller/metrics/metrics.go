@@ -0,0 +1,115 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Controller metrics exposed in Prometheus text format.
+
+const (
+	metricAnnotationUsage  = "haproxy_ingress_annotation_usage"
+	metricAnnotationErrors = "haproxy_ingress_annotation_parse_errors_total"
+)
+
+// AnnotationUsage is the number of resources using each annotation,
+// indexed by resource kind ("configmap", "namespace-configmap", "ingress", "service") then annotation name.
+type AnnotationUsage map[string]map[string]int
+
+var annotations = struct {
+	sync.Mutex
+	usage  AnnotationUsage
+	errors map[string]uint64
+}{
+	usage:  AnnotationUsage{},
+	errors: map[string]uint64{},
+}
+
+// SetAnnotationUsage replaces the annotations usage with the one
+// computed by the last synchronization.
+func SetAnnotationUsage(usage AnnotationUsage) {
+	annotations.Lock()
+	annotations.usage = usage
+	annotations.Unlock()
+}
+
+// AnnotationParseError counts a failure to parse the given annotation
+func AnnotationParseError(name string) {
+	annotations.Lock()
+	annotations.errors[name]++
+	annotations.Unlock()
+}
+
+// Handler serves the controller metrics
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		buf := bufio.NewWriter(w)
+		writeAnnotationMetrics(buf)
+		_ = buf.Flush()
+	})
+}
+
+func writeAnnotationMetrics(w *bufio.Writer) {
+	annotations.Lock()
+	defer annotations.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s Number of resources using the annotation.\n", metricAnnotationUsage)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", metricAnnotationUsage)
+	for _, resource := range sortedKeys(annotations.usage) {
+		names := annotations.usage[resource]
+		for _, name := range sortedKeys(names) {
+			fmt.Fprintf(w, "%s{annotation=\"%s\",resource=\"%s\"} %d\n", metricAnnotationUsage, escapeLabel(name), escapeLabel(resource), names[name])
+		}
+	}
+
+	fmt.Fprintf(w, "# HELP %s Number of times the annotation value failed to be parsed.\n", metricAnnotationErrors)
+	fmt.Fprintf(w, "# TYPE %s counter\n", metricAnnotationErrors)
+	for _, name := range sortedKeys(annotations.errors) {
+		fmt.Fprintf(w, "%s{annotation=\"%s\"} %d\n", metricAnnotationErrors, escapeLabel(name), annotations.errors[name])
+	}
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch v := m.(type) {
+	case AnnotationUsage:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	case map[string]int:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	case map[string]uint64:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
@@ -18,6 +18,8 @@ import (
 	"os"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/tools/cache"
 
@@ -25,67 +27,168 @@ import (
 )
 
 func (c *HAProxyController) monitorChanges() {
-	go c.SyncData()
+	c.run(c.SyncData)
 
 	informersSynced := []cache.InformerSynced{}
-	stop := make(chan struct{})
+	stop := c.stop
+	queue := NewResourceQueue()
 
+	// Backend custom resources are optional, only watched when their CRD is installed
+	backendCRD := c.k8s.IsCustomResourceSupported(store.BackendResource)
+	if !backendCRD {
+		logger.Debugf("%s custom resource not supported in this cluster, cr-backend annotation ignored", store.BackendResource.Resource)
+	}
+	tlsPolicyCRD := c.k8s.IsCustomResourceSupported(store.TLSPolicyResource)
+	if !tlsPolicyCRD {
+		logger.Debugf("%s custom resource not supported in this cluster", store.TLSPolicyResource.Resource)
+	}
+	// ServiceImports are only watched when Multi-Cluster Services API is installed
+	serviceImports := c.k8s.IsCustomResourceSupported(store.ServiceImportResource)
+	if serviceImports {
+		logger.Debugf("watching %s resources of apiGroup %s", store.ServiceImportResource.Resource, store.ServiceImportResource.GroupVersion())
+	}
 	for _, namespace := range c.getWhitelistedNamespaces() {
 		factory := informers.NewSharedInformerFactoryWithOptions(c.k8s.API, c.Store.GetTimeFromAnnotation("cache-resync-period"), informers.WithNamespace(namespace))
+		// Ingresses can be filtered with a label selector, the Services and Secrets
+		// they reference being watched whatever their labels
+		labeledFactory := factory
+		if selector := c.OSArgs.WatchLabelSelector.String(); selector != "" {
+			labeledFactory = informers.NewSharedInformerFactoryWithOptions(c.k8s.API, c.Store.GetTimeFromAnnotation("cache-resync-period"), informers.WithNamespace(namespace), informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.LabelSelector = selector
+			}))
+		}
+
+		esi := c.getEndpointSliceSharedInformer(factory)
+		if esi == nil {
+			logger.Panic("endpointslice resources not supported in this cluster")
+		}
+		queue.Watch(ENDPOINTS, esi, c.k8s.EndpointSliceEvent, stop)
 
-		pi := factory.Core().V1().Endpoints().Informer()
-		c.k8s.EventsEndpoints(c.eventChan, stop, pi)
+		podi := factory.Core().V1().Pods().Informer()
+		queue.Watch(POD, podi, c.k8s.PodEvent, stop)
 
 		svci := factory.Core().V1().Services().Informer()
-		c.k8s.EventsServices(c.eventChan, c.statusChan, stop, svci, c.PublishService)
+		queue.Watch(SERVICE, svci, c.k8s.ServiceEvent(c.statusChan, c.PublishService), stop)
 
 		nsi := factory.Core().V1().Namespaces().Informer()
-		c.k8s.EventsNamespaces(c.eventChan, stop, nsi)
+		queue.Watch(NAMESPACE, nsi, c.k8s.NamespaceEvent, stop)
 
 		si := factory.Core().V1().Secrets().Informer()
-		c.k8s.EventsSecrets(c.eventChan, stop, si)
+		queue.Watch(SECRET, si, c.k8s.SecretEvent, stop)
 
 		ci := factory.Core().V1().ConfigMaps().Informer()
-		c.k8s.EventsConfigfMaps(c.eventChan, stop, ci)
+		queue.Watch(CONFIGMAP, ci, c.k8s.ConfigMapEvent, stop)
 
 		var ii, ici cache.SharedIndexInformer
-		ii, ici = c.getIngressSharedInformers(factory)
+		ii, ici = c.getIngressSharedInformers(labeledFactory, factory)
 		if ii == nil {
 			logger.Panic("ingress resources not supported in this cluster")
 		}
-		c.k8s.EventsIngresses(c.eventChan, stop, ii)
+		queue.Watch(INGRESS, ii, c.k8s.IngressEvent, stop)
 
-		informersSynced = []cache.InformerSynced{pi.HasSynced, svci.HasSynced, nsi.HasSynced, ii.HasSynced, si.HasSynced, ci.HasSynced}
+		informersSynced = append(informersSynced, esi.HasSynced, podi.HasSynced, svci.HasSynced, nsi.HasSynced, ii.HasSynced, si.HasSynced, ci.HasSynced)
 
 		if ici != nil {
-			c.k8s.EventsIngressClass(c.eventChan, stop, ici)
+			queue.Watch(INGRESS_CLASS, ici, c.k8s.IngressClassEvent, stop)
 			informersSynced = append(informersSynced, ici.HasSynced)
 		}
+
+		crFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.k8s.DynamicAPI, c.Store.GetTimeFromAnnotation("cache-resync-period"), namespace, nil)
+		if backendCRD {
+			bi := crFactory.ForResource(store.BackendResource).Informer()
+			queue.Watch(BACKEND, bi, c.k8s.BackendEvent, stop)
+			informersSynced = append(informersSynced, bi.HasSynced)
+		}
+
+		if tlsPolicyCRD {
+			tpi := crFactory.ForResource(store.TLSPolicyResource).Informer()
+			queue.Watch(TLS_POLICY, tpi, c.k8s.TLSPolicyEvent, stop)
+			informersSynced = append(informersSynced, tpi.HasSynced)
+		}
+
+		if serviceImports {
+			sii := crFactory.ForResource(store.ServiceImportResource).Informer()
+			queue.Watch(SERVICE, sii, c.k8s.ServiceImportEvent, stop)
+			informersSynced = append(informersSynced, sii.HasSynced)
+		}
+	}
+
+	if c.OSArgs.Peers.Name != "" {
+		factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.k8s.DynamicAPI, c.Store.GetTimeFromAnnotation("cache-resync-period"), c.OSArgs.Peers.Namespace, func(options *metav1.ListOptions) {
+			options.FieldSelector = "metadata.name=" + c.OSArgs.Peers.Name
+		})
+		peersi := factory.ForResource(store.PeersResource).Informer()
+		queue.Watch(PEERS, peersi, c.k8s.PeersEvent, stop)
+		informersSynced = append(informersSynced, peersi.HasSynced)
+	}
+
+	if c.OSArgs.GlobalConfig.Name != "" {
+		factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.k8s.DynamicAPI, c.Store.GetTimeFromAnnotation("cache-resync-period"), c.OSArgs.GlobalConfig.Namespace, func(options *metav1.ListOptions) {
+			options.FieldSelector = "metadata.name=" + c.OSArgs.GlobalConfig.Name
+		})
+		globali := factory.ForResource(store.GlobalResource).Informer()
+		queue.Watch(GLOBAL, globali, c.k8s.GlobalEvent, stop)
+		informersSynced = append(informersSynced, globali.HasSynced)
 	}
 
+	c.run(func() {
+		queue.Run(c.eventChan, stop)
+	})
+
 	if !cache.WaitForCacheSync(stop, informersSynced...) {
-		logger.Panic("Caches are not populated due to an underlying error, cannot run the Ingress Controller")
+		select {
+		case <-stop:
+			return
+		default:
+			logger.Panic("Caches are not populated due to an underlying error, cannot run the Ingress Controller")
+		}
 	}
 
 	syncPeriod := c.Store.GetTimeFromAnnotation("sync-period")
 	logger.Debugf("Executing syncPeriod every %s", syncPeriod.String())
+	ticker := time.NewTicker(syncPeriod)
+	defer ticker.Stop()
 	for {
-		time.Sleep(syncPeriod)
-		c.eventChan <- SyncDataEvent{SyncType: COMMAND}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			select {
+			case c.eventChan <- SyncDataEvent{SyncType: COMMAND}:
+			case <-stop:
+				return
+			}
+		}
 	}
 }
 
-// SyncData gets all kubernetes changes, aggregates them and apply to HAProxy.
-// All the changes must come through this function
+// SyncData gets all kubernetes changes, aggregates them and apply to HAProxy
+// until the controller is stopped. All the changes must come through this function
+// A full sync is also done every full-sync-period to reconcile HAProxy configuration,
+// removing configuration left by deleted resources such as orphan config snippets,
+// and to repair changes done outside the controller or by failed syncs.
 func (c *HAProxyController) SyncData() {
 	hadChanges := false
-	for job := range c.eventChan {
+	fullSyncPeriod := c.Store.GetTimeFromAnnotation("full-sync-period")
+	for {
+		var job SyncDataEvent
+		select {
+		case <-c.stop:
+			return
+		case job = <-c.eventChan:
+		}
 		ns := c.Store.GetNamespace(job.Namespace)
 		change := false
 		switch job.SyncType {
 		case COMMAND:
 			c.reload = c.auxCfgUpdated()
-			if hadChanges || c.reload {
+			if time.Since(c.lastFullSync) >= fullSyncPeriod {
+				c.fullSync()
+				c.lastFullSync = time.Now()
+				hadChanges = false
+				continue
+			}
+			if hadChanges || c.reload || c.Store.DrainingSrvsExpired() {
 				c.updateHAProxy()
 				hadChanges = false
 				continue
@@ -97,19 +200,31 @@ func (c *HAProxyController) SyncData() {
 		case INGRESS_CLASS:
 			change = c.Store.EventIngressClass(job.Data.(*store.IngressClass))
 		case ENDPOINTS:
-			change = c.Store.EventEndpoints(ns, job.Data.(*store.Endpoints), c.Client.SyncBackendSrvs)
+			change = c.Store.EventEndpointSlice(ns, job.Data.(*store.EndpointSlice), c.Client.SyncBackendSrvs)
+		case POD:
+			change = c.Store.EventPod(ns, job.Data.(*store.Pod))
 		case SERVICE:
 			change = c.Store.EventService(ns, job.Data.(*store.Service))
 		case CONFIGMAP:
 			change = c.Store.EventConfigMap(ns, job.Data.(*store.ConfigMap))
 		case SECRET:
 			change = c.Store.EventSecret(ns, job.Data.(*store.Secret))
+		case PEERS:
+			change = c.Store.EventPeers(job.Data.(*store.Peers))
+		case GLOBAL:
+			change = c.Store.EventGlobal(job.Data.(*store.Global))
+		case BACKEND:
+			change = c.Store.EventBackend(ns, job.Data.(*store.Backend))
+		case TLS_POLICY:
+			change = c.Store.EventTLSPolicy(ns, job.Data.(*store.TLSPolicy))
 		}
 		hadChanges = hadChanges || change
 	}
 }
 
-func (c *HAProxyController) getIngressSharedInformers(factory informers.SharedInformerFactory) (ii, ici cache.SharedIndexInformer) {
+// getIngressSharedInformers returns the Ingress informer of ingressFactory and IngressClass informer
+// of classFactory, of the most recent API group supported by the cluster.
+func (c *HAProxyController) getIngressSharedInformers(ingressFactory, classFactory informers.SharedInformerFactory) (ii, ici cache.SharedIndexInformer) {
 	for i, apiGroup := range []string{"networking.k8s.io/v1", "networking.k8s.io/v1beta1", "extensions/v1beta1"} {
 		resources, err := c.k8s.API.ServerResourcesForGroupVersion(apiGroup)
 		if err != nil {
@@ -119,20 +234,20 @@ func (c *HAProxyController) getIngressSharedInformers(factory informers.SharedIn
 			if rs.Name == "ingresses" {
 				switch i {
 				case 0:
-					ii = factory.Networking().V1().Ingresses().Informer()
+					ii = ingressFactory.Networking().V1().Ingresses().Informer()
 				case 1:
-					ii = factory.Networking().V1beta1().Ingresses().Informer()
+					ii = ingressFactory.Networking().V1beta1().Ingresses().Informer()
 				case 2:
-					ii = factory.Extensions().V1beta1().Ingresses().Informer()
+					ii = ingressFactory.Extensions().V1beta1().Ingresses().Informer()
 				}
 				logger.Debugf("watching ingress resources of apiGroup %s:", apiGroup)
 			}
 			if rs.Name == "ingressclasses" {
 				switch i {
 				case 0:
-					ici = factory.Networking().V1().IngressClasses().Informer()
+					ici = classFactory.Networking().V1().IngressClasses().Informer()
 				case 1:
-					ici = factory.Networking().V1beta1().IngressClasses().Informer()
+					ici = classFactory.Networking().V1beta1().IngressClasses().Informer()
 				}
 			}
 		}
@@ -143,6 +258,26 @@ func (c *HAProxyController) getIngressSharedInformers(factory informers.SharedIn
 	return ii, ici
 }
 
+func (c *HAProxyController) getEndpointSliceSharedInformer(factory informers.SharedInformerFactory) cache.SharedIndexInformer {
+	for i, apiGroup := range []string{"discovery.k8s.io/v1", "discovery.k8s.io/v1beta1"} {
+		resources, err := c.k8s.API.ServerResourcesForGroupVersion(apiGroup)
+		if err != nil {
+			continue
+		}
+		for _, rs := range resources.APIResources {
+			if rs.Name != "endpointslices" {
+				continue
+			}
+			logger.Debugf("watching endpointslice resources of apiGroup %s:", apiGroup)
+			if i == 0 {
+				return factory.Discovery().V1().EndpointSlices().Informer()
+			}
+			return factory.Discovery().V1beta1().EndpointSlices().Informer()
+		}
+	}
+	return nil
+}
+
 func (c *HAProxyController) getWhitelistedNamespaces() []string {
 	if len(c.Store.NamespacesAccess.Whitelist) == 0 {
 		return []string{""}
@@ -152,10 +287,17 @@ func (c *HAProxyController) getWhitelistedNamespaces() []string {
 	for ns := range c.Store.NamespacesAccess.Whitelist {
 		namespaces = append(namespaces, ns)
 	}
-	cfgMapNS := c.OSArgs.ConfigMap.Namespace
-	if _, ok := c.Store.NamespacesAccess.Whitelist[cfgMapNS]; !ok {
-		namespaces = append(namespaces, cfgMapNS)
-		logger.Warningf("configmap Namespace '%s' not whitelisted. Whitelisting it anyway", cfgMapNS)
+	added := make(map[string]struct{})
+	for _, cfgMap := range c.OSArgs.ConfigMap {
+		cfgMapNS := cfgMap.Namespace
+		if _, ok := c.Store.NamespacesAccess.Whitelist[cfgMapNS]; ok {
+			continue
+		}
+		if _, ok := added[cfgMapNS]; !ok {
+			added[cfgMapNS] = struct{}{}
+			namespaces = append(namespaces, cfgMapNS)
+			logger.Warningf("configmap Namespace '%s' not whitelisted. Whitelisting it anyway", cfgMapNS)
+		}
 	}
 	logger.Infof("Whitelisted Namespaces: %s", namespaces)
 	return namespaces
@@ -0,0 +1,162 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"sync"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+// queueMaxRetries is the number of times a resource failing to be converted
+// is retried, with exponential backoff, before being dropped until its next change.
+const queueMaxRetries = 5
+
+// Converter converts a resource of an informer cache into the SyncDataEvent applying it to the store,
+// status being ADDED for existing resources and DELETED for removed ones. ErrIgnored is returned
+// for resources not relevant to the controller, other errors being retried.
+type Converter func(obj interface{}, status store.Status) (SyncDataEvent, error)
+
+// ResourceQueue feeds the store with the resources of shared informers through a rate limited
+// workqueue. Informer events only enqueue the key of their resource, so that successive events
+// of a resource not yet processed are deduplicated, the resource being then read from the informer
+// cache: the store is given its latest state, whether it was added, modified or resynced.
+type ResourceQueue struct {
+	queue   workqueue.RateLimitingInterface
+	sources []queueSource
+	mu      sync.Mutex
+	// last state of deleted resources, removed from informer caches, by queue key
+	deleted map[queueKey]interface{}
+}
+
+type queueSource struct {
+	syncType SyncType
+	informer cache.SharedIndexInformer
+	convert  Converter
+}
+
+type queueKey struct {
+	source int
+	key    string
+}
+
+func NewResourceQueue() *ResourceQueue {
+	return &ResourceQueue{
+		queue:   workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "resources"),
+		deleted: make(map[queueKey]interface{}),
+	}
+}
+
+// Watch enqueues the resources of the informer and runs it until stop is closed
+func (q *ResourceQueue) Watch(syncType SyncType, informer cache.SharedIndexInformer, convert Converter, stop chan struct{}) {
+	source := len(q.sources)
+	q.sources = append(q.sources, queueSource{syncType: syncType, informer: informer, convert: convert})
+	enqueue := func(obj interface{}) {
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err != nil {
+			logger.Errorf("%s: %s", syncType, err)
+			return
+		}
+		// Resource re-created before its deletion was processed
+		q.mu.Lock()
+		delete(q.deleted, queueKey{source: source, key: key})
+		q.mu.Unlock()
+		q.queue.Add(queueKey{source: source, key: key})
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: enqueue,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			enqueue(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			key, err := cache.MetaNamespaceKeyFunc(obj)
+			if err != nil {
+				logger.Errorf("%s: %s", syncType, err)
+				return
+			}
+			q.mu.Lock()
+			q.deleted[queueKey{source: source, key: key}] = obj
+			q.mu.Unlock()
+			q.queue.Add(queueKey{source: source, key: key})
+		},
+	})
+	go informer.Run(stop)
+}
+
+// Run sends the events of enqueued resources to channel until stop is closed
+func (q *ResourceQueue) Run(channel chan SyncDataEvent, stop chan struct{}) {
+	go func() {
+		<-stop
+		q.queue.ShutDown()
+	}()
+	for q.processNext(channel, stop) {
+	}
+}
+
+func (q *ResourceQueue) processNext(channel chan SyncDataEvent, stop chan struct{}) bool {
+	item, shutdown := q.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer q.queue.Done(item)
+	key := item.(queueKey)
+	source := q.sources[key.source]
+	status := ADDED
+	obj, exists, err := source.informer.GetIndexer().GetByKey(key.key)
+	if err == nil && !exists {
+		q.mu.Lock()
+		obj, exists = q.deleted[key]
+		q.mu.Unlock()
+		if !exists {
+			q.queue.Forget(item)
+			return true
+		}
+		status = DELETED
+	}
+	var event SyncDataEvent
+	if err == nil {
+		event, err = source.convert(obj, status)
+	}
+	switch {
+	case errors.Is(err, ErrIgnored):
+	case err != nil && q.queue.NumRequeues(item) < queueMaxRetries:
+		logger.Warningf("%s '%s': %s, retrying", source.syncType, key.key, err)
+		q.queue.AddRateLimited(item)
+		return true
+	case err != nil:
+		logger.Errorf("%s '%s': %s, dropped until its next change", source.syncType, key.key, err)
+	default:
+		logger.Tracef("%s %s: %s", source.syncType, status, key.key)
+		select {
+		case channel <- event:
+		case <-stop:
+			return false
+		}
+	}
+	if status == DELETED {
+		q.mu.Lock()
+		delete(q.deleted, key)
+		q.mu.Unlock()
+	}
+	q.queue.Forget(item)
+	return true
+}
@@ -0,0 +1,212 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+var errConversion = errors.New("conversion failed")
+
+// fakeInformer holds resources in an indexer updated by the tests, which
+// also call the registered event handler. Other informer calls panic.
+type fakeInformer struct {
+	cache.SharedIndexInformer
+	indexer cache.Indexer
+	handler cache.ResourceEventHandler
+}
+
+func (i *fakeInformer) AddEventHandler(handler cache.ResourceEventHandler) { i.handler = handler }
+
+func (i *fakeInformer) GetIndexer() cache.Indexer { return i.indexer }
+
+func (i *fakeInformer) Run(stop <-chan struct{}) { <-stop }
+
+// fakeConverter fails its first fails conversions, or ignores resources,
+// and records the status of each conversion
+type fakeConverter struct {
+	fails    int
+	ignore   bool
+	statuses []store.Status
+}
+
+func (c *fakeConverter) convert(obj interface{}, status store.Status) (SyncDataEvent, error) {
+	c.statuses = append(c.statuses, status)
+	switch {
+	case c.ignore:
+		return SyncDataEvent{}, ErrIgnored
+	case len(c.statuses) <= c.fails:
+		return SyncDataEvent{}, errConversion
+	}
+	return SyncDataEvent{SyncType: SERVICE, Namespace: obj.(*corev1.Service).Namespace, Data: status}, nil
+}
+
+// newTestQueue returns a queue watching a fake informer, with a fast rate limiter
+func newTestQueue(t *testing.T, converter *fakeConverter) (*ResourceQueue, *fakeInformer, chan struct{}) {
+	t.Helper()
+	q := NewResourceQueue()
+	q.queue = workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, 10*time.Millisecond))
+	informer := &fakeInformer{indexer: cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})}
+	stop := make(chan struct{})
+	q.Watch(SERVICE, informer, converter.convert, stop)
+	t.Cleanup(func() {
+		close(stop)
+		q.queue.ShutDown()
+	})
+	return q, informer, stop
+}
+
+// processNext processes the next enqueued resource, failing the test if there is none
+func processNext(t *testing.T, q *ResourceQueue, channel chan SyncDataEvent, stop chan struct{}) {
+	t.Helper()
+	done := make(chan bool, 1)
+	go func() { done <- q.processNext(channel, stop) }()
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatal("processNext() = false, want true")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no resource processed")
+	}
+}
+
+// expectAdded checks the next event of channel, already sent, is an ADDED one
+func expectAdded(t *testing.T, channel chan SyncDataEvent) {
+	t.Helper()
+	select {
+	case event := <-channel:
+		if event.Data != ADDED {
+			t.Errorf("event %+v, want %s", event, ADDED)
+		}
+	default:
+		t.Errorf("no event, want %s", ADDED)
+	}
+}
+
+func testService() *corev1.Service {
+	return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "app"}}
+}
+
+func TestResourceQueue(t *testing.T) {
+	tests := []struct {
+		name    string
+		fails   int
+		ignore  bool
+		deleted bool
+		calls   int
+		event   bool
+	}{
+		{name: "added", calls: 1, event: true},
+		{name: "deleted", deleted: true, calls: 1, event: true},
+		{name: "added after retries", fails: 2, calls: 3, event: true},
+		{name: "deleted after retries", fails: 2, deleted: true, calls: 3, event: true},
+		{name: "added after last retry", fails: queueMaxRetries, calls: queueMaxRetries + 1, event: true},
+		{name: "added dropped", fails: queueMaxRetries + 1, calls: queueMaxRetries + 1},
+		{name: "deleted dropped", fails: queueMaxRetries + 1, deleted: true, calls: queueMaxRetries + 1},
+		{name: "added ignored", ignore: true, calls: 1},
+		{name: "deleted ignored", ignore: true, deleted: true, calls: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			converter := &fakeConverter{fails: test.fails, ignore: test.ignore}
+			q, informer, stop := newTestQueue(t, converter)
+			channel := make(chan SyncDataEvent, 1)
+			status := ADDED
+			svc := testService()
+			_ = informer.indexer.Add(svc)
+			informer.handler.OnAdd(svc)
+			if test.deleted {
+				status = DELETED
+				_ = informer.indexer.Delete(svc)
+				informer.handler.OnDelete(svc)
+			}
+			for i := 0; i < test.calls; i++ {
+				processNext(t, q, channel, stop)
+			}
+			if len(converter.statuses) != test.calls {
+				t.Errorf("%d conversions, want %d", len(converter.statuses), test.calls)
+			}
+			for i, s := range converter.statuses {
+				if s != status {
+					t.Errorf("conversion %d status = %s, want %s", i, s, status)
+				}
+			}
+			select {
+			case event := <-channel:
+				if !test.event {
+					t.Errorf("unexpected event %+v", event)
+				} else if event.Data != status || event.Namespace != svc.Namespace {
+					t.Errorf("event %+v, want %s of namespace %s", event, status, svc.Namespace)
+				}
+			default:
+				if test.event {
+					t.Errorf("no event, want %s", status)
+				}
+			}
+			if n := q.queue.Len(); n != 0 {
+				t.Errorf("%d resources left in queue", n)
+			}
+			if n := len(q.deleted); n != 0 {
+				t.Errorf("%d deleted resources left", n)
+			}
+		})
+	}
+}
+
+func TestResourceQueueNextChange(t *testing.T) {
+	converter := &fakeConverter{fails: queueMaxRetries + 1}
+	q, informer, stop := newTestQueue(t, converter)
+	channel := make(chan SyncDataEvent, 1)
+	svc := testService()
+	_ = informer.indexer.Add(svc)
+	informer.handler.OnAdd(svc)
+	for i := 0; i <= queueMaxRetries; i++ {
+		processNext(t, q, channel, stop)
+	}
+	item := queueKey{source: 0, key: "test/app"}
+	if n := q.queue.NumRequeues(item); n != 0 {
+		t.Errorf("dropped resource requeued %d times, want it forgotten", n)
+	}
+	// Next change of a dropped resource is processed with a new retry budget
+	informer.handler.OnUpdate(svc, svc)
+	processNext(t, q, channel, stop)
+	if n := len(converter.statuses); n != queueMaxRetries+2 {
+		t.Errorf("%d conversions, want %d", n, queueMaxRetries+2)
+	}
+	expectAdded(t, channel)
+	// Resource re-created before its deletion is processed is only added
+	_ = informer.indexer.Delete(svc)
+	informer.handler.OnDelete(svc)
+	_ = informer.indexer.Add(svc)
+	informer.handler.OnAdd(svc)
+	if n := len(q.deleted); n != 0 {
+		t.Errorf("%d deleted resources after re-creation, want none", n)
+	}
+	processNext(t, q, channel, stop)
+	expectAdded(t, channel)
+	if n := q.queue.Len(); n != 0 {
+		t.Errorf("%d resources left in queue", n)
+	}
+}
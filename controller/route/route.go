@@ -16,6 +16,7 @@ package route
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/haproxytech/client-native/v2/models"
@@ -33,15 +34,46 @@ const (
 	FrontendHTTPS = "https"
 )
 
-var CustomRoutes = make(map[string]string)
 var logger = utils.GetLogger()
 
+// CustomRoutes are the ACL conditions of the routes added with use_backend
+// directives by backend name, kept by each controller between syncs.
+type CustomRoutes struct {
+	routes map[string]string
+	// active are the backends of routes added during current sync
+	active map[string]struct{}
+}
+
+func NewCustomRoutes() *CustomRoutes {
+	return &CustomRoutes{
+		routes: make(map[string]string),
+		active: make(map[string]struct{}),
+	}
+}
+
+// Len returns the number of custom routes
+func (r *CustomRoutes) Len() int {
+	return len(r.routes)
+}
+
+// Delete deletes the custom route of backendName, reload being required if it existed.
+func (r *CustomRoutes) Delete(backendName string) (reload bool) {
+	if _, ok := r.routes[backendName]; !ok {
+		return false
+	}
+	delete(r.routes, backendName)
+	logger.Debugf("Custom Route to backend '%s' deleted, reload required", backendName)
+	return true
+}
+
 type Route struct {
 	Host           string
 	Path           *store.IngressPath
 	HAProxyRules   []haproxy.RuleID
 	BackendName    string
 	SSLPassthrough bool
+	// Frontend is the extra frontend of the route, empty for main frontends
+	Frontend string
 }
 
 // AddHostPathRoute adds Host/Path ingress route to haproxy Map files used for backend switching.
@@ -49,9 +81,13 @@ func AddHostPathRoute(route Route, mapFiles *haproxy.Maps) error {
 	if route.BackendName == "" {
 		return fmt.Errorf("backendName missing")
 	}
-	// Wildcard host
-	if route.Host != "" && route.Host[0] == '*' {
-		route.Host = route.Host[1:]
+	// Wildcard hosts are mapped by the suffix matched after the first label of the request host
+	suffix, err := utils.WildcardHostSuffix(route.Host)
+	if err != nil {
+		return err
+	}
+	if suffix != "" {
+		route.Host = suffix
 	}
 	value := route.BackendName
 	for _, id := range route.HAProxyRules {
@@ -66,48 +102,72 @@ func AddHostPathRoute(route Route, mapFiles *haproxy.Maps) error {
 		return nil
 	}
 	// HTTP
+	hostMap := haproxy.FrontendMap(haproxy.MAP_HOST, route.Frontend)
+	pathExactMap := haproxy.FrontendMap(haproxy.MAP_PATH_EXACT, route.Frontend)
+	pathPrefixExactMap := haproxy.FrontendMap(haproxy.MAP_PATH_PREFIX_EXACT, route.Frontend)
+	pathPrefixMap := haproxy.FrontendMap(haproxy.MAP_PATH_PREFIX, route.Frontend)
 	if route.Host != "" {
-		mapFiles.AppendRow(haproxy.MAP_HOST, route.Host+"\t\t\t"+route.Host)
+		mapFiles.AppendRow(hostMap, route.Host+"\t\t\t"+route.Host)
 	} else if route.Path.Path == "" {
 		return fmt.Errorf("neither Host nor Path are provided for backend %v,", route.BackendName)
 	}
 
+	// Exact paths take precedence over the prefix paths matching the whole request path,
+	// which take precedence over the other prefix paths, the longest prefix matching first.
 	path := route.Path.Path
 	switch {
 	case route.Path.PathTypeMatch == store.PATH_TYPE_EXACT:
-		mapFiles.AppendRow(haproxy.MAP_PATH_EXACT, route.Host+path+"\t\t\t"+value)
+		mapFiles.AppendRow(pathExactMap, route.Host+path+"\t\t\t"+value)
 	case path == "" || path == "/":
-		mapFiles.AppendRow(haproxy.MAP_PATH_PREFIX, route.Host+"/"+"\t\t\t"+value)
+		mapFiles.AppendRow(pathPrefixMap, route.Host+"/"+"\t\t\t"+value)
 	case route.Path.PathTypeMatch == store.PATH_TYPE_PREFIX:
+		// Prefix paths match request paths element-wise: "/foo" matches "/foo" and "/foo/bar", not "/foobar"
 		path = strings.TrimSuffix(path, "/")
-		mapFiles.AppendRow(haproxy.MAP_PATH_EXACT, route.Host+path+"\t\t\t"+value)
-		mapFiles.AppendRow(haproxy.MAP_PATH_PREFIX, route.Host+path+"/"+"\t\t\t"+value)
+		mapFiles.AppendRow(pathPrefixExactMap, route.Host+path+"\t\t\t"+value)
+		mapFiles.AppendRow(pathPrefixMap, route.Host+path+"/"+"\t\t\t"+value)
 	case route.Path.PathTypeMatch == store.PATH_TYPE_IMPLEMENTATION_SPECIFIC:
+		// ImplementationSpecific paths are matched as string prefixes
 		path = strings.TrimSuffix(path, "/")
-		mapFiles.AppendRow(haproxy.MAP_PATH_EXACT, route.Host+path+"\t\t\t"+value)
-		mapFiles.AppendRow(haproxy.MAP_PATH_PREFIX, route.Host+path+"\t\t\t"+value)
+		mapFiles.AppendRow(pathPrefixExactMap, route.Host+path+"\t\t\t"+value)
+		mapFiles.AppendRow(pathPrefixMap, route.Host+path+"\t\t\t"+value)
 	default:
 		return fmt.Errorf("unknown path type '%s' with backend '%s'", route.Path.PathTypeMatch, route.BackendName)
 	}
 	return nil
 }
 
-// AddCustomRoute adds an ingress route with specific ACL via use_backend haproxy directive
-func AddCustomRoute(route Route, routeACLAnn string, api api.HAProxyClient) (reload bool, err error) {
+// Add adds an ingress route with specific ACL via use_backend haproxy directive
+func (r *CustomRoutes) Add(route Route, routeACLAnn string, api api.HAProxyClient) (reload bool, err error) {
 	var routeCond string
-	if route.Host != "" {
+	suffix, err := utils.WildcardHostSuffix(route.Host)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case suffix != "":
+		// Same value as the host map row of the wildcard host
+		routeCond = fmt.Sprintf("{ var(txn.host_match) %s } ", suffix)
+	case route.Host != "":
 		routeCond = fmt.Sprintf("{ var(txn.host) %s } ", route.Host)
 	}
 	if route.Path.Path != "" {
-		if route.Path.PathTypeMatch == store.PATH_TYPE_EXACT {
+		path := strings.TrimSuffix(route.Path.Path, "/")
+		switch {
+		case route.Path.PathTypeMatch == store.PATH_TYPE_EXACT:
 			routeCond = fmt.Sprintf("%s { path %s } ", routeCond, route.Path.Path)
-		} else {
+		case route.Path.PathTypeMatch == store.PATH_TYPE_PREFIX && path != "":
+			routeCond = fmt.Sprintf("%s { path -m reg ^%s(/|$) } ", routeCond, regexp.QuoteMeta(path))
+		default:
 			routeCond = fmt.Sprintf("%s { path -m beg %s } ", routeCond, route.Path.Path)
 		}
 	}
 	routeCond = fmt.Sprintf("%s { %s } ", routeCond, routeACLAnn)
 
-	for _, frontend := range []string{FrontendHTTP, FrontendHTTPS} {
+	frontends := []string{FrontendHTTP, FrontendHTTPS}
+	if route.Frontend != "" {
+		frontends = []string{route.Frontend}
+	}
+	for _, frontend := range frontends {
 		err = api.BackendSwitchingRuleCreate(frontend, models.BackendSwitchingRule{
 			Cond:     "if",
 			CondTest: routeCond,
@@ -118,16 +178,19 @@ func AddCustomRoute(route Route, routeACLAnn string, api api.HAProxyClient) (rel
 			return
 		}
 	}
-	if acl := CustomRoutes[route.BackendName]; acl != routeCond {
-		CustomRoutes[route.BackendName] = routeCond
+	r.active[route.BackendName] = struct{}{}
+	if acl := r.routes[route.BackendName]; acl != routeCond {
+		r.routes[route.BackendName] = routeCond
 		reload = true
 		logger.Debugf("Custom Route to backend '%s' added, reload required", route.BackendName)
 	}
 	return reload, err
 }
 
-func CustomRoutesReset(api api.HAProxyClient) (err error) {
-	for _, frontend := range []string{FrontendHTTP, FrontendHTTPS} {
+// CustomRoutesReset deletes the custom routes of the main frontends
+// and of the given extra frontends.
+func CustomRoutesReset(api api.HAProxyClient, extraFrontends ...string) (err error) {
+	for _, frontend := range append([]string{FrontendHTTP, FrontendHTTPS}, extraFrontends...) {
 		api.BackendSwitchingRuleDeleteAll(frontend)
 		err = api.BackendSwitchingRuleCreate(frontend, models.BackendSwitchingRule{
 			Name:  "%[var(txn.path_match),field(1,.)]",
@@ -139,3 +202,16 @@ func CustomRoutesReset(api api.HAProxyClient) (err error) {
 	}
 	return err
 }
+
+// Purge deletes the custom routes which were not added
+// during current sync, reload being required if any.
+func (r *CustomRoutes) Purge() (reload bool) {
+	for backendName := range r.routes {
+		if _, ok := r.active[backendName]; ok {
+			continue
+		}
+		reload = r.Delete(backendName) || reload
+	}
+	r.active = make(map[string]struct{})
+	return reload
+}
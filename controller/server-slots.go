@@ -0,0 +1,86 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"strings"
+	"time"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/service"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+// srvSlotsShrinkDelay is the time a server slot is disabled before it can be
+// trimmed, so that slots of pods briefly scaled down are reused without reload.
+const srvSlotsShrinkDelay = 10 * time.Minute
+
+// shrinkServerSlots trims the trailing server slots of backends, disabled for srvSlotsShrinkDelay,
+// down to the scale-server-slots count, so that backends once scaled to many pods do not keep
+// their servers forever. Deleting servers requires a reload, slots are then only trimmed when
+// HAProxy is reloaded anyway.
+func (c *HAProxyController) shrinkServerSlots() {
+	srvSlots := service.ServerSlots(c.Store)
+	for _, ns := range c.Store.Namespaces {
+		for _, endpoints := range ns.Endpoints {
+			for _, portEndpoints := range endpoints.Ports {
+				c.shrinkPortServerSlots(portEndpoints, srvSlots)
+			}
+		}
+	}
+}
+
+func (c *HAProxyController) shrinkPortServerSlots(endpoints *store.PortEndpoints, srvSlots int) {
+	now := time.Now()
+	for _, srv := range endpoints.HAProxySrvs {
+		switch {
+		case srv.Address != "":
+			srv.DisabledSince = time.Time{}
+		case srv.DisabledSince.IsZero():
+			srv.DisabledSince = now
+		}
+	}
+	if !c.reload && !c.restart {
+		return
+	}
+	var backends []string
+	if endpoints.BackendName != "" {
+		backends = append(backends, endpoints.BackendName)
+	}
+	for backend := range endpoints.PathBackends {
+		backends = append(backends, backend)
+	}
+	if len(backends) == 0 {
+		return
+	}
+	count := len(endpoints.HAProxySrvs)
+	for ; count > srvSlots; count-- {
+		srv := endpoints.HAProxySrvs[count-1]
+		if srv.Address != "" || now.Sub(srv.DisabledSince) < srvSlotsShrinkDelay {
+			break
+		}
+	}
+	if count == len(endpoints.HAProxySrvs) {
+		return
+	}
+	for _, srv := range endpoints.HAProxySrvs[count:] {
+		for _, backend := range backends {
+			if err := c.Client.BackendServerDelete(backend, srv.Name); err != nil && !strings.Contains(err.Error(), "does not exist") {
+				logger.Errorf("backend '%s': unable to trim server slot '%s': %s", backend, srv.Name, err)
+			}
+		}
+	}
+	logger.Debugf("backend '%s': %d server slots disabled for more than %s trimmed", backends[0], len(endpoints.HAProxySrvs)-count, srvSlotsShrinkDelay)
+	endpoints.HAProxySrvs = endpoints.HAProxySrvs[:count]
+}
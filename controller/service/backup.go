@@ -0,0 +1,30 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+// getBackup returns the servers of backup-service annotation, nil when not set or invalid.
+// They are backup servers, only used by HAProxy when all backend service servers are down.
+func (s *SvcContext) getBackup() (backup *extraSrvs) {
+	annService := s.store.GetValueFromAnnotations("backup-service", s.service.Annotations, s.ingress.Annotations)
+	if annService == "" {
+		return nil
+	}
+	backup, err := s.getExtraSrvs(annService)
+	if err != nil {
+		logger.Errorf("Ingress '%s/%s': backup-service: %s", s.ingress.Namespace, s.ingress.Name, err)
+		return nil
+	}
+	return backup
+}
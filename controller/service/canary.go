@@ -0,0 +1,81 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"strconv"
+	"strings"
+)
+
+// getCanary returns the canary servers of the backend and the percentage
+// of traffic sent to them, nil when canary-service annotation is not set or invalid.
+func (s *SvcContext) getCanary() (canary *extraSrvs, percent int64) {
+	annSources := []map[string]string{s.service.Annotations, s.ingress.Annotations}
+	annService := s.store.GetValueFromAnnotations("canary-service", annSources...)
+	if annService == "" {
+		return nil, 0
+	}
+	annWeight := s.store.GetValueFromAnnotations("canary-weight", annSources...)
+	percent, err := strconv.ParseInt(strings.TrimSuffix(annWeight, "%"), 10, 64)
+	if err != nil || percent < 0 || percent > 100 {
+		logger.Errorf("Ingress '%s/%s': incorrect canary-weight value '%s', expecting a percentage between 0 and 100", s.ingress.Namespace, s.ingress.Name, annWeight)
+		return nil, 0
+	}
+	if canary, err = s.getExtraSrvs(annService); err != nil {
+		logger.Errorf("Ingress '%s/%s': canary-service: %s", s.ingress.Namespace, s.ingress.Name, err)
+		return nil, 0
+	}
+	return canary, percent
+}
+
+// canaryWeights returns the weights of backend and canary servers so that
+// canary servers receive canaryPercent of the traffic.
+func canaryWeights(servers, canaryServers int, canaryPercent int64) (weight, canaryWeight int64) {
+	if servers == 0 || canaryServers == 0 {
+		return 1, 1
+	}
+	weight = (100 - canaryPercent) * int64(canaryServers)
+	canaryWeight = canaryPercent * int64(servers)
+	if d := gcd(weight, canaryWeight); d > 1 {
+		weight /= d
+		canaryWeight /= d
+	}
+	highest := weight
+	if canaryWeight > highest {
+		highest = canaryWeight
+	}
+	if highest > maxWeight {
+		weight = scaleWeight(weight, highest)
+		canaryWeight = scaleWeight(canaryWeight, highest)
+	}
+	return weight, canaryWeight
+}
+
+// scaleWeight scales down weight to the maxWeight range,
+// a non-zero weight is kept non-zero.
+func scaleWeight(weight, highest int64) int64 {
+	scaled := (weight*maxWeight + highest/2) / highest
+	if scaled == 0 && weight != 0 {
+		return 1
+	}
+	return scaled
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-test/deep"
 
@@ -27,6 +28,7 @@ import (
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
 )
 
 // HandleEndpoints lookups the IngressPath related endpoints and handles corresponding backend servers configuration in HAProxy
@@ -39,11 +41,27 @@ func (s *SvcContext) HandleEndpoints(client api.HAProxyClient, store store.K8s,
 		return
 	}
 	// set backendName in store.PortEndpoints for runtime updates.
-	endpoints.BackendName = s.backendName
-	if s.service.DNS == "" {
-		srvsScaled = s.scaleHAProxySrvs(endpoints, store)
+	if s.pathTimeout != nil {
+		if endpoints.PathBackends == nil {
+			endpoints.PathBackends = make(map[string]bool)
+		}
+		endpoints.PathBackends[s.backendName] = true
+	} else {
+		endpoints.BackendName = s.backendName
+	}
+	templateSize := s.serverTemplateSize()
+	if templateSize == 0 && !s.newBackend {
+		srvsScaled = s.deleteServerTemplates(client)
+	}
+	if s.service.DNS == "" && templateSize == 0 {
+		endpoints.DrainDelay = s.drainDelay()
+		s.expireDrainingSrvs(client, endpoints)
+		srvsScaled = s.scaleHAProxySrvs(endpoints, store) || srvsScaled
+	} else if !s.newBackend && templateSize == 0 {
+		srvsScaled = s.syncExternalNameSrvs(client, endpoints) || srvsScaled
 	}
 	srv = &models.Server{}
+	crBackend, _ := s.crBackendAnnotations()
 	annotations.HandleServerAnnotations(
 		srv,
 		store,
@@ -51,24 +69,250 @@ func (s *SvcContext) HandleEndpoints(client api.HAProxyClient, store store.K8s,
 		certs,
 		s.service.Annotations,
 		s.ingress.Annotations,
+		crBackend,
 		s.store.ConfigMaps.Main.Annotations,
 	)
+	// ExternalName servers are resolved at startup, and at runtime with a resolvers section
+	if s.service.DNS != "" {
+		srv.Resolvers = s.resolvers()
+	}
+	// HTTP responses cannot be observed in TCP mode, connection errors are observed instead
+	if s.tcpService && srv.Observe == "layer7" {
+		srv.Observe = "layer4"
+	}
+	if templateSize > 0 {
+		return s.syncServerTemplates(client, *srv, endpoints, templateSize) || srvsScaled
+	}
+	canary, canaryPercent := s.getCanary()
+	var canaryWeight int64
+	if canary != nil {
+		var weight int64
+		weight, canaryWeight = canaryWeights(activeSrvs(endpoints), len(canary.addresses), canaryPercent)
+		srv.Weight = &weight
+	}
+	failover, failoverWeight, failoverActive := s.getFailover(activeSrvs(endpoints))
+	if failover != nil && srv.Weight == nil {
+		srv.Weight = utils.PtrInt64(localWeight)
+	}
+	activeStandby := s.activeStandby()
+	topology := s.getTopology(endpoints, activeStandby)
+	// Topology weights do not override the ones of canary and failover servers
+	if srv.Weight != nil && topology.mode == topologyWeight {
+		topology.mode = ""
+	}
 	if !s.newBackend {
-		oldSrv, _ = client.ServerGet("SRV_1", s.backendName)
 		srv.Name = "SRV_1"
-		result := deep.Equal(oldSrv, srv)
+		if len(endpoints.HAProxySrvs) > 0 {
+			// First server may be named after its pod
+			srv.Name = endpoints.HAProxySrvs[0].Name
+		}
+		oldSrv, _ = client.ServerGet(srv.Name, s.backendName)
+		// First server configuration includes the weight of its pod and its topology backup state
+		expectedSrv := *srv
+		if len(endpoints.HAProxySrvs) > 0 && endpoints.HAProxySrvs[0].Weight != nil {
+			expectedSrv.Weight = endpoints.HAProxySrvs[0].Weight
+		}
+		if len(endpoints.HAProxySrvs) > 0 && endpoints.HAProxySrvs[0].Backup {
+			expectedSrv.Backup = "enabled"
+		}
+		result := deep.Equal(oldSrv, &expectedSrv)
 		if len(result) != 0 {
 			srvsActiveAnn = true
 			logger.Debugf("Ingress '%s/%s': server options for backend '%s' were updated:%s\nReload required", s.ingress.Namespace, s.ingress.Name, endpoints.BackendName, result)
 		}
+		if len(endpoints.HAProxySrvs) > 1 {
+			standbySrv, errSrv := client.ServerGet(endpoints.HAProxySrvs[1].Name, s.backendName)
+			if errSrv == nil && (standbySrv.Backup == "enabled") != (activeStandby || endpoints.HAProxySrvs[1].Backup) {
+				srvsActiveAnn = true
+				logger.Debugf("Ingress '%s/%s': active-standby mode of backend '%s' set to %t, reload required", s.ingress.Namespace, s.ingress.Name, endpoints.BackendName, activeStandby)
+			}
+		}
+	}
+	srvsRenamed := s.renameHAProxySrvs(client, endpoints)
+	var srvsWeight, srvsTopology bool
+	podWeights := s.store.GetPodWeights(s.service.Namespace)
+	for i, srvSlot := range endpoints.HAProxySrvs {
+		weight := podWeight(podWeights, srvSlot.Address)
+		if weight == nil {
+			weight = topology.weight(srvSlot.Address)
+		}
+		weightModified := !utils.EqualInt64Ptr(weight, srvSlot.Weight)
+		backup := topology.backup(srvSlot)
+		// Backup state cannot be changed at runtime
+		if backup != srvSlot.Backup && !s.newBackend {
+			srvsTopology = true
+			logger.Debugf("Ingress '%s/%s': topology backup state of server '%s/%s' set to %t, reload required", s.ingress.Namespace, s.ingress.Name, s.backendName, srvSlot.Name, backup)
+		}
+		if srvSlot.Modified || s.newBackend || srvsActiveAnn || weightModified || backup != srvSlot.Backup {
+			slotSrv := *srv
+			if (activeStandby && i > 0) || backup {
+				slotSrv.Backup = "enabled"
+			}
+			if weight != nil {
+				slotSrv.Weight = weight
+			}
+			s.updateHAProxySrv(client, slotSrv, *srvSlot, endpoints.AddrPort(srvSlot.Address))
+			if weightModified && !s.newBackend && !srvsActiveAnn {
+				srvsWeight = !s.setRuntimeWeight(client, srvSlot.Name, slotSrv.Weight) || srvsWeight
+			}
+			srvSlot.Weight = weight
+			srvSlot.Backup = backup
+		}
+	}
+	srvsCanary := s.syncExtraSrvs(client, *srv, canaryPrefix, canary, canaryWeight, false, srvsActiveAnn)
+	srvsFailover := s.syncExtraSrvs(client, *srv, failoverPrefix, failover, failoverWeight, !failoverActive, srvsActiveAnn)
+	srvsBackup := s.syncExtraSrvs(client, *srv, backupPrefix, s.getBackup(), 1, true, srvsActiveAnn)
+
+	return srvsScaled || srvsActiveAnn || srvsRenamed || srvsWeight || srvsTopology || srvsCanary || srvsFailover || srvsBackup
+}
+
+// renameHAProxySrvs names backend servers after the pods they target when
+// pod-server-names annotation is set, servers without pod keep their slot name.
+// Pods of headless services, usually addressed individually, name their servers by default.
+// HAProxy servers cannot be renamed at runtime so a reload is required.
+func (s *SvcContext) renameHAProxySrvs(client api.HAProxyClient, endpoints *store.PortEndpoints) (reload bool) {
+	var podNames map[string]string
+	enabled := s.service.Headless
+	ann := s.store.GetValueFromAnnotations("pod-server-names", s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations)
+	if ann != "" {
+		var err error
+		enabled, err = utils.GetBoolValue(ann, "pod-server-names")
+		if err != nil {
+			logger.Errorf("Ingress '%s/%s': pod-server-names: %s", s.ingress.Namespace, s.ingress.Name, err)
+			return false
+		}
 	}
+	if enabled {
+		podNames = endpoints.PodNames
+	}
+	for i, srvSlot := range endpoints.HAProxySrvs {
+		// Renaming would reset the drain state of the server
+		if !srvSlot.DrainSince.IsZero() {
+			continue
+		}
+		name := fmt.Sprintf("SRV_%d", i+1)
+		if podName, ok := podNames[srvSlot.Address]; ok && srvSlot.Address != "" {
+			name = podName
+		}
+		if name == srvSlot.Name {
+			continue
+		}
+		// Server is created with its new name by updateHAProxySrv
+		if !s.newBackend {
+			// Servers of new slots do not exist yet
+			if err := client.BackendServerDelete(s.backendName, srvSlot.Name); err != nil && !strings.Contains(err.Error(), "does not exist") {
+				logger.Error(err)
+			}
+		}
+		// Other backends sharing the slot no more see the old name
+		for _, backend := range sharingBackends(endpoints, s.backendName) {
+			_ = client.BackendServerDelete(backend, srvSlot.Name)
+		}
+		logger.Debugf("Ingress '%s/%s': server '%s/%s' renamed to '%s', reload required", s.ingress.Namespace, s.ingress.Name, s.backendName, srvSlot.Name, name)
+		srvSlot.Name = name
+		srvSlot.Modified = true
+		reload = true
+	}
+	return reload
+}
+
+// sharingBackends returns the backends, other than backendName, whose servers
+// are the srvs of endpoints, i.e. the service backend and ingress paths ones.
+func sharingBackends(endpoints *store.PortEndpoints, backendName string) (backends []string) {
+	if endpoints.BackendName != "" && endpoints.BackendName != backendName {
+		backends = append(backends, endpoints.BackendName)
+	}
+	for backend := range endpoints.PathBackends {
+		if backend != backendName {
+			backends = append(backends, backend)
+		}
+	}
+	return backends
+}
+
+// podWeight returns the weight set by the pod with the given address, nil if none
+func podWeight(podWeights map[string]int64, address string) *int64 {
+	if address == "" {
+		return nil
+	}
+	if weight, ok := podWeights[address]; ok {
+		return &weight
+	}
+	return nil
+}
+
+// setRuntimeWeight sets the weight of a backend server via runtime API,
+// it returns false when it fails in which case a reload is required.
+func (s *SvcContext) setRuntimeWeight(client api.HAProxyClient, srvName string, weight *int64) bool {
+	// HAProxy default server weight
+	value := int64(1)
+	if weight != nil {
+		value = *weight
+	}
+	if err := client.SetServerWeight(s.backendName, srvName, strconv.FormatInt(value, 10)); err != nil {
+		logger.Warningf("Ingress '%s/%s': unable to set weight of server '%s/%s' via runtime API: %s, reload required", s.ingress.Namespace, s.ingress.Name, s.backendName, srvName, err)
+		return false
+	}
+	logger.Debugf("Ingress '%s/%s': weight of server '%s/%s' set to %d", s.ingress.Namespace, s.ingress.Name, s.backendName, srvName, value)
+	return true
+}
+
+// activeStandby returns true when the active-standby annotation is set, in which case
+// all backend servers but the first one are backup servers: traffic is only sent to
+// the first server, or to the first healthy backup server when it is down.
+func (s *SvcContext) activeStandby() bool {
+	annActiveStandby := s.store.GetValueFromAnnotations("active-standby", s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations)
+	if annActiveStandby == "" {
+		return false
+	}
+	enabled, err := utils.GetBoolValue(annActiveStandby, "active-standby")
+	if err != nil {
+		logger.Errorf("Ingress '%s/%s': active-standby: %s", s.ingress.Namespace, s.ingress.Name, err)
+		return false
+	}
+	return enabled
+}
+
+// activeSrvs returns the number of backend servers with an endpoint address, excluding draining ones
+func activeSrvs(endpoints *store.PortEndpoints) (count int) {
 	for _, srvSlot := range endpoints.HAProxySrvs {
-		if srvSlot.Modified || s.newBackend || srvsActiveAnn {
-			s.updateHAProxySrv(client, *srv, *srvSlot, endpoints.Port)
+		if srvSlot.Address != "" && srvSlot.DrainSince.IsZero() {
+			count++
 		}
 	}
+	return count
+}
 
-	return srvsScaled || srvsActiveAnn
+// drainDelay returns the time servers are kept in drain once their pod left
+// service endpoints, so in-flight requests and keep-alive connections complete.
+func (s *SvcContext) drainDelay() time.Duration {
+	ann := s.store.GetValueFromAnnotations("drain-delay", s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations)
+	if ann == "" {
+		return 0
+	}
+	delay, err := utils.ParseTime(ann)
+	if err != nil || *delay < 0 {
+		logger.Errorf("Ingress '%s/%s': drain-delay: incorrect value '%s'", s.ingress.Namespace, s.ingress.Name, ann)
+		return 0
+	}
+	return time.Duration(*delay) * time.Millisecond
+}
+
+// expireDrainingSrvs disables the servers whose drain-delay expired, freeing their slot
+func (s *SvcContext) expireDrainingSrvs(client api.HAProxyClient, endpoints *store.PortEndpoints) {
+	for _, srvSlot := range endpoints.HAProxySrvs {
+		if srvSlot.DrainSince.IsZero() || time.Since(srvSlot.DrainSince) < endpoints.DrainDelay {
+			continue
+		}
+		logger.Debugf("Ingress '%s/%s': drain of server '%s/%s' completed", s.ingress.Namespace, s.ingress.Name, s.backendName, srvSlot.Name)
+		srvSlot.DrainSince = time.Time{}
+		srvSlot.Address = ""
+		srvSlot.Modified = true
+		if !s.newBackend {
+			logger.Error(client.SetServerAddr(s.backendName, srvSlot.Name, "127.0.0.1", 0))
+			logger.Error(client.SetServerState(s.backendName, srvSlot.Name, "maint"))
+		}
+	}
 }
 
 // updateHAProxySrv updates corresponding HAProxy backend server or creates one if it does not exist
@@ -96,25 +340,31 @@ func (s *SvcContext) updateHAProxySrv(client api.HAProxyClient, srv models.Serve
 	}
 }
 
-// scaleHAproxySrvs adds servers to match available addresses
-func (s *SvcContext) scaleHAProxySrvs(endpoints *store.PortEndpoints, k8sStore store.K8s) (reload bool) {
-	var flag bool
-	var srvSlots int
-	var disabled []*store.HAProxySrv
-	// Add disabled HAProxySrvs to match "scale-server-slots"
+// ServerSlots returns the number of server slots of backends, set by "scale-server-slots"
+func ServerSlots(k8sStore store.K8s) int {
 	// scale-server-slots has a default value in defaultAnnotations
 	// "servers-increment", "server-slots" are legacy annotations
 	for _, annotation := range []string{"servers-increment", "server-slots", "scale-server-slots"} {
 		annServerSlots := k8sStore.GetValueFromAnnotations(annotation, k8sStore.ConfigMaps.Main.Annotations)
-		if annServerSlots != "" {
-			if value, err := strconv.Atoi(annServerSlots); err == nil {
-				srvSlots = value
-				break
-			} else {
-				logger.Error(err)
-			}
+		if annServerSlots == "" {
+			continue
+		}
+		value, err := strconv.Atoi(annServerSlots)
+		if err != nil {
+			logger.Error(err)
+			continue
 		}
+		return value
 	}
+	return 0
+}
+
+// scaleHAproxySrvs adds servers to match available addresses
+func (s *SvcContext) scaleHAProxySrvs(endpoints *store.PortEndpoints, k8sStore store.K8s) (reload bool) {
+	var flag bool
+	var disabled []*store.HAProxySrv
+	// Add disabled HAProxySrvs to match "scale-server-slots"
+	srvSlots := ServerSlots(k8sStore)
 	for len(endpoints.HAProxySrvs) < srvSlots {
 		srv := &store.HAProxySrv{
 			Name:     fmt.Sprintf("SRV_%d", len(endpoints.HAProxySrvs)+1),
@@ -155,6 +405,9 @@ func (s *SvcContext) scaleHAProxySrvs(endpoints *store.PortEndpoints, k8sStore s
 }
 
 func (s *SvcContext) getEndpoints(k8s store.K8s) (endpoints *store.PortEndpoints, err error) {
+	if s.headlessDNS() {
+		return s.getHeadlessEndpoints()
+	}
 	var ok bool
 	var e *store.Endpoints
 	if ns := k8s.Namespaces[s.service.Namespace]; ns != nil {
@@ -166,13 +419,10 @@ func (s *SvcContext) getEndpoints(k8s store.K8s) (endpoints *store.PortEndpoints
 		}
 		return nil, fmt.Errorf("no Endpoints for service '%s'", s.service.Name)
 	}
-	sp := s.path.SvcPortResolved
-	if sp != nil {
-		for portName, endpoints := range e.Ports {
-			if portName == sp.Name || endpoints.Port == sp.Port {
-				return endpoints, nil
-			}
-		}
+	// Service port is resolved with the backend name, the named
+	// port of an ingress path being then the name of its endpoints
+	if sp := s.path.SvcPortResolved; sp != nil {
+		return e.GetPortEndpoints(sp.Name)
 	}
 	if s.path.SvcPortString != "" {
 		return nil, fmt.Errorf("no matching endpoints for service '%s' and port '%s'", s.service.Name, s.path.SvcPortString)
@@ -186,6 +436,10 @@ func (s *SvcContext) getExternalNameEndpoints() (endpoints *store.PortEndpoints,
 	for _, sp := range s.service.Ports {
 		if sp.Name == s.path.SvcPortString || sp.Port == s.path.SvcPortInt {
 			port = sp.Port
+			// Remap to the port of the external endpoints
+			if sp.TargetPort != 0 {
+				port = sp.TargetPort
+			}
 		}
 	}
 	if port == 0 {
@@ -197,13 +451,65 @@ func (s *SvcContext) getExternalNameEndpoints() (endpoints *store.PortEndpoints,
 	}
 	endpoints = &store.PortEndpoints{
 		Port: port,
-		HAProxySrvs: []*store.HAProxySrv{
-			{
-				Name:     "SRV_1",
-				Address:  s.service.DNS,
-				Modified: true,
-			},
-		},
+	}
+	for i, target := range s.getExternalNameTargets() {
+		endpoints.HAProxySrvs = append(endpoints.HAProxySrvs, &store.HAProxySrv{
+			Name:     fmt.Sprintf("SRV_%d", i+1),
+			Address:  target,
+			Modified: true,
+		})
 	}
 	return endpoints, nil
 }
+
+// getExternalNameTargets returns the service external name followed by
+// the additional DNS targets of the externalname-targets annotation.
+func (s *SvcContext) getExternalNameTargets() []string {
+	targets := []string{s.service.DNS}
+	annTargets := s.store.GetValueFromAnnotations("externalname-targets", s.service.Annotations)
+	if annTargets == "" {
+		return targets
+	}
+	for _, target := range strings.Split(annTargets, ",") {
+		target = strings.TrimSpace(target)
+		if target == "" || target == s.service.DNS {
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// syncExternalNameSrvs deletes ExternalName backend servers which are no more
+// in the targets list and reports if remaining servers targets changed.
+func (s *SvcContext) syncExternalNameSrvs(client api.HAProxyClient, endpoints *store.PortEndpoints) (reload bool) {
+	servers, err := client.BackendServersGet(s.backendName)
+	if err != nil {
+		return false
+	}
+	targets := make(map[string]string, len(endpoints.HAProxySrvs))
+	for _, srvSlot := range endpoints.HAProxySrvs {
+		targets[srvSlot.Name] = srvSlot.Address
+	}
+	srvCount := 0
+	for _, server := range servers {
+		if isExtraSrv(server.Name) {
+			continue
+		}
+		srvCount++
+		address, ok := targets[server.Name]
+		if !ok {
+			logger.Error(client.BackendServerDelete(s.backendName, server.Name))
+			reload = true
+			continue
+		}
+		if server.Address != address || server.Port == nil || *server.Port != endpoints.Port {
+			reload = true
+		}
+	}
+	if reload || srvCount != len(endpoints.HAProxySrvs) {
+		logger.Debugf("ExternalName targets of backend '%s' updated, reload required", s.backendName)
+		return true
+	}
+	return false
+}
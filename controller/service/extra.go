@@ -0,0 +1,187 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// Name prefixes of backend servers of canary-service, failover-service and backup-service annotations
+const (
+	canaryPrefix   = "CANARY_"
+	failoverPrefix = "FAILOVER_"
+	backupPrefix   = "BACKUP_"
+)
+
+// maxWeight is the maximum weight of an HAProxy server
+const maxWeight = 256
+
+// extraSrvs holds the endpoints of a service, other than the backend one,
+// whose servers are added to the backend by annotations.
+type extraSrvs struct {
+	addresses []string
+	port      int64
+	// addrPorts are the ports of addresses targeting another port than port
+	addrPorts map[string]int64
+}
+
+// addrPort returns the port of the extra server with the given address
+func (e *extraSrvs) addrPort(address string) int64 {
+	if port, ok := e.addrPorts[address]; ok {
+		return port
+	}
+	return e.port
+}
+
+// isExtraSrv reports whether the backend server belongs to another service than the backend one
+func isExtraSrv(name string) bool {
+	return strings.HasPrefix(name, canaryPrefix) || strings.HasPrefix(name, failoverPrefix) || strings.HasPrefix(name, backupPrefix)
+}
+
+// getExtraSrvs returns the endpoints of the service given as
+// "[namespace/]name[:port]", the backend service port being used by default.
+// ExternalName services endpoint is their external name.
+func (s *SvcContext) getExtraSrvs(value string) (*extraSrvs, error) {
+	svc, port := value, ""
+	if i := strings.LastIndex(value, ":"); i != -1 {
+		svc, port = value[:i], value[i+1:]
+	}
+	if port == "" && s.path.SvcPortResolved != nil {
+		port = s.path.SvcPortResolved.Name
+		if port == "" {
+			port = strconv.FormatInt(s.path.SvcPortResolved.Port, 10)
+		}
+	}
+	ns, name := s.ingress.Namespace, svc
+	if parts := strings.Split(svc, "/"); len(parts) == 2 {
+		ns, name = parts[0], parts[1]
+	} else if len(parts) > 2 {
+		return nil, fmt.Errorf("'%s': incorrect service path", value)
+	}
+	if ns == s.service.Namespace && name == s.service.Name {
+		return nil, fmt.Errorf("'%s': service cannot be the backend service", value)
+	}
+	service, err := getService(s.store, ns, name)
+	if err != nil {
+		return nil, err
+	}
+	var svcPort *store.ServicePort
+	for i, sp := range service.Ports {
+		if sp.Name == port || strconv.FormatInt(sp.Port, 10) == port {
+			svcPort = &service.Ports[i]
+			break
+		}
+	}
+	if svcPort == nil {
+		return nil, fmt.Errorf("service '%s/%s': port '%s' not found", ns, name, port)
+	}
+	if service.DNS != "" {
+		srvs := &extraSrvs{
+			addresses: []string{service.DNS},
+			port:      svcPort.Port,
+		}
+		if svcPort.TargetPort != 0 {
+			srvs.port = svcPort.TargetPort
+		}
+		return srvs, nil
+	}
+	if namespace := s.store.Namespaces[ns]; namespace != nil {
+		if e, ok := namespace.Endpoints[name]; ok && e.Status != store.DELETED {
+			endpoints, err := e.GetPortEndpoints(svcPort.Name)
+			if err != nil {
+				return nil, err
+			}
+			srvs := &extraSrvs{
+				port:      endpoints.Port,
+				addrPorts: endpoints.AddrPorts,
+			}
+			for _, srv := range endpoints.HAProxySrvs {
+				if srv.Address != "" {
+					srvs.addresses = append(srvs.addresses, srv.Address)
+				}
+			}
+			for address := range endpoints.AddrNew {
+				srvs.addresses = append(srvs.addresses, address)
+			}
+			sort.Strings(srvs.addresses)
+			return srvs, nil
+		}
+	}
+	return nil, fmt.Errorf("service '%s/%s': no endpoints", ns, name)
+}
+
+// syncExtraSrvs creates, updates or deletes the backend servers named with
+// prefix from the extra service endpoints, srv holding the server options.
+func (s *SvcContext) syncExtraSrvs(client api.HAProxyClient, srv models.Server, prefix string, extra *extraSrvs, weight int64, backup, optionsUpdated bool) (reload bool) {
+	servers, _ := client.BackendServersGet(s.backendName)
+	current := make(map[string]*models.Server)
+	for _, server := range servers {
+		if strings.HasPrefix(server.Name, prefix) {
+			current[server.Name] = server
+		}
+	}
+	backupValue := "disabled"
+	if backup {
+		backupValue = "enabled"
+	}
+	if extra != nil {
+		for i, address := range extra.addresses {
+			extraSrv := srv
+			extraSrv.Name = fmt.Sprintf("%s%d", prefix, i+1)
+			extraSrv.Address = address
+			port := extra.addrPort(address)
+			extraSrv.Port = utils.PtrInt64(port)
+			extraSrv.Weight = utils.PtrInt64(weight)
+			extraSrv.Backup = backupValue
+			extraSrv.Maintenance = "disabled"
+			server, ok := current[extraSrv.Name]
+			delete(current, extraSrv.Name)
+			if ok && !optionsUpdated && server.Address == address &&
+				server.Port != nil && *server.Port == port &&
+				server.Weight != nil && *server.Weight == weight &&
+				(server.Backup == "enabled") == backup {
+				continue
+			}
+			var err error
+			if ok {
+				err = client.BackendServerEdit(s.backendName, extraSrv)
+			} else {
+				err = client.BackendServerCreate(s.backendName, extraSrv)
+			}
+			if err != nil {
+				logger.Error(err)
+				continue
+			}
+			reload = true
+		}
+	}
+	for name := range current {
+		logger.Error(client.BackendServerDelete(s.backendName, name))
+		reload = true
+	}
+	if reload {
+		logger.Debugf("Ingress '%s/%s': '%s' servers of backend '%s' updated, reload required", s.ingress.Namespace, s.ingress.Name, prefix, s.backendName)
+	}
+	return reload
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"strconv"
+)
+
+// localWeight is the weight of backend service servers when
+// failover-service annotation is set, failover-weight being relative to it.
+const localWeight = 100
+
+// getFailover returns the failover servers of the backend with their weight.
+// They are backup servers, only used by HAProxy when all backend service servers
+// are down, unless the number of ready endpoints of the backend service is
+// below failover-min-servers in which case they are promoted to active servers.
+func (s *SvcContext) getFailover(readySrvs int) (failover *extraSrvs, weight int64, active bool) {
+	annSources := []map[string]string{s.service.Annotations, s.ingress.Annotations}
+	annService := s.store.GetValueFromAnnotations("failover-service", annSources...)
+	if annService == "" {
+		return nil, 0, false
+	}
+	annWeight := s.store.GetValueFromAnnotations("failover-weight", annSources...)
+	weight, err := strconv.ParseInt(annWeight, 10, 64)
+	if err != nil || weight < 0 || weight > maxWeight {
+		logger.Errorf("Ingress '%s/%s': incorrect failover-weight value '%s', expecting an integer between 0 and %d", s.ingress.Namespace, s.ingress.Name, annWeight, maxWeight)
+		return nil, 0, false
+	}
+	annMinSrvs := s.store.GetValueFromAnnotations("failover-min-servers", annSources...)
+	minSrvs, err := strconv.Atoi(annMinSrvs)
+	if err != nil || minSrvs < 0 {
+		logger.Errorf("Ingress '%s/%s': incorrect failover-min-servers value '%s'", s.ingress.Namespace, s.ingress.Name, annMinSrvs)
+		return nil, 0, false
+	}
+	if failover, err = s.getExtraSrvs(annService); err != nil {
+		logger.Errorf("Ingress '%s/%s': failover-service: %s", s.ingress.Namespace, s.ingress.Name, err)
+		return nil, 0, false
+	}
+	active = readySrvs < minSrvs
+	if active {
+		logger.Debugf("Ingress '%s/%s': backend '%s' has %d ready servers out of %d required, failover servers promoted", s.ingress.Namespace, s.ingress.Name, s.backendName, readySrvs, minSrvs)
+	}
+	return failover, weight, active
+}
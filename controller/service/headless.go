@@ -0,0 +1,78 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+// headlessTemplateSize is the number of servers of the template resolving
+// the pods of a headless service when server-template annotation is not set.
+const headlessTemplateSize = 10
+
+// headlessDNS returns true when the pods of a headless service are resolved at runtime
+// with the DNS records of the service, as set by headless-resolution annotation, instead
+// of being read from its EndpointSlices. Headless services without ports are always
+// resolved, their EndpointSlices having no port to reach pods on.
+func (s *SvcContext) headlessDNS() bool {
+	if !s.service.Headless || s.service.DNS != "" {
+		return false
+	}
+	if len(s.service.Ports) == 0 {
+		return true
+	}
+	ann := s.store.GetValueFromAnnotations("headless-resolution", s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations)
+	switch ann {
+	case "", "endpoints":
+		return false
+	case "dns":
+		return true
+	default:
+		logger.Errorf("Ingress '%s/%s': headless-resolution: incorrect value '%s', expecting 'endpoints' or 'dns'", s.ingress.Namespace, s.ingress.Name, ann)
+		return false
+	}
+}
+
+// getHeadlessEndpoints returns the DNS name resolved by the server template of a headless
+// service: the SRV record of a named port, which gives the port of each pod, or the A records
+// of the service with its target port for unnamed ports and services without ports.
+func (s *SvcContext) getHeadlessEndpoints() (endpoints *store.PortEndpoints, err error) {
+	sp := s.path.SvcPortResolved
+	if sp == nil {
+		return nil, fmt.Errorf("service '%s/%s': port not resolved", s.service.Namespace, s.service.Name)
+	}
+	fqdn := fmt.Sprintf("%s.%s.svc.%s", s.service.Name, s.service.Namespace, s.settings.ClusterDomain)
+	endpoints = &store.PortEndpoints{Port: sp.TargetPort}
+	switch {
+	case sp.Name != "":
+		protocol := strings.ToLower(sp.Protocol)
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		fqdn = fmt.Sprintf("_%s._%s.%s", sp.Name, protocol, fqdn)
+		endpoints.Port = 0
+	case sp.TargetPort == 0:
+		return nil, fmt.Errorf("service '%s/%s': port %d targets a named port, which cannot be resolved without port name", s.service.Namespace, s.service.Name, sp.Port)
+	}
+	endpoints.HAProxySrvs = []*store.HAProxySrv{{
+		Name:     "SRV_1",
+		Address:  fqdn,
+		Modified: true,
+	}}
+	return endpoints, nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"strings"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// getPathTimeoutServer returns the server timeout set for the ingress path by
+// path-timeout-server annotation, a comma separated list of "<path>=<timeout>".
+// Paths with their own timeout get a dedicated backend, nil otherwise.
+func (s *SvcContext) getPathTimeoutServer() *int64 {
+	if s.path.Path == "" {
+		return nil
+	}
+	ann := s.store.GetValueFromAnnotations("path-timeout-server", s.ingress.Annotations)
+	for _, item := range strings.Split(ann, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(parts) != 2 || parts[0] != s.path.Path {
+			continue
+		}
+		timeout, err := utils.ParseTime(strings.TrimSpace(parts[1]))
+		if err != nil {
+			logger.Errorf("Ingress '%s/%s': path-timeout-server: path '%s': %s", s.ingress.Namespace, s.ingress.Name, s.path.Path, err)
+			return nil
+		}
+		return timeout
+	}
+	return nil
+}
+
+// pathBackendName returns the name of the dedicated backend of the ingress path,
+// derived from the service backend name, characters invalid in HAProxy names being replaced.
+func (s *SvcContext) pathBackendName(backendName string) string {
+	path := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, s.path.Path)
+	return backendName + "-" + s.ingress.Name + path
+}
@@ -16,11 +16,15 @@ package service
 
 import (
 	"fmt"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/go-test/deep"
 
+	"github.com/haproxytech/client-native/v2/misc"
 	"github.com/haproxytech/client-native/v2/models"
+	"github.com/haproxytech/config-parser/v4/types"
 
 	"github.com/haproxytech/kubernetes-ingress/controller/annotations"
 	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
@@ -30,23 +34,50 @@ import (
 
 var logger = utils.GetLogger()
 
+// Settings are the controller settings applied to the backends of services,
+// they are given by each controller to the SvcContext it creates.
+type Settings struct {
+	// TransparentProxy allows the transparent-proxy annotation, it is set when
+	// the controller is started with --transparent-proxy and NET_ADMIN capability.
+	TransparentProxy bool
+	// ErrFileDir is the directory of the Errorfiles ConfigMap entries,
+	// referenced by backend-error-pages annotation.
+	ErrFileDir string
+	// ClusterDomain is the DNS domain of the cluster, set with --cluster-domain,
+	// used to resolve the pods of headless services.
+	ClusterDomain string
+	// Zone is the zone of the controller, set with --zone or from the topology
+	// label of its node, used by the topology-aware-routing annotation.
+	Zone string
+}
+
 type SvcContext struct {
 	store       store.K8s
+	settings    Settings
 	ingress     *store.Ingress
 	path        *store.IngressPath
 	service     *store.Service
 	tcpService  bool
 	newBackend  bool
 	backendName string
+	// pathTimeout is the server timeout of the ingress path, set for paths with a dedicated backend
+	pathTimeout *int64
 }
 
-func NewCtx(k8s store.K8s, ingress *store.Ingress, path *store.IngressPath, tcpService bool) (*SvcContext, error) {
-	service, err := getService(k8s, ingress.Namespace, path.SvcName)
+func NewCtx(k8s store.K8s, settings Settings, ingress *store.Ingress, path *store.IngressPath, tcpService bool) (*SvcContext, error) {
+	var service *store.Service
+	var err error
+	if path.BackendResource != "" {
+		service, err = getUpstreamService(k8s, ingress.Namespace, path.BackendResource)
+	} else {
+		service, err = getService(k8s, ingress.Namespace, path.SvcName)
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &SvcContext{
 		store:      k8s,
+		settings:   settings,
 		ingress:    ingress,
 		path:       path,
 		service:    service,
@@ -69,7 +100,8 @@ func (s *SvcContext) GetService() *store.Service {
 }
 
 // GetBackendName checks if servicePort provided in IngressPath exists and construct corresponding backend name
-// Backend name is in format "ServiceNS-ServiceName-PortName"
+// Backend name is in format "ServiceNS-ServiceName-PortName", followed by "-IngressName<Path>"
+// for ingress paths with a dedicated backend.
 func (s *SvcContext) GetBackendName() (string, error) {
 	if s.backendName != "" {
 		return s.backendName, nil
@@ -84,6 +116,15 @@ func (s *SvcContext) GetBackendName() (string, error) {
 			break
 		}
 	}
+	// ServiceImports are referenced without port, their first port is used
+	if !found && s.path.SvcPortInt == 0 && s.path.SvcPortString == "" && len(s.service.Ports) != 0 {
+		svcPort, found = s.service.Ports[0], true
+	}
+	// Pods of headless services without ports are reached on the ingress port
+	if !found && s.service.Headless && len(s.service.Ports) == 0 && s.path.SvcPortInt != 0 {
+		svcPort = store.ServicePort{Port: s.path.SvcPortInt, TargetPort: s.path.SvcPortInt}
+		found = true
+	}
 	if !found {
 		if s.path.SvcPortString != "" {
 			return "", fmt.Errorf("service %s: no service port matching '%s'", s.service.Name, s.path.SvcPortString)
@@ -96,6 +137,9 @@ func (s *SvcContext) GetBackendName() (string, error) {
 	} else {
 		s.backendName = fmt.Sprintf("%s-%s-%s", s.service.Namespace, s.service.Name, strconv.Itoa(int(svcPort.Port)))
 	}
+	if s.pathTimeout = s.getPathTimeoutServer(); s.pathTimeout != nil {
+		s.backendName = s.pathBackendName(s.backendName)
+	}
 	return s.backendName, nil
 }
 
@@ -110,7 +154,7 @@ func (s *SvcContext) HandleBackend(client api.HAProxyClient, store store.K8s) (r
 		Name: backendName,
 		Mode: "http",
 	}
-	if s.service.DNS != "" {
+	if s.service.DNS != "" || s.headlessDNS() {
 		backend.DefaultServer = &models.DefaultServer{InitAddr: "last,libc,none"}
 	}
 	if s.tcpService {
@@ -125,14 +169,35 @@ func (s *SvcContext) HandleBackend(client api.HAProxyClient, store store.K8s) (r
 		reload = true
 		logger.Debugf("Ingress '%s/%s': new backend '%s', reload required", s.ingress.Namespace, s.ingress.Name, backendName)
 	}
+	crBackend, crBackendOwner := s.crBackendAnnotations()
 	annotations.HandleBackendAnnotations(
 		backend,
 		store,
 		client,
+		[]string{
+			fmt.Sprintf("service %s/%s", s.service.Namespace, s.service.Name),
+			fmt.Sprintf("ingress %s/%s", s.ingress.Namespace, s.ingress.Name),
+			crBackendOwner,
+			fmt.Sprintf("configmap %s/%s", s.store.ConfigMaps.Main.Namespace, s.store.ConfigMaps.Main.Name),
+		},
 		s.service.Annotations,
 		s.ingress.Annotations,
+		crBackend,
 		s.store.ConfigMaps.Main.Annotations,
 	)
+	if s.pathTimeout != nil {
+		backend.ServerTimeout = s.pathTimeout
+	}
+	persistenceSrc := s.persistenceSrcTable()
+	backend.StickTable = persistenceSrc
+	// All backup-service servers share the load once backend service servers are down
+	if s.store.GetValueFromAnnotations("backup-service", s.service.Annotations, s.ingress.Annotations) != "" {
+		backend.Allbackups = "enabled"
+	}
+	// All servers out of the controller zone share the load once local ones are down
+	if s.store.GetValueFromAnnotations("topology-aware-routing", s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations) == topologyBackup {
+		backend.Allbackups = "enabled"
+	}
 	// Update Backend
 	result := deep.Equal(oldBackend, backend)
 	if len(result) != 0 {
@@ -142,10 +207,385 @@ func (s *SvcContext) HandleBackend(client api.HAProxyClient, store store.K8s) (r
 		reload = true
 		logger.Debugf("Ingress '%s/%s': backend '%s' updated: %s\nReload required", s.ingress.Namespace, s.ingress.Name, backend.Name, result)
 	}
+	if backend.Mode == "http" {
+		reload = s.handleH1CaseAdjust(client) || reload
+		reload = s.handleRetryOn(client) || reload
+		reload = s.handleCache(client) || reload
+		reload = s.handleErrorFiles(client) || reload
+	}
+	reload = s.handlePersistenceSrc(client, persistenceSrc != nil) || reload
+	reload = s.handleFullconn(client) || reload
+	reload = s.handleTransparentProxy(client) || reload
+	reload = s.handleTCPCheck(client) || reload
 
 	return reload, backendName, nil
 }
 
+// crBackendAnnotations returns the annotations of the Backend custom resource referenced
+// by cr-backend annotation, they take precedence over the ConfigMap ones only.
+func (s *SvcContext) crBackendAnnotations() (annotations map[string]string, owner string) {
+	ref := s.store.GetValueFromAnnotations("cr-backend", s.service.Annotations, s.ingress.Annotations)
+	if ref == "" {
+		return nil, ""
+	}
+	backend, err := s.store.GetBackend(ref, s.service.Namespace)
+	if err != nil {
+		logger.Errorf("service '%s/%s': cr-backend: %s", s.service.Namespace, s.service.Name, err)
+		return nil, ""
+	}
+	return backend.Annotations, fmt.Sprintf("backend %s/%s", backend.Namespace, backend.Name)
+}
+
+// handleH1CaseAdjust enables h1-case-adjust-bogus-server option, which is not
+// part of backend model, when h1-case-adjust-backend annotation is set.
+func (s *SvcContext) handleH1CaseAdjust(client api.HAProxyClient) (reload bool) {
+	enabled := false
+	ann := s.store.GetValueFromAnnotations("h1-case-adjust-backend", s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations)
+	if ann != "" {
+		var err error
+		if enabled, err = utils.GetBoolValue(ann, "h1-case-adjust-backend"); err != nil {
+			logger.Errorf("Ingress '%s/%s': h1-case-adjust-backend: %s", s.ingress.Namespace, s.ingress.Name, err)
+			return false
+		}
+	}
+	current, err := client.BackendH1CaseAdjustGet(s.backendName)
+	if err != nil || current == enabled {
+		return false
+	}
+	if err = client.BackendH1CaseAdjustSet(s.backendName, enabled); err != nil {
+		logger.Error(err)
+		return false
+	}
+	logger.Debugf("Ingress '%s/%s': h1-case-adjust of backend '%s' set to %t, reload required", s.ingress.Namespace, s.ingress.Name, s.backendName, enabled)
+	return true
+}
+
+// handleTCPCheck sets the tcp-check rules, which are not part of backend model,
+// of check-tcp-script annotation when check-type annotation is "tcp-check".
+// The script is given inline or as a reference to a ConfigMap key, one rule per line.
+func (s *SvcContext) handleTCPCheck(client api.HAProxyClient) (reload bool) {
+	var rules []string
+	annCheckType := s.store.GetValueFromAnnotations("check-type", s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations)
+	if strings.TrimSpace(annCheckType) == "tcp-check" {
+		script := s.store.GetValueFromAnnotations("check-tcp-script", s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations)
+		if store.IsValueRef(script) {
+			var err error
+			if script, err = s.store.FetchValueRef(script, s.service.Namespace); err != nil {
+				logger.Errorf("Ingress '%s/%s': check-tcp-script: %s", s.ingress.Namespace, s.ingress.Name, err)
+				return false
+			}
+		}
+		for _, line := range strings.Split(script, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if !strings.HasPrefix(line, "tcp-check ") {
+				line = "tcp-check " + line
+			}
+			rules = append(rules, line)
+		}
+	}
+	current, err := client.BackendTCPCheckGet(s.backendName)
+	if err != nil || equalStrings(current, rules) {
+		return false
+	}
+	if err = client.BackendTCPCheckSet(s.backendName, rules); err != nil {
+		logger.Error(err)
+		return false
+	}
+	logger.Debugf("Ingress '%s/%s': tcp-check rules of backend '%s' updated, reload required", s.ingress.Namespace, s.ingress.Name, s.backendName)
+	return true
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// retryOnKeywords are the retry-on conditions other than HTTP status codes
+var retryOnKeywords = map[string]struct{}{
+	"none": {}, "conn-failure": {}, "empty-response": {}, "junk-response": {},
+	"response-timeout": {}, "0rtt-rejected": {}, "all-retryable-errors": {},
+}
+
+// handleRetryOn sets the retry-on backend directive, which is not part of
+// backend model, with the conditions of retry-on annotation.
+func (s *SvcContext) handleRetryOn(client api.HAProxyClient) (reload bool) {
+	var conditions []string
+	ann := s.store.GetValueFromAnnotations("retry-on", s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations)
+	for _, condition := range strings.FieldsFunc(ann, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if _, ok := retryOnKeywords[condition]; !ok {
+			if status, err := strconv.Atoi(condition); err != nil || status < 100 || status > 599 {
+				logger.Errorf("Ingress '%s/%s': retry-on: incorrect condition '%s'", s.ingress.Namespace, s.ingress.Name, condition)
+				return false
+			}
+		}
+		conditions = append(conditions, condition)
+	}
+	value := strings.Join(conditions, " ")
+	current, err := client.BackendRetryOnGet(s.backendName)
+	if err != nil || current == value {
+		return false
+	}
+	if err = client.BackendRetryOnSet(s.backendName, value); err != nil {
+		logger.Error(err)
+		return false
+	}
+	logger.Debugf("Ingress '%s/%s': retry-on of backend '%s' set to '%s', reload required", s.ingress.Namespace, s.ingress.Name, s.backendName, value)
+	return true
+}
+
+// persistenceSrcTable returns the stick table of the backend storing the server
+// of each client source address when persistence-src annotation is set.
+func (s *SvcContext) persistenceSrcTable() *models.BackendStickTable {
+	annSources := []map[string]string{s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations}
+	ann := s.store.GetValueFromAnnotations("persistence-src", annSources...)
+	if ann == "" {
+		return nil
+	}
+	enabled, err := utils.GetBoolValue(ann, "persistence-src")
+	if err != nil {
+		logger.Errorf("Ingress '%s/%s': persistence-src: %s", s.ingress.Namespace, s.ingress.Name, err)
+		return nil
+	}
+	if !enabled {
+		return nil
+	}
+	annSize := s.store.GetValueFromAnnotations("persistence-src-size", annSources...)
+	size := misc.ParseSize(annSize)
+	if size == nil || *size <= 0 {
+		logger.Errorf("Ingress '%s/%s': persistence-src-size: incorrect value '%s'", s.ingress.Namespace, s.ingress.Name, annSize)
+		return nil
+	}
+	annExpire := s.store.GetValueFromAnnotations("persistence-src-expire", annSources...)
+	expire, err := utils.ParseTime(annExpire)
+	if err != nil || *expire <= 0 {
+		logger.Errorf("Ingress '%s/%s': persistence-src-expire: incorrect value '%s'", s.ingress.Namespace, s.ingress.Name, annExpire)
+		return nil
+	}
+	return &models.BackendStickTable{
+		Type:   "ip",
+		Size:   size,
+		Expire: expire,
+		Peers:  "localinstance",
+	}
+}
+
+// handlePersistenceSrc sets the "stick on src" rule of the backend,
+// stick rules not being part of backend model.
+func (s *SvcContext) handlePersistenceSrc(client api.HAProxyClient, enabled bool) (reload bool) {
+	rules, err := client.BackendStickRulesGet(s.backendName)
+	if err != nil {
+		logger.Error(err)
+		return false
+	}
+	found := false
+	for i := len(rules) - 1; i >= 0; i-- {
+		rule := rules[i]
+		if rule.Type != "on" || rule.Pattern != "src" || rule.Index == nil {
+			continue
+		}
+		if enabled && !found {
+			found = true
+			continue
+		}
+		if err = client.BackendStickRuleDelete(s.backendName, *rule.Index); err != nil {
+			logger.Error(err)
+			return false
+		}
+		reload = true
+	}
+	if enabled && !found {
+		err = client.BackendStickRuleCreate(s.backendName, models.StickRule{
+			Index:   utils.PtrInt64(0),
+			Type:    "on",
+			Pattern: "src",
+		})
+		if err != nil {
+			logger.Error(err)
+			return false
+		}
+		reload = true
+	}
+	if reload {
+		logger.Debugf("Ingress '%s/%s': persistence-src of backend '%s' set to %t, reload required", s.ingress.Namespace, s.ingress.Name, s.backendName, enabled)
+	}
+	return reload
+}
+
+// handleErrorFiles sets the errorfile directives, which are not part of backend
+// model, of backend-error-pages annotation. Its value is a list of "<code>=<key>"
+// referencing Errorfiles ConfigMap entries, overriding the defaults ones for the backend.
+func (s *SvcContext) handleErrorFiles(client api.HAProxyClient) (reload bool) {
+	var errorFiles []types.ErrorFile
+	ann := s.store.GetValueFromAnnotations("backend-error-pages", s.service.Annotations, s.ingress.Annotations)
+	for _, item := range strings.Fields(strings.ReplaceAll(ann, ",", " ")) {
+		parts := strings.SplitN(item, "=", 2)
+		code, key := parts[0], parts[0]
+		if len(parts) == 2 {
+			key = parts[1]
+		}
+		if err := utils.CheckErrorFileCode(code); err != nil {
+			logger.Errorf("Ingress '%s/%s': backend-error-pages: %s", s.ingress.Namespace, s.ingress.Name, err)
+			return false
+		}
+		errorfiles := s.store.ConfigMaps.Errorfiles
+		if _, ok := errorfiles.Annotations[key]; !ok || !errorfiles.Loaded {
+			logger.Errorf("Ingress '%s/%s': backend-error-pages: key '%s' not found in ConfigMap '%s/%s'", s.ingress.Namespace, s.ingress.Name, key, errorfiles.Namespace, errorfiles.Name)
+			return false
+		}
+		errorFiles = append(errorFiles, types.ErrorFile{
+			Code: code,
+			File: filepath.Join(s.settings.ErrFileDir, key),
+		})
+	}
+	current, err := client.BackendErrorFilesGet(s.backendName)
+	if err != nil || equalErrorFiles(current, errorFiles) {
+		return false
+	}
+	if err = client.BackendErrorFilesSet(s.backendName, errorFiles); err != nil {
+		logger.Error(err)
+		return false
+	}
+	logger.Debugf("Ingress '%s/%s': errorfiles of backend '%s' updated, reload required", s.ingress.Namespace, s.ingress.Name, s.backendName)
+	return true
+}
+
+func equalErrorFiles(a, b []types.ErrorFile) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Code != b[i].Code || a[i].File != b[i].File {
+			return false
+		}
+	}
+	return true
+}
+
+// handleFullconn sets the fullconn backend directive, which is not part of
+// backend model, when backend-fullconn annotation is set.
+func (s *SvcContext) handleFullconn(client api.HAProxyClient) (reload bool) {
+	value := s.store.GetValueFromAnnotations("backend-fullconn", s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations)
+	if value != "" {
+		if fullconn, err := strconv.ParseInt(value, 10, 64); err != nil || fullconn < 1 {
+			logger.Errorf("Ingress '%s/%s': backend-fullconn: incorrect value '%s'", s.ingress.Namespace, s.ingress.Name, value)
+			return false
+		}
+	}
+	current, err := client.BackendFullconnGet(s.backendName)
+	if err != nil || current == value {
+		return false
+	}
+	if err = client.BackendFullconnSet(s.backendName, value); err != nil {
+		logger.Error(err)
+		return false
+	}
+	logger.Debugf("Ingress '%s/%s': fullconn of backend '%s' set to '%s', reload required", s.ingress.Namespace, s.ingress.Name, s.backendName, value)
+	return true
+}
+
+// handleTransparentProxy makes the backend connect to servers with the client
+// address as source when transparent-proxy annotation is set.
+func (s *SvcContext) handleTransparentProxy(client api.HAProxyClient) (reload bool) {
+	enabled := false
+	ann := s.store.GetValueFromAnnotations("transparent-proxy", s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations)
+	if ann != "" {
+		var err error
+		if enabled, err = utils.GetBoolValue(ann, "transparent-proxy"); err != nil {
+			logger.Errorf("Ingress '%s/%s': transparent-proxy: %s", s.ingress.Namespace, s.ingress.Name, err)
+			return false
+		}
+	}
+	if enabled && !s.settings.TransparentProxy {
+		logger.Errorf("Ingress '%s/%s': transparent-proxy: controller not started with --transparent-proxy, ignoring", s.ingress.Namespace, s.ingress.Name)
+		enabled = false
+	}
+	current, err := client.BackendTransparentProxyGet(s.backendName)
+	if err != nil || current == enabled {
+		return false
+	}
+	if err = client.BackendTransparentProxySet(s.backendName, enabled); err != nil {
+		logger.Error(err)
+		return false
+	}
+	logger.Debugf("Ingress '%s/%s': transparent-proxy of backend '%s' set to %t, reload required", s.ingress.Namespace, s.ingress.Name, s.backendName, enabled)
+	return true
+}
+
+// handleCache configures a cache section dedicated to the backend, with
+// the rules to lookup and store responses, when cache-enable is set.
+func (s *SvcContext) handleCache(client api.HAProxyClient) (reload bool) {
+	annSources := []map[string]string{s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations}
+	enabled, err := utils.GetBoolValue(s.store.GetValueFromAnnotations("cache-enable", annSources...), "cache-enable")
+	if err != nil {
+		logger.Errorf("Ingress '%s/%s': cache-enable: %s", s.ingress.Namespace, s.ingress.Name, err)
+		return false
+	}
+	currentCache, _ := client.BackendCacheGet(s.backendName)
+	if !enabled {
+		if currentCache == "" {
+			return false
+		}
+		logger.Error(client.BackendCacheSet(s.backendName, ""))
+		logger.Error(client.CacheDelete(currentCache))
+		logger.Debugf("Ingress '%s/%s': cache of backend '%s' disabled, reload required", s.ingress.Namespace, s.ingress.Name, s.backendName)
+		return true
+	}
+	cache := api.Cache{Name: s.backendName}
+	annMaxAge := s.store.GetValueFromAnnotations("cache-max-age", annSources...)
+	maxAge, err := utils.ParseTime(annMaxAge)
+	if err != nil || *maxAge < 1000 {
+		logger.Errorf("Ingress '%s/%s': cache-max-age: incorrect value '%s'", s.ingress.Namespace, s.ingress.Name, annMaxAge)
+		return false
+	}
+	cache.MaxAge = *maxAge / 1000
+	annSize := s.store.GetValueFromAnnotations("cache-total-max-size", annSources...)
+	cache.TotalMaxSize, err = utils.ParseInt(annSize)
+	if err != nil || cache.TotalMaxSize < 1 || cache.TotalMaxSize > 4095 {
+		logger.Errorf("Ingress '%s/%s': cache-total-max-size: incorrect value '%s'", s.ingress.Namespace, s.ingress.Name, annSize)
+		return false
+	}
+	if current, errGet := client.CacheGet(cache.Name); errGet != nil || *current != cache {
+		if err = client.CacheSet(cache); err != nil {
+			logger.Error(err)
+			return false
+		}
+		reload = true
+	}
+	if currentCache != cache.Name {
+		if err = client.BackendCacheSet(s.backendName, cache.Name); err != nil {
+			logger.Error(err)
+			return false
+		}
+		reload = true
+	}
+	if reload {
+		logger.Debugf("Ingress '%s/%s': cache of backend '%s' updated, reload required", s.ingress.Namespace, s.ingress.Name, s.backendName)
+	}
+	return reload
+}
+
+// getUpstreamService returns the service of the upstream of a Backend custom resource
+func getUpstreamService(k8s store.K8s, namespace, name string) (*store.Service, error) {
+	backend, err := k8s.GetBackend(name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if backend.Upstream == nil {
+		return nil, fmt.Errorf("backend '%s/%s' has no upstream", namespace, name)
+	}
+	return backend.UpstreamService(), nil
+}
+
 func getService(k8s store.K8s, namespace, name string) (*store.Service, error) {
 	var service *store.Service
 	ns, ok := k8s.Namespaces[namespace]
@@ -0,0 +1,132 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/go-test/deep"
+
+	"github.com/haproxytech/client-native/v2/models"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/api"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+// defaultResolvers is the resolvers section, defined in haproxy.cfg,
+// used to resolve ExternalName services without "resolver" annotation.
+const defaultResolvers = "kubernetes"
+
+// serverTemplateSize returns the number of servers of the template used for each target
+// of an ExternalName service, 0 when server-template is not set. Resolved headless services
+// always use a server template, headlessTemplateSize being their default size.
+func (s *SvcContext) serverTemplateSize() int {
+	headless := s.headlessDNS()
+	if s.service.DNS == "" && !headless {
+		return 0
+	}
+	annTemplate := s.store.GetValueFromAnnotations("server-template", s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations)
+	if annTemplate == "" {
+		if headless {
+			return headlessTemplateSize
+		}
+		return 0
+	}
+	size, err := strconv.Atoi(annTemplate)
+	if err != nil || size < 0 {
+		logger.Errorf("Ingress '%s/%s': server-template: incorrect value '%s'", s.ingress.Namespace, s.ingress.Name, annTemplate)
+		return 0
+	}
+	return size
+}
+
+// resolvers returns the resolvers section of the "resolver" annotation,
+// used to resolve ExternalName services at runtime.
+func (s *SvcContext) resolvers() string {
+	return s.store.GetValueFromAnnotations("resolver", s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations)
+}
+
+// syncServerTemplates configures a server template per ExternalName target, so HAProxy
+// resolves it at runtime and uses up to size of its DNS records as servers.
+// Backend servers, used without server template, are deleted.
+func (s *SvcContext) syncServerTemplates(client api.HAProxyClient, srv models.Server, endpoints *store.PortEndpoints, size int) (reload bool) {
+	servers, _ := client.BackendServersGet(s.backendName)
+	for _, server := range servers {
+		if isExtraSrv(server.Name) {
+			continue
+		}
+		logger.Error(client.BackendServerDelete(s.backendName, server.Name))
+		reload = true
+	}
+	// Server annotations are applied to templates as they share server options
+	options, err := json.Marshal(srv)
+	if err != nil {
+		logger.Error(err)
+		return reload
+	}
+	resolvers := s.resolvers()
+	if resolvers == "" {
+		resolvers = defaultResolvers
+	}
+	templates := make(models.ServerTemplates, 0, len(endpoints.HAProxySrvs))
+	for i, srvSlot := range endpoints.HAProxySrvs {
+		template := &models.ServerTemplate{}
+		if err = json.Unmarshal(options, template); err != nil {
+			logger.Error(err)
+			return reload
+		}
+		port := endpoints.Port
+		template.Prefix = fmt.Sprintf("SRV_%d_", i+1)
+		template.NumOrRange = strconv.Itoa(size)
+		template.Fqdn = srvSlot.Address
+		// Port of SRV records is given by their targets
+		if port != 0 {
+			template.Port = &port
+		}
+		template.Resolvers = resolvers
+		templates = append(templates, template)
+	}
+	current, _ := client.BackendServerTemplatesGet(s.backendName)
+	if result := deep.Equal(current, templates); len(result) == 0 {
+		return reload
+	}
+	for _, template := range current {
+		logger.Error(client.BackendServerTemplateDelete(s.backendName, template.Prefix))
+	}
+	for _, template := range templates {
+		logger.Error(client.BackendServerTemplateCreate(s.backendName, *template))
+	}
+	logger.Debugf("Server templates of backend '%s' updated, reload required", s.backendName)
+	return true
+}
+
+// deleteServerTemplates deletes the server templates of the backend
+// when server-template annotation is no more set.
+func (s *SvcContext) deleteServerTemplates(client api.HAProxyClient) (reload bool) {
+	templates, err := client.BackendServerTemplatesGet(s.backendName)
+	if err != nil {
+		return false
+	}
+	for _, template := range templates {
+		logger.Error(client.BackendServerTemplateDelete(s.backendName, template.Prefix))
+		reload = true
+	}
+	if reload {
+		logger.Debugf("Server templates of backend '%s' deleted, reload required", s.backendName)
+	}
+	return reload
+}
@@ -0,0 +1,108 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// topologyLocalWeight is the weight of local zone servers in "weight"
+// topology-aware-routing mode, other servers having HAProxy default weight 1.
+const topologyLocalWeight = 100
+
+const (
+	topologyWeight = "weight"
+	topologyBackup = "backup"
+)
+
+// srvTopology is the topology-aware-routing of a backend, mode being
+// empty when disabled, and local its addresses in the controller zone.
+type srvTopology struct {
+	mode  string
+	local map[string]bool
+}
+
+// getTopology returns the topology-aware-routing of the backend servers. Local servers
+// are the ones EndpointSlice hints assign to the controller zone, or the ones in the
+// zone when some endpoints have no hints. Routing is disabled when none is local.
+func (s *SvcContext) getTopology(endpoints *store.PortEndpoints, activeStandby bool) (topology srvTopology) {
+	ann := s.store.GetValueFromAnnotations("topology-aware-routing", s.service.Annotations, s.ingress.Annotations, s.store.ConfigMaps.Main.Annotations)
+	switch ann {
+	case "", "disabled":
+		return topology
+	case topologyWeight, topologyBackup:
+	default:
+		logger.Errorf("Ingress '%s/%s': topology-aware-routing: incorrect value '%s', expecting 'weight' or 'backup'", s.ingress.Namespace, s.ingress.Name, ann)
+		return topology
+	}
+	if s.settings.Zone == "" {
+		logger.Warningf("Ingress '%s/%s': topology-aware-routing: controller zone is unknown, ignored", s.ingress.Namespace, s.ingress.Name)
+		return topology
+	}
+	// Standby servers are already backup servers
+	if ann == topologyBackup && activeStandby {
+		return topology
+	}
+	var addresses []string
+	hinted := true
+	for _, srvSlot := range endpoints.HAProxySrvs {
+		// Addresses of draining servers left endpoints
+		if srvSlot.Address == "" || !srvSlot.DrainSince.IsZero() {
+			continue
+		}
+		addresses = append(addresses, srvSlot.Address)
+		if _, ok := endpoints.ZoneHints[srvSlot.Address]; !ok {
+			hinted = false
+		}
+	}
+	topology.local = make(map[string]bool)
+	for _, address := range addresses {
+		if !hinted {
+			topology.local[address] = endpoints.Zones[address] == s.settings.Zone
+			continue
+		}
+		for _, zone := range endpoints.ZoneHints[address] {
+			if zone == s.settings.Zone {
+				topology.local[address] = true
+			}
+		}
+	}
+	for _, local := range topology.local {
+		if local {
+			topology.mode = ann
+			return topology
+		}
+	}
+	logger.Debugf("Ingress '%s/%s': no endpoints of backend '%s' in zone '%s', topology-aware-routing ignored", s.ingress.Namespace, s.ingress.Name, s.backendName, s.settings.Zone)
+	return topology
+}
+
+// weight returns the weight of the local server with the given address in "weight" mode, nil otherwise
+func (t srvTopology) weight(address string) *int64 {
+	if t.mode != topologyWeight || !t.local[address] {
+		return nil
+	}
+	return utils.PtrInt64(topologyLocalWeight)
+}
+
+// backup returns true when the server of the slot is out of the zone in "backup" mode.
+// Disabled and draining servers keep their backup state, avoiding reloads for servers not in use.
+func (t srvTopology) backup(srvSlot *store.HAProxySrv) bool {
+	if srvSlot.Address == "" || !srvSlot.DrainSince.IsZero() {
+		return srvSlot.Backup
+	}
+	return t.mode == topologyBackup && !t.local[srvSlot.Address]
+}
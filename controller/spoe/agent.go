@@ -0,0 +1,223 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spoe
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+var logger = utils.GetLogger()
+
+// DefaultAgentAddr is the address the controller SPOE agent listens on
+// when --spoe-agent-address is not set
+const DefaultAgentAddr = "127.0.0.1:6061"
+
+// Message is a SPOE message sent by HAProxy with its arguments
+type Message struct {
+	Name string
+	Args map[string]interface{}
+}
+
+// Action is a set-var action returned to HAProxy
+type Action struct {
+	Scope Scope
+	Name  string
+	Value interface{}
+}
+
+// Handler processes a SPOE message and returns the actions to apply
+type Handler func(msg Message) []Action
+
+// Agent is a SPOE agent dispatching received messages to registered handlers
+type Agent struct {
+	Addr     string
+	handlers map[string]Handler
+	listener net.Listener
+	closed   bool
+	mu       sync.RWMutex
+}
+
+func NewAgent(addr string) *Agent {
+	return &Agent{
+		Addr:     addr,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Handle registers the handler for the given SPOE message name
+func (a *Agent) Handle(message string, handler Handler) {
+	a.mu.Lock()
+	a.handlers[message] = handler
+	a.mu.Unlock()
+}
+
+// ListenAndServe accepts HAProxy connections until listener fails or Close is called,
+// it returns nil in the latter case.
+func (a *Agent) ListenAndServe() error {
+	listener, err := net.Listen("tcp", a.Addr)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	a.mu.Lock()
+	closed := a.closed
+	a.listener = listener
+	a.mu.Unlock()
+	if closed {
+		return nil
+	}
+	logger.Infof("SPOE agent listening on %s", a.Addr)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			a.mu.RLock()
+			closed = a.closed
+			a.mu.RUnlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		go a.serve(conn)
+	}
+}
+
+// Close stops accepting HAProxy connections, established ones being
+// served until HAProxy closes them.
+func (a *Agent) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closed = true
+	if a.listener == nil {
+		return nil
+	}
+	return a.listener.Close()
+}
+
+func (a *Agent) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		f, err := readFrame(conn)
+		if err != nil {
+			if err != errInvalidFrame {
+				return
+			}
+			logger.Errorf("SPOE agent: %s", err)
+			a.disconnect(conn, errStatusIO, err.Error())
+			return
+		}
+		switch f.ftype {
+		case FRAME_HAPROXY_HELLO:
+			healthcheck, err := a.hello(conn, f)
+			if err != nil {
+				logger.Errorf("SPOE agent: %s", err)
+				return
+			}
+			if healthcheck {
+				return
+			}
+		case FRAME_NOTIFY:
+			if err = a.notify(conn, f); err != nil {
+				logger.Errorf("SPOE agent: %s", err)
+				return
+			}
+		case FRAME_HAPROXY_DISCONNECT:
+			a.disconnect(conn, errStatusNone, "")
+			return
+		default:
+			logger.Errorf("SPOE agent: unexpected frame type %d", f.ftype)
+			a.disconnect(conn, errStatusIO, "unexpected frame")
+			return
+		}
+	}
+}
+
+// hello answers HAProxy handshake, healthcheck is true when HAProxy
+// only checks the agent availability and closes the connection.
+func (a *Agent) hello(conn net.Conn, f frame) (healthcheck bool, err error) {
+	kv, err := decodeKVList(f.payload)
+	if err != nil {
+		return false, err
+	}
+	versions, _ := kv["supported-versions"].(string)
+	if !strings.Contains(versions, spopVersion) {
+		a.disconnect(conn, errStatusVersion, "unsupported version")
+		return false, fmt.Errorf("unsupported SPOP versions '%s'", versions)
+	}
+	frameSize := maxFrameSize
+	if size, ok := kv["max-frame-size"].(uint32); ok && size < frameSize {
+		frameSize = size
+	}
+	var payload []byte
+	payload = appendString(payload, "version")
+	payload = appendTypedData(payload, spopVersion)
+	payload = appendString(payload, "max-frame-size")
+	payload = appendTypedData(payload, frameSize)
+	payload = appendString(payload, "capabilities")
+	payload = appendTypedData(payload, "")
+	err = writeFrame(conn, frame{
+		ftype:   FRAME_AGENT_HELLO,
+		flags:   flagFin,
+		payload: payload,
+	})
+	if err != nil {
+		return false, err
+	}
+	healthcheck, _ = kv["healthcheck"].(bool)
+	return healthcheck, nil
+}
+
+func (a *Agent) notify(conn net.Conn, f frame) error {
+	messages, err := decodeMessages(f.payload)
+	if err != nil {
+		return err
+	}
+	var actions []Action
+	for _, msg := range messages {
+		a.mu.RLock()
+		handler, ok := a.handlers[msg.Name]
+		a.mu.RUnlock()
+		if !ok {
+			logger.Warningf("SPOE agent: no handler for message '%s'", msg.Name)
+			continue
+		}
+		actions = append(actions, handler(msg)...)
+	}
+	return writeFrame(conn, frame{
+		ftype:    FRAME_ACK,
+		flags:    flagFin,
+		streamID: f.streamID,
+		frameID:  f.frameID,
+		payload:  encodeActions(actions),
+	})
+}
+
+func (a *Agent) disconnect(conn net.Conn, status uint32, message string) {
+	var payload []byte
+	payload = appendString(payload, "status-code")
+	payload = appendTypedData(payload, status)
+	payload = appendString(payload, "message")
+	payload = appendTypedData(payload, message)
+	_ = writeFrame(conn, frame{
+		ftype:   FRAME_AGENT_DISCONNECT,
+		flags:   flagFin,
+		payload: payload,
+	})
+}
@@ -0,0 +1,134 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spoe
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SPOE messages handled by ForwardAuth
+const (
+	MsgForwardAuth = "forward-auth"
+	MsgAuthRequest = "auth-request"
+)
+
+// ForwardAuthHeadersSep separates header names in the "response_headers"
+// and "forward_headers" arguments
+const ForwardAuthHeadersSep = ";"
+
+const forwardAuthTimeout = 2 * time.Second
+
+// ForwardAuth returns a Handler sending a subrequest to the external authentication
+// service found in the "url" argument, forwarding the original request "authorization"
+// and "cookie" headers as well as its "method", "host" and "uri" via X-Original-* and
+// X-Forwarded-* headers.
+// When the "forward_headers" argument is set, the listed headers are also copied
+// from the arguments named by ForwardAuthHeaderArg.
+// The handler sets the following transaction variables: "valid" (bool, true on 2xx),
+// "status" (int) and, for each header listed in the "response_headers" argument,
+// the variable returned by ForwardAuthHeaderVar.
+func ForwardAuth() Handler {
+	client := &http.Client{
+		Timeout: forwardAuthTimeout,
+		// Redirects are answered by the authentication service to the client
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return func(msg Message) []Action {
+		return forwardAuth(client, msg)
+	}
+}
+
+// ForwardAuthHeaderVar returns the name of the variable holding
+// the given authentication response header.
+func ForwardAuthHeaderVar(header string) string {
+	return "hdr_" + strings.ReplaceAll(strings.ToLower(header), "-", "_")
+}
+
+// ForwardAuthHeaderArg returns the name of the message argument holding
+// the given request header forwarded to the authentication service.
+func ForwardAuthHeaderArg(header string) string {
+	return "fwd_" + strings.ReplaceAll(strings.ToLower(header), "-", "_")
+}
+
+func forwardAuth(client *http.Client, msg Message) []Action {
+	authURL, _ := msg.Args["url"].(string)
+	if authURL == "" {
+		return []Action{{Scope: SCOPE_TRANSACTION, Name: "valid", Value: false}}
+	}
+	method, _ := msg.Args["method"].(string)
+	host, _ := msg.Args["host"].(string)
+	uri, _ := msg.Args["uri"].(string)
+	scheme := "http"
+	if ssl, _ := msg.Args["ssl"].(bool); ssl {
+		scheme = "https"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), forwardAuthTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, authURL, nil)
+	if err != nil {
+		logger.Errorf("SPOE agent: forward-auth: %s", err)
+		return []Action{{Scope: SCOPE_TRANSACTION, Name: "valid", Value: false}}
+	}
+	for arg, header := range map[string]string{"authorization": "Authorization", "cookie": "Cookie"} {
+		if value, _ := msg.Args[arg].(string); value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+	if forwardHeaders, _ := msg.Args["forward_headers"].(string); forwardHeaders != "" {
+		for _, header := range strings.Split(forwardHeaders, ForwardAuthHeadersSep) {
+			if value, _ := msg.Args[ForwardAuthHeaderArg(header)].(string); header != "" && value != "" {
+				req.Header.Set(header, value)
+			}
+		}
+	}
+	req.Header.Set("X-Original-URL", scheme+"://"+host+uri)
+	req.Header.Set("X-Original-Method", method)
+	req.Header.Set("X-Forwarded-Proto", scheme)
+	req.Header.Set("X-Forwarded-Host", host)
+	req.Header.Set("X-Forwarded-Uri", uri)
+	req.Header.Set("X-Forwarded-Method", method)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Errorf("SPOE agent: forward-auth: %s", err)
+		return []Action{{Scope: SCOPE_TRANSACTION, Name: "valid", Value: false}}
+	}
+	resp.Body.Close()
+
+	valid := resp.StatusCode >= 200 && resp.StatusCode < 300
+	actions := []Action{
+		{Scope: SCOPE_TRANSACTION, Name: "valid", Value: valid},
+		{Scope: SCOPE_TRANSACTION, Name: "status", Value: resp.StatusCode},
+	}
+	if !valid {
+		return actions
+	}
+	headers, _ := msg.Args["response_headers"].(string)
+	for _, header := range strings.Split(headers, ForwardAuthHeadersSep) {
+		if header == "" {
+			continue
+		}
+		if value := resp.Header.Get(header); value != "" {
+			actions = append(actions, Action{Scope: SCOPE_TRANSACTION, Name: ForwardAuthHeaderVar(header), Value: value})
+		}
+	}
+	return actions
+}
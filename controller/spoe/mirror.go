@@ -0,0 +1,96 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spoe
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MsgMirror is the SPOE message handled by Mirror
+const MsgMirror = "mirror"
+
+const mirrorTimeout = 10 * time.Second
+
+// Maximum lengths of the URI, headers and body of mirrored requests, so that
+// mirror messages fit in a single frame as fragmentation is not supported
+const (
+	MirrorMaxURILen     = 1024
+	MirrorMaxHeadersLen = 8192
+	MirrorMaxBodyLen    = 6144
+)
+
+// Mirror returns a Handler replaying the request described by the "method",
+// "uri", "headers" and "body" arguments to the service found in the "url" argument.
+// The request is sent asynchronously and its response discarded, so the
+// handler returns immediately without any action.
+func Mirror() Handler {
+	client := &http.Client{
+		Timeout: mirrorTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return func(msg Message) []Action {
+		go mirror(client, msg)
+		return nil
+	}
+}
+
+func mirror(client *http.Client, msg Message) {
+	mirrorURL, _ := msg.Args["url"].(string)
+	if mirrorURL == "" {
+		return
+	}
+	method, _ := msg.Args["method"].(string)
+	uri, _ := msg.Args["uri"].(string)
+	body, _ := msg.Args["body"].([]byte)
+
+	ctx, cancel := context.WithTimeout(context.Background(), mirrorTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, mirrorURL+uri, bytes.NewReader(body))
+	if err != nil {
+		logger.Errorf("SPOE agent: mirror: %s", err)
+		return
+	}
+	headers, _ := msg.Args["headers"].(string)
+	for _, line := range strings.Split(headers, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := http.CanonicalHeaderKey(strings.TrimSpace(parts[0]))
+		switch name {
+		case "", "Content-Length", "Transfer-Encoding", "Connection":
+			continue
+		case "Host":
+			req.Host = strings.TrimSpace(parts[1])
+			continue
+		}
+		req.Header.Add(name, strings.TrimSpace(parts[1]))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Debugf("SPOE agent: mirror: %s", err)
+		return
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
@@ -0,0 +1,336 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spoe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Minimal implementation of the Stream Processing Offload Protocol (SPOP v2.0)
+// as described in HAProxy doc/SPOE.txt. Only synchronous, unfragmented frames
+// are supported which is what HAProxy uses when no capabilities are announced.
+
+type frameType byte
+
+//nolint: golint,stylecheck
+const (
+	FRAME_HAPROXY_HELLO      frameType = 1
+	FRAME_HAPROXY_DISCONNECT frameType = 2
+	FRAME_NOTIFY             frameType = 3
+	FRAME_AGENT_HELLO        frameType = 101
+	FRAME_AGENT_DISCONNECT   frameType = 102
+	FRAME_ACK                frameType = 103
+)
+
+//nolint: golint,stylecheck
+const (
+	DATA_NULL byte = iota
+	DATA_BOOL
+	DATA_INT32
+	DATA_UINT32
+	DATA_INT64
+	DATA_UINT64
+	DATA_IPV4
+	DATA_IPV6
+	DATA_STRING
+	DATA_BINARY
+)
+
+// Scope of variables set by the agent
+type Scope byte
+
+//nolint: golint,stylecheck
+const (
+	SCOPE_PROCESS Scope = iota
+	SCOPE_SESSION
+	SCOPE_TRANSACTION
+	SCOPE_REQUEST
+	SCOPE_RESPONSE
+)
+
+const (
+	actionSetVar  byte   = 1
+	flagFin       uint32 = 1
+	maxFrameSize  uint32 = 16380
+	spopVersion          = "2.0"
+	errStatusNone        = 0
+	errStatusIO          = 1
+	errStatusVersion     = 3
+)
+
+var errInvalidFrame = errors.New("spoe: invalid frame")
+
+type frame struct {
+	ftype    frameType
+	flags    uint32
+	streamID uint64
+	frameID  uint64
+	payload  []byte
+}
+
+func readFrame(conn net.Conn) (f frame, err error) {
+	var size [4]byte
+	if _, err = readFull(conn, size[:]); err != nil {
+		return
+	}
+	length := binary.BigEndian.Uint32(size[:])
+	if length > maxFrameSize || length < 7 {
+		return f, errInvalidFrame
+	}
+	data := make([]byte, length)
+	if _, err = readFull(conn, data); err != nil {
+		return
+	}
+	f.ftype = frameType(data[0])
+	f.flags = binary.BigEndian.Uint32(data[1:5])
+	data = data[5:]
+	var n int
+	if f.streamID, n, err = decodeVarint(data); err != nil {
+		return
+	}
+	data = data[n:]
+	if f.frameID, n, err = decodeVarint(data); err != nil {
+		return
+	}
+	f.payload = data[n:]
+	return f, nil
+}
+
+func writeFrame(conn net.Conn, f frame) error {
+	data := make([]byte, 4, 64+len(f.payload))
+	data = append(data, byte(f.ftype))
+	data = append(data, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[5:9], f.flags)
+	data = appendVarint(data, f.streamID)
+	data = appendVarint(data, f.frameID)
+	data = append(data, f.payload...)
+	binary.BigEndian.PutUint32(data[:4], uint32(len(data)-4))
+	_, err := conn.Write(data)
+	return err
+}
+
+func readFull(conn net.Conn, buf []byte) (n int, err error) {
+	for n < len(buf) && err == nil {
+		var nn int
+		nn, err = conn.Read(buf[n:])
+		n += nn
+	}
+	if n == len(buf) {
+		err = nil
+	}
+	return n, err
+}
+
+func appendVarint(data []byte, i uint64) []byte {
+	if i < 240 {
+		return append(data, byte(i))
+	}
+	data = append(data, byte(i)|240)
+	i = (i - 240) >> 4
+	for i >= 128 {
+		data = append(data, byte(i)|128)
+		i = (i - 128) >> 7
+	}
+	return append(data, byte(i))
+}
+
+func decodeVarint(data []byte) (value uint64, n int, err error) {
+	if len(data) == 0 {
+		return 0, 0, errInvalidFrame
+	}
+	value = uint64(data[0])
+	n = 1
+	if value < 240 {
+		return value, n, nil
+	}
+	shift := uint(4)
+	for {
+		if n >= len(data) {
+			return 0, 0, errInvalidFrame
+		}
+		b := data[n]
+		n++
+		value += uint64(b) << shift
+		shift += 7
+		if b < 128 {
+			break
+		}
+	}
+	return value, n, nil
+}
+
+func appendString(data []byte, s string) []byte {
+	data = appendVarint(data, uint64(len(s)))
+	return append(data, s...)
+}
+
+func decodeString(data []byte) (s string, n int, err error) {
+	length, n, err := decodeVarint(data)
+	if err != nil {
+		return
+	}
+	if uint64(len(data)-n) < length {
+		return "", 0, errInvalidFrame
+	}
+	return string(data[n : n+int(length)]), n + int(length), nil
+}
+
+// appendTypedData encodes supported Go values as SPOP typed data
+func appendTypedData(data []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(data, DATA_NULL)
+	case bool:
+		if v {
+			return append(data, DATA_BOOL|0x10)
+		}
+		return append(data, DATA_BOOL)
+	case int32:
+		return appendVarint(append(data, DATA_INT32), uint64(v))
+	case uint32:
+		return appendVarint(append(data, DATA_UINT32), uint64(v))
+	case int64:
+		return appendVarint(append(data, DATA_INT64), uint64(v))
+	case int:
+		return appendVarint(append(data, DATA_INT64), uint64(v))
+	case uint64:
+		return appendVarint(append(data, DATA_UINT64), v)
+	case net.IP:
+		if ip := v.To4(); ip != nil {
+			return append(append(data, DATA_IPV4), ip...)
+		}
+		return append(append(data, DATA_IPV6), v.To16()...)
+	case []byte:
+		data = append(data, DATA_BINARY)
+		data = appendVarint(data, uint64(len(v)))
+		return append(data, v...)
+	default:
+		return appendString(append(data, DATA_STRING), fmt.Sprint(v))
+	}
+}
+
+func decodeTypedData(data []byte) (value interface{}, n int, err error) {
+	if len(data) == 0 {
+		return nil, 0, errInvalidFrame
+	}
+	dataType := data[0] & 0x0F
+	flags := data[0] >> 4
+	n = 1
+	switch dataType {
+	case DATA_NULL:
+		return nil, n, nil
+	case DATA_BOOL:
+		return flags&1 == 1, n, nil
+	case DATA_INT32, DATA_UINT32, DATA_INT64, DATA_UINT64:
+		v, nn, err := decodeVarint(data[n:])
+		if err != nil {
+			return nil, 0, err
+		}
+		switch dataType {
+		case DATA_INT32:
+			value = int32(v)
+		case DATA_UINT32:
+			value = uint32(v)
+		case DATA_INT64:
+			value = int64(v)
+		default:
+			value = v
+		}
+		return value, n + nn, nil
+	case DATA_IPV4, DATA_IPV6:
+		size := net.IPv4len
+		if dataType == DATA_IPV6 {
+			size = net.IPv6len
+		}
+		if len(data[n:]) < size {
+			return nil, 0, errInvalidFrame
+		}
+		ip := make(net.IP, size)
+		copy(ip, data[n:n+size])
+		return ip, n + size, nil
+	case DATA_STRING:
+		s, nn, err := decodeString(data[n:])
+		return s, n + nn, err
+	case DATA_BINARY:
+		s, nn, err := decodeString(data[n:])
+		return []byte(s), n + nn, err
+	}
+	return nil, 0, errInvalidFrame
+}
+
+// decodeKVList decodes a list of key/value pairs until the end of data
+func decodeKVList(data []byte) (kv map[string]interface{}, err error) {
+	kv = make(map[string]interface{})
+	for len(data) > 0 {
+		key, n, err := decodeString(data)
+		if err != nil {
+			return nil, err
+		}
+		data = data[n:]
+		value, n, err := decodeTypedData(data)
+		if err != nil {
+			return nil, err
+		}
+		data = data[n:]
+		kv[key] = value
+	}
+	return kv, nil
+}
+
+// decodeMessages decodes the list of messages held by a NOTIFY frame
+func decodeMessages(data []byte) (messages []Message, err error) {
+	for len(data) > 0 {
+		var msg Message
+		var n int
+		msg.Name, n, err = decodeString(data)
+		if err != nil {
+			return nil, err
+		}
+		data = data[n:]
+		if len(data) == 0 {
+			return nil, errInvalidFrame
+		}
+		nbArgs := int(data[0])
+		data = data[1:]
+		msg.Args = make(map[string]interface{}, nbArgs)
+		for i := 0; i < nbArgs; i++ {
+			var key string
+			if key, n, err = decodeString(data); err != nil {
+				return nil, err
+			}
+			data = data[n:]
+			var value interface{}
+			if value, n, err = decodeTypedData(data); err != nil {
+				return nil, err
+			}
+			data = data[n:]
+			msg.Args[key] = value
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+func encodeActions(actions []Action) (data []byte) {
+	for _, action := range actions {
+		data = append(data, actionSetVar, 3, byte(action.Scope))
+		data = appendString(data, action.Name)
+		data = appendTypedData(data, action.Value)
+	}
+	return data
+}
@@ -0,0 +1,272 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spoe
+
+import (
+	"bytes"
+	"math"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// HAProxy HELLO frame: FIN flag, stream and frame IDs 0, supported-versions "2.0",
+// max-frame-size 16380, capabilities "pipelining,async" and an engine-id.
+var haproxyHello = []byte("\x00\x00\x00\x81" +
+	"\x01\x00\x00\x00\x01\x00\x00" +
+	"\x12supported-versions\x08\x032.0" +
+	"\x0emax-frame-size\x03\xfc\xf0\x06" +
+	"\x0ccapabilities\x08\x10pipelining,async" +
+	"\x09engine-id\x08\x243f4e8c8a-5e4f-4b8e-9d1c-2b7a1e0c6d5f")
+
+// HAProxy NOTIFY frame: FIN flag, stream ID 5, frame ID 1 and a forward-auth
+// message with the url and method strings and the ssl boolean arguments.
+var haproxyNotify = []byte("\x00\x00\x00\x38" +
+	"\x03\x00\x00\x00\x01\x05\x01" +
+	"\x0cforward-auth\x03" +
+	"\x03url\x08\x0chttp://auth/" +
+	"\x06method\x08\x03GET" +
+	"\x03ssl\x11")
+
+// sendFrames writes raw frames to one end of a pipe and returns the other end
+func sendFrames(t *testing.T, frames ...[]byte) net.Conn {
+	t.Helper()
+	client, server := net.Pipe()
+	go func() {
+		defer client.Close()
+		for _, f := range frames {
+			if _, err := client.Write(f); err != nil {
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+func TestVarint(t *testing.T) {
+	encodings := map[uint64][]byte{
+		0:     {0x00},
+		239:   {0xef},
+		240:   {0xf0, 0x00},
+		2287:  {0xff, 0x7f},
+		2288:  {0xf0, 0x80, 0x00},
+		16380: {0xfc, 0xf0, 0x06},
+	}
+	for value, encoded := range encodings {
+		if data := appendVarint(nil, value); !bytes.Equal(data, encoded) {
+			t.Errorf("appendVarint(%d) = %x, want %x", value, data, encoded)
+		}
+	}
+	for _, value := range []uint64{0, 1, 239, 240, 2287, 2288, 264431, 264432, 1 << 32, math.MaxInt64, math.MaxUint64} {
+		data := appendVarint([]byte{}, value)
+		decoded, n, err := decodeVarint(append(data, 0xaa))
+		if err != nil || decoded != value || n != len(data) {
+			t.Errorf("decodeVarint(%x) = %d, %d, %v, want %d, %d", data, decoded, n, err, value, len(data))
+		}
+		for i := 0; i < len(data); i++ {
+			if _, _, err = decodeVarint(data[:i]); err != errInvalidFrame {
+				t.Errorf("decodeVarint(%x) error = %v, want %v", data[:i], err, errInvalidFrame)
+			}
+		}
+	}
+}
+
+func TestTypedData(t *testing.T) {
+	values := []struct {
+		in  interface{}
+		out interface{}
+	}{
+		{nil, nil},
+		{true, true},
+		{false, false},
+		{int32(-5), int32(-5)},
+		{uint32(16380), uint32(16380)},
+		{int64(math.MinInt64), int64(math.MinInt64)},
+		{42, int64(42)},
+		{uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{net.IPv4(10, 0, 0, 1), net.IP{10, 0, 0, 1}},
+		{net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::1")},
+		{"", ""},
+		{"X-Api-Key", "X-Api-Key"},
+		{[]byte{0, 1, 2, 255}, []byte{0, 1, 2, 255}},
+		{403, int64(403)},
+	}
+	for _, v := range values {
+		data := appendTypedData(nil, v.in)
+		decoded, n, err := decodeTypedData(append(data, 0xaa))
+		if err != nil || !reflect.DeepEqual(decoded, v.out) || n != len(data) {
+			t.Errorf("decodeTypedData(%x) = %#v, %d, %v, want %#v, %d", data, decoded, n, err, v.out, len(data))
+		}
+		for i := 0; i < len(data); i++ {
+			if _, _, err = decodeTypedData(data[:i]); err != errInvalidFrame {
+				t.Errorf("decodeTypedData(%x) error = %v, want %v", data[:i], err, errInvalidFrame)
+			}
+		}
+	}
+	if _, _, err := decodeTypedData([]byte{0x0a}); err != errInvalidFrame {
+		t.Errorf("decodeTypedData of unknown type error = %v, want %v", err, errInvalidFrame)
+	}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	want := frame{
+		ftype:    FRAME_ACK,
+		flags:    flagFin,
+		streamID: 300,
+		frameID:  70000,
+		payload:  encodeActions([]Action{{Scope: SCOPE_TRANSACTION, Name: "valid", Value: true}}),
+	}
+	go func() {
+		defer client.Close()
+		_ = writeFrame(client, want)
+	}()
+	f, err := readFrame(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("readFrame() = %+v, want %+v", f, want)
+	}
+}
+
+func TestReadFrameErrors(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"too short":         []byte("\x00\x00\x00\x06\x01\x00\x00\x00\x01\x00"),
+		"too long":          []byte("\x00\x00\x40\x00\x01\x00\x00\x00\x01\x00\x00"),
+		"truncated varints": []byte("\x00\x00\x00\x07\x03\x00\x00\x00\x01\xf0\x80"),
+	} {
+		if _, err := readFrame(sendFrames(t, data)); err != errInvalidFrame {
+			t.Errorf("%s: readFrame() error = %v, want %v", name, err, errInvalidFrame)
+		}
+	}
+	for _, size := range []int{0, 2, 4, 10, len(haproxyNotify) - 1} {
+		if _, err := readFrame(sendFrames(t, haproxyNotify[:size])); err == nil {
+			t.Errorf("readFrame() of %d bytes out of %d: no error", size, len(haproxyNotify))
+		}
+	}
+}
+
+func TestHAProxyHello(t *testing.T) {
+	f, err := readFrame(sendFrames(t, haproxyHello))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.ftype != FRAME_HAPROXY_HELLO || f.flags != flagFin || f.streamID != 0 || f.frameID != 0 {
+		t.Errorf("readFrame() = %+v, want HAProxy HELLO", f)
+	}
+	kv, err := decodeKVList(f.payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"supported-versions": "2.0",
+		"max-frame-size":     uint32(16380),
+		"capabilities":       "pipelining,async",
+		"engine-id":          "3f4e8c8a-5e4f-4b8e-9d1c-2b7a1e0c6d5f",
+	}
+	if !reflect.DeepEqual(kv, want) {
+		t.Errorf("decodeKVList() = %v, want %v", kv, want)
+	}
+	// Truncated lists either fail or, when cut between two pairs, hold the first pairs
+	for i := 1; i < len(f.payload); i++ {
+		kv, err = decodeKVList(f.payload[:i])
+		if err != nil {
+			if err != errInvalidFrame {
+				t.Errorf("decodeKVList() of %d bytes out of %d error = %v, want %v", i, len(f.payload), err, errInvalidFrame)
+			}
+			continue
+		}
+		if len(kv) >= len(want) {
+			t.Errorf("decodeKVList() of %d bytes out of %d = %v, want fewer pairs", i, len(f.payload), kv)
+		}
+		for key, value := range kv {
+			if !reflect.DeepEqual(value, want[key]) {
+				t.Errorf("decodeKVList() of %d bytes out of %d: %s = %#v, want %#v", i, len(f.payload), key, value, want[key])
+			}
+		}
+	}
+}
+
+func TestHAProxyNotify(t *testing.T) {
+	f, err := readFrame(sendFrames(t, haproxyNotify))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.ftype != FRAME_NOTIFY || f.flags != flagFin || f.streamID != 5 || f.frameID != 1 {
+		t.Errorf("readFrame() = %+v, want HAProxy NOTIFY", f)
+	}
+	messages, err := decodeMessages(f.payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Message{{
+		Name: MsgForwardAuth,
+		Args: map[string]interface{}{"url": "http://auth/", "method": "GET", "ssl": true},
+	}}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("decodeMessages() = %+v, want %+v", messages, want)
+	}
+	for i := 1; i < len(f.payload); i++ {
+		if _, err = decodeMessages(f.payload[:i]); err == nil {
+			t.Errorf("decodeMessages() of %d bytes out of %d: no error", i, len(f.payload))
+		}
+	}
+}
+
+func TestAgentServe(t *testing.T) {
+	agent := NewAgent("")
+	agent.Handle(MsgForwardAuth, func(msg Message) []Action {
+		return []Action{{Scope: SCOPE_TRANSACTION, Name: "method", Value: msg.Args["method"]}}
+	})
+	haproxy, conn := net.Pipe()
+	defer haproxy.Close()
+	go agent.serve(conn)
+
+	go func() { _, _ = haproxy.Write(haproxyHello) }()
+	f, err := readFrame(haproxy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv, err := decodeKVList(f.payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantHello := map[string]interface{}{
+		"version":        spopVersion,
+		"max-frame-size": maxFrameSize,
+		"capabilities":   "",
+	}
+	if f.ftype != FRAME_AGENT_HELLO || !reflect.DeepEqual(kv, wantHello) {
+		t.Errorf("agent HELLO = %+v %v, want %v", f, kv, wantHello)
+	}
+
+	go func() { _, _ = haproxy.Write(haproxyNotify) }()
+	if f, err = readFrame(haproxy); err != nil {
+		t.Fatal(err)
+	}
+	wantAck := frame{
+		ftype:    FRAME_ACK,
+		flags:    flagFin,
+		streamID: 5,
+		frameID:  1,
+		payload:  []byte("\x01\x03\x02\x06method\x08\x03GET"),
+	}
+	if !reflect.DeepEqual(f, wantAck) {
+		t.Errorf("agent ACK = %+v, want %+v", f, wantAck)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spoe
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+	"time"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SPOE messages handled by TokenReview
+const (
+	MsgTokenReview      = "token-review"
+	MsgTokenReviewAuthz = "token-review-authz"
+)
+
+const (
+	tokenReviewTimeout     = 2 * time.Second
+	tokenReviewCacheTTL    = time.Minute
+	tokenReviewNegativeTTL = 10 * time.Second
+	tokenReviewCacheSize   = 4096
+	tokenReviewConcurrency = 8
+)
+
+type tokenReviewResult struct {
+	valid bool
+	user  string
+}
+
+// authzKey identifies a SubjectAccessReview of a user
+type authzKey struct {
+	uid  string
+	user string
+	verb string
+	path string
+}
+
+type tokenReview struct {
+	client kubernetes.Interface
+	// authn holds TokenReview statuses by token SHA-256 hash
+	authn *cache.LRUExpireCache
+	// authz holds SubjectAccessReview results by authzKey
+	authz *cache.LRUExpireCache
+	// reviews bounds the number of concurrent Kubernetes API calls
+	reviews chan struct{}
+}
+
+// TokenReview returns a Handler validating the bearer token found in the "authorization"
+// argument against Kubernetes TokenReview API. When "path" and "method" arguments are
+// provided the authenticated user is also authorized via a SubjectAccessReview on the
+// corresponding non-resource URL.
+// Authentication results are cached by token hash and authorization ones by user, verb
+// and path, for a minute or 10 seconds when negative, in bounded LRU caches. Kubernetes
+// API calls are limited to 8 concurrent ones, requests waiting longer than the review
+// timeout being denied.
+// The handler sets the following transaction variables: "valid" (bool) and "user" (string).
+func TokenReview(client kubernetes.Interface) Handler {
+	return newTokenReview(client).handle
+}
+
+func newTokenReview(client kubernetes.Interface) *tokenReview {
+	return &tokenReview{
+		client:  client,
+		authn:   cache.NewLRUExpireCache(tokenReviewCacheSize),
+		authz:   cache.NewLRUExpireCache(tokenReviewCacheSize),
+		reviews: make(chan struct{}, tokenReviewConcurrency),
+	}
+}
+
+func (t *tokenReview) handle(msg Message) []Action {
+	authorization, _ := msg.Args["authorization"].(string)
+	path, _ := msg.Args["path"].(string)
+	method, _ := msg.Args["method"].(string)
+	result := tokenReviewResult{}
+	if token := bearerToken(authorization); token != "" {
+		result = t.review(token, path, strings.ToLower(method))
+	}
+	return []Action{
+		{Scope: SCOPE_TRANSACTION, Name: "valid", Value: result.valid},
+		{Scope: SCOPE_TRANSACTION, Name: "user", Value: result.user},
+	}
+}
+
+func (t *tokenReview) review(token, path, verb string) (result tokenReviewResult) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenReviewTimeout)
+	defer cancel()
+	status, ok := t.authenticate(ctx, token)
+	if !ok || !status.Authenticated {
+		return result
+	}
+	result.user = status.User.Username
+	result.valid = true
+	if path != "" && verb != "" {
+		result.valid = t.authorize(ctx, status.User, path, verb)
+	}
+	return result
+}
+
+// authenticate returns the TokenReview status of token, ok being false when it could not be reviewed
+func (t *tokenReview) authenticate(ctx context.Context, token string) (status authnv1.TokenReviewStatus, ok bool) {
+	key := sha256.Sum256([]byte(token))
+	if cached, found := t.authn.Get(key); found {
+		return cached.(authnv1.TokenReviewStatus), true
+	}
+	if !t.acquire(ctx) {
+		logger.Warning("SPOE agent: TokenReview: too many concurrent reviews")
+		return status, false
+	}
+	defer t.release()
+	review, err := t.client.AuthenticationV1().TokenReviews().Create(ctx, &authnv1.TokenReview{
+		Spec: authnv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		// Do not cache API failures
+		logger.Errorf("SPOE agent: TokenReview: %s", err)
+		return status, false
+	}
+	ttl := tokenReviewCacheTTL
+	if !review.Status.Authenticated {
+		ttl = tokenReviewNegativeTTL
+	}
+	t.authn.Add(key, review.Status, ttl)
+	return review.Status, true
+}
+
+// authorize returns true when user is allowed to use verb on the non-resource URL path
+func (t *tokenReview) authorize(ctx context.Context, user authnv1.UserInfo, path, verb string) bool {
+	key := authzKey{uid: user.UID, user: user.Username, verb: verb, path: path}
+	if cached, found := t.authz.Get(key); found {
+		return cached.(bool)
+	}
+	if !t.acquire(ctx) {
+		logger.Warning("SPOE agent: SubjectAccessReview: too many concurrent reviews")
+		return false
+	}
+	defer t.release()
+	extra := make(map[string]authzv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authzv1.ExtraValue(v)
+	}
+	sar, err := t.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authzv1.SubjectAccessReview{
+		Spec: authzv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			NonResourceAttributes: &authzv1.NonResourceAttributes{
+				Path: path,
+				Verb: verb,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		// Do not cache API failures
+		logger.Errorf("SPOE agent: SubjectAccessReview: %s", err)
+		return false
+	}
+	ttl := tokenReviewCacheTTL
+	if !sar.Status.Allowed {
+		ttl = tokenReviewNegativeTTL
+	}
+	t.authz.Add(key, sar.Status.Allowed, ttl)
+	return sar.Status.Allowed
+}
+
+// acquire waits for a review slot until ctx is done, returning false in that case
+func (t *tokenReview) acquire(ctx context.Context) bool {
+	select {
+	case t.reviews <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (t *tokenReview) release() {
+	<-t.reviews
+}
+
+func bearerToken(authorization string) string {
+	parts := strings.Fields(authorization)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return ""
+	}
+	return parts[1]
+}
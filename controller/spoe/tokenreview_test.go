@@ -0,0 +1,136 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spoe
+
+import (
+	"context"
+	"testing"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	authnv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	authzv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+)
+
+// fakeClient authenticates the "valid" token as user "alice", allowed to get "/allowed",
+// and counts TokenReview and SubjectAccessReview calls. Other API calls panic.
+type fakeClient struct {
+	kubernetes.Interface
+	tokenReviews  int
+	accessReviews int
+}
+
+type fakeAuthn struct {
+	authnv1client.AuthenticationV1Interface
+	client *fakeClient
+}
+
+type fakeTokenReviews struct {
+	authnv1client.TokenReviewInterface
+	client *fakeClient
+}
+
+type fakeAuthz struct {
+	authzv1client.AuthorizationV1Interface
+	client *fakeClient
+}
+
+type fakeAccessReviews struct {
+	authzv1client.SubjectAccessReviewInterface
+	client *fakeClient
+}
+
+func (c *fakeClient) AuthenticationV1() authnv1client.AuthenticationV1Interface {
+	return fakeAuthn{client: c}
+}
+
+func (a fakeAuthn) TokenReviews() authnv1client.TokenReviewInterface {
+	return fakeTokenReviews{client: a.client}
+}
+
+func (r fakeTokenReviews) Create(ctx context.Context, review *authnv1.TokenReview, opts metav1.CreateOptions) (*authnv1.TokenReview, error) {
+	r.client.tokenReviews++
+	if review.Spec.Token == "valid" {
+		review.Status.Authenticated = true
+		review.Status.User = authnv1.UserInfo{Username: "alice", UID: "1"}
+	}
+	return review, nil
+}
+
+func (c *fakeClient) AuthorizationV1() authzv1client.AuthorizationV1Interface {
+	return fakeAuthz{client: c}
+}
+
+func (a fakeAuthz) SubjectAccessReviews() authzv1client.SubjectAccessReviewInterface {
+	return fakeAccessReviews{client: a.client}
+}
+
+func (r fakeAccessReviews) Create(ctx context.Context, sar *authzv1.SubjectAccessReview, opts metav1.CreateOptions) (*authzv1.SubjectAccessReview, error) {
+	r.client.accessReviews++
+	attrs := sar.Spec.NonResourceAttributes
+	sar.Status.Allowed = sar.Spec.User == "alice" && attrs.Path == "/allowed" && attrs.Verb == "get"
+	return sar, nil
+}
+
+func fakeReviews() (*tokenReview, *fakeClient) {
+	client := &fakeClient{}
+	return newTokenReview(client), client
+}
+
+func TestTokenReviewCache(t *testing.T) {
+	reviewer, client := fakeReviews()
+	tests := []struct {
+		token, path, verb string
+		valid             bool
+		tokenReviews      int
+		accessReviews     int
+	}{
+		{"valid", "", "", true, 1, 0},
+		{"valid", "/allowed", "get", true, 1, 1},
+		{"valid", "/allowed", "get", true, 1, 1},
+		{"valid", "/denied", "get", false, 1, 2},
+		{"valid", "/denied", "get", false, 1, 2},
+		{"invalid", "/allowed", "get", false, 2, 2},
+		{"invalid", "/allowed", "get", false, 2, 2},
+		{"other", "", "", false, 3, 2},
+	}
+	for i, test := range tests {
+		result := reviewer.review(test.token, test.path, test.verb)
+		if result.valid != test.valid {
+			t.Errorf("%d: review(%s, %s, %s) valid = %t, want %t", i, test.token, test.path, test.verb, result.valid, test.valid)
+		}
+		if client.tokenReviews != test.tokenReviews || client.accessReviews != test.accessReviews {
+			t.Errorf("%d: %d TokenReviews and %d SubjectAccessReviews, want %d and %d", i, client.tokenReviews, client.accessReviews, test.tokenReviews, test.accessReviews)
+		}
+	}
+}
+
+func TestTokenReviewConcurrency(t *testing.T) {
+	reviewer, client := fakeReviews()
+	for i := 0; i < tokenReviewConcurrency; i++ {
+		reviewer.reviews <- struct{}{}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, ok := reviewer.authenticate(ctx, "valid"); ok || client.tokenReviews != 0 {
+		t.Errorf("authenticate() without review slot = %t with %d TokenReviews, want false without any", ok, client.tokenReviews)
+	}
+	reviewer.release()
+	if status, ok := reviewer.authenticate(context.Background(), "valid"); !ok || !status.Authenticated {
+		t.Errorf("authenticate() with a review slot = %+v, %t, want authenticated", status, ok)
+	}
+}
@@ -16,9 +16,17 @@ import (
 	"github.com/haproxytech/kubernetes-ingress/controller/store"
 )
 
-func UpdateIngress(client *kubernetes.Clientset, k store.K8s, channel chan SyncIngress) {
+// UpdateIngress updates the status of Ingresses received on channel,
+// or of all Ingresses when the published Service changes, until stop is closed.
+func UpdateIngress(client *kubernetes.Clientset, k store.K8s, channel chan SyncIngress, stop <-chan struct{}) {
 	addresses := []string{}
-	for status := range channel {
+	for {
+		var status SyncIngress
+		select {
+		case <-stop:
+			return
+		case status = <-channel:
+		}
 		// Published Service updated: Update all Ingresses
 		if status.Service != nil && getServiceAddresses(status.Service, &addresses) {
 			logger.Debug("Addresses of Ingress Controller service changed, status of all ingress resources are going to be updated")
@@ -40,7 +48,12 @@ func getServiceAddresses(service *corev1.Service, curAddr *[]string) (updated bo
 	case corev1.ServiceTypeExternalName:
 		addresses = []string{service.Spec.ExternalName}
 	case corev1.ServiceTypeClusterIP:
-		addresses = []string{service.Spec.ClusterIP}
+		// Headless services have no address of their own, only their external IPs are published
+		if service.Spec.ClusterIP == corev1.ClusterIPNone {
+			addresses = append(addresses, service.Spec.ExternalIPs...)
+		} else {
+			addresses = []string{service.Spec.ClusterIP}
+		}
 	case corev1.ServiceTypeNodePort:
 		if service.Spec.ExternalIPs != nil {
 			addresses = append(addresses, service.Spec.ExternalIPs...)
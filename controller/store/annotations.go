@@ -16,9 +16,16 @@ package store
 
 import (
 	"strings"
+	"sync"
 	"time"
 )
 
+// knownAnnotations holds the names of the annotations looked up by the controller
+var knownAnnotations = struct {
+	sync.RWMutex
+	names map[string]struct{}
+}{names: map[string]struct{}{}}
+
 // CopyAnnotations returns a copy of annotations map and removes prefixe from annotations name
 func CopyAnnotations(in map[string]string) map[string]string {
 	out := make(map[string]string, len(in))
@@ -36,6 +43,14 @@ func convertAnnotationName(annotation string) string {
 // GetValueFromAnnotations returns value by checking in multiple annotations.
 // moves through list until it finds value
 func (k K8s) GetValueFromAnnotations(annotationName string, annotations ...map[string]string) string {
+	knownAnnotations.RLock()
+	_, known := knownAnnotations.names[annotationName]
+	knownAnnotations.RUnlock()
+	if !known {
+		knownAnnotations.Lock()
+		knownAnnotations.names[annotationName] = struct{}{}
+		knownAnnotations.Unlock()
+	}
 	for _, a := range annotations {
 		val, ok := a[annotationName]
 		if ok {
@@ -45,6 +60,15 @@ func (k K8s) GetValueFromAnnotations(annotationName string, annotations ...map[s
 	return defaultAnnotationValues[annotationName]
 }
 
+// IsKnownAnnotation returns true if the annotation has been looked up by the controller,
+// making the difference with annotations of other tools once their prefix removed.
+func IsKnownAnnotation(annotationName string) bool {
+	knownAnnotations.RLock()
+	defer knownAnnotations.RUnlock()
+	_, ok := knownAnnotations.names[annotationName]
+	return ok
+}
+
 func (k K8s) GetTimeFromAnnotation(name string) time.Duration {
 	d := k.GetValueFromAnnotations(name)
 	if d == "" {
@@ -62,36 +86,58 @@ func (k K8s) SetDefaultAnnotation(annotation, value string) {
 }
 
 var defaultAnnotationValues = map[string]string{
-	"auth-realm":              "Protected Content",
-	"check":                   "true",
-	"cors-allow-origin":       "*",
-	"cors-allow-methods":      "*",
-	"cors-allow-headers":      "*",
-	"cors-max-age":            "5s",
-	"cookie-indirect":         "true",
-	"cookie-nocache":          "true",
-	"cookie-type":             "insert",
-	"forwarded-for":           "true",
-	"load-balance":            "roundrobin",
-	"log-format":              "%ci:%cp [%tr] %ft %b/%s %TR/%Tw/%Tc/%Tr/%Ta %ST %B %CC %CS %tsc %ac/%fc/%bc/%sc/%rc %sq/%bq %hr %hs \"%HM %[var(txn.base)] %HV\"",
-	"rate-limit-size":         "100k",
-	"rate-limit-period":       "1s",
-	"rate-limit-status-code":  "403",
-	"request-capture-len":     "128",
-	"ssl-redirect-code":       "302",
-	"request-redirect-code":   "302",
-	"ssl-redirect-port":       "443",
-	"ssl-passthrough":         "false",
-	"server-ssl":              "false",
-	"scale-server-slots":      "42",
-	"syslog-server":           "address:127.0.0.1, facility: local0, level: notice",
-	"timeout-http-request":    "5s",
-	"timeout-connect":         "5s",
-	"timeout-client":          "50s",
-	"timeout-queue":           "5s",
-	"timeout-server":          "50s",
-	"timeout-tunnel":          "1h",
-	"timeout-http-keep-alive": "1m",
-	"hard-stop-after":         "1h",
-	"client-crt-optional":     "false",
+	"ab-cookie-value":              "b",
+	"auth-realm":                   "Protected Content",
+	"cache-enable":                 "false",
+	"cache-max-age":                "60s",
+	"cache-total-max-size":         "4",
+	"canary-weight":                "0",
+	"check":                        "true",
+	"cors-allow-origin":            "*",
+	"cors-allow-methods":           "*",
+	"cors-allow-headers":           "*",
+	"cors-max-age":                 "5s",
+	"cookie-indirect":              "true",
+	"cookie-nocache":               "true",
+	"cookie-type":                  "insert",
+	"fault-abort-percent":          "100",
+	"fault-delay-percent":          "100",
+	"failover-min-servers":         "1",
+	"failover-weight":              "100",
+	"forwarded-for":                "true",
+	"hsts":                         "false",
+	"hsts-max-age":                 "15768000",
+	"hsts-include-subdomains":      "false",
+	"hsts-preload":                 "false",
+	"load-balance":                 "roundrobin",
+	"persistence-src-expire":       "30m",
+	"persistence-src-size":         "100k",
+	"log-format":                   "%ci:%cp [%tr] %ft %b/%s %TR/%Tw/%Tc/%Tr/%Ta %ST %B %CC %CS %tsc %ac/%fc/%bc/%sc/%rc %sq/%bq %hr %hs \"%HM %[var(txn.base)] %HV\"",
+	"rate-limit-size":              "100k",
+	"rate-limit-period":            "1s",
+	"rate-limit-key":               "src",
+	"rate-limit-status-code":       "403",
+	"request-capture-len":          "128",
+	"ssl-redirect-code":            "302",
+	"request-redirect-code":        "302",
+	"ssl-redirect-port":            "443",
+	"ssl-passthrough":              "false",
+	"static-response-content-type": "text/plain",
+	"server-ssl":                   "false",
+	"scale-server-slots":           "42",
+	"ssl-default-bind-ciphers":     "ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-AES256-GCM-SHA384:DHE-RSA-AES128-GCM-SHA256:DHE-DSS-AES128-GCM-SHA256:kEDH+AESGCM:ECDHE-RSA-AES128-SHA256:ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA:ECDHE-ECDSA-AES128-SHA:ECDHE-RSA-AES256-SHA384:ECDHE-ECDSA-AES256-SHA384:ECDHE-RSA-AES256-SHA:ECDHE-ECDSA-AES256-SHA:DHE-RSA-AES128-SHA256:DHE-RSA-AES128-SHA:DHE-DSS-AES128-SHA256:DHE-RSA-AES256-SHA256:DHE-DSS-AES256-SHA:DHE-RSA-AES256-SHA:!aNULL:!eNULL:!EXPORT:!DES:!RC4:!3DES:!MD5:!PSK",
+	"ssl-default-bind-options":     "no-sslv3 no-tls-tickets no-tlsv10",
+	"stats-refresh":                "10s",
+	"stats-uri":                    "/",
+	"syslog-server":                "address:127.0.0.1, facility: local0, level: notice",
+	"time-window-timezone":         "UTC",
+	"timeout-http-request":         "5s",
+	"timeout-connect":              "5s",
+	"timeout-client":               "50s",
+	"timeout-queue":                "5s",
+	"timeout-server":               "50s",
+	"timeout-tunnel":               "1h",
+	"timeout-http-keep-alive":      "1m",
+	"hard-stop-after":              "1h",
+	"client-crt-optional":          "false",
 }
@@ -15,11 +15,18 @@
 package store
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	networkingv1 "k8s.io/api/networking/v1"
 	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
 )
 
 //nolint:golint,stylecheck
@@ -31,8 +38,138 @@ const (
 	PATH_TYPE_EXACT                   = "Exact"
 	PATH_TYPE_PREFIX                  = "Prefix"
 	PATH_TYPE_IMPLEMENTATION_SPECIFIC = "ImplementationSpecific"
+
+	PEERS_DEFAULT_PORT = 10000
 )
 
+// IngressClassDefaultAnnotation marks the IngressClass of ingresses without class
+const IngressClassDefaultAnnotation = "ingressclass.kubernetes.io/is-default-class"
+
+// PeersResource is the Peers custom resource provided by the core.haproxy.org/v1alpha1 CRD
+var PeersResource = schema.GroupVersionResource{Group: "core.haproxy.org", Version: "v1alpha1", Resource: "peers"}
+
+// GlobalResource is the Global custom resource provided by the core.haproxy.org/v1alpha1 CRD
+var GlobalResource = schema.GroupVersionResource{Group: "core.haproxy.org", Version: "v1alpha1", Resource: "globals"}
+
+// BackendResource is the Backend custom resource provided by the core.haproxy.org/v1alpha1 CRD
+var BackendResource = schema.GroupVersionResource{Group: "core.haproxy.org", Version: "v1alpha1", Resource: "backends"}
+
+// TLSPolicyResource is the GroupVersionResource of the TLSPolicy custom resource
+var TLSPolicyResource = schema.GroupVersionResource{Group: "core.haproxy.org", Version: "v1alpha1", Resource: "tlspolicies"}
+
+// ServiceImportResource is the ServiceImport resource of the Multi-Cluster Services API,
+// exposing in a cluster a service exported by the clusters of its ClusterSet
+var ServiceImportResource = schema.GroupVersionResource{Group: "multicluster.x-k8s.io", Version: "v1alpha1", Resource: "serviceimports"}
+
+// ServiceImportSuffix is appended to the name of ServiceImports stored as namespace services,
+// so that they have other endpoints and backends than the services of the same name,
+// service names having no dots.
+const ServiceImportSuffix = ".clusterset"
+
+// BackendResourceSuffix is appended to the name of Backend custom resources referenced by
+// ingress backends, for the backends of their upstream not to collide with service ones.
+const BackendResourceSuffix = ".backend"
+
+// ServiceImportName returns the name of the ServiceImport stored as a namespace service
+func ServiceImportName(name string) string {
+	return name + ServiceImportSuffix
+}
+
+// specField maps a field of a custom resource spec to the annotation of the same setting
+type specField struct {
+	path []string
+	key  string
+}
+
+// globalSpecFields maps the fields of the Global custom resource spec to the main ConfigMap keys
+var globalSpecFields = []specField{
+	{[]string{"maxconn"}, "maxconn"},
+	{[]string{"nbthread"}, "nbthread"},
+	{[]string{"cpuMap"}, "cpu-map"},
+	{[]string{"threadGroups"}, "thread-groups"},
+	{[]string{"numaCPUMapping"}, "numa-cpu-mapping"},
+	{[]string{"hardStopAfter"}, "hard-stop-after"},
+	{[]string{"gracePeriod"}, "grace-period"},
+	{[]string{"configSnippet"}, "global-config-snippet"},
+	{[]string{"frontendLimits", "maxconn"}, "frontend-maxconn"},
+	{[]string{"frontendLimits", "rateLimitSessions"}, "frontend-rate-limit-sessions"},
+	{[]string{"frontendLimits", "backlog"}, "frontend-backlog"},
+	{[]string{"frontendLimits", "rejectConnAbove"}, "frontend-reject-conn-above"},
+	{[]string{"frontendLimits", "rejectRateAbove"}, "frontend-reject-rate-above"},
+	{[]string{"frontendTimeouts", "client"}, "frontend-timeout-client"},
+	{[]string{"frontendTimeouts", "clientFin"}, "frontend-timeout-client-fin"},
+	{[]string{"frontendTimeouts", "httpRequest"}, "frontend-timeout-http-request"},
+	{[]string{"logging", "logFormat"}, "log-format"},
+	{[]string{"logging", "dontlognull"}, "dontlognull"},
+	{[]string{"logging", "logasap"}, "logasap"},
+	{[]string{"tune", "bufsize"}, "tune.bufsize"},
+	{[]string{"tune", "maxrewrite"}, "tune.maxrewrite"},
+	{[]string{"tune", "sslCachesize"}, "tune.ssl.cachesize"},
+	{[]string{"tune", "h2MaxConcurrentStreams"}, "tune.h2.max-concurrent-streams"},
+	{[]string{"ssl", "defaultBindCiphers"}, "ssl-default-bind-ciphers"},
+	{[]string{"ssl", "defaultBindCiphersuites"}, "ssl-default-bind-ciphersuites"},
+	{[]string{"ssl", "defaultBindCurves"}, "ssl-default-bind-curves"},
+	{[]string{"ssl", "defaultBindOptions"}, "ssl-default-bind-options"},
+	{[]string{"ssl", "defaultServerCiphers"}, "ssl-default-server-ciphers"},
+	{[]string{"ssl", "defaultServerCiphersuites"}, "ssl-default-server-ciphersuites"},
+	{[]string{"ssl", "defaultServerOptions"}, "ssl-default-server-options"},
+	{[]string{"ssl", "minVersion"}, "ssl-min-ver"},
+	{[]string{"ssl", "maxVersion"}, "ssl-max-ver"},
+	{[]string{"timeouts", "httpRequest"}, "timeout-http-request"},
+	{[]string{"timeouts", "httpKeepAlive"}, "timeout-http-keep-alive"},
+	{[]string{"timeouts", "connect"}, "timeout-connect"},
+	{[]string{"timeouts", "client"}, "timeout-client"},
+	{[]string{"timeouts", "clientFin"}, "timeout-client-fin"},
+	{[]string{"timeouts", "queue"}, "timeout-queue"},
+	{[]string{"timeouts", "server"}, "timeout-server"},
+	{[]string{"timeouts", "serverFin"}, "timeout-server-fin"},
+	{[]string{"timeouts", "tunnel"}, "timeout-tunnel"},
+}
+
+// backendSpecFields maps the fields of the Backend custom resource spec to backend and server annotations
+var backendSpecFields = []specField{
+	{[]string{"loadBalance"}, "load-balance"},
+	{[]string{"abortonclose"}, "abortonclose"},
+	{[]string{"forwardedFor"}, "forwarded-for"},
+	{[]string{"retries"}, "retries"},
+	{[]string{"redispatch"}, "redispatch"},
+	{[]string{"cookiePersistence"}, "cookie-persistence"},
+	{[]string{"configSnippet"}, "backend-config-snippet"},
+	{[]string{"checks", "enabled"}, "check"},
+	{[]string{"checks", "interval"}, "check-interval"},
+	{[]string{"checks", "http"}, "check-http"},
+	{[]string{"checks", "type"}, "check-type"},
+	{[]string{"timeouts", "check"}, "timeout-check"},
+	{[]string{"timeouts", "httpKeepAlive"}, "timeout-http-keep-alive"},
+	{[]string{"timeouts", "queue"}, "timeout-queue"},
+	{[]string{"timeouts", "server"}, "timeout-server"},
+	{[]string{"timeouts", "tunnel"}, "timeout-tunnel"},
+	{[]string{"servers", "maxconn"}, "server-maxconn"},
+	{[]string{"servers", "maxqueue"}, "server-maxqueue"},
+	{[]string{"servers", "sendProxyProtocol"}, "send-proxy-protocol"},
+}
+
+// syslogServerParams are the params of the Global custom resource syslog servers,
+// in the order of the syslog-server ConfigMap key lines
+var syslogServerParams = []string{"address", "port", "facility", "level", "minlevel", "format", "length", "size", "server"}
+
+// logForwardParams are the params of the Global custom resource log forwards,
+// in the order of the log-forward ConfigMap key lines
+var logForwardParams = []string{"name", "binds", "dgramBinds", "maxconn", "backlog", "timeoutClient", "logs"}
+
+// specLineKeys are the ConfigMap key line params of custom resource list item
+// fields named otherwise, each item of list fields being a param of its own.
+var specLineKeys = map[string]string{
+	"binds":         "bind",
+	"dgramBinds":    "dgram-bind",
+	"logs":          "log",
+	"timeoutClient": "timeout-client",
+}
+
+// extraFrontendParams are the params of the Global custom resource frontends,
+// in the order of the frontends ConfigMap key lines
+var extraFrontendParams = []string{"name", "port", "ssl"}
+
 // ConvertToIngress detects the interface{} provided by the SharedInformer and select
 // the proper strategy to convert and return the resource as a store.Ingress struct
 func ConvertToIngress(resource interface{}) (ingress *Ingress, err error) {
@@ -75,6 +212,36 @@ func ConvertToIngressClass(resource interface{}) (ingress *IngressClass, err err
 	return
 }
 
+// ConvertToPeers converts the unstructured object provided by the dynamic SharedInformer
+// into a store.Peers struct
+func ConvertToPeers(resource interface{}, status Status) (peers *Peers, err error) {
+	data, ok := resource.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unrecognized type for: %T", resource)
+	}
+	if data.GetDeletionTimestamp() != nil {
+		status = DELETED
+	}
+	peers = &Peers{
+		Namespace: data.GetNamespace(),
+		Name:      data.GetName(),
+		Port:      PEERS_DEFAULT_PORT,
+		Status:    status,
+	}
+	peers.Service, _, err = unstructured.NestedString(data.Object, "spec", "service")
+	if err != nil {
+		return nil, err
+	}
+	port, found, err := unstructured.NestedInt64(data.Object, "spec", "port")
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		peers.Port = port
+	}
+	return peers, nil
+}
+
 // ingressNetworkingV1Beta1Strategy is the Strategy implementation for converting an
 // ingresses.networking.k8s.io/v1beta1 object into a store.Ingress resource.
 type ingressNetworkingV1Beta1Strategy struct {
@@ -98,17 +265,24 @@ func (n ingressNetworkingV1Beta1Strategy) ConvertIngress() *Ingress {
 					continue
 				}
 				for _, k8sPath := range k8sRule.HTTP.Paths {
+					svcName := backendServiceName(k8sPath.Backend.ServiceName, k8sPath.Backend.Resource)
+					resource := backendResourceName(k8sPath.Backend.Resource)
+					if svcName == "" && resource == "" {
+						logger.Warningf("Ingress '%s/%s': backend of path '%s' is neither a service nor a supported resource, path ignored", n.ig.Namespace, n.ig.Name, k8sPath.Path)
+						continue
+					}
 					prefix := ""
 					if k8sPath.PathType != nil {
 						prefix = string(*k8sPath.PathType)
 					}
 					paths[prefix+"-"+k8sPath.Path] = &IngressPath{
-						Path:          k8sPath.Path,
-						PathTypeMatch: string(*k8sPath.PathType),
-						SvcName:       k8sPath.Backend.ServiceName,
-						SvcPortInt:    int64(k8sPath.Backend.ServicePort.IntValue()),
-						SvcPortString: k8sPath.Backend.ServicePort.StrVal,
-						Status:        "",
+						Path:            k8sPath.Path,
+						PathTypeMatch:   string(*k8sPath.PathType),
+						SvcName:         svcName,
+						SvcPortInt:      int64(k8sPath.Backend.ServicePort.IntValue()),
+						SvcPortString:   k8sPath.Backend.ServicePort.StrVal,
+						BackendResource: resource,
+						Status:          "",
 					}
 				}
 				if rule, ok := rules[k8sRule.Host]; ok {
@@ -129,10 +303,17 @@ func (n ingressNetworkingV1Beta1Strategy) ConvertIngress() *Ingress {
 			if ingressBackend == nil {
 				return nil
 			}
+			svcName := backendServiceName(ingressBackend.ServiceName, ingressBackend.Resource)
+			resource := backendResourceName(ingressBackend.Resource)
+			if svcName == "" && resource == "" {
+				logger.Warningf("Ingress '%s/%s': default backend is neither a service nor a supported resource, ignored", n.ig.Namespace, n.ig.Name)
+				return nil
+			}
 			return &IngressPath{
-				SvcName:          ingressBackend.ServiceName,
+				SvcName:          svcName,
 				SvcPortInt:       int64(ingressBackend.ServicePort.IntValue()),
 				SvcPortString:    ingressBackend.ServicePort.StrVal,
+				BackendResource:  resource,
 				IsDefaultBackend: true,
 				Status:           "",
 			}
@@ -164,6 +345,7 @@ func (n ingressNetworkingV1Beta1Strategy) ConvertClass() *IngressClass {
 		APIVersion: NETWORKINGV1BETA1,
 		Name:       n.class.GetName(),
 		Controller: n.class.Spec.Controller,
+		Default:    n.class.GetAnnotations()[IngressClassDefaultAnnotation] == "true",
 		Status: func() Status {
 			if n.class.ObjectMeta.GetDeletionTimestamp() != nil {
 				return DELETED
@@ -194,17 +376,24 @@ func (e ingressExtensionsStrategy) ConvertIngress() *Ingress {
 					continue
 				}
 				for _, k8sPath := range k8sRule.HTTP.Paths {
+					svcName := backendServiceName(k8sPath.Backend.ServiceName, k8sPath.Backend.Resource)
+					resource := backendResourceName(k8sPath.Backend.Resource)
+					if svcName == "" && resource == "" {
+						logger.Warningf("Ingress '%s/%s': backend of path '%s' is neither a service nor a supported resource, path ignored", e.ig.Namespace, e.ig.Name, k8sPath.Path)
+						continue
+					}
 					prefix := ""
 					if k8sPath.PathType != nil {
 						prefix = string(*k8sPath.PathType)
 					}
 					paths[prefix+"-"+k8sPath.Path] = &IngressPath{
-						Path:          k8sPath.Path,
-						PathTypeMatch: string(*k8sPath.PathType),
-						SvcName:       k8sPath.Backend.ServiceName,
-						SvcPortInt:    int64(k8sPath.Backend.ServicePort.IntValue()),
-						SvcPortString: k8sPath.Backend.ServicePort.StrVal,
-						Status:        "",
+						Path:            k8sPath.Path,
+						PathTypeMatch:   string(*k8sPath.PathType),
+						SvcName:         svcName,
+						SvcPortInt:      int64(k8sPath.Backend.ServicePort.IntValue()),
+						SvcPortString:   k8sPath.Backend.ServicePort.StrVal,
+						BackendResource: resource,
+						Status:          "",
 					}
 				}
 				if rule, ok := rules[k8sRule.Host]; ok {
@@ -225,10 +414,17 @@ func (e ingressExtensionsStrategy) ConvertIngress() *Ingress {
 			if ingressBackend == nil {
 				return nil
 			}
+			svcName := backendServiceName(ingressBackend.ServiceName, ingressBackend.Resource)
+			resource := backendResourceName(ingressBackend.Resource)
+			if svcName == "" && resource == "" {
+				logger.Warningf("Ingress '%s/%s': default backend is neither a service nor a supported resource, ignored", e.ig.Namespace, e.ig.Name)
+				return nil
+			}
 			return &IngressPath{
-				SvcName:          ingressBackend.ServiceName,
+				SvcName:          svcName,
 				SvcPortInt:       int64(ingressBackend.ServicePort.IntValue()),
 				SvcPortString:    ingressBackend.ServicePort.StrVal,
+				BackendResource:  resource,
 				IsDefaultBackend: true,
 				Status:           "",
 			}
@@ -278,17 +474,24 @@ func (n ingressNetworkingV1Strategy) ConvertIngress() *Ingress {
 					continue
 				}
 				for _, k8sPath := range k8sRule.HTTP.Paths {
+					svcName, svcPort := ingressV1BackendService(k8sPath.Backend)
+					resource := backendResourceName(k8sPath.Backend.Resource)
+					if svcName == "" && resource == "" {
+						logger.Warningf("Ingress '%s/%s': backend of path '%s' is neither a service nor a supported resource, path ignored", n.ig.Namespace, n.ig.Name, k8sPath.Path)
+						continue
+					}
 					prefix := ""
 					if k8sPath.PathType != nil {
 						prefix = string(*k8sPath.PathType)
 					}
 					paths[prefix+"-"+k8sPath.Path] = &IngressPath{
-						Path:          k8sPath.Path,
-						PathTypeMatch: string(*k8sPath.PathType),
-						SvcName:       k8sPath.Backend.Service.Name,
-						SvcPortInt:    int64(k8sPath.Backend.Service.Port.Number),
-						SvcPortString: k8sPath.Backend.Service.Port.Name,
-						Status:        "",
+						Path:            k8sPath.Path,
+						PathTypeMatch:   string(*k8sPath.PathType),
+						SvcName:         svcName,
+						SvcPortInt:      int64(svcPort.Number),
+						SvcPortString:   svcPort.Name,
+						BackendResource: resource,
+						Status:          "",
 					}
 				}
 				if rule, ok := rules[k8sRule.Host]; ok {
@@ -309,10 +512,17 @@ func (n ingressNetworkingV1Strategy) ConvertIngress() *Ingress {
 			if ingressBackend == nil {
 				return nil
 			}
+			svcName, svcPort := ingressV1BackendService(*ingressBackend)
+			resource := backendResourceName(ingressBackend.Resource)
+			if svcName == "" && resource == "" {
+				logger.Warningf("Ingress '%s/%s': default backend is neither a service nor a supported resource, ignored", n.ig.Namespace, n.ig.Name)
+				return nil
+			}
 			return &IngressPath{
-				SvcName:          ingressBackend.Service.Name,
-				SvcPortInt:       int64(ingressBackend.Service.Port.Number),
-				SvcPortString:    ingressBackend.Service.Port.Name,
+				SvcName:          svcName,
+				SvcPortInt:       int64(svcPort.Number),
+				SvcPortString:    svcPort.Name,
+				BackendResource:  resource,
 				IsDefaultBackend: true,
 				Status:           "",
 			}
@@ -344,6 +554,7 @@ func (n ingressNetworkingV1Strategy) ConvertClass() *IngressClass {
 		APIVersion: NETWORKINGV1,
 		Name:       n.class.GetName(),
 		Controller: n.class.Spec.Controller,
+		Default:    n.class.GetAnnotations()[IngressClassDefaultAnnotation] == "true",
 		Status: func() Status {
 			if n.class.ObjectMeta.GetDeletionTimestamp() != nil {
 				return DELETED
@@ -353,9 +564,319 @@ func (n ingressNetworkingV1Strategy) ConvertClass() *IngressClass {
 	}
 }
 
+// backendServiceName returns the service of an ingress backend, a ServiceImport
+// being referenced as a resource, empty for other resources.
+func backendServiceName(serviceName string, resource *corev1.TypedLocalObjectReference) string {
+	if serviceName != "" || resource == nil {
+		return serviceName
+	}
+	if resource.Kind != "ServiceImport" || resource.APIGroup == nil || *resource.APIGroup != ServiceImportResource.Group {
+		return ""
+	}
+	return ServiceImportName(resource.Name)
+}
+
+// backendResourceName returns the name of the Backend custom resource referenced by an ingress
+// backend, its static response or upstream being used instead of a service.
+func backendResourceName(resource *corev1.TypedLocalObjectReference) string {
+	if resource == nil || resource.Kind != "Backend" || resource.APIGroup == nil || *resource.APIGroup != BackendResource.Group {
+		return ""
+	}
+	return resource.Name
+}
+
+// ingressV1BackendService returns the service and port of a networking.k8s.io/v1
+// ingress backend, ServiceImports having no port in their reference.
+func ingressV1BackendService(backend networkingv1.IngressBackend) (name string, port networkingv1.ServiceBackendPort) {
+	if backend.Service != nil {
+		return backend.Service.Name, backend.Service.Port
+	}
+	return backendServiceName("", backend.Resource), port
+}
+
 func getIgClass(className *string) string {
 	if className == nil {
 		return ""
 	}
 	return *className
 }
+
+// ConvertToGlobal converts the unstructured object provided by the dynamic SharedInformer
+// into a store.Global struct, the spec being converted to main ConfigMap keys
+func ConvertToGlobal(resource interface{}, status Status) (global *Global, err error) {
+	data, ok := resource.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unrecognized type for: %T", resource)
+	}
+	if data.GetDeletionTimestamp() != nil {
+		status = DELETED
+	}
+	global = &Global{
+		Namespace: data.GetNamespace(),
+		Name:      data.GetName(),
+		Status:    status,
+	}
+	if global.Annotations, err = specAnnotations(data, globalSpecFields); err != nil {
+		return nil, err
+	}
+	lines, err := specLines(data, syslogServerParams, "logging", "syslogServers")
+	if err != nil {
+		return nil, err
+	}
+	if lines != "" {
+		global.Annotations["syslog-server"] = lines
+	}
+	if lines, err = specLines(data, logForwardParams, "logging", "logForwards"); err != nil {
+		return nil, err
+	}
+	if lines != "" {
+		global.Annotations["log-forward"] = lines
+	}
+	if lines, err = specLines(data, extraFrontendParams, "frontends"); err != nil {
+		return nil, err
+	}
+	if lines != "" {
+		global.Annotations["frontends"] = lines
+	}
+	return global, nil
+}
+
+// specLines converts the items of a custom resource spec list to the lines of a ConfigMap key,
+// each line being the "param:value" comma separated list of the item params.
+func specLines(data *unstructured.Unstructured, params []string, path ...string) (string, error) {
+	items, found, err := unstructured.NestedSlice(data.Object, append([]string{"spec"}, path...)...)
+	if err != nil || !found {
+		return "", err
+	}
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		values, ok := item.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("invalid %s item '%v'", path[len(path)-1], item)
+		}
+		var line []string
+		for _, param := range params {
+			value, ok := values[param]
+			if !ok {
+				continue
+			}
+			key := param
+			if k, ok := specLineKeys[param]; ok {
+				key = k
+			}
+			if list, ok := value.([]interface{}); ok {
+				for _, v := range list {
+					line = append(line, fmt.Sprintf("%s:%v", key, v))
+				}
+				continue
+			}
+			line = append(line, fmt.Sprintf("%s:%v", key, value))
+		}
+		lines = append(lines, strings.Join(line, ", "))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// ConvertToBackend converts the unstructured object provided by the dynamic SharedInformer
+// into a store.Backend struct, the spec being converted to backend and server annotations
+func ConvertToBackend(resource interface{}, status Status) (backend *Backend, err error) {
+	data, ok := resource.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unrecognized type for: %T", resource)
+	}
+	if data.GetDeletionTimestamp() != nil {
+		status = DELETED
+	}
+	backend = &Backend{
+		Namespace: data.GetNamespace(),
+		Name:      data.GetName(),
+		Status:    status,
+	}
+	if backend.Annotations, err = specAnnotations(data, backendSpecFields); err != nil {
+		return nil, err
+	}
+	if backend.StaticResponse, err = backendStaticResponse(data); err != nil {
+		return nil, err
+	}
+	if backend.Upstream, err = backendUpstream(data); err != nil {
+		return nil, err
+	}
+	return backend, nil
+}
+
+// backendStaticResponse returns the response served by the Backend custom resource, nil if none
+func backendStaticResponse(data *unstructured.Unstructured) (*StaticResponse, error) {
+	fields, found, err := unstructured.NestedMap(data.Object, "spec", "staticResponse")
+	if err != nil || !found {
+		return nil, err
+	}
+	response := &StaticResponse{}
+	statusCode, _, err := unstructured.NestedInt64(fields, "statusCode")
+	if err != nil {
+		return nil, err
+	}
+	if statusCode < 200 || statusCode > 599 {
+		return nil, fmt.Errorf("staticResponse: incorrect statusCode '%d'", statusCode)
+	}
+	response.StatusCode = statusCode
+	if response.ContentType, _, err = unstructured.NestedString(fields, "contentType"); err != nil {
+		return nil, err
+	}
+	if response.Body, _, err = unstructured.NestedString(fields, "body"); err != nil {
+		return nil, err
+	}
+	// Response has no content without content type
+	if response.Body != "" && response.ContentType == "" {
+		response.ContentType = "text/plain"
+	}
+	return response, nil
+}
+
+// backendUpstream returns the servers of the Backend custom resource, nil if none
+func backendUpstream(data *unstructured.Unstructured) (*Upstream, error) {
+	fields, found, err := unstructured.NestedMap(data.Object, "spec", "upstream")
+	if err != nil || !found {
+		return nil, err
+	}
+	upstream := &Upstream{}
+	if upstream.Addresses, _, err = unstructured.NestedStringSlice(fields, "addresses"); err != nil {
+		return nil, err
+	}
+	if len(upstream.Addresses) == 0 {
+		return nil, errors.New("upstream: no addresses")
+	}
+	if upstream.Port, _, err = unstructured.NestedInt64(fields, "port"); err != nil {
+		return nil, err
+	}
+	if upstream.Port < 1 || upstream.Port > 65535 {
+		return nil, fmt.Errorf("upstream: incorrect port '%d'", upstream.Port)
+	}
+	return upstream, nil
+}
+
+// ConvertToTLSPolicy converts the unstructured object provided by the dynamic SharedInformer into a store.TLSPolicy
+func ConvertToTLSPolicy(resource interface{}, status Status) (policy *TLSPolicy, err error) {
+	data, ok := resource.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unrecognized type for: %T", resource)
+	}
+	if data.GetDeletionTimestamp() != nil {
+		status = DELETED
+	}
+	policy = &TLSPolicy{
+		Namespace: data.GetNamespace(),
+		Name:      data.GetName(),
+		Status:    status,
+	}
+	spec, _, err := unstructured.NestedMap(data.Object, "spec")
+	if err != nil {
+		return nil, err
+	}
+	if policy.Hosts, _, err = unstructured.NestedStringSlice(spec, "hosts"); err != nil {
+		return nil, err
+	}
+	for i, host := range policy.Hosts {
+		policy.Hosts[i] = strings.ToLower(host)
+		if _, err = utils.WildcardHostSuffix(policy.Hosts[i]); err != nil {
+			return nil, err
+		}
+	}
+	if policy.MinVersion, _, err = unstructured.NestedString(spec, "minVersion"); err != nil {
+		return nil, err
+	}
+	switch policy.MinVersion {
+	case "", "SSLv3", "TLSv1.0", "TLSv1.1", "TLSv1.2", "TLSv1.3":
+	default:
+		return nil, fmt.Errorf("incorrect minVersion '%s'", policy.MinVersion)
+	}
+	for field, value := range map[string]*string{"ciphers": &policy.Ciphers, "ciphersuites": &policy.Ciphersuites} {
+		if *value, _, err = unstructured.NestedString(spec, field); err != nil {
+			return nil, err
+		}
+		if strings.ContainsAny(*value, " \t[]") {
+			return nil, fmt.Errorf("incorrect %s '%s', expecting a colon separated list", field, *value)
+		}
+	}
+	if policy.ClientCA, _, err = unstructured.NestedString(spec, "clientAuth", "caSecret"); err != nil {
+		return nil, err
+	}
+	if policy.ClientCrtOptional, _, err = unstructured.NestedBool(spec, "clientAuth", "optional"); err != nil {
+		return nil, err
+	}
+	hsts, found, err := unstructured.NestedMap(spec, "hsts")
+	if err != nil || !found {
+		return policy, err
+	}
+	policy.HSTS = &HSTS{MaxAge: 15768000}
+	maxAge, found, err := unstructured.NestedInt64(hsts, "maxAge")
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		policy.HSTS.MaxAge = maxAge
+	}
+	if policy.HSTS.IncludeSubdomains, _, err = unstructured.NestedBool(hsts, "includeSubdomains"); err != nil {
+		return nil, err
+	}
+	if policy.HSTS.Preload, _, err = unstructured.NestedBool(hsts, "preload"); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// specAnnotations returns the annotations values of the custom resource spec fields
+func specAnnotations(data *unstructured.Unstructured, fields []specField) (map[string]string, error) {
+	annotations := make(map[string]string)
+	for _, field := range fields {
+		value, found, err := unstructured.NestedFieldNoCopy(data.Object, append([]string{"spec"}, field.path...)...)
+		if err != nil {
+			return nil, err
+		}
+		if found && value != nil {
+			annotations[field.key] = fmt.Sprint(value)
+		}
+	}
+	return annotations, nil
+}
+
+// ConvertToServiceImport converts the unstructured object provided by the dynamic SharedInformer
+// into a store.Service named with ServiceImportName, its endpoints being the EndpointSlices
+// imported from the clusters exporting the service.
+func ConvertToServiceImport(resource interface{}, status Status) (service *Service, err error) {
+	data, ok := resource.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unrecognized type for: %T", resource)
+	}
+	if data.GetDeletionTimestamp() != nil {
+		status = DELETED
+	}
+	service = &Service{
+		Namespace:   data.GetNamespace(),
+		Name:        ServiceImportName(data.GetName()),
+		Annotations: CopyAnnotations(data.GetAnnotations()),
+		Ports:       []ServicePort{},
+		Status:      status,
+	}
+	ports, _, err := unstructured.NestedSlice(data.Object, "spec", "ports")
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range ports {
+		port, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("incorrect port '%v'", item)
+		}
+		servicePort := ServicePort{}
+		if servicePort.Name, _, err = unstructured.NestedString(port, "name"); err != nil {
+			return nil, err
+		}
+		if servicePort.Protocol, _, err = unstructured.NestedString(port, "protocol"); err != nil {
+			return nil, err
+		}
+		if servicePort.Port, _, err = unstructured.NestedInt64(port, "port"); err != nil {
+			return nil, err
+		}
+		service.Ports = append(service.Ports, servicePort)
+	}
+	return service, nil
+}
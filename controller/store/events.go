@@ -14,6 +14,10 @@
 
 package store
 
+import (
+	"sort"
+)
+
 func (k *K8s) EventNamespace(ns *Namespace, data *Namespace) (updateRequired bool) {
 	updateRequired = false
 	switch data.Status {
@@ -31,7 +35,16 @@ func (k *K8s) EventNamespace(ns *Namespace, data *Namespace) (updateRequired boo
 	return updateRequired
 }
 
+// EventIngressClass stores IngressClasses, ingresses being processed again on changes
+// as they can start or stop matching the controller, by their class or as the default one.
 func (k *K8s) EventIngressClass(data *IngressClass) (updateRequired bool) {
+	defer func() {
+		if updateRequired {
+			for _, ns := range k.Namespaces {
+				modifyIngresses(ns)
+			}
+		}
+	}()
 	switch data.Status {
 	case MODIFIED:
 		newIgClass := data
@@ -40,20 +53,21 @@ func (k *K8s) EventIngressClass(data *IngressClass) (updateRequired bool) {
 			logger.Warningf("IngressClass '%s' not registered with controller !", data.Name)
 			return false
 		}
-		if oldIgClass.Equal(oldIgClass) {
+		if oldIgClass.Equal(newIgClass) {
 			return false
 		}
 		k.IngressClasses[data.Name] = newIgClass
 		updateRequired = true
 	case ADDED:
 		if old, ok := k.IngressClasses[data.Name]; ok {
-			if old.Status == DELETED {
-				k.IngressClasses[data.Name].Status = ADDED
-			}
 			if !old.Equal(data) {
 				data.Status = MODIFIED
 				return k.EventIngressClass(data)
 			}
+			if old.Status == DELETED {
+				old.Status = ADDED
+				return true
+			}
 			return false
 		}
 		k.IngressClasses[data.Name] = data
@@ -118,7 +132,8 @@ func (k *K8s) EventIngress(ns *Namespace, data *Ingress, controllerClass string)
 						// compare path for differences
 						if newPath.SvcName != oldPath.SvcName ||
 							newPath.SvcPortInt != oldPath.SvcPortInt ||
-							newPath.SvcPortString != oldPath.SvcPortString {
+							newPath.SvcPortString != oldPath.SvcPortString ||
+							newPath.BackendResource != oldPath.BackendResource {
 							newPath.Status = MODIFIED
 							newRule.Status = MODIFIED
 						}
@@ -203,7 +218,119 @@ func (k *K8s) EventIngress(ns *Namespace, data *Ingress, controllerClass string)
 	return updateRequired
 }
 
-func (k *K8s) EventEndpoints(ns *Namespace, data *Endpoints, syncHAproxySrvs func(oldEndpoints, newEndpoints *PortEndpoints) error) (updateRequired bool) {
+// EventEndpointSlice updates the EndpointSlice of a service, then the service
+// endpoints merged from all its slices when the slice addresses changed.
+func (k *K8s) EventEndpointSlice(ns *Namespace, data *EndpointSlice, syncHAproxySrvs func(oldEndpoints, newEndpoints *PortEndpoints) error) (updateRequired bool) {
+	slices := ns.EndpointSlices[data.Service]
+	switch data.Status {
+	case DELETED:
+		if _, ok := slices[data.Name]; !ok {
+			logger.Warningf("EndpointSlice '%s' not registered with controller, cannot delete !", data.Name)
+			return false
+		}
+		delete(slices, data.Name)
+	default:
+		if old, ok := slices[data.Name]; ok && old.Equal(data) {
+			return false
+		}
+		if slices == nil {
+			slices = make(map[string]*EndpointSlice)
+			ns.EndpointSlices[data.Service] = slices
+		}
+		slices[data.Name] = data
+	}
+	endpoints := mergeEndpointSlices(data.Namespace, data.Service, slices)
+	if len(slices) == 0 {
+		delete(ns.EndpointSlices, data.Service)
+		endpoints.Status = DELETED
+	}
+	return k.eventEndpoints(ns, endpoints, syncHAproxySrvs)
+}
+
+// mergeEndpointSlices returns the endpoints of a service from its slices: the ready
+// addresses of each port, or its serving terminating addresses when none is ready
+// so that connections are still served while the service rolls out.
+func mergeEndpointSlices(namespace, service string, slices map[string]*EndpointSlice) *Endpoints {
+	endpoints := &Endpoints{
+		Namespace: namespace,
+		Service:   service,
+		Ports:     make(map[string]*PortEndpoints),
+		Status:    ADDED,
+	}
+	// Sorted for a port number shared by slices to be picked consistently
+	names := make([]string, 0, len(slices))
+	for name := range slices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	serving := make(map[string]map[string]struct{})
+	podNames := make(map[string]map[string]string)
+	// Slices of a port name may target different ports, when the service targets
+	// a named container port which pods of the service define with different numbers
+	addrPorts := make(map[string]map[string]int64)
+	zones := make(map[string]string)
+	zoneHints := make(map[string][]string)
+	for _, name := range names {
+		for portName, slicePort := range slices[name].Ports {
+			portEndpoints, ok := endpoints.Ports[portName]
+			if !ok {
+				portEndpoints = &PortEndpoints{
+					Port:    slicePort.Port,
+					AddrNew: make(map[string]struct{}),
+				}
+				endpoints.Ports[portName] = portEndpoints
+				serving[portName] = make(map[string]struct{})
+				podNames[portName] = make(map[string]string)
+				addrPorts[portName] = make(map[string]int64)
+			}
+			for addr := range slicePort.Ready {
+				portEndpoints.AddrNew[addr] = struct{}{}
+				addrPorts[portName][addr] = slicePort.Port
+			}
+			for addr := range slicePort.Serving {
+				serving[portName][addr] = struct{}{}
+				addrPorts[portName][addr] = slicePort.Port
+			}
+			for addr, podName := range slicePort.PodNames {
+				podNames[portName][addr] = podName
+			}
+			for addr, zone := range slicePort.Zones {
+				zones[addr] = zone
+			}
+			for addr, hints := range slicePort.ZoneHints {
+				zoneHints[addr] = hints
+			}
+		}
+	}
+	for portName, portEndpoints := range endpoints.Ports {
+		if len(portEndpoints.AddrNew) == 0 {
+			portEndpoints.AddrNew = serving[portName]
+		}
+		portEndpoints.PodNames = make(map[string]string)
+		portEndpoints.Zones = make(map[string]string)
+		portEndpoints.ZoneHints = make(map[string][]string)
+		portEndpoints.AddrPorts = make(map[string]int64)
+		for addr := range portEndpoints.AddrNew {
+			if port := addrPorts[portName][addr]; port != portEndpoints.Port {
+				portEndpoints.AddrPorts[addr] = port
+			}
+			if podName, ok := podNames[portName][addr]; ok {
+				portEndpoints.PodNames[addr] = podName
+			}
+			if zone, ok := zones[addr]; ok {
+				portEndpoints.Zones[addr] = zone
+			}
+			if hints, ok := zoneHints[addr]; ok {
+				portEndpoints.ZoneHints[addr] = hints
+			}
+		}
+		portEndpoints.AddrCount = len(portEndpoints.AddrNew)
+		portEndpoints.HAProxySrvs = make([]*HAProxySrv, 0, portEndpoints.AddrCount)
+	}
+	return endpoints
+}
+
+func (k *K8s) eventEndpoints(ns *Namespace, data *Endpoints, syncHAproxySrvs func(oldEndpoints, newEndpoints *PortEndpoints) error) (updateRequired bool) {
 	switch data.Status {
 	case MODIFIED:
 		newEndpoints := data
@@ -232,7 +359,7 @@ func (k *K8s) EventEndpoints(ns *Namespace, data *Endpoints, syncHAproxySrvs fun
 			}
 			if !old.Equal(data) {
 				data.Status = MODIFIED
-				return k.EventEndpoints(ns, data, syncHAproxySrvs)
+				return k.eventEndpoints(ns, data, syncHAproxySrvs)
 			}
 			return updateRequired
 		}
@@ -268,6 +395,7 @@ func (k *K8s) EventService(ns *Namespace, data *Service) (updateRequired bool) {
 		if old, ok := ns.Services[data.Name]; ok {
 			if old.Status == DELETED {
 				ns.Services[data.Name].Status = ADDED
+				updateRequired = true
 			}
 			if !old.Equal(data) {
 				data.Status = MODIFIED
@@ -291,9 +419,15 @@ func (k *K8s) EventService(ns *Namespace, data *Service) (updateRequired bool) {
 
 func (k *K8s) EventConfigMap(ns *Namespace, data *ConfigMap) (updateRequired bool) {
 	var cm *ConfigMap
+	var mainSource bool
+	for _, source := range k.ConfigMaps.MainSources {
+		if source.Namespace == ns.Name && source.Name == data.Name {
+			cm, mainSource = source, true
+			break
+		}
+	}
 	switch {
-	case k.ConfigMaps.Main.Namespace == ns.Name && k.ConfigMaps.Main.Name == data.Name:
-		cm = k.ConfigMaps.Main
+	case mainSource:
 	case k.ConfigMaps.TCPServices.Namespace == ns.Name && k.ConfigMaps.TCPServices.Name == data.Name:
 		cm = k.ConfigMaps.TCPServices
 	case k.ConfigMaps.Errorfiles.Namespace == ns.Name && k.ConfigMaps.Errorfiles.Name == data.Name:
@@ -301,11 +435,15 @@ func (k *K8s) EventConfigMap(ns *Namespace, data *ConfigMap) (updateRequired boo
 	case k.ConfigMaps.PatternFiles.Namespace == ns.Name && k.ConfigMaps.PatternFiles.Name == data.Name:
 		cm = k.ConfigMaps.PatternFiles
 	default:
-		return false
+		return k.eventNamespaceConfigMap(ns, data)
 	}
 	switch data.Status {
 	case ADDED:
-		if cm.Loaded && !cm.Equal(data) {
+		// Unchanged resources are re-added on resync
+		if cm.Loaded && cm.Equal(data) {
+			return false
+		}
+		if cm.Loaded {
 			data.Status = MODIFIED
 			return k.EventConfigMap(ns, data)
 		}
@@ -315,6 +453,7 @@ func (k *K8s) EventConfigMap(ns *Namespace, data *ConfigMap) (updateRequired boo
 		logger.Debugf("configmap '%s/%s' processed", cm.Namespace, cm.Name)
 	case MODIFIED:
 		*cm = *data
+		cm.Loaded = true
 		updateRequired = true
 		logger.Infof("configmap '%s/%s' updated", cm.Namespace, cm.Name)
 	case DELETED:
@@ -322,6 +461,204 @@ func (k *K8s) EventConfigMap(ns *Namespace, data *ConfigMap) (updateRequired boo
 		updateRequired = true
 		logger.Debugf("configmap '%s/%s' deleted", cm.Namespace, cm.Name)
 	}
+	if mainSource && updateRequired {
+		k.ConfigMaps.mergeMain(data.Status)
+	}
+	return updateRequired
+}
+
+// mergeMain sets the keys of the main ConfigMap from the loaded ConfigMaps set with --configmap,
+// each one taking precedence over the previous ones.
+func (c ConfigMaps) mergeMain(status Status) {
+	c.Main.Annotations = make(map[string]string)
+	c.Main.Loaded = false
+	for _, source := range c.MainSources {
+		if !source.Loaded {
+			continue
+		}
+		for name, value := range source.Annotations {
+			c.Main.Annotations[name] = value
+		}
+		c.Main.Loaded = true
+	}
+	switch {
+	case !c.Main.Loaded:
+		c.Main.Status = DELETED
+	case status == DELETED:
+		// Keys of the remaining ConfigMaps are still in use
+		c.Main.Status = MODIFIED
+	default:
+		c.Main.Status = status
+	}
+}
+
+// eventNamespaceConfigMap stores other ConfigMaps, their keys can be referenced in annotations values.
+// Ingresses of the namespace are modified by changes of its haproxy-ingress-config ConfigMap.
+func (k *K8s) eventNamespaceConfigMap(ns *Namespace, data *ConfigMap) (updateRequired bool) {
+	old, ok := ns.ConfigMaps[data.Name]
+	switch data.Status {
+	case ADDED, MODIFIED:
+		if ok && old.Status != DELETED && old.Equal(data) {
+			return false
+		}
+		ns.ConfigMaps[data.Name] = data
+		logger.Tracef("configmap '%s/%s' processed", ns.Name, data.Name)
+	case DELETED:
+		if !ok {
+			return false
+		}
+		delete(ns.ConfigMaps, data.Name)
+		logger.Tracef("configmap '%s/%s' deleted", ns.Name, data.Name)
+	default:
+		return false
+	}
+	if data.Name == NamespaceConfigMapName {
+		modifyIngresses(ns)
+	}
+	return true
+}
+
+// modifyIngresses sets the unchanged ingresses of ns as MODIFIED, so that they
+// are processed again after a change of a resource applying to all of them.
+func modifyIngresses(ns *Namespace) {
+	for _, ingress := range ns.Ingresses {
+		if ingress.Status == EMPTY {
+			ingress.Status = MODIFIED
+		}
+	}
+}
+
+// EventBackend stores Backend custom resources, referenced by cr-backend annotation
+// and by ingress backends as resource
+func (k *K8s) EventBackend(ns *Namespace, data *Backend) (updateRequired bool) {
+	old, ok := ns.Backends[data.Name]
+	switch data.Status {
+	case ADDED, MODIFIED:
+		if ok && old.Status != DELETED && old.Equal(data) {
+			return false
+		}
+		ns.Backends[data.Name] = data
+		logger.Tracef("backend '%s/%s' processed", ns.Name, data.Name)
+		return true
+	case DELETED:
+		if !ok {
+			return false
+		}
+		old.Status = DELETED
+		logger.Tracef("backend '%s/%s' deleted", ns.Name, data.Name)
+		return true
+	}
+	return false
+}
+
+// EventTLSPolicy stores TLSPolicy custom resources, applied to the frontend hosts they define
+func (k *K8s) EventTLSPolicy(ns *Namespace, data *TLSPolicy) (updateRequired bool) {
+	old, ok := ns.TLSPolicies[data.Name]
+	switch data.Status {
+	case ADDED, MODIFIED:
+		if ok && old.Status != DELETED && old.Equal(data) {
+			return false
+		}
+		ns.TLSPolicies[data.Name] = data
+		logger.Tracef("tlspolicy '%s/%s' processed", ns.Name, data.Name)
+		return true
+	case DELETED:
+		if !ok {
+			return false
+		}
+		old.Status = DELETED
+		logger.Tracef("tlspolicy '%s/%s' deleted", ns.Name, data.Name)
+		return true
+	}
+	return false
+}
+
+func (k *K8s) EventPeers(data *Peers) (updateRequired bool) {
+	if k.Peers.Namespace != data.Namespace || k.Peers.Name != data.Name {
+		return false
+	}
+	switch data.Status {
+	case ADDED:
+		// Unchanged resources are re-added on resync
+		if k.Peers.Loaded && k.Peers.Equal(data) {
+			return false
+		}
+		if k.Peers.Loaded {
+			data.Status = MODIFIED
+			return k.EventPeers(data)
+		}
+		*k.Peers = *data
+		k.Peers.Loaded = true
+		updateRequired = true
+		logger.Debugf("peers '%s/%s' processed", data.Namespace, data.Name)
+	case MODIFIED:
+		*k.Peers = *data
+		k.Peers.Loaded = true
+		updateRequired = true
+		logger.Infof("peers '%s/%s' updated", data.Namespace, data.Name)
+	case DELETED:
+		k.Peers.Loaded = false
+		k.Peers.Status = DELETED
+		updateRequired = true
+		logger.Debugf("peers '%s/%s' deleted", data.Namespace, data.Name)
+	}
+	return updateRequired
+}
+
+func (k *K8s) EventGlobal(data *Global) (updateRequired bool) {
+	if k.Global.Namespace != data.Namespace || k.Global.Name != data.Name {
+		return false
+	}
+	switch data.Status {
+	case ADDED:
+		// Unchanged resources are re-added on resync
+		if k.Global.Loaded && k.Global.Equal(data) {
+			return false
+		}
+		if k.Global.Loaded {
+			data.Status = MODIFIED
+			return k.EventGlobal(data)
+		}
+		*k.Global = *data
+		k.Global.Loaded = true
+		updateRequired = true
+		logger.Debugf("global '%s/%s' processed", data.Namespace, data.Name)
+	case MODIFIED:
+		*k.Global = *data
+		k.Global.Loaded = true
+		updateRequired = true
+		logger.Infof("global '%s/%s' updated", data.Namespace, data.Name)
+	case DELETED:
+		k.Global.Loaded = false
+		k.Global.Status = DELETED
+		k.Global.Annotations = map[string]string{}
+		updateRequired = true
+		logger.Debugf("global '%s/%s' deleted", data.Namespace, data.Name)
+	}
+	return updateRequired
+}
+
+// EventPod stores pods setting a backend server weight, an update is only
+// required when the weight of a pod is added, modified or removed.
+func (k *K8s) EventPod(ns *Namespace, data *Pod) (updateRequired bool) {
+	old, ok := ns.Pods[data.Name]
+	switch data.Status {
+	case MODIFIED, ADDED:
+		if ok && old.Status != DELETED && old.Equal(data) {
+			return false
+		}
+		if data.Weight == nil {
+			delete(ns.Pods, data.Name)
+			return ok
+		}
+		ns.Pods[data.Name] = data
+		updateRequired = true
+	case DELETED:
+		if ok {
+			old.Status = DELETED
+			updateRequired = true
+		}
+	}
 	return updateRequired
 }
 
@@ -345,6 +682,7 @@ func (k *K8s) EventSecret(ns *Namespace, data *Secret) (updateRequired bool) {
 		if old, ok := ns.Secret[data.Name]; ok {
 			if old.Status == DELETED {
 				ns.Secret[data.Name].Status = ADDED
+				updateRequired = true
 			}
 			if !old.Equal(data) {
 				data.Status = MODIFIED
@@ -16,7 +16,11 @@ package store
 
 import (
 	"fmt"
+	"net"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/haproxytech/kubernetes-ingress/controller/utils"
 )
@@ -26,6 +30,8 @@ type K8s struct {
 	IngressClasses   map[string]*IngressClass
 	NamespacesAccess NamespacesWatch
 	ConfigMaps       ConfigMaps
+	Peers            *Peers
+	Global           *Global
 }
 
 type NamespacesWatch struct {
@@ -36,6 +42,18 @@ type NamespacesWatch struct {
 var logger = utils.GetLogger()
 
 func NewK8sStore(args utils.OSArgs) K8s {
+	main := &ConfigMap{}
+	mainSources := make([]*ConfigMap, 0, len(args.ConfigMap))
+	for i, cm := range args.ConfigMap {
+		// Main ConfigMap is named after the first one, as owner of its keys
+		if i == 0 {
+			main.Namespace, main.Name = cm.Namespace, cm.Name
+		}
+		mainSources = append(mainSources, &ConfigMap{
+			Namespace: cm.Namespace,
+			Name:      cm.Name,
+		})
+	}
 	return K8s{
 		Namespaces:     make(map[string]*Namespace),
 		IngressClasses: make(map[string]*IngressClass),
@@ -44,10 +62,8 @@ func NewK8sStore(args utils.OSArgs) K8s {
 			Blacklist: map[string]struct{}{},
 		},
 		ConfigMaps: ConfigMaps{
-			Main: &ConfigMap{
-				Namespace: args.ConfigMap.Namespace,
-				Name:      args.ConfigMap.Name,
-			},
+			Main:        main,
+			MainSources: mainSources,
 			TCPServices: &ConfigMap{
 				Namespace: args.ConfigMapTCPServices.Namespace,
 				Name:      args.ConfigMapTCPServices.Name,
@@ -61,6 +77,14 @@ func NewK8sStore(args utils.OSArgs) K8s {
 				Name:      args.ConfigMapPatternFiles.Name,
 			},
 		},
+		Peers: &Peers{
+			Namespace: args.Peers.Namespace,
+			Name:      args.Peers.Name,
+		},
+		Global: &Global{
+			Namespace: args.GlobalConfig.Namespace,
+			Name:      args.GlobalConfig.Name,
+		},
 	}
 }
 
@@ -121,9 +145,40 @@ func (k K8s) Clean() {
 					for _, srv := range endpoints.HAProxySrvs {
 						srv.Modified = false
 					}
+					for backend, used := range endpoints.PathBackends {
+						if !used {
+							delete(endpoints.PathBackends, backend)
+							continue
+						}
+						endpoints.PathBackends[backend] = false
+					}
 				}
 			}
 		}
+		for _, data := range namespace.Pods {
+			switch data.Status {
+			case DELETED:
+				delete(namespace.Pods, data.Name)
+			default:
+				data.Status = EMPTY
+			}
+		}
+		for _, data := range namespace.Backends {
+			switch data.Status {
+			case DELETED:
+				delete(namespace.Backends, data.Name)
+			default:
+				data.Status = EMPTY
+			}
+		}
+		for _, data := range namespace.TLSPolicies {
+			switch data.Status {
+			case DELETED:
+				delete(namespace.TLSPolicies, data.Name)
+			default:
+				data.Status = EMPTY
+			}
+		}
 		for _, data := range namespace.Secret {
 			switch data.Status {
 			case DELETED:
@@ -142,6 +197,12 @@ func (k K8s) Clean() {
 			cm.Status = EMPTY
 		}
 	}
+	if k.Peers.Status != DELETED {
+		k.Peers.Status = EMPTY
+	}
+	if k.Global.Status != DELETED {
+		k.Global.Status = EMPTY
+	}
 	for _, igClass := range k.IngressClasses {
 		switch igClass.Status {
 		case DELETED:
@@ -152,6 +213,32 @@ func (k K8s) Clean() {
 	}
 }
 
+// NamespaceConfigMapName is the name of the optional ConfigMap of a namespace whose keys
+// override the main ConfigMap ones for the ingresses of the namespace.
+const NamespaceConfigMapName = "haproxy-ingress-config"
+
+// WithNamespaceConfig returns the ingress with the keys of its namespace ConfigMap added to its
+// annotations, ingress annotations taking precedence. The stored ingress is left unchanged.
+func (k K8s) WithNamespaceConfig(ingress *Ingress) *Ingress {
+	ns, ok := k.Namespaces[ingress.Namespace]
+	if !ok {
+		return ingress
+	}
+	cm, ok := ns.ConfigMaps[NamespaceConfigMapName]
+	if !ok || cm.Status == DELETED || len(cm.Annotations) == 0 {
+		return ingress
+	}
+	merged := *ingress
+	merged.Annotations = make(map[string]string, len(cm.Annotations)+len(ingress.Annotations))
+	for name, value := range cm.Annotations {
+		merged.Annotations[name] = value
+	}
+	for name, value := range ingress.Annotations {
+		merged.Annotations[name] = value
+	}
+	return &merged
+}
+
 // GetNamespace returns Namespace. Creates one if not existing
 func (k K8s) GetNamespace(name string) *Namespace {
 	namespace, ok := k.Namespaces[name]
@@ -159,18 +246,124 @@ func (k K8s) GetNamespace(name string) *Namespace {
 		return namespace
 	}
 	newNamespace := &Namespace{
-		Name:      name,
-		Relevant:  k.isRelevantNamespace(name),
-		Endpoints: make(map[string]*Endpoints),
-		Services:  make(map[string]*Service),
-		Ingresses: make(map[string]*Ingress),
-		Secret:    make(map[string]*Secret),
-		Status:    ADDED,
+		Name:           name,
+		Relevant:       k.isRelevantNamespace(name),
+		Endpoints:      make(map[string]*Endpoints),
+		EndpointSlices: make(map[string]map[string]*EndpointSlice),
+		Services:       make(map[string]*Service),
+		Pods:           make(map[string]*Pod),
+		Ingresses:      make(map[string]*Ingress),
+		Secret:         make(map[string]*Secret),
+		ConfigMaps:     make(map[string]*ConfigMap),
+		Backends:       make(map[string]*Backend),
+		TLSPolicies:    make(map[string]*TLSPolicy),
+		Status:         ADDED,
 	}
 	k.Namespaces[name] = newNamespace
 	return newNamespace
 }
 
+// DrainingSrvsExpired returns true when a backend server drain-delay
+// expired, the server being then disabled at next HAProxy update.
+func (k K8s) DrainingSrvsExpired() bool {
+	for _, ns := range k.Namespaces {
+		for _, endpoints := range ns.Endpoints {
+			for _, portEndpoints := range endpoints.Ports {
+				for _, srv := range portEndpoints.HAProxySrvs {
+					if !srv.DrainSince.IsZero() && time.Since(srv.DrainSince) >= portEndpoints.DrainDelay {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// GetPodWeights returns the weights set by the pods of the namespace, by pod IP
+func (k K8s) GetPodWeights(namespace string) map[string]int64 {
+	ns, ok := k.Namespaces[namespace]
+	if !ok {
+		return nil
+	}
+	weights := make(map[string]int64)
+	for _, pod := range ns.Pods {
+		if pod.Status != DELETED && pod.Weight != nil && pod.IP != "" {
+			weights[pod.IP] = *pod.Weight
+		}
+	}
+	return weights
+}
+
+// GetServicePortAddresses returns the sorted "address:port" endpoints of the named port of a service,
+// merged from its EndpointSlices, for endpoints not proxied through HAProxy backends.
+func (k K8s) GetServicePortAddresses(namespace, service, portName string) []string {
+	ns, ok := k.Namespaces[namespace]
+	if !ok {
+		return nil
+	}
+	endpoints := mergeEndpointSlices(namespace, service, ns.EndpointSlices[service])
+	portEndpoints, err := endpoints.GetPortEndpoints(portName)
+	if err != nil {
+		logger.Warning(err)
+		return nil
+	}
+	addresses := make([]string, 0, len(portEndpoints.AddrNew))
+	for addr := range portEndpoints.AddrNew {
+		addresses = append(addresses, net.JoinHostPort(addr, strconv.FormatInt(portEndpoints.AddrPort(addr), 10)))
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// GetPortEndpoints returns the endpoints of the service port with the given name,
+// merged from all the EndpointSlices of the service, which name their ports after
+// the service ones. Unnamed ports are the ones of single port services.
+func (e *Endpoints) GetPortEndpoints(portName string) (*PortEndpoints, error) {
+	if portEndpoints, ok := e.Ports[portName]; ok {
+		return portEndpoints, nil
+	}
+	if len(e.Ports) == 0 {
+		return nil, fmt.Errorf("service '%s/%s': no endpoints", e.Namespace, e.Service)
+	}
+	names := make([]string, 0, len(e.Ports))
+	for name := range e.Ports {
+		names = append(names, fmt.Sprintf("'%s'", name))
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("service '%s/%s': no endpoints for port '%s', endpoints ports are %s", e.Namespace, e.Service, portName, strings.Join(names, ", "))
+}
+
+// GetTLSPolicies returns the TLS policies of relevant namespaces, sorted by namespace and name
+// so that the first policy of a host is consistently used when several policies define it.
+func (k K8s) GetTLSPolicies() (policies []*TLSPolicy) {
+	for _, ns := range k.Namespaces {
+		if !ns.Relevant {
+			continue
+		}
+		for _, policy := range ns.TLSPolicies {
+			if policy.Status != DELETED {
+				policies = append(policies, policy)
+			}
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].Namespace != policies[j].Namespace {
+			return policies[i].Namespace < policies[j].Namespace
+		}
+		return policies[i].Name < policies[j].Name
+	})
+	return policies
+}
+
+// AddrPort returns the port targeted by the endpoints address
+func (p *PortEndpoints) AddrPort(address string) int64 {
+	if port, ok := p.AddrPorts[address]; ok {
+		return port
+	}
+	return p.Port
+}
+
 // FetchSecret fetches secret with secretPath format "namespace/secretName"
 // if format is just "secretName" defaultNs param will be used.
 func (k K8s) FetchSecret(secretPath, defaultNs string) (*Secret, error) {
@@ -194,6 +387,102 @@ func (k K8s) FetchSecret(secretPath, defaultNs string) (*Secret, error) {
 	return secret, nil
 }
 
+// Prefixes of annotations values referencing a ConfigMap or Secret key
+const (
+	configMapRefPrefix = "cm://"
+	secretRefPrefix    = "secret://"
+)
+
+// IsValueRef returns true if the annotation value references a ConfigMap or Secret key
+func IsValueRef(value string) bool {
+	return strings.HasPrefix(value, configMapRefPrefix) || strings.HasPrefix(value, secretRefPrefix)
+}
+
+// FetchValueRef returns the value of the ConfigMap or Secret key referenced with the
+// "cm://namespace/name/key" or "secret://namespace/name/key" format,
+// if namespace is omitted defaultNs param will be used.
+func (k K8s) FetchValueRef(ref, defaultNs string) (string, error) {
+	var path string
+	isSecret := strings.HasPrefix(ref, secretRefPrefix)
+	if isSecret {
+		path = strings.TrimPrefix(ref, secretRefPrefix)
+	} else {
+		path = strings.TrimPrefix(ref, configMapRefPrefix)
+	}
+	parts := strings.Split(path, "/")
+	namespace := defaultNs
+	switch len(parts) {
+	case 2:
+	case 3:
+		namespace = parts[0]
+		parts = parts[1:]
+	default:
+		return "", fmt.Errorf("invalid reference '%s', expected format is '<cm|secret>://[namespace/]name/key'", ref)
+	}
+	name, key := parts[0], parts[1]
+	ns, ok := k.Namespaces[namespace]
+	if !ok {
+		return "", fmt.Errorf("namespace '%s' does not exist", namespace)
+	}
+	if isSecret {
+		secret, ok := ns.Secret[name]
+		if !ok || secret.Status == DELETED {
+			return "", fmt.Errorf("secret '%s/%s' does not exist", namespace, name)
+		}
+		value, ok := secret.Data[key]
+		if !ok {
+			return "", fmt.Errorf("key '%s' not found in secret '%s/%s'", key, namespace, name)
+		}
+		return string(value), nil
+	}
+	configMap, ok := ns.ConfigMaps[name]
+	if !ok || configMap.Status == DELETED {
+		return "", fmt.Errorf("configmap '%s/%s' does not exist", namespace, name)
+	}
+	value, ok := configMap.Annotations[key]
+	if !ok {
+		return "", fmt.Errorf("key '%s' not found in configmap '%s/%s'", key, namespace, name)
+	}
+	return value, nil
+}
+
+// GetBackend returns the Backend custom resource of the "[namespace/]name" reference,
+// if namespace is omitted defaultNs param will be used.
+func (k K8s) GetBackend(ref, defaultNs string) (*Backend, error) {
+	namespace, name := defaultNs, ref
+	if i := strings.IndexByte(ref, '/'); i != -1 {
+		namespace, name = ref[:i], ref[i+1:]
+	}
+	ns, ok := k.Namespaces[namespace]
+	if !ok {
+		return nil, fmt.Errorf("namespace '%s' does not exist", namespace)
+	}
+	backend, ok := ns.Backends[name]
+	if !ok || backend.Status == DELETED {
+		return nil, fmt.Errorf("backend '%s/%s' does not exist", namespace, name)
+	}
+	return backend, nil
+}
+
+// UpstreamService returns the service of the Backend upstream, an ExternalName service whose
+// additional targets are set by externalname-targets annotation, with the Backend settings.
+func (b *Backend) UpstreamService() *Service {
+	service := &Service{
+		Namespace: b.Namespace,
+		Name:      b.Name + BackendResourceSuffix,
+		Ports:     []ServicePort{{Protocol: "TCP", Port: b.Upstream.Port}},
+		DNS:       b.Upstream.Addresses[0],
+		Annotations: map[string]string{
+			"cr-backend": b.Namespace + "/" + b.Name,
+		},
+		Status: b.Status,
+	}
+	if len(b.Upstream.Addresses) > 1 {
+		service.Annotations["externalname-targets"] = strings.Join(b.Upstream.Addresses[1:], ",")
+	}
+	return service
+}
+
 func (k K8s) isRelevantNamespace(namespace string) bool {
 	if namespace == "" {
 		return false
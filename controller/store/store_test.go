@@ -0,0 +1,82 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+func TestNamespaceConfigPrecedence(t *testing.T) {
+	k := NewK8sStore(utils.OSArgs{ConfigMap: []utils.NamespaceValue{{Namespace: "default", Name: "haproxy-configmap"}}})
+	k.EventConfigMap(k.GetNamespace("default"), &ConfigMap{
+		Namespace: "default",
+		Name:      "haproxy-configmap",
+		Status:    ADDED,
+		Annotations: map[string]string{
+			"timeout-server": "1m",
+			"load-balance":   "roundrobin",
+			"ssl-redirect":   "false",
+		},
+	})
+	ns := k.GetNamespace("team-a")
+	k.EventIngress(ns, &Ingress{
+		Namespace:   "team-a",
+		Name:        "app",
+		Status:      ADDED,
+		Annotations: map[string]string{"timeout-server": "3m"},
+	}, "")
+	k.Clean()
+	if !k.EventConfigMap(ns, &ConfigMap{
+		Namespace: "team-a",
+		Name:      NamespaceConfigMapName,
+		Status:    ADDED,
+		Annotations: map[string]string{
+			"timeout-server": "2m",
+			"load-balance":   "leastconn",
+		},
+	}) {
+		t.Fatal("EventConfigMap() of the namespace ConfigMap did not require an update")
+	}
+	ingress := ns.Ingresses["app"]
+	if ingress.Status != MODIFIED {
+		t.Errorf("ingress status after namespace ConfigMap change = %s, want %s", ingress.Status, MODIFIED)
+	}
+
+	merged := k.WithNamespaceConfig(ingress)
+	for name, want := range map[string]string{
+		"timeout-server": "3m",        // ingress
+		"load-balance":   "leastconn", // namespace ConfigMap
+		"ssl-redirect":   "false",     // main ConfigMap
+		"maxconn":        "",          // none
+	} {
+		if value := k.GetValueFromAnnotations(name, merged.Annotations, k.ConfigMaps.Main.Annotations); value != want {
+			t.Errorf("%s = '%s', want '%s'", name, value, want)
+		}
+	}
+	if len(ingress.Annotations) != 1 {
+		t.Errorf("WithNamespaceConfig() modified ingress annotations: %v", ingress.Annotations)
+	}
+
+	k.Clean()
+	k.EventConfigMap(ns, &ConfigMap{Namespace: "team-a", Name: NamespaceConfigMapName, Status: DELETED})
+	if ingress.Status != MODIFIED {
+		t.Errorf("ingress status after namespace ConfigMap deletion = %s, want %s", ingress.Status, MODIFIED)
+	}
+	if value := k.GetValueFromAnnotations("load-balance", k.WithNamespaceConfig(ingress).Annotations, k.ConfigMaps.Main.Annotations); value != "roundrobin" {
+		t.Errorf("load-balance after namespace ConfigMap deletion = '%s', want 'roundrobin'", value)
+	}
+}
@@ -14,10 +14,13 @@
 
 package store
 
-import "bytes"
+import (
+	"bytes"
+	"strings"
+)
 
 func (a *ServicePort) Equal(b *ServicePort) bool {
-	if a.Name != b.Name || a.Protocol != b.Protocol || a.Port != b.Port {
+	if a.Name != b.Name || a.Protocol != b.Protocol || a.Port != b.Port || a.TargetPort != b.TargetPort {
 		return false
 	}
 	return true
@@ -34,6 +37,9 @@ func (a *IngressClass) Equal(b *IngressClass) bool {
 	if a.Controller != b.Controller {
 		return false
 	}
+	if a.Default != b.Default {
+		return false
+	}
 	return true
 }
 
@@ -54,6 +60,9 @@ func (a *IngressPath) Equal(b *IngressPath) bool {
 	if a.SvcPortString != b.SvcPortString {
 		return false
 	}
+	if a.BackendResource != b.BackendResource {
+		return false
+	}
 	return true
 }
 
@@ -140,7 +149,7 @@ func (a *Service) Equal(b *Service) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	if a.Name != b.Name {
+	if a.Name != b.Name || a.DNS != b.DNS || a.Headless != b.Headless {
 		return false
 	}
 	if len(a.Annotations) != len(b.Annotations) {
@@ -157,7 +166,7 @@ func (a *Service) Equal(b *Service) bool {
 	}
 	for index, p1 := range a.Ports {
 		p2 := b.Ports[index]
-		if p1.Name != p2.Name || p1.Protocol != p2.Protocol || p1.Port != p2.Port {
+		if !p1.Equal(&p2) {
 			return false
 		}
 	}
@@ -184,7 +193,88 @@ func (a *ConfigMap) Equal(b *ConfigMap) bool {
 	return true
 }
 
+// Equal compares two globals, ignores statuses
+func (a *Global) Equal(b *Global) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if a.Name != b.Name || len(a.Annotations) != len(b.Annotations) {
+		return false
+	}
+	for name, value := range a.Annotations {
+		if b.Annotations[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal compares two backends, ignores statuses
+func (a *Backend) Equal(b *Backend) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if a.Name != b.Name || len(a.Annotations) != len(b.Annotations) {
+		return false
+	}
+	for name, value := range a.Annotations {
+		if b.Annotations[name] != value {
+			return false
+		}
+	}
+	if (a.StaticResponse == nil) != (b.StaticResponse == nil) || (a.StaticResponse != nil && *a.StaticResponse != *b.StaticResponse) {
+		return false
+	}
+	if (a.Upstream == nil) != (b.Upstream == nil) {
+		return false
+	}
+	if a.Upstream != nil {
+		if a.Upstream.Port != b.Upstream.Port || strings.Join(a.Upstream.Addresses, ",") != strings.Join(b.Upstream.Addresses, ",") {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal compares two TLS policies, ignores statuses
+func (a *TLSPolicy) Equal(b *TLSPolicy) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if a.Namespace != b.Namespace || a.Name != b.Name || strings.Join(a.Hosts, ",") != strings.Join(b.Hosts, ",") {
+		return false
+	}
+	if a.MinVersion != b.MinVersion || a.Ciphers != b.Ciphers || a.Ciphersuites != b.Ciphersuites {
+		return false
+	}
+	if a.ClientCA != b.ClientCA || a.ClientCrtOptional != b.ClientCrtOptional {
+		return false
+	}
+	return (a.HSTS == nil) == (b.HSTS == nil) && (a.HSTS == nil || *a.HSTS == *b.HSTS)
+}
+
+// Equal compares two peers, ignores statuses
+func (a *Peers) Equal(b *Peers) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	return a.Name == b.Name && a.Service == b.Service && a.Port == b.Port
+}
+
 // Equal compares two secrets, ignores statuses and old values
+func (a *Pod) Equal(b *Pod) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if a.Name != b.Name || a.IP != b.IP {
+		return false
+	}
+	if (a.Weight == nil) != (b.Weight == nil) {
+		return false
+	}
+	return a.Weight == nil || *a.Weight == *b.Weight
+}
+
 func (a *Secret) Equal(b *Secret) bool {
 	if a == nil || b == nil {
 		return false
@@ -258,5 +348,91 @@ func (oldE *PortEndpoints) Equal(newE *PortEndpoints) bool {
 			return false
 		}
 	}
+	if len(oldE.AddrPorts) != len(newE.AddrPorts) {
+		return false
+	}
+	for addr, port := range oldE.AddrPorts {
+		if newE.AddrPorts[addr] != port {
+			return false
+		}
+	}
+	if len(oldE.PodNames) != len(newE.PodNames) {
+		return false
+	}
+	for addr, name := range oldE.PodNames {
+		if newE.PodNames[addr] != name {
+			return false
+		}
+	}
+	return equalTopology(oldE.Zones, newE.Zones, oldE.ZoneHints, newE.ZoneHints)
+}
+
+// Equal checks if two EndpointSlices have same addresses
+func (a *EndpointSlice) Equal(b *EndpointSlice) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if a.Namespace != b.Namespace || a.Name != b.Name || a.Service != b.Service {
+		return false
+	}
+	if len(a.Ports) != len(b.Ports) {
+		return false
+	}
+	for portName, aPort := range a.Ports {
+		if !aPort.Equal(b.Ports[portName]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *SlicePort) Equal(b *SlicePort) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if a.Port != b.Port {
+		return false
+	}
+	if !equalAddresses(a.Ready, b.Ready) || !equalAddresses(a.Serving, b.Serving) {
+		return false
+	}
+	if len(a.PodNames) != len(b.PodNames) {
+		return false
+	}
+	for addr, name := range a.PodNames {
+		if b.PodNames[addr] != name {
+			return false
+		}
+	}
+	return equalTopology(a.Zones, b.Zones, a.ZoneHints, b.ZoneHints)
+}
+
+// equalTopology checks if addresses have the same zones and zone hints
+func equalTopology(aZones, bZones map[string]string, aHints, bHints map[string][]string) bool {
+	if len(aZones) != len(bZones) || len(aHints) != len(bHints) {
+		return false
+	}
+	for addr, zone := range aZones {
+		if bZones[addr] != zone {
+			return false
+		}
+	}
+	for addr, zones := range aHints {
+		if strings.Join(bHints[addr], ",") != strings.Join(zones, ",") {
+			return false
+		}
+	}
+	return true
+}
+
+func equalAddresses(a, b map[string]struct{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for addr := range a {
+		if _, ok := b[addr]; !ok {
+			return false
+		}
+	}
 	return true
 }
@@ -14,12 +14,17 @@
 
 package store
 
+import "time"
+
 // ServicePort describes port of a service
 type ServicePort struct {
 	Name     string
 	Protocol string
 	Port     int64
-	Status   Status
+	// TargetPort is only used by ExternalName services
+	// to remap the port of the external endpoints.
+	TargetPort int64
+	Status     Status
 }
 
 type HAProxySrv struct {
@@ -27,6 +32,14 @@ type HAProxySrv struct {
 	Name     string
 	Address  string
 	Modified bool
+	// Weight is the weight of the pod currently in the srv, nil when not set by the pod
+	Weight *int64
+	// DrainSince is set when the srv address left endpoints and the srv is in drain
+	DrainSince time.Time
+	// Backup is set when the srv is a backup server for being out of the controller zone
+	Backup bool
+	// DisabledSince is set when the srv is disabled, long disabled srvs being trimmed
+	DisabledSince time.Time
 }
 
 // PortEndpoints describes endpoints of a service port
@@ -37,9 +50,22 @@ type PortEndpoints struct {
 	AddrCount       int
 	AddrNew         map[string]struct{}
 	HAProxySrvs     []*HAProxySrv
+	// DrainDelay is the time srvs are kept in drain once their address left endpoints
+	DrainDelay time.Duration
+	// AddrPorts are the ports of endpoints addresses targeting another port than Port
+	AddrPorts map[string]int64
+	// PodNames are the names of the pods targeted by endpoints addresses
+	PodNames map[string]string
+	// Zones are the zones of endpoints addresses, when known
+	Zones map[string]string
+	// ZoneHints are the zones endpoints addresses are hinted to be consumed from
+	ZoneHints map[string][]string
+	// PathBackends are the dedicated backends of ingress paths sharing the srvs
+	// of BackendName, true when used since last sync
+	PathBackends map[string]bool
 }
 
-// Endpoints describes endpoints of a service
+// Endpoints describes endpoints of a service, merged from its EndpointSlices
 type Endpoints struct {
 	Namespace string
 	Service   string
@@ -47,6 +73,32 @@ type Endpoints struct {
 	Status    Status
 }
 
+// EndpointSlice describes an EndpointSlice of a service, a service
+// having as many slices as needed to hold all its endpoints.
+type EndpointSlice struct {
+	Namespace string
+	Name      string
+	Service   string
+	Ports     map[string]*SlicePort
+	Status    Status
+}
+
+// SlicePort describes the addresses of an EndpointSlice port by condition
+type SlicePort struct {
+	Port int64
+	// Ready are the addresses of endpoints ready to receive traffic
+	Ready map[string]struct{}
+	// Serving are the addresses of terminating endpoints still serving,
+	// only used when the service port has no ready address
+	Serving map[string]struct{}
+	// PodNames are the names of the pods targeted by addresses
+	PodNames map[string]string
+	// Zones are the zones of addresses, when known
+	Zones map[string]string
+	// ZoneHints are the zones addresses are hinted to be consumed from
+	ZoneHints map[string][]string
+}
+
 // Service is useful data from k8s structures about service
 type Service struct {
 	Namespace   string
@@ -54,10 +106,21 @@ type Service struct {
 	Ports       []ServicePort
 	Addresses   []string // Used only for publish-service
 	DNS         string
+	Headless    bool // No cluster IP, pods being reached directly
 	Annotations map[string]string
 	Status      Status
 }
 
+// Pod is useful data from k8s structures about pod, only pods
+// setting a weight for their backend server are relevant.
+type Pod struct {
+	Namespace string
+	Name      string
+	IP        string
+	Weight    *int64
+	Status    Status
+}
+
 // Namespace is useful data from k8s structures about namespace
 type Namespace struct {
 	_         [0]int
@@ -65,26 +128,41 @@ type Namespace struct {
 	Relevant  bool
 	Ingresses map[string]*Ingress
 	Endpoints map[string]*Endpoints
-	Services  map[string]*Service
-	Secret    map[string]*Secret
-	Status    Status
+	// EndpointSlices by service and slice name
+	EndpointSlices map[string]map[string]*EndpointSlice
+	Services       map[string]*Service
+	Pods           map[string]*Pod
+	Secret         map[string]*Secret
+	// ConfigMaps which can be referenced in annotations values, see FetchValueRef
+	ConfigMaps map[string]*ConfigMap
+	// Backends custom resources referenced by cr-backend annotation
+	Backends map[string]*Backend
+	// TLSPolicies custom resources, applied to frontend hosts
+	TLSPolicies map[string]*TLSPolicy
+	Status      Status
 }
 
 type IngressClass struct {
 	APIVersion string
 	Name       string
 	Controller string
-	Status     Status
+	// Default is true for the cluster default class, set with the
+	// "ingressclass.kubernetes.io/is-default-class" annotation.
+	Default bool
+	Status  Status
 }
 
 // IngressPath is useful data from k8s structures about ingress path
 type IngressPath struct {
-	SvcName          string
-	SvcPortInt       int64
-	SvcPortString    string
-	SvcPortResolved  *ServicePort
-	Path             string
-	PathTypeMatch    string
+	SvcName         string
+	SvcPortInt      int64
+	SvcPortString   string
+	SvcPortResolved *ServicePort
+	Path            string
+	PathTypeMatch   string
+	// BackendResource is the Backend custom resource of the ingress namespace
+	// referenced by the ingress backend instead of a service.
+	BackendResource  string
 	IsDefaultBackend bool
 	Status           Status
 }
@@ -118,7 +196,10 @@ type IngressTLS struct {
 }
 
 type ConfigMaps struct {
+	// Main merges the keys of MainSources, the ConfigMaps set with --configmap,
+	// each one taking precedence over the previous ones
 	Main         *ConfigMap
+	MainSources  []*ConfigMap
 	TCPServices  *ConfigMap
 	Errorfiles   *ConfigMap
 	PatternFiles *ConfigMap
@@ -133,6 +214,78 @@ type ConfigMap struct {
 	Status      Status
 }
 
+// Peers is useful data from the Peers custom resource, Service is the
+// service whose endpoints are the controller replicas sharing stick tables
+type Peers struct {
+	Namespace string
+	Name      string
+	Loaded    bool
+	Service   string
+	Port      int64
+	Status    Status
+}
+
+// Global is useful data from the Global custom resource, its typed spec
+// is converted to Annotations named after the main ConfigMap keys
+type Global struct {
+	Namespace   string
+	Name        string
+	Loaded      bool
+	Annotations map[string]string
+	Status      Status
+}
+
+// Backend is useful data from the Backend custom resource, its typed spec is
+// converted to Annotations named after the backend and server annotations
+type Backend struct {
+	Namespace   string
+	Name        string
+	Annotations map[string]string
+	// StaticResponse is served for ingress paths referencing the Backend as resource
+	StaticResponse *StaticResponse
+	// Upstream are the servers of ingress paths referencing the Backend as resource
+	Upstream *Upstream
+	Status   Status
+}
+
+// StaticResponse is a response served by HAProxy without backend servers
+type StaticResponse struct {
+	StatusCode  int64
+	ContentType string
+	Body        string
+}
+
+// Upstream are servers out of the cluster, addressed by IP address or DNS name
+type Upstream struct {
+	Addresses []string
+	Port      int64
+}
+
+// TLSPolicy is useful data from the TLSPolicy custom resource, the TLS settings
+// of HTTPS frontend hosts, managed independently from the ingresses of the hosts
+type TLSPolicy struct {
+	Namespace string
+	Name      string
+	// Hosts are the hosts the policy applies to, wildcard hosts matching their subdomains
+	Hosts        []string
+	MinVersion   string
+	Ciphers      string
+	Ciphersuites string
+	// ClientCA is the secret of the CA verifying client certificates, "namespace/name" or
+	// the name of a secret of the policy namespace
+	ClientCA          string
+	ClientCrtOptional bool
+	HSTS              *HSTS
+	Status            Status
+}
+
+// HSTS are the settings of the Strict-Transport-Security response header
+type HSTS struct {
+	MaxAge            int64
+	IncludeSubdomains bool
+	Preload           bool
+}
+
 // Secret is useful data from k8s structures about secret
 type Secret struct {
 	Namespace string
@@ -0,0 +1,113 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy/rules"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+// handleTLSPolicies applies the TLS settings of TLSPolicy custom resources to their hosts,
+// in the lines of the frontend crt-list, and sets their HSTS header in HTTPS responses.
+// A host defined by several policies gets the settings of the first one by namespace and name.
+func (c *HAProxyController) handleTLSPolicies() {
+	hostPolicies := make(map[string]*store.TLSPolicy)
+	var hosts []string
+	for _, policy := range c.Store.GetTLSPolicies() {
+		for _, host := range policy.Hosts {
+			if other, ok := hostPolicies[host]; ok {
+				logger.Warningf("TLSPolicy '%s/%s': host '%s' already defined by TLSPolicy '%s/%s', ignored", policy.Namespace, policy.Name, host, other.Namespace, other.Name)
+				continue
+			}
+			hostPolicies[host] = policy
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		policy := hostPolicies[host]
+		options := haproxy.TLSOptions{
+			MinVersion:   policy.MinVersion,
+			Ciphers:      policy.Ciphers,
+			Ciphersuites: policy.Ciphersuites,
+		}
+		if policy.ClientCA != "" {
+			caFile, err := c.Cfg.Certificates.HandleTLSSecret(c.Store, haproxy.SecretCtx{
+				DefaultNS:  policy.Namespace,
+				SecretPath: policy.ClientCA,
+				SecretType: haproxy.CA_CERT,
+			})
+			options.Verify = "required"
+			if policy.ClientCrtOptional {
+				options.Verify = "optional"
+			}
+			if err != nil {
+				// Serving the host without client authentication would bypass the policy, clients are rejected instead
+				msg := fmt.Sprintf("client CA secret '%s' not available, clients of host '%s' rejected", policy.ClientCA, host)
+				logger.Errorf("TLSPolicy '%s/%s': %s", policy.Namespace, policy.Name, msg)
+				c.recordEvent(fmt.Sprintf("tlspolicy %s/%s", policy.Namespace, policy.Name), corev1.EventTypeWarning, "ClientCANotFound", msg)
+				if caFile, err = c.Cfg.Certificates.DenyAllCA(); err != nil {
+					logger.Errorf("TLSPolicy '%s/%s': %s", policy.Namespace, policy.Name, err)
+					continue
+				}
+				options.Verify = "required"
+			}
+			options.CAFile = caFile
+		}
+		c.Cfg.Certificates.SetHostTLS(host, options)
+		if policy.HSTS != nil {
+			c.setTLSPolicyHSTS(policy, host, hostPolicies)
+		}
+	}
+}
+
+// setTLSPolicyHSTS sets the HSTS header of the policy in the HTTPS responses of host. The header
+// of a wildcard host is not set for its subdomains having a policy of their own.
+func (c *HAProxyController) setTLSPolicyHSTS(policy *store.TLSPolicy, host string, hostPolicies map[string]*store.TLSPolicy) {
+	hdrValue := fmt.Sprintf("max-age=%d", policy.HSTS.MaxAge)
+	if policy.HSTS.IncludeSubdomains {
+		hdrValue += "; includeSubDomains"
+	}
+	if policy.HSTS.Preload {
+		hdrValue += "; preload"
+	}
+	condTest := fmt.Sprintf("{ var(txn.host) -m str %s }", host)
+	if suffix, _ := utils.WildcardHostSuffix(host); suffix != "" {
+		conds := []string{fmt.Sprintf("{ var(txn.host),regsub(^[^.]*,,) -m str %s }", suffix)}
+		for other := range hostPolicies {
+			if other != host && utils.MatchHost(host, other) {
+				conds = append(conds, fmt.Sprintf("!{ var(txn.host) -m str %s }", other))
+			}
+		}
+		sort.Strings(conds[1:])
+		condTest = strings.Join(conds, " ")
+	}
+	logger.Tracef("TLSPolicy '%s/%s': Configuring HSTS '%s' for host '%s'", policy.Namespace, policy.Name, hdrValue, host)
+	resSetHdr := rules.SetHdr{
+		HdrName:   "Strict-Transport-Security",
+		HdrFormat: "\"" + hdrValue + "\"",
+		Response:  true,
+		CondTest:  condTest,
+	}
+	logger.Error(c.Cfg.HAProxyRules.AddRule(resSetHdr, "", c.Cfg.FrontHTTPS))
+}
@@ -0,0 +1,141 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/haproxy"
+	"github.com/haproxytech/kubernetes-ingress/controller/store"
+)
+
+func TestTLSPolicyCrtList(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy store.TLSPolicy
+		// lines of the crt-list, "%ft" and "%ca" being the frontend and CA certificates directories
+		lines []string
+	}{
+		{
+			name:   "min version and ciphers",
+			policy: store.TLSPolicy{Hosts: []string{"app.example.com"}, MinVersion: "TLSv1.2", Ciphers: "ECDHE-RSA-AES128-GCM-SHA256"},
+			lines: []string{
+				"%ft/0_test_default-cert.pem",
+				"%ft/0_test_default-cert.pem [ssl-min-ver TLSv1.2 ciphers ECDHE-RSA-AES128-GCM-SHA256] app.example.com",
+			},
+		},
+		{
+			name:   "ciphersuites of a wildcard host",
+			policy: store.TLSPolicy{Hosts: []string{"*.example.com"}, Ciphersuites: "TLS_AES_128_GCM_SHA256"},
+			lines: []string{
+				"%ft/0_test_default-cert.pem",
+				"%ft/0_test_default-cert.pem [ciphersuites TLS_AES_128_GCM_SHA256] *.example.com",
+			},
+		},
+		{
+			name:   "client CA",
+			policy: store.TLSPolicy{Hosts: []string{"app.example.com"}, ClientCA: "client-ca"},
+			lines: []string{
+				"%ft/0_test_default-cert.pem",
+				"%ft/0_test_default-cert.pem [ca-file %ca/test_client-ca.pem verify required] app.example.com",
+			},
+		},
+		{
+			name:   "optional client certificate",
+			policy: store.TLSPolicy{Hosts: []string{"app.example.com"}, MinVersion: "TLSv1.3", ClientCA: "test/client-ca", ClientCrtOptional: true},
+			lines: []string{
+				"%ft/0_test_default-cert.pem",
+				"%ft/0_test_default-cert.pem [ssl-min-ver TLSv1.3 ca-file %ca/test_client-ca.pem verify optional] app.example.com",
+			},
+		},
+		{
+			name:   "missing client CA",
+			policy: store.TLSPolicy{Hosts: []string{"app.example.com"}, ClientCA: "missing", ClientCrtOptional: true},
+			lines: []string{
+				"%ft/0_test_default-cert.pem",
+				"%ft/0_test_default-cert.pem [ca-file %ca/deny-all.pem verify required] app.example.com",
+			},
+		},
+		{
+			name:   "no TLS settings",
+			policy: store.TLSPolicy{Hosts: []string{"app.example.com"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _, _ := newTestController(t)
+			dir := t.TempDir()
+			caDir, ftDir := filepath.Join(dir, "ca"), filepath.Join(dir, "ft")
+			for _, d := range []string{caDir, ftDir} {
+				if err := os.Mkdir(d, 0700); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c.Cfg.Certificates = haproxy.NewCertificates(caDir, ftDir, dir)
+			ns := c.Store.GetNamespace("test")
+			ns.Secret["default-cert"] = &store.Secret{Namespace: "test", Name: "default-cert", Data: map[string][]byte{"tls.crt": []byte("crt\n"), "tls.key": []byte("key\n")}}
+			ns.Secret["client-ca"] = &store.Secret{Namespace: "test", Name: "client-ca", Data: map[string][]byte{"tls.crt": []byte("ca\n")}}
+			policy := test.policy
+			policy.Namespace, policy.Name = "test", "policy"
+			ns.TLSPolicies[policy.Name] = &policy
+			if _, err := c.Cfg.Certificates.HandleTLSSecret(c.Store, haproxy.SecretCtx{DefaultNS: "test", SecretPath: "default-cert", SecretType: haproxy.FT_DEFAULT_CERT}); err != nil {
+				t.Fatal(err)
+			}
+
+			c.handleTLSPolicies()
+			dirs := strings.NewReplacer("%ft", ftDir, "%ca", caDir)
+			var want []string
+			for _, line := range test.lines {
+				want = append(want, dirs.Replace(line))
+			}
+			if lines := c.Cfg.Certificates.FrontendCrtList(); !reflect.DeepEqual(lines, want) {
+				t.Errorf("crt-list lines %q, want %q", lines, want)
+			}
+		})
+	}
+}
+
+func TestTLSPolicyDenyAllCA(t *testing.T) {
+	dir := t.TempDir()
+	certificates := haproxy.NewCertificates(dir, dir, dir)
+	caPath, err := certificates.DenyAllCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(caPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, rest := pem.Decode(content)
+	if block == nil || block.Type != "CERTIFICATE" || len(rest) != 0 {
+		t.Fatalf("deny-all CA file content %q, want a single certificate", content)
+	}
+	ca, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ca.IsCA || ca.KeyUsage&x509.KeyUsageCertSign == 0 {
+		t.Errorf("deny-all certificate is not a CA: %+v", ca)
+	}
+	if again, _ := certificates.DenyAllCA(); again != caPath {
+		t.Errorf("DenyAllCA() = %s, want the same CA %s", again, caPath)
+	}
+}
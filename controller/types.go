@@ -36,14 +36,19 @@ type Mode string
 const (
 	CONTROLLER_CLASS = "haproxy.org/ingress-controller"
 	// SyncType values
+	BACKEND       SyncType = "BACKEND"
 	COMMAND       SyncType = "COMMAND"
 	CONFIGMAP     SyncType = "CONFIGMAP"
 	ENDPOINTS     SyncType = "ENDPOINTS"
+	GLOBAL        SyncType = "GLOBAL"
 	INGRESS       SyncType = "INGRESS"
 	INGRESS_CLASS SyncType = "INGRESS_CLASS"
 	NAMESPACE     SyncType = "NAMESPACE"
+	PEERS         SyncType = "PEERS"
+	POD           SyncType = "POD"
 	SERVICE       SyncType = "SERVICE"
 	SECRET        SyncType = "SECRET"
+	TLS_POLICY    SyncType = "TLS_POLICY"
 	// Modes
 	HTTP Mode = "http"
 	TCP  Mode = "tcp"
@@ -0,0 +1,261 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package udp
+
+import (
+	"container/list"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/haproxytech/kubernetes-ingress/controller/utils"
+)
+
+var logger = utils.GetLogger()
+
+// SessionTimeout is the time a client session is kept without datagrams
+// in either direction before its upstream socket is closed.
+const SessionTimeout = 30 * time.Second
+
+// MaxSessions is the number of client sessions of a listener, each one holding an upstream
+// socket. Once reached, the oldest session is closed for a new client, so that a flood of
+// datagrams from spoofed addresses cannot exhaust the file descriptors of the controller.
+const MaxSessions = 1024
+
+// maxDatagramSize is the largest UDP payload
+const maxDatagramSize = 65535
+
+// Proxy load-balances UDP datagrams, which HAProxy does not handle,
+// from listening addresses to the endpoints of services. Datagrams of a
+// client are sent to the same endpoint for as long as its session lasts.
+type Proxy struct {
+	listeners      map[string]*listener
+	sessionTimeout time.Duration
+	maxSessions    int
+	mu             sync.Mutex
+}
+
+func NewProxy() *Proxy {
+	return &Proxy{
+		listeners:      make(map[string]*listener),
+		sessionTimeout: SessionTimeout,
+		maxSessions:    MaxSessions,
+	}
+}
+
+// Update proxies each listening address of listeners to its "address:port" targets.
+// Addresses no more in listeners are closed, as are the sessions to removed targets.
+func (p *Proxy) Update(listeners map[string][]string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for addr, l := range p.listeners {
+		if _, ok := listeners[addr]; !ok {
+			l.close()
+			delete(p.listeners, addr)
+			logger.Debugf("UDP proxy: stopped listening on %s", addr)
+		}
+	}
+	var errors utils.Errors
+	for addr, targets := range listeners {
+		l, ok := p.listeners[addr]
+		if !ok {
+			var err error
+			if l, err = listen(addr, p.sessionTimeout, p.maxSessions); err != nil {
+				errors.Add(err)
+				continue
+			}
+			p.listeners[addr] = l
+			logger.Infof("UDP proxy: listening on %s", addr)
+		}
+		l.setTargets(targets)
+	}
+	return errors.Result()
+}
+
+// Close stops all listeners and their sessions
+func (p *Proxy) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for addr, l := range p.listeners {
+		l.close()
+		delete(p.listeners, addr)
+	}
+}
+
+type listener struct {
+	conn        *net.UDPConn
+	timeout     time.Duration
+	maxSessions int
+	targets     []string
+	next        int
+	sessions    map[string]*session
+	// order holds sessions from the oldest to the newest one
+	order *list.List
+	mu    sync.Mutex
+}
+
+type session struct {
+	upstream *net.UDPConn
+	target   string
+	elem     *list.Element
+}
+
+func listen(addr string, timeout time.Duration, maxSessions int) (*listener, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("UDP proxy: %w", err)
+	}
+	// IPv6 address only listens on IPv6 so that it does not conflict with the IPv4 one
+	network := "udp6"
+	if udpAddr.IP.To4() != nil {
+		network = "udp4"
+	}
+	conn, err := net.ListenUDP(network, udpAddr)
+	if err != nil {
+		return nil, fmt.Errorf("UDP proxy: %w", err)
+	}
+	l := &listener{
+		conn:        conn,
+		timeout:     timeout,
+		maxSessions: maxSessions,
+		sessions:    make(map[string]*session),
+		order:       list.New(),
+	}
+	go l.serve()
+	return l, nil
+}
+
+func (l *listener) setTargets(targets []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.targets = targets
+	current := make(map[string]struct{}, len(targets))
+	for _, target := range targets {
+		current[target] = struct{}{}
+	}
+	for _, s := range l.sessions {
+		if _, ok := current[s.target]; !ok {
+			// Relay of the session removes it when its upstream is closed
+			s.upstream.Close()
+		}
+	}
+}
+
+func (l *listener) close() {
+	l.conn.Close()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.sessions {
+		s.upstream.Close()
+	}
+}
+
+// serve forwards client datagrams to the upstream of their session until the listener is closed
+func (l *listener) serve() {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, client, err := l.conn.ReadFromUDP(buf)
+		if err != nil {
+			// Listener closed
+			return
+		}
+		s, err := l.session(client)
+		if err != nil {
+			logger.Errorf("UDP proxy %s: %s", l.conn.LocalAddr(), err)
+			continue
+		}
+		if err = s.upstream.SetReadDeadline(time.Now().Add(l.timeout)); err == nil {
+			_, err = s.upstream.Write(buf[:n])
+		}
+		if err != nil {
+			logger.Debugf("UDP proxy %s: datagram of %s to %s dropped: %s", l.conn.LocalAddr(), client, s.target, err)
+		}
+	}
+}
+
+// session returns the session of client, creating it with the next target when there is none
+func (l *listener) session(client *net.UDPAddr) (*session, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s, ok := l.sessions[client.String()]; ok {
+		return s, nil
+	}
+	if len(l.targets) == 0 {
+		return nil, fmt.Errorf("datagram of %s dropped, no endpoints available", client)
+	}
+	if len(l.sessions) >= l.maxSessions {
+		l.evictOldest()
+	}
+	target := l.targets[l.next%len(l.targets)]
+	l.next = (l.next + 1) % len(l.targets)
+	targetAddr, err := net.ResolveUDPAddr("udp", target)
+	if err != nil {
+		return nil, err
+	}
+	upstream, err := net.DialUDP("udp", nil, targetAddr)
+	if err != nil {
+		return nil, err
+	}
+	s := &session{
+		upstream: upstream,
+		target:   target,
+	}
+	s.elem = l.order.PushBack(client.String())
+	l.sessions[client.String()] = s
+	go l.relay(client, s)
+	return s, nil
+}
+
+// evictOldest closes the oldest session, l.mu being locked
+func (l *listener) evictOldest() {
+	elem := l.order.Front()
+	if elem == nil {
+		return
+	}
+	client := l.order.Remove(elem).(string)
+	if s, ok := l.sessions[client]; ok {
+		delete(l.sessions, client)
+		// Relay of the session exits when its upstream is closed
+		s.upstream.Close()
+	}
+	logger.Debugf("UDP proxy %s: %d sessions reached, session of %s closed", l.conn.LocalAddr(), l.maxSessions, client)
+}
+
+// relay sends upstream datagrams back to client until the session times out or is closed
+func (l *listener) relay(client *net.UDPAddr, s *session) {
+	defer func() {
+		s.upstream.Close()
+		l.mu.Lock()
+		if l.sessions[client.String()] == s {
+			delete(l.sessions, client.String())
+		}
+		l.order.Remove(s.elem)
+		l.mu.Unlock()
+	}()
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, err := s.upstream.Read(buf)
+		if err != nil {
+			return
+		}
+		if err = s.upstream.SetReadDeadline(time.Now().Add(l.timeout)); err != nil {
+			return
+		}
+		if _, err = l.conn.WriteToUDP(buf[:n], client); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package udp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+const testTimeout = 2 * time.Second
+
+// echoServer replies to each datagram with its content until the test ends
+func echoServer(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			_, _ = conn.WriteToUDP(buf[:n], addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// newTestProxy returns a proxy listener of the echo server with small session limits
+func newTestProxy(t *testing.T, timeout time.Duration, maxSessions int) *listener {
+	t.Helper()
+	p := NewProxy()
+	p.sessionTimeout = timeout
+	p.maxSessions = maxSessions
+	if err := p.Update(map[string][]string{"127.0.0.1:0": {echoServer(t)}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.Close)
+	return p.listeners["127.0.0.1:0"]
+}
+
+// dial returns a client of the listener, each one on its own port
+func dial(t *testing.T, l *listener) *net.UDPConn {
+	t.Helper()
+	conn, err := net.DialUDP("udp4", nil, l.conn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// echo sends msg through the proxy and checks it is replied to the same client
+func echo(t *testing.T, client *net.UDPConn, msg string) {
+	t.Helper()
+	if _, err := client.Write([]byte(msg)); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetReadDeadline(time.Now().Add(testTimeout)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, maxDatagramSize)
+	n, err := client.Read(buf)
+	if err != nil {
+		t.Fatalf("no reply to '%s': %s", msg, err)
+	}
+	if reply := string(buf[:n]); reply != msg {
+		t.Errorf("reply '%s', want '%s'", reply, msg)
+	}
+}
+
+// sessions returns whether each client has a session and the number of sessions
+func sessions(l *listener, clients ...*net.UDPConn) ([]bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	active := make([]bool, len(clients))
+	for i, client := range clients {
+		_, active[i] = l.sessions[client.LocalAddr().String()]
+	}
+	return active, len(l.sessions)
+}
+
+func checkSessions(t *testing.T, l *listener, clients []*net.UDPConn, want ...bool) {
+	t.Helper()
+	active, n := sessions(l, clients...)
+	count := 0
+	for i := range want {
+		if active[i] != want[i] {
+			t.Errorf("client %d session = %t, want %t", i, active[i], want[i])
+		}
+		if want[i] {
+			count++
+		}
+	}
+	if n != count {
+		t.Errorf("%d sessions, want %d", n, count)
+	}
+}
+
+func TestProxySessions(t *testing.T) {
+	l := newTestProxy(t, time.Minute, 2)
+	clients := []*net.UDPConn{dial(t, l), dial(t, l), dial(t, l)}
+
+	echo(t, clients[0], "first")
+	checkSessions(t, l, clients, true, false, false)
+	echo(t, clients[1], "second")
+	echo(t, clients[0], "first again")
+	checkSessions(t, l, clients, true, true, false)
+	// Oldest session is evicted once MaxSessions is reached
+	echo(t, clients[2], "third")
+	checkSessions(t, l, clients, false, true, true)
+	echo(t, clients[0], "first after eviction")
+	checkSessions(t, l, clients, true, false, true)
+}
+
+func TestProxySessionTimeout(t *testing.T) {
+	l := newTestProxy(t, 100*time.Millisecond, 2)
+	clients := []*net.UDPConn{dial(t, l), dial(t, l)}
+
+	echo(t, clients[0], "first")
+	echo(t, clients[1], "second")
+	checkSessions(t, l, clients, true, true)
+	deadline := time.Now().Add(testTimeout)
+	for _, n := sessions(l); n != 0; _, n = sessions(l) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d sessions still open after %s", n, testTimeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Expired session is created again for the next datagram of its client
+	echo(t, clients[1], "second again")
+	checkSessions(t, l, clients, false, true)
+}
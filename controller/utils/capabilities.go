@@ -0,0 +1,55 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//nolint: golint,stylecheck
+const (
+	// Linux capability numbers, see linux/capability.h
+	CAP_NET_ADMIN = 12
+)
+
+// HasCapability reports whether the given capability is in the
+// effective set of the current process.
+func HasCapability(capability uint) (bool, error) {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "CapEff:") {
+			continue
+		}
+		capEff, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, "CapEff:")), 16, 64)
+		if err != nil {
+			return false, fmt.Errorf("unable to parse effective capabilities: %w", err)
+		}
+		return capEff&(1<<capability) != 0, nil
+	}
+	if err = scanner.Err(); err != nil {
+		return false, err
+	}
+	return false, fmt.Errorf("effective capabilities not found in /proc/self/status")
+}
@@ -0,0 +1,51 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// HtpasswdKey is the secret key holding credentials in htpasswd format
+const HtpasswdKey = "auth"
+
+// ParseHtpasswd parses "user:hash" lines of an htpasswd file.
+// Hashes must be supported by crypt(3) as they are evaluated by HAProxy,
+// Apache specific "$apr1$" and "{SHA}" schemes are not and are returned as errors.
+func ParseHtpasswd(data []byte) (credentials map[string][]byte, errors []error) {
+	credentials = make(map[string][]byte)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			errors = append(errors, fmt.Errorf("invalid htpasswd entry '%s'", parts[0]))
+			continue
+		}
+		user, hash := parts[0], parts[1]
+		if strings.HasPrefix(hash, "$apr1$") || strings.HasPrefix(hash, "{SHA}") {
+			errors = append(errors, fmt.Errorf("unsupported password hash for user '%s', use bcrypt, md5-crypt or sha-crypt", user))
+			continue
+		}
+		credentials[user] = []byte(hash)
+	}
+	return credentials, errors
+}
@@ -0,0 +1,135 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeWindow is a weekly time window, from From to To minutes of the day
+// on the days FirstDay to LastDay (1 for Monday to 7 for Sunday).
+// A window whose To is before From ends on the next day.
+type TimeWindow struct {
+	FirstDay int
+	LastDay  int
+	From     int
+	To       int
+}
+
+var weekDays = map[string]int{"mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6, "sun": 7}
+
+// ParseTimeWindows parses a comma separated list of time windows in the format
+// "[Day[-Day]] HH:MM-HH:MM", e.g. "Mon-Fri 08:00-18:00, Sat 09:00-12:00".
+// The end time is excluded, windows without days apply to every day.
+func ParseTimeWindows(value string) (windows []TimeWindow, err error) {
+	for _, item := range strings.Split(value, ",") {
+		fields := strings.Fields(item)
+		w := TimeWindow{FirstDay: 1, LastDay: 7}
+		switch len(fields) {
+		case 1:
+		case 2:
+			if w.FirstDay, w.LastDay, err = parseDays(fields[0]); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("invalid time window '%s'", strings.TrimSpace(item))
+		}
+		hours := strings.Split(fields[len(fields)-1], "-")
+		if len(hours) != 2 {
+			return nil, fmt.Errorf("invalid time range '%s'", fields[len(fields)-1])
+		}
+		if w.From, err = parseClock(hours[0]); err != nil {
+			return nil, err
+		}
+		if w.To, err = parseClock(hours[1]); err != nil {
+			return nil, err
+		}
+		if w.From == w.To || w.From == 24*60 {
+			return nil, fmt.Errorf("invalid time range '%s'", fields[len(fields)-1])
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// Ranges returns the window as inclusive ranges of "DHHMM" integers,
+// D being the day of the week, as returned by "%u%H%M" time format.
+func (w TimeWindow) Ranges() (ranges []string) {
+	day := w.FirstDay
+	for {
+		if w.From < w.To {
+			ranges = append(ranges, dayClockRange(day, w.From, w.To-1))
+		} else {
+			ranges = append(ranges, dayClockRange(day, w.From, 24*60-1))
+			if w.To > 0 {
+				ranges = append(ranges, dayClockRange(day%7+1, 0, w.To-1))
+			}
+		}
+		if day == w.LastDay {
+			break
+		}
+		day = day%7 + 1
+	}
+	return ranges
+}
+
+// TimeZoneOffset returns the current offset in seconds of the named timezone to UTC
+func TimeZoneOffset(name string) (int64, error) {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return 0, err
+	}
+	_, offset := time.Now().In(location).Zone()
+	return int64(offset), nil
+}
+
+func parseDays(value string) (first, last int, err error) {
+	days := strings.Split(value, "-")
+	if len(days) > 2 {
+		return 0, 0, fmt.Errorf("invalid days '%s'", value)
+	}
+	var ok bool
+	if first, ok = weekDays[strings.ToLower(days[0])]; !ok {
+		return 0, 0, fmt.Errorf("invalid day '%s'", days[0])
+	}
+	last = first
+	if len(days) == 2 {
+		if last, ok = weekDays[strings.ToLower(days[1])]; !ok {
+			return 0, 0, fmt.Errorf("invalid day '%s'", days[1])
+		}
+	}
+	return first, last, nil
+}
+
+// parseClock returns the minutes of the day of a "HH:MM" time, "24:00" being the end of the day
+func parseClock(value string) (int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 || len(parts[1]) != 2 {
+		return 0, fmt.Errorf("invalid time '%s'", value)
+	}
+	hours, errH := strconv.Atoi(parts[0])
+	minutes, errM := strconv.Atoi(parts[1])
+	if errH != nil || errM != nil || hours < 0 || minutes < 0 || minutes > 59 || hours > 24 || (hours == 24 && minutes != 0) {
+		return 0, fmt.Errorf("invalid time '%s'", value)
+	}
+	return hours*60 + minutes, nil
+}
+
+func dayClockRange(day, from, to int) string {
+	return fmt.Sprintf("%d%02d%02d:%d%02d%02d", day, from/60, from%60, day, to/60, to%60)
+}
@@ -16,10 +16,12 @@ package utils
 
 import (
 	"encoding/hex"
+	"fmt"
 	"hash/fnv"
 	"os"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 func HomeDir() string {
@@ -43,6 +45,44 @@ func PtrString(value string) *string {
 	return &value
 }
 
+// EqualInt64Ptr returns true when a and b are both nil or point to the same value
+func EqualInt64Ptr(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// QuoteHAProxy returns s double quoted as an HAProxy configuration argument, escaping
+// the characters interpreted by HAProxy within double quotes. New lines, carriage
+// returns and tabs are written as their \n, \r and \t escape sequences, other control
+// characters cannot be written in a configuration line and are rejected.
+func QuoteHAProxy(s string) (string, error) {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\' || r == '$':
+			b.WriteByte('\\')
+		case r == '\n':
+			b.WriteString(`\n`)
+			continue
+		case r == '\r':
+			b.WriteString(`\r`)
+			continue
+		case r == '\t':
+			b.WriteString(`\t`)
+			continue
+		case unicode.IsControl(r):
+			return "", fmt.Errorf("control character %U not allowed in %q", r, s)
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String(), nil
+}
+
 func ParseInt(data string) (v int64, err error) {
 	i, err := strconv.Atoi(data)
 	if err == nil {
@@ -93,3 +133,42 @@ func GetBoolValue(dataValue, dataName string) (result bool, err error) {
 	}
 	return result, nil
 }
+
+// CheckErrorFileCode returns an error if code is not an HTTP status code
+// HAProxy can serve an errorfile for.
+func CheckErrorFileCode(code string) error {
+	var codes = [15]string{"200", "400", "401", "403", "404", "405", "407", "408", "410", "425", "429", "500", "502", "503", "504"}
+	for _, c := range codes {
+		if code == c {
+			return nil
+		}
+	}
+	return fmt.Errorf("HTTP error code '%s' not supported", code)
+}
+
+// WildcardHostSuffix returns the suffix of the hosts matched by a wildcard host, ".example.com"
+// for "*.example.com", or an empty suffix for other hosts. As per the Ingress specification a
+// wildcard only matches a single DNS label and is only allowed as the first label of the host.
+func WildcardHostSuffix(host string) (suffix string, err error) {
+	if !strings.Contains(host, "*") {
+		return "", nil
+	}
+	if !strings.HasPrefix(host, "*.") || len(host) == 2 || strings.Count(host, "*") > 1 {
+		return "", fmt.Errorf("invalid wildcard host '%s'", host)
+	}
+	return host[1:], nil
+}
+
+// MatchHost returns true when host is matched by the ingress host pattern, either the same
+// host or a wildcard host matching it, e.g. "*.example.com" matching "foo.example.com".
+func MatchHost(pattern, host string) bool {
+	if pattern == host {
+		return true
+	}
+	suffix, err := WildcardHostSuffix(pattern)
+	if err != nil || suffix == "" || !strings.HasSuffix(host, suffix) {
+		return false
+	}
+	label := strings.TrimSuffix(host, suffix)
+	return label != "" && !strings.Contains(label, ".")
+}
@@ -0,0 +1,47 @@
+// Copyright 2019 HAProxy Technologies LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import "testing"
+
+func TestQuoteHAProxy(t *testing.T) {
+	tests := []struct {
+		in   string
+		out  string
+		fail bool
+	}{
+		{in: "", out: `""`},
+		{in: "application/json", out: `"application/json"`},
+		{in: `say "hi" \ $HOME`, out: `"say \"hi\" \\ \$HOME"`},
+		{in: "{\n\t\"status\": \"maintenance\"\r\n}\n", out: `"{\n\t\"status\": \"maintenance\"\r\n}\n"`},
+		{in: "<p>été</p>", out: `"<p>été</p>"`},
+		{in: "null\x00byte", fail: true},
+		{in: "bell\a", fail: true},
+		{in: "escape\x1b[0m", fail: true},
+		{in: "delete\x7f", fail: true},
+	}
+	for _, test := range tests {
+		out, err := QuoteHAProxy(test.in)
+		if test.fail {
+			if err == nil {
+				t.Errorf("QuoteHAProxy(%q) = %s, want an error", test.in, out)
+			}
+			continue
+		}
+		if err != nil || out != test.out {
+			t.Errorf("QuoteHAProxy(%q) = %s, %v, want %s", test.in, out, err, test.out)
+		}
+	}
+}
@@ -30,6 +30,8 @@ type test struct {
 	target string
 	host   string
 	paths  []string
+	// test requires Exact and Prefix path types, not supported before k8s 1.18
+	pathTypes bool
 }
 
 var ingressRules = []IngressRule{
@@ -43,18 +45,27 @@ var ingressRules = []IngressRule{
 	{Service: "http-echo-8", Host: "sub.app.haproxy"},
 	{Service: "http-echo-9", Host: "*.haproxy"},
 	{Service: "http-echo-10", Path: "/test"},
+	{Service: "http-echo-11", Host: "app.haproxy", Path: "/foo", PathType: "Prefix"},
+	{Service: "http-echo-12", Host: "app.haproxy", Path: "/foo/bar/", PathType: "Prefix"},
+	{Service: "http-echo-13", Host: "app.haproxy", Path: "/same", PathType: "Exact"},
+	{Service: "http-echo-14", Host: "app.haproxy", Path: "/same", PathType: "Prefix"},
 }
 var tests = []test{
-	{ingressRules[0].Service, "app.haproxy", []string{"/", "/test", "/exact/", "/exactslash", "/exactslash/foo", "/prefixxx"}},
-	{ingressRules[1].Service, "app.haproxy", []string{"/a"}},
-	{ingressRules[2].Service, "app.haproxy", []string{"/a/b"}},
-	{ingressRules[3].Service, "app.haproxy", []string{"/exact"}},
-	{ingressRules[4].Service, "app.haproxy", []string{"/exactslash/"}},
-	{ingressRules[5].Service, "app.haproxy", []string{"/prefix", "/prefix/", "/prefix/foo"}},
-	{ingressRules[6].Service, "app.haproxy", []string{"/prefixslash", "/prefixslash/", "/prefixslash/foo/bar"}},
-	{ingressRules[7].Service, "sub.app.haproxy", []string{"/test"}},
-	{ingressRules[8].Service, "test.haproxy", []string{"/test"}},
-	{ingressRules[9].Service, "foo.bar", []string{"/test"}},
+	{ingressRules[0].Service, "app.haproxy", []string{"/", "/test", "/prefixxx"}, false},
+	{ingressRules[0].Service, "app.haproxy", []string{"/exact/", "/exactslash", "/exactslash/foo"}, true},
+	{ingressRules[1].Service, "app.haproxy", []string{"/a"}, false},
+	{ingressRules[2].Service, "app.haproxy", []string{"/a/b", "/a/b/c"}, false},
+	{ingressRules[3].Service, "app.haproxy", []string{"/exact"}, false},
+	{ingressRules[4].Service, "app.haproxy", []string{"/exactslash/"}, false},
+	{ingressRules[5].Service, "app.haproxy", []string{"/prefix", "/prefix/", "/prefix/foo"}, false},
+	{ingressRules[6].Service, "app.haproxy", []string{"/prefixslash", "/prefixslash/", "/prefixslash/foo/bar"}, false},
+	{ingressRules[7].Service, "sub.app.haproxy", []string{"/test"}, false},
+	{ingressRules[8].Service, "test.haproxy", []string{"/test"}, false},
+	{ingressRules[9].Service, "foo.bar", []string{"/test"}, false},
+	{ingressRules[10].Service, "app.haproxy", []string{"/foo", "/foo/", "/foo/barbaz", "/foo/baz/bar"}, true},
+	{ingressRules[11].Service, "app.haproxy", []string{"/foo/bar", "/foo/bar/", "/foo/bar/baz"}, true},
+	{ingressRules[12].Service, "app.haproxy", []string{"/same"}, true},
+	{ingressRules[13].Service, "app.haproxy", []string{"/same/", "/same/foo"}, true},
 }
 
 func (suite *IngressMatchSuite) BeforeTest(suiteName, testName string) {
@@ -63,7 +74,6 @@ func (suite *IngressMatchSuite) BeforeTest(suiteName, testName string) {
 	suite.NoError(err)
 	if major == 1 && minor < 18 {
 		suite.tmplData.PathTypeSupported = false
-		tests[0] = test{ingressRules[0].Service, "app.haproxy", []string{"/", "/test", "/prefixxx"}}
 	}
 }
 
@@ -77,6 +87,9 @@ func (suite *IngressMatchSuite) Test_Http_MatchPath() {
 	suite.Require().NoError(suite.test.DeployYamlTemplate("config/ingress.yaml.tmpl", suite.test.GetNS(), suite.tmplData))
 
 	for _, test := range tests {
+		if test.pathTypes && !suite.tmplData.PathTypeSupported {
+			continue
+		}
 		for _, path := range test.paths {
 			suite.Run("test="+test.host+path, func() {
 				suite.Eventually(func() bool {
@@ -116,8 +116,15 @@ func main() {
 	if osArgs.ConfigMapPatternFiles.Name != "" {
 		logger.Printf("Pattern files provided in '%s'", osArgs.ConfigMapPatternFiles)
 	}
+	if osArgs.WatchLabelSelector.String() != "" {
+		logger.Printf("Ingresses watched with label selector '%s'", osArgs.WatchLabelSelector)
+	}
 	logger.Debugf("Kubernetes Informers resync period: %s", osArgs.CacheResyncPeriod.String())
 	logger.Printf("Controller sync period: %s\n", osArgs.SyncPeriod.String())
+	if osArgs.FullSyncPeriod == 0 {
+		osArgs.FullSyncPeriod = osArgs.CacheResyncPeriod
+	}
+	logger.Debugf("Controller full sync period: %s", osArgs.FullSyncPeriod.String())
 
 	hostname, err := os.Hostname()
 	logger.Error(err)
@@ -144,10 +151,6 @@ func main() {
 	}
 	logger.Error(os.Chdir(cfg.Env.CfgDir))
 
-	controller := c.HAProxyController{
-		Cfg:    cfg,
-		OSArgs: osArgs,
-	}
 	logger.FileName = true
 	// K8s Store
 	s := store.NewK8sStore(osArgs)
@@ -155,14 +158,24 @@ func main() {
 	s.SetDefaultAnnotation("ssl-certificate", defaultCertificate)
 	s.SetDefaultAnnotation("sync-period", osArgs.SyncPeriod.String())
 	s.SetDefaultAnnotation("cache-resync-period", osArgs.CacheResyncPeriod.String())
+	s.SetDefaultAnnotation("full-sync-period", osArgs.FullSyncPeriod.String())
 	for _, namespace := range osArgs.NamespaceWhitelist {
 		s.NamespacesAccess.Whitelist[namespace] = struct{}{}
 	}
 	for _, namespace := range osArgs.NamespaceBlacklist {
 		s.NamespacesAccess.Blacklist[namespace] = struct{}{}
 	}
-	controller.Store = s
-	controller.Start()
+	controller := c.New(c.Options{
+		Cfg:    cfg,
+		OSArgs: osArgs,
+		Store:  &s,
+	})
+	if err = controller.Start(); err != nil {
+		logger.Error(err)
+		controller.Stop()
+		exitCode = 1
+		return
+	}
 	signalC := make(chan os.Signal, 1)
 	signal.Notify(signalC, os.Interrupt, syscall.SIGTERM, syscall.SIGUSR1)
 	<-signalC